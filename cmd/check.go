@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 
 	"github.com/notes-bin/ddns6/internal/config"
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 // checkCmd 验证配置和 API 连通性。
@@ -57,9 +59,7 @@ var checkCmd = &cobra.Command{
 				}
 			}
 			if factory == nil {
-				fmt.Printf("Unknown provider: %s\n", provider)
-				fmt.Println("Available providers: tencent, cloudflare, alicloud, godaddy, huaweicloud, duckdns, noip, he, dynv6, porkbun, digitalocean, baiducloud, dnspod")
-				return nil
+				return &usageError{err: fmt.Errorf("unknown provider: %s (available: tencent, cloudflare, alicloud, godaddy, huaweicloud, duckdns, noip, he, dynv6, porkbun, digitalocean, baiducloud, dnspod)", provider)}
 			}
 			fmt.Printf("Provider '%s' is valid\n", provider)
 
@@ -77,17 +77,19 @@ var checkCmd = &cobra.Command{
 			// 检查域名
 			domain := getString(cmd, "domain")
 			if domain == "" {
-				fmt.Println("--domain is missing")
-				return nil
+				return fmt.Errorf("--domain is missing")
 			}
 			fmt.Printf("--domain is set to %s\n", domain)
 
 			// API 连通性测试
 			fmt.Println("\n--- API Connectivity Test ---")
-			domains, providerClient, err := factory.run(cmd)
+			policy, err := retryPolicy(cmd, nil)
 			if err != nil {
-				fmt.Printf("Failed to create provider: %v\n", err)
-				return nil
+				return fmt.Errorf("retry: %w", err)
+			}
+			domains, providerClient, err := factory.run(cmd, policy)
+			if err != nil {
+				return fmt.Errorf("failed to create provider: %w", err)
 			}
 
 			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
@@ -95,8 +97,7 @@ var checkCmd = &cobra.Command{
 
 			records, err := ddns.CollectMatchingRecords(ctx, providerClient, domains, "AAAA", false)
 			if err != nil {
-				fmt.Printf("API test failed: %v\n", err)
-				return nil
+				return fmt.Errorf("API test failed: %w", err)
 			}
 
 			fmt.Printf("API connection successful (found %d AAAA records)\n", len(records))
@@ -106,84 +107,102 @@ var checkCmd = &cobra.Command{
 		// 配置文件模式
 		cfg, err := config.Load()
 		if err != nil {
-			fmt.Printf("Config load failed: %v\n", err)
-			return nil
+			return fmt.Errorf("config load failed: %w", err)
 		}
 		fmt.Printf("Config loaded successfully\n\n")
+		policy, err := retryPolicy(cmd, cfg)
+		if err != nil {
+			return fmt.Errorf("retry: %w", err)
+		}
 
-		return checkFromConfig(cfg)
+		return checkFromConfig(cfg, policy)
 	},
 }
 
 // checkFromConfig 从配置文件执行验证。
-func checkFromConfig(cfg *config.Config) error {
+//
+// 配置了多个 profile 时，逐个验证每个 profile 的字段和 API 连通性，
+// 某个 profile 失败不影响其余 profile，所有失败合并后返回。
+func checkFromConfig(cfg *config.Config, policy retry.Policy) error {
 	fmt.Println("--- Config Validation ---")
-	if cfg.Provider != "" {
-		fmt.Printf("provider: %s\n", cfg.Provider)
+	interval, err := cfg.GetInterval()
+	if err != nil {
+		fmt.Printf("interval: %s (parse error: %v)\n", interval, err)
+	} else {
+		fmt.Printf("interval: %s\n", interval)
+	}
+	fmt.Printf("interface: %s\n", cfg.Interface)
+
+	profiles := cfg.GetProfiles()
+	var errs []error
+	for _, profile := range profiles {
+		if len(profiles) > 1 {
+			fmt.Printf("\n=== Profile: %s ===\n", profile.Name)
+		}
+		if err := checkProfile(profile, policy); err != nil {
+			if len(profiles) == 1 {
+				return err
+			}
+			errs = append(errs, fmt.Errorf("profile %s: %w", profile.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkProfile 验证单个 profile 的字段、Provider 有效性和 API 连通性，返回第一个未通过的检查项。
+func checkProfile(profile config.Profile, policy retry.Policy) error {
+	if profile.Provider != "" {
+		fmt.Printf("provider: %s\n", profile.Provider)
 	} else {
-		fmt.Println("provider: empty")
-		return nil
+		return fmt.Errorf("provider: empty")
 	}
-	if cfg.Domain != "" {
-		fmt.Printf("domain: %s\n", cfg.Domain)
+	if profile.Domain != "" {
+		fmt.Printf("domain: %s\n", profile.Domain)
 	} else {
-		fmt.Println("domain: empty")
-		return nil
+		return fmt.Errorf("domain: empty")
 	}
-	if len(cfg.Subdomains) > 0 {
-		fmt.Printf("subdomains: %v\n", cfg.Subdomains)
+	if len(profile.Subdomains) > 0 {
+		fmt.Printf("subdomains: %v\n", profile.SubdomainNames())
 	} else {
 		fmt.Println("subdomains: none (will default to @)")
 	}
-	if len(cfg.Auth) > 0 {
-		fmt.Printf("auth: %d field(s) configured\n", len(cfg.Auth))
-		for k := range cfg.Auth {
+	if len(profile.Auth) > 0 {
+		fmt.Printf("auth: %d field(s) configured\n", len(profile.Auth))
+		for k := range profile.Auth {
 			fmt.Printf("- %s: ***\n", k)
 		}
 	} else {
-		fmt.Println("auth: empty")
-		return nil
+		return fmt.Errorf("auth: empty")
 	}
-	interval, err := cfg.GetInterval()
-	if err != nil {
-		fmt.Printf("interval: %s (parse error: %v)\n", interval, err)
-	} else {
-		fmt.Printf("interval: %s\n", interval)
-	}
-	fmt.Printf("interface: %s\n", cfg.Interface)
-	fmt.Printf("ttl: %d\n", cfg.GetTTL())
+	fmt.Printf("ttl: %d\n", profile.GetTTL())
 
 	// 验证 Provider 是否有效
 	var factory *providerFactory
 	for i, p := range providerFactories {
-		if p.name == cfg.Provider {
+		if p.name == profile.Provider {
 			factory = &providerFactories[i]
 			break
 		}
 	}
 	if factory == nil {
-		fmt.Printf("Unknown provider '%s' in config\n", cfg.Provider)
-		fmt.Println("Available: tencent, cloudflare, alicloud, godaddy, huaweicloud, duckdns, noip, he, dynv6, porkbun, digitalocean, baiducloud, dnspod")
-		return nil
+		return fmt.Errorf("unknown provider '%s' in config (available: tencent, cloudflare, alicloud, godaddy, huaweicloud, duckdns, noip, he, dynv6, porkbun, digitalocean, baiducloud, dnspod)", profile.Provider)
 	}
-	fmt.Printf("Provider '%s' is valid\n", cfg.Provider)
+	fmt.Printf("Provider '%s' is valid\n", profile.Provider)
 
 	// API 连通性测试
 	fmt.Println("\n--- API Connectivity Test ---")
-	providerClient, err := factory.fromConfig(cfg)
+	providerClient, err := factory.fromConfig(profile, policy)
 	if err != nil {
-		fmt.Printf("Failed to create provider: %v\n", err)
-		return nil
+		return fmt.Errorf("failed to create provider: %w", err)
 	}
 
-	domains := buildDomains(cfg.Domain, cfg.Subdomains, cfg.GetTTL())
+	domains := buildProfileDomains(profile)
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
 	records, err := ddns.CollectMatchingRecords(ctx, providerClient, domains, "AAAA", false)
 	if err != nil {
-		fmt.Printf("API test failed: %v\n", err)
-		return nil
+		return fmt.Errorf("API test failed: %w", err)
 	}
 
 	fmt.Printf("API connection successful (found %d AAAA records)\n", len(records))
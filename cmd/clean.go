@@ -49,11 +49,7 @@ var cleanCmd = &cobra.Command{
 			cmd.Help()
 			return nil
 		}
-		if err := runCleanWithConfig(cmd); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
-			os.Exit(1)
-		}
-		return nil
+		return runCleanWithConfig(cmd)
 	},
 }
 
@@ -173,9 +169,9 @@ func handleClean(cmd *cobra.Command, domains []*ddns.Domain, p ddns.DNSProvider)
 
 // runCleanWithConfig 从 ~/.ddns6/config.yaml 加载配置并执行 clean。
 func runCleanWithConfig(cmd *cobra.Command) error {
-	return runWithConfig(cmd, "clean", func(cmd *cobra.Command, cfg *config.Config, domains []*ddns.Domain, p ddns.DNSProvider) error {
-		if restrictedProviders[cfg.Provider] {
-			return fmt.Errorf("%s does not support 'clean' via API - %s only provides update endpoints, use its web panel to manage records", cfg.Provider, cfg.Provider)
+	return runWithConfig(cmd, "clean", func(cmd *cobra.Command, profile config.Profile, domains []*ddns.Domain, p ddns.DNSProvider) error {
+		if restrictedProviders[profile.Provider] {
+			return fmt.Errorf("%s does not support 'clean' via API - %s only provides update endpoints, use its web panel to manage records", profile.Provider, profile.Provider)
 		}
 		return handleClean(cmd, domains, p)
 	})
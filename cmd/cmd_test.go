@@ -2,13 +2,21 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/notes-bin/ddns6/internal/config"
+	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 // ============================================================
@@ -65,7 +73,7 @@ func TestBuildDomains_ZeroTTL(t *testing.T) {
 	}
 }
 
-func TestBuildDomains_TypeIsAlwaysAAAA(t *testing.T) {
+func TestBuildDomains_DefaultTypeAAAA(t *testing.T) {
 	domains := buildDomains("example.com", []string{"www"}, 600)
 	if len(domains) != 1 {
 		t.Fatalf("期望 1 个域名, 得到 %d", len(domains))
@@ -75,6 +83,21 @@ func TestBuildDomains_TypeIsAlwaysAAAA(t *testing.T) {
 	}
 }
 
+func TestBuildDomains_DualStack(t *testing.T) {
+	domains := buildDomains("example.com", []string{"www", "@"}, 600, "A", "AAAA")
+	if len(domains) != 4 {
+		t.Fatalf("期望 4 个域名, 得到 %d", len(domains))
+	}
+	want := []struct{ subDomain, typ string }{
+		{"www", "A"}, {"www", "AAAA"}, {"@", "A"}, {"@", "AAAA"},
+	}
+	for i, w := range want {
+		if domains[i].SubDomain != w.subDomain || domains[i].Type != w.typ {
+			t.Errorf("domains[%d] = %s/%s, 期望 %s/%s", i, domains[i].SubDomain, domains[i].Type, w.subDomain, w.typ)
+		}
+	}
+}
+
 // ============================================================
 // formatProviderFlags 测试
 // ============================================================
@@ -196,15 +219,15 @@ func TestRequireFlags_EmptyFlags(t *testing.T) {
 }
 
 // ============================================================
-// createProviderFromConfig 测试
+// createProviderFromProfile 测试
 // ============================================================
 
-func TestCreateProviderFromConfig_Unsupported(t *testing.T) {
-	cfg := &config.Config{
+func TestCreateProviderFromProfile_Unsupported(t *testing.T) {
+	profile := config.Profile{
 		Provider: "invalid_provider",
 		Domain:   "example.com",
 	}
-	_, err := createProviderFromConfig(cfg)
+	_, err := createProviderFromProfile(profile, retry.DefaultPolicy)
 	if err == nil {
 		t.Fatal("不支持的 provider 应返回错误")
 	}
@@ -213,17 +236,190 @@ func TestCreateProviderFromConfig_Unsupported(t *testing.T) {
 	}
 }
 
-func TestCreateProviderFromConfig_EmptyProvider(t *testing.T) {
-	cfg := &config.Config{
+func TestCreateProviderFromProfile_EmptyProvider(t *testing.T) {
+	profile := config.Profile{
 		Provider: "",
 		Domain:   "example.com",
 	}
-	_, err := createProviderFromConfig(cfg)
+	_, err := createProviderFromProfile(profile, retry.DefaultPolicy)
 	if err == nil {
 		t.Fatal("空 provider 应返回错误")
 	}
 }
 
+func TestBuildServiceProfiles(t *testing.T) {
+	cfg := &config.Config{
+		Profiles: []config.Profile{
+			{Name: "cf", Provider: "cloudflare", Domain: "example.com",
+				Subdomains: []config.Subdomain{{Name: "www"}}},
+			{Name: "tc", Provider: "tencent", Domain: "example.cn",
+				Subdomains: []config.Subdomain{{Name: "@"}, {Name: "nas"}}},
+		},
+	}
+	profiles, err := buildServiceProfiles(cfg, retry.DefaultPolicy)
+	if err != nil {
+		t.Fatalf("buildServiceProfiles 不应返回错误: %v", err)
+	}
+	if len(profiles) != 2 {
+		t.Fatalf("期望 2 个 profile, 得到 %d", len(profiles))
+	}
+	if profiles[0].Name != "cf" || len(profiles[0].Domains) != 1 || profiles[0].Domains[0].Domain != "example.com" {
+		t.Errorf("profiles[0] 不符合预期: %+v", profiles[0])
+	}
+	if profiles[1].Name != "tc" || len(profiles[1].Domains) != 2 || profiles[1].Domains[1].SubDomain != "nas" {
+		t.Errorf("profiles[1] 不符合预期: %+v", profiles[1])
+	}
+}
+
+func TestBuildProfileDomains_Suffix(t *testing.T) {
+	domains := buildProfileDomains(config.Profile{Domain: "example.com", Subdomains: []config.Subdomain{
+		{Name: "www"},
+		{Name: "printer", Suffix: "::1234", PrefixLength: 56},
+	}})
+	if len(domains) != 2 {
+		t.Fatalf("期望 2 个域名, 得到 %d", len(domains))
+	}
+	if domains[0].Suffix != nil {
+		t.Errorf("未设置 suffix 的子域名应发布本机地址, 得到后缀 %v", domains[0].Suffix)
+	}
+	if d := domains[1]; d.Suffix.String() != "::1234" || d.PrefixLength != 56 {
+		t.Errorf("printer 应设置后缀 ::1234/56, 得到 %v/%d", d.Suffix, d.PrefixLength)
+	}
+}
+
+func TestBuildServiceProfiles_Unsupported(t *testing.T) {
+	cfg := &config.Config{
+		Profiles: []config.Profile{{Name: "bad", Provider: "invalid_provider", Domain: "example.com"}},
+	}
+	_, err := buildServiceProfiles(cfg, retry.DefaultPolicy)
+	if err == nil {
+		t.Fatal("不支持的 provider 应返回错误")
+	}
+	if !strings.Contains(err.Error(), "profile bad") {
+		t.Errorf("错误信息应包含 profile 名称, 得到: %v", err)
+	}
+}
+
+func TestBuildProfileDomains_Policy(t *testing.T) {
+	domains := buildProfileDomains(config.Profile{Domain: "example.com",
+		Policy: &config.PolicyConfig{RejectBogons: true},
+		Subdomains: []config.Subdomain{
+			{Name: "www"},
+			{Name: "lab", Policy: &config.PolicyConfig{Deny: []string{"2409:8a00::/32"}}},
+		}})
+	if !domains[0].Policy.RejectBogons {
+		t.Error("www 应使用 profile 的地址策略")
+	}
+	if d := domains[1]; d.Policy.RejectBogons || len(d.Policy.Deny) != 1 {
+		t.Errorf("lab 的 policy 应覆盖 profile, 得到 %+v", d.Policy)
+	}
+}
+
+func TestBuildServiceProfiles_WithdrawDisable(t *testing.T) {
+	profile := func(provider string) config.Profile {
+		return config.Profile{Name: provider, Provider: provider, Domain: "example.com", OnWithdraw: "keep",
+			Subdomains: []config.Subdomain{{Name: "www", OnWithdraw: "disable"}}}
+	}
+	profiles, err := buildServiceProfiles(&config.Config{Profiles: []config.Profile{profile("tencent")}}, retry.DefaultPolicy)
+	if err != nil {
+		t.Fatalf("tencent 支持暂停记录，不应返回错误: %v", err)
+	}
+	if d := profiles[0].Domains[0]; d.OnWithdraw != ddns.WithdrawDisable {
+		t.Errorf("子域名的 on_withdraw 应覆盖 profile, 得到 %q", d.OnWithdraw)
+	}
+
+	_, err = buildServiceProfiles(&config.Config{Profiles: []config.Profile{profile("cloudflare")}}, retry.DefaultPolicy)
+	if err == nil || !strings.Contains(err.Error(), "does not support") {
+		t.Errorf("不支持暂停记录的服务商应返回错误, 得到: %v", err)
+	}
+}
+
+func TestRestartRequiredChanges(t *testing.T) {
+	old := &config.Config{Interval: "5m", TTL: 600, Profiles: []config.Profile{{Name: "cf"}}}
+	cfg := &config.Config{Interval: "10m", TTL: 300, StatusAddr: "127.0.0.1:9876"}
+	got := restartRequiredChanges(old, cfg)
+	if want := []string{"interval", "status_addr"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("需重启的设置应为 %v（profiles、ttl 支持热重载）, 得到 %v", want, got)
+	}
+}
+
+func TestTriggerConfig_FlagsOverrideConfig(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().Duration("debounce", ddns.DefaultDebounce, "")
+	cmd.Flags().Duration("debounce-max-wait", ddns.DefaultDebounceMaxWait, "")
+	cmd.Flags().Bool("include-ula", false, "")
+	cmd.Flags().StringArray("watch-interface", nil, "")
+	cfg := &config.Config{Netlink: &config.NetlinkConfig{Debounce: "3s", MaxWait: "30s", Interfaces: []string{"eth0"}}}
+
+	cmd.Flags().Set("debounce-max-wait", "2m")
+	cmd.Flags().Set("watch-interface", "ppp*")
+	trigger, err := triggerConfig(cmd, cfg)
+	if err != nil {
+		t.Fatalf("triggerConfig 不应返回错误: %v", err)
+	}
+	if trigger.Debounce != 3*time.Second || trigger.MaxWait != 2*time.Minute {
+		t.Errorf("未设置的参数应使用配置文件，设置的参数应覆盖配置: %+v", trigger)
+	}
+	if strings.Join(trigger.Interfaces, ",") != "ppp*" {
+		t.Errorf("--watch-interface 应覆盖配置文件的 interfaces, 得到 %v", trigger.Interfaces)
+	}
+
+	cmd.Flags().Set("debounce", "0s")
+	if _, err := triggerConfig(cmd, cfg); err == nil {
+		t.Error("--debounce 为 0 时应返回错误")
+	}
+}
+
+func TestVerifyConfig_FlagsOverrideConfig(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().Bool("verify", false, "")
+	cmd.Flags().Duration("verify-timeout", ddns.DefaultVerifyTimeout, "")
+	cfg := &config.Config{Verify: &config.VerifyConfig{Timeout: "1m", Interval: "5s"}}
+
+	if v, err := verifyConfig(cmd, cfg); err != nil || v != nil {
+		t.Fatalf("配置文件未启用且未指定 --verify 时不应验证: %+v, %v", v, err)
+	}
+
+	cmd.Flags().Set("verify", "true")
+	cmd.Flags().Set("verify-timeout", "2m")
+	v, err := verifyConfig(cmd, cfg)
+	if err != nil || v == nil {
+		t.Fatalf("--verify 应启用传播验证: %+v, %v", v, err)
+	}
+	if v.Timeout != 2*time.Minute || v.Interval != 5*time.Second {
+		t.Errorf("--verify-timeout 应覆盖配置文件，未设置的参数使用配置文件: %+v", v)
+	}
+
+	cfg.Verify.Enabled = true
+	cmd.Flags().Set("verify", "false")
+	if v, _ := verifyConfig(cmd, cfg); v != nil {
+		t.Error("--verify=false 应关闭配置文件启用的传播验证")
+	}
+}
+
+func TestIPv6Fetchers_FlagOverridesConfig(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().StringArray("fetcher", nil, "")
+	cfg := &config.Config{Fetchers: &config.FetchersConfig{IPv6: []config.FetcherConfig{{DNS: "2400:3200:baba::1"}}}}
+
+	fetchers, err := ipv6Fetchers(cmd, cfg)
+	if err != nil || len(fetchers) != 1 || ipaddr.FetcherName(fetchers[0]) != "2400:3200:baba::1" {
+		t.Fatalf("未指定 --fetcher 时应使用配置文件的获取器: %v, %v", fetchers, err)
+	}
+
+	cmd.Flags().Set("fetcher", "https://6.ipw.cn")
+	cmd.Flags().Set("fetcher", "interface:ppp0")
+	fetchers, err = ipv6Fetchers(cmd, cfg)
+	if err != nil || len(fetchers) != 2 || ipaddr.FetcherName(fetchers[1]) != "interface:ppp0" {
+		t.Errorf("--fetcher 应取代配置文件的获取器: %v, %v", fetchers, err)
+	}
+
+	cmd.Flags().Set("fetcher", "smtp:mail.example.com")
+	if _, err := ipv6Fetchers(cmd, cfg); err == nil {
+		t.Error("未知的获取器类型应返回错误")
+	}
+}
+
 // ============================================================
 // getString / getDuration 测试
 // ============================================================
@@ -336,3 +532,138 @@ func TestNotRestricted(t *testing.T) {
 		}
 	}
 }
+
+// ============================================================
+// retryPolicy 测试
+// ============================================================
+
+func TestRetryPolicy_FlagOverridesConfig(t *testing.T) {
+	defaults := retry.DefaultPolicy
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Int("retry-attempts", defaults.Attempts, "")
+	cmd.Flags().Duration("retry-base-delay", defaults.BaseDelay, "")
+	cmd.Flags().Duration("retry-max-delay", defaults.MaxDelay, "")
+	if err := cmd.Flags().Set("retry-max-delay", "5s"); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Retry: &config.RetryConfig{Attempts: 5, MaxDelay: "1m"}}
+	p, err := retryPolicy(cmd, cfg)
+	if err != nil {
+		t.Fatalf("retryPolicy 返回错误: %v", err)
+	}
+	if p.Attempts != 5 {
+		t.Errorf("Attempts = %d, 期望配置文件的 5", p.Attempts)
+	}
+	if p.MaxDelay != 5*time.Second {
+		t.Errorf("MaxDelay = %v, 期望命令行的 5s", p.MaxDelay)
+	}
+	if p.BaseDelay != defaults.BaseDelay {
+		t.Errorf("BaseDelay = %v, 期望默认值 %v", p.BaseDelay, defaults.BaseDelay)
+	}
+	if retry.DefaultPolicy != defaults {
+		t.Errorf("retryPolicy 不应修改 retry.DefaultPolicy, 得到 %+v", retry.DefaultPolicy)
+	}
+}
+
+func TestRetryPolicy_InvalidAttempts(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().Int("retry-attempts", retry.DefaultPolicy.Attempts, "")
+	if err := cmd.Flags().Set("retry-attempts", "0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := retryPolicy(cmd, nil); err == nil {
+		t.Error("--retry-attempts 0 应返回错误")
+	}
+}
+
+// ============================================================
+// healthURL 测试
+// ============================================================
+
+func TestHealthURL(t *testing.T) {
+	cases := map[string]string{
+		"127.0.0.1:9876": "http://127.0.0.1:9876/healthz",
+		":9876":          "http://127.0.0.1:9876/healthz",
+		"0.0.0.0:9876":   "http://127.0.0.1:9876/healthz",
+		"[::]:9876":      "http://[::1]:9876/healthz",
+		"[::1]:9876":     "http://[::1]:9876/healthz",
+	}
+	for addr, want := range cases {
+		got, err := healthURL(addr)
+		if err != nil || got != want {
+			t.Errorf("healthURL(%q) = %q (err=%v), 期望 %q", addr, got, err, want)
+		}
+	}
+	if _, err := healthURL("localhost"); err == nil {
+		t.Error("缺少端口的地址应返回错误")
+	}
+}
+
+// ============================================================
+// 退出码测试
+// ============================================================
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{&ExitError{Code: ExitChanged}, ExitChanged},
+		{fmt.Errorf("update: %w", &ExitError{Code: ExitUsage, Err: errors.New("bad flag")}), ExitUsage},
+		{errors.New("2 of 3 domains failed to update"), ExitFailure},
+	}
+	for _, c := range cases {
+		if got := ExitCode(c.err); got != c.want {
+			t.Errorf("ExitCode(%v) = %d, 期望 %d", c.err, got, c.want)
+		}
+	}
+
+	if !isUsageError(fmt.Errorf("update: %w", &usageError{err: errors.New("unknown flag: --foo")})) {
+		t.Error("包装后的 usageError 应视为用法错误")
+	}
+	if isUsageError(errors.New(`unknown command "foo" for "ddns6"`)) {
+		t.Error("未经 usageError 包装的错误不应按错误信息视为用法错误")
+	}
+}
+
+var initRootOnce sync.Once
+
+// executeArgs 以 args 执行 ddns6 命令（丢弃输出，不写日志文件），返回退出码。
+func executeArgs(t *testing.T, args ...string) int {
+	t.Helper()
+	initRootOnce.Do(initRootCmd)
+	t.Setenv("HOME", t.TempDir())
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+	rootCmd.SetArgs(append([]string{"--log-file", ""}, args...))
+	return ExitCode(execute())
+}
+
+func TestExecute_ExitCodes(t *testing.T) {
+	cases := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"nosuch"}, ExitUsage},
+		{[]string{"update", "nosuch"}, ExitUsage},
+		{[]string{"update", "cloudflare", "--domain", "example.com"}, ExitUsage},
+		{[]string{"run", "tencent", "--domain", "example.com", "--secret-id", "id"}, ExitUsage},
+		{[]string{"list", "cloudflare", "--domain", "example.com"}, ExitUsage},
+		{[]string{"check", "nosuch"}, ExitUsage},
+		{[]string{"completion"}, ExitUsage},
+		{[]string{"healthcheck", "extra"}, ExitUsage},
+		{[]string{"update", "--no-such-flag"}, ExitUsage},
+		{[]string{"update"}, ExitFailure}, // 配置文件不存在：执行失败而非用法错误
+	}
+	for _, c := range cases {
+		if got := executeArgs(t, c.args...); got != c.want {
+			t.Errorf("ddns6 %s 的退出码 = %d, 期望 %d", strings.Join(c.args, " "), got, c.want)
+		}
+	}
+}
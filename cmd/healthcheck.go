@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/notes-bin/ddns6/internal/config"
+)
+
+// healthcheckCmd 探测运行中服务的 /healthz 端点，供 Docker HEALTHCHECK 等使用。
+var healthcheckCmd = &cobra.Command{
+	Use:   "healthcheck",
+	Short: "探测运行中服务的 /healthz 端点",
+	Long: `探测 ddns6 run 启动的 HTTP 状态端点（--status-addr）的 /healthz。
+
+端点返回 200 时退出码为 0，否则（503、连接失败、超时）退出码为 1，
+可直接用作 Docker HEALTHCHECK 或 systemd/监控脚本的探针。
+
+端点地址依次取自 --status-addr、DDNS6_STATUS_ADDR 和配置文件 status_addr。
+监听地址为 0.0.0.0、[::] 或省略主机时，改为探测本机回环地址。
+
+示例:
+  ddns6 healthcheck --status-addr 127.0.0.1:9876
+  DDNS6_STATUS_ADDR=:9876 ddns6 healthcheck`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		if err := runHealthcheck(cmd, timeout); err != nil {
+			return fmt.Errorf("unhealthy: %w", err)
+		}
+		return nil
+	},
+}
+
+// registerHealthcheckCommand 注册 healthcheck 命令的参数。
+func registerHealthcheckCommand() {
+	healthcheckCmd.Flags().Duration("timeout", 5*time.Second, "请求超时时间（默认 5s）")
+}
+
+// runHealthcheck 请求 /healthz，打印响应内容；非 200 响应或请求失败时返回错误。
+func runHealthcheck(cmd *cobra.Command, timeout time.Duration) error {
+	addr := getString(cmd, "status-addr")
+	if !cmd.Flags().Changed("status-addr") {
+		if cfg, err := config.Load(); err == nil && cfg.StatusAddr != "" {
+			addr = cfg.StatusAddr
+		}
+	}
+	if addr == "" {
+		return fmt.Errorf("status endpoint not configured (use --status-addr, DDNS6_STATUS_ADDR or status_addr in config)")
+	}
+	url, err := healthURL(addr)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	os.Stdout.Write(body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// healthURL 将状态端点的监听地址转换为 /healthz 的探测 URL。
+//
+// 未指定主机或为通配地址（0.0.0.0、::）时探测对应地址族的回环地址。
+func healthURL(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid status address '%s': %w", addr, err)
+	}
+	switch host {
+	case "", "0.0.0.0":
+		host = "127.0.0.1"
+	case "::":
+		host = "::1"
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/healthz", nil
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -39,11 +38,7 @@ var listCmd = &cobra.Command{
 			cmd.Help()
 			return nil
 		}
-		if err := runListWithConfig(cmd); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
-			os.Exit(1)
-		}
-		return nil
+		return runListWithConfig(cmd)
 	},
 }
 
@@ -97,9 +92,9 @@ func handleList(cmd *cobra.Command, domains []*ddns.Domain, p ddns.DNSProvider)
 
 // runListWithConfig 从 ~/.ddns6/config.yaml 加载配置并执行 list。
 func runListWithConfig(cmd *cobra.Command) error {
-	return runWithConfig(cmd, "list", func(cmd *cobra.Command, cfg *config.Config, domains []*ddns.Domain, p ddns.DNSProvider) error {
-		if restrictedProviders[cfg.Provider] {
-			return fmt.Errorf("%s does not support 'list' via API - %s only provides update endpoints, use its web panel to manage records", cfg.Provider, cfg.Provider)
+	return runWithConfig(cmd, "list", func(cmd *cobra.Command, profile config.Profile, domains []*ddns.Domain, p ddns.DNSProvider) error {
+		if restrictedProviders[profile.Provider] {
+			return fmt.Errorf("%s does not support 'list' via API - %s only provides update endpoints, use its web panel to manage records", profile.Provider, profile.Provider)
 		}
 		return handleList(cmd, domains, p)
 	})
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// dryRun 返回是否指定了 ddns6 run --dry-run。
+func dryRun(cmd *cobra.Command) bool {
+	if cmd == nil {
+		return false
+	}
+	v, _ := cmd.Flags().GetBool("dry-run")
+	return v
+}
+
+// runPlan 执行一次不写入服务商的同步并打印计划，有域名无法生成计划时返回错误。
+// 收到 SIGINT/SIGTERM 时取消进行中的 API 调用。
+func runPlan(profiles []ddns.Profile, fetchers []ipaddr.IPv6Fetcher, opts []ddns.Option) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	changes, err := ddns.Plan(ctx, profiles, fetchers, opts...)
+	if err != nil {
+		return err
+	}
+	fmt.Print(ddns.FormatPlan(changes))
+	fmt.Printf("\nDry-run mode. No records were changed; omit --dry-run to apply.\n")
+
+	failed := 0
+	for _, c := range changes {
+		if c.Action == ddns.PlanFailed {
+			failed++
+		}
+	}
+	slog.Info("dry run finished", "module", "cmd", "records", len(changes), "failed", failed)
+	if failed > 0 {
+		return fmt.Errorf("%d domains could not be planned", failed)
+	}
+	return nil
+}
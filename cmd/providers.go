@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"reflect"
 	"strings"
 	"time"
 
@@ -9,6 +13,7 @@ import (
 
 	"github.com/notes-bin/ddns6/internal/config"
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/internal/notify"
 	"github.com/notes-bin/ddns6/internal/providers/alicloud"
 	"github.com/notes-bin/ddns6/internal/providers/baiducloud"
 	"github.com/notes-bin/ddns6/internal/providers/cloudflare"
@@ -22,6 +27,8 @@ import (
 	"github.com/notes-bin/ddns6/internal/providers/noip"
 	"github.com/notes-bin/ddns6/internal/providers/porkbun"
 	"github.com/notes-bin/ddns6/internal/providers/tencent"
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 // providerFlag 运营商命令行参数定义
@@ -40,10 +47,10 @@ type providerFactory struct {
 	short       string
 	flags       []providerFlag
 	noListClean bool // true 表示此 provider 不支持 list/clean（如 duckdns、he、noip）
-	// run 从命令行参数创建域名列表和 DNSProvider
-	run func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error)
-	// fromConfig 从配置文件创建 DNSProvider
-	fromConfig func(cfg *config.Config) (ddns.DNSProvider, error)
+	// run 从命令行参数创建域名列表和 DNSProvider，policy 为 API 调用的重试策略
+	run func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error)
+	// fromConfig 从配置文件中的 profile 创建 DNSProvider，policy 为 API 调用的重试策略
+	fromConfig func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error)
 }
 
 // restrictedProviders 返回不支持 list/clean 的 provider 名称集合
@@ -61,15 +68,15 @@ var providerFactories = []providerFactory{
 			{"secret-id", "Tencent Cloud SecretID (必填，从 https://console.cloud.tencent.com/cam 获取)"},
 			{"secret-key", "Tencent Cloud SecretKey (必填)"},
 		},
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			return domains, tencent.NewDNSPod(getString(cmd, "secret-id"), getString(cmd, "secret-key")), nil
+			return domains, tencent.NewDNSPod(getString(cmd, "secret-id"), getString(cmd, "secret-key"), tencent.WithRetryPolicy(policy)), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			return tencent.NewDNSPod(cfg.Auth["secret_id"], cfg.Auth["secret_key"]), nil
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			return tencent.NewDNSPod(profile.Auth["secret_id"], profile.Auth["secret_key"], tencent.WithRetryPolicy(policy)), nil
 		},
 	},
 	{
@@ -77,15 +84,15 @@ var providerFactories = []providerFactory{
 		flags: []providerFlag{
 			{"api-token", "Cloudflare API Token (必填，需具有 DNS:Edit 权限)"},
 		},
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			return domains, cloudflare.NewClient(cloudflare.WithAPIToken(getString(cmd, "api-token"))), nil
+			return domains, cloudflare.NewClient(cloudflare.WithAPIToken(getString(cmd, "api-token")), cloudflare.WithRetryPolicy(policy)), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			return cloudflare.NewClient(cloudflare.WithAPIToken(cfg.Auth["api_token"])), nil
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			return cloudflare.NewClient(cloudflare.WithAPIToken(profile.Auth["api_token"]), cloudflare.WithRetryPolicy(policy)), nil
 		},
 	},
 	{
@@ -95,23 +102,23 @@ var providerFactories = []providerFactory{
 			{"access-key-secret", "Alibaba Cloud Access Key Secret (必填)"},
 			{"sign-version", "签名版本：v1（默认，HMAC-SHA1）或 v3（ACS3-HMAC-SHA256）"},
 		},
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			opts := []alicloud.Option{}
+			opts := []alicloud.Option{alicloud.WithRetryPolicy(policy)}
 			if sv := getString(cmd, "sign-version"); sv != "" {
 				opts = append(opts, alicloud.WithSignVersion(sv))
 			}
 			return domains, alicloud.NewClient(getString(cmd, "access-key-id"), getString(cmd, "access-key-secret"), opts...), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			opts := []alicloud.Option{}
-			if sv, ok := cfg.Auth["sign_version"]; ok && sv != "" {
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			opts := []alicloud.Option{alicloud.WithRetryPolicy(policy)}
+			if sv, ok := profile.Auth["sign_version"]; ok && sv != "" {
 				opts = append(opts, alicloud.WithSignVersion(sv))
 			}
-			return alicloud.NewClient(cfg.Auth["access_key_id"], cfg.Auth["access_key_secret"], opts...), nil
+			return alicloud.NewClient(profile.Auth["access_key_id"], profile.Auth["access_key_secret"], opts...), nil
 		},
 	},
 	{
@@ -120,15 +127,15 @@ var providerFactories = []providerFactory{
 			{"api-key", "GoDaddy API Key (必填，从 GoDaddy Developer Portal 获取)"},
 			{"api-secret", "GoDaddy API Secret (必填)"},
 		},
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			return domains, godaddy.NewClient(getString(cmd, "api-key"), getString(cmd, "api-secret")), nil
+			return domains, godaddy.NewClient(getString(cmd, "api-key"), getString(cmd, "api-secret"), godaddy.WithRetryPolicy(policy)), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			return godaddy.NewClient(cfg.Auth["api_key"], cfg.Auth["api_secret"]), nil
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			return godaddy.NewClient(profile.Auth["api_key"], profile.Auth["api_secret"], godaddy.WithRetryPolicy(policy)), nil
 		},
 	},
 	{
@@ -137,15 +144,15 @@ var providerFactories = []providerFactory{
 			{"access-key", "Huawei Cloud Access Key (必填，从 IAM 用户获取)"},
 			{"secret-key", "Huawei Cloud Secret Key (必填)"},
 		},
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			return domains, huaweicloud.NewClient(getString(cmd, "access-key"), getString(cmd, "secret-key")), nil
+			return domains, huaweicloud.NewClient(getString(cmd, "access-key"), getString(cmd, "secret-key"), huaweicloud.WithRetryPolicy(policy)), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			return huaweicloud.NewClient(cfg.Auth["access_key"], cfg.Auth["secret_key"]), nil
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			return huaweicloud.NewClient(profile.Auth["access_key"], profile.Auth["secret_key"], huaweicloud.WithRetryPolicy(policy)), nil
 		},
 	},
 	{
@@ -154,15 +161,15 @@ var providerFactories = []providerFactory{
 			{"token", "DuckDNS API Token (必填)"},
 		},
 		noListClean: true,
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			return domains, duckdns.NewClient(getString(cmd, "token")), nil
+			return domains, duckdns.NewClient(getString(cmd, "token"), duckdns.WithRetryPolicy(policy)), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			return duckdns.NewClient(cfg.Auth["token"]), nil
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			return duckdns.NewClient(profile.Auth["token"], duckdns.WithRetryPolicy(policy)), nil
 		},
 	},
 	{
@@ -172,15 +179,15 @@ var providerFactories = []providerFactory{
 			{"password", "No-IP Password (必填)"},
 		},
 		noListClean: true,
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			return domains, noip.NewClient(getString(cmd, "username"), getString(cmd, "password")), nil
+			return domains, noip.NewClient(getString(cmd, "username"), getString(cmd, "password"), noip.WithRetryPolicy(policy)), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			return noip.NewClient(cfg.Auth["username"], cfg.Auth["password"]), nil
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			return noip.NewClient(profile.Auth["username"], profile.Auth["password"], noip.WithRetryPolicy(policy)), nil
 		},
 	},
 	{
@@ -189,15 +196,15 @@ var providerFactories = []providerFactory{
 			{"password", "HE DNS DDNS Key (必填，从 dns.he.net 获取)"},
 		},
 		noListClean: true,
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			return domains, he.NewClient(getString(cmd, "password")), nil
+			return domains, he.NewClient(getString(cmd, "password"), he.WithRetryPolicy(policy)), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			return he.NewClient(cfg.Auth["password"]), nil
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			return he.NewClient(profile.Auth["password"], he.WithRetryPolicy(policy)), nil
 		},
 	},
 	{
@@ -205,15 +212,15 @@ var providerFactories = []providerFactory{
 		flags: []providerFlag{
 			{"token", "Dynv6 API Token (必填)"},
 		},
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			return domains, dynv6.NewClient(getString(cmd, "token")), nil
+			return domains, dynv6.NewClient(getString(cmd, "token"), dynv6.WithRetryPolicy(policy)), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			return dynv6.NewClient(cfg.Auth["token"]), nil
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			return dynv6.NewClient(profile.Auth["token"], dynv6.WithRetryPolicy(policy)), nil
 		},
 	},
 	{
@@ -222,15 +229,15 @@ var providerFactories = []providerFactory{
 			{"api-key", "Porkbun API Key (必填)"},
 			{"api-secret", "Porkbun Secret API Key (必填)"},
 		},
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			return domains, porkbun.NewClient(getString(cmd, "api-key"), getString(cmd, "api-secret")), nil
+			return domains, porkbun.NewClient(getString(cmd, "api-key"), getString(cmd, "api-secret"), porkbun.WithRetryPolicy(policy)), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			return porkbun.NewClient(cfg.Auth["api_key"], cfg.Auth["api_secret"]), nil
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			return porkbun.NewClient(profile.Auth["api_key"], profile.Auth["api_secret"], porkbun.WithRetryPolicy(policy)), nil
 		},
 	},
 	{
@@ -238,15 +245,15 @@ var providerFactories = []providerFactory{
 		flags: []providerFlag{
 			{"token", "DigitalOcean API Token (必填，需具有 write 权限)"},
 		},
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			return domains, digitalocean.NewClient(getString(cmd, "token")), nil
+			return domains, digitalocean.NewClient(getString(cmd, "token"), digitalocean.WithRetryPolicy(policy)), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			return digitalocean.NewClient(cfg.Auth["token"]), nil
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			return digitalocean.NewClient(profile.Auth["token"], digitalocean.WithRetryPolicy(policy)), nil
 		},
 	},
 	{
@@ -255,15 +262,15 @@ var providerFactories = []providerFactory{
 			{"access-key", "Baidu Cloud Access Key (必填)"},
 			{"secret-key", "Baidu Cloud Secret Key (必填)"},
 		},
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			return domains, baiducloud.NewClient(getString(cmd, "access-key"), getString(cmd, "secret-key")), nil
+			return domains, baiducloud.NewClient(getString(cmd, "access-key"), getString(cmd, "secret-key"), baiducloud.WithRetryPolicy(policy)), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			return baiducloud.NewClient(cfg.Auth["access_key"], cfg.Auth["secret_key"]), nil
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			return baiducloud.NewClient(profile.Auth["access_key"], profile.Auth["secret_key"], baiducloud.WithRetryPolicy(policy)), nil
 		},
 	},
 	{
@@ -271,15 +278,15 @@ var providerFactories = []providerFactory{
 		flags: []providerFlag{
 			{"login-token", "DNSPod Login Token (必填，格式: ID,Token)"},
 		},
-		run: func(cmd *cobra.Command) ([]*ddns.Domain, ddns.DNSProvider, error) {
+		run: func(cmd *cobra.Command, policy retry.Policy) ([]*ddns.Domain, ddns.DNSProvider, error) {
 			domains, err := createDomainConfigs(cmd)
 			if err != nil {
 				return nil, nil, err
 			}
-			return domains, dnspod.NewClient(getString(cmd, "login-token")), nil
+			return domains, dnspod.NewClient(getString(cmd, "login-token"), dnspod.WithRetryPolicy(policy)), nil
 		},
-		fromConfig: func(cfg *config.Config) (ddns.DNSProvider, error) {
-			return dnspod.NewClient(cfg.Auth["login_token"]), nil
+		fromConfig: func(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
+			return dnspod.NewClient(profile.Auth["login_token"], dnspod.WithRetryPolicy(policy)), nil
 		},
 	},
 }
@@ -302,8 +309,10 @@ func registerProviders() {
   --domain string       根域名（必填，如 example.com）
   --subdomain string    子域名，可多次指定（默认 "@"）
   --ttl int             DNS 记录 TTL，单位秒（默认 600）
+  --record-type string  记录类型 A/AAAA，可多次指定（默认 "AAAA"）
   --interval duration   非 Linux 平台轮询间隔（默认 5m）
   --interface string    监听的网络接口（仅 Linux Netlink 模式）
+  --dry-run             只打印计划的修改，不写入任何记录
   --debug               开启调试日志
 
 示例:
@@ -318,12 +327,27 @@ func registerProviders() {
 				if err := requireFlags(cmd, p.flags); err != nil {
 					return err
 				}
-				domains, task, err := p.run(cmd)
+				policy, err := retryPolicy(cmd, nil)
 				if err != nil {
 					return err
 				}
+				domains, task, err := p.run(cmd, policy)
+				if err != nil {
+					return err
+				}
+				opts, err := serviceOptions(cmd, nil)
+				if err != nil {
+					return err
+				}
+				fetchers, err := ipv6Fetchers(cmd, nil)
+				if err != nil {
+					return err
+				}
+				if dryRun(cmd) {
+					return runPlan([]ddns.Profile{{Name: "default", Provider: task, Domains: domains}}, fetchers, opts)
+				}
 				iface := getString(cmd, "interface")
-				return ddns.RunService(domains, task, getDuration(cmd, "interval"), ddns.DefaultIPv6Fetchers, iface)
+				return ddns.RunService(domains, task, getDuration(cmd, "interval"), fetchers, iface, opts...)
 			},
 		}
 		for _, f := range p.flags {
@@ -386,7 +410,11 @@ func registerProviderSubCommands(parent *cobra.Command, commandName string, extr
 				if err := requireFlags(cmd, pd.flags); err != nil {
 					return err
 				}
-				domains, provider, err := pd.run(cmd)
+				policy, err := retryPolicy(cmd, nil)
+				if err != nil {
+					return err
+				}
+				domains, provider, err := pd.run(cmd, policy)
 				if err != nil {
 					return err
 				}
@@ -435,7 +463,7 @@ func formatSampleFlags(flags []providerFlag) string {
 	return b.String()
 }
 
-// requireFlags 验证必填字符串 flag 非空。
+// requireFlags 验证必填字符串 flag 非空，缺少时返回 usageError。
 // 在 RunE 中调用，确保必填参数已提供后再执行业务逻辑。
 func requireFlags(cmd *cobra.Command, flags []providerFlag) error {
 	for _, f := range flags {
@@ -444,7 +472,7 @@ func requireFlags(cmd *cobra.Command, flags []providerFlag) error {
 			return fmt.Errorf("invalid --%s flag: %w", f.name, err)
 		}
 		if v == "" {
-			return fmt.Errorf("--%s is required (use --help to see details)", f.name)
+			return &usageError{err: fmt.Errorf("--%s is required (use --help to see details)", f.name)}
 		}
 	}
 	return nil
@@ -454,26 +482,105 @@ func requireFlags(cmd *cobra.Command, flags []providerFlag) error {
 // 配置文件模式：从 ~/.ddns6/config.yaml 创建 provider
 // ============================================================
 
-// runWithConfig 从配置文件加载配置，构造域名列表和 Provider，然后交给 handler 执行。
+// loadConfig 加载配置文件，失败时附带 init 和 CLI 模式的使用提示。
 //
 // commandName 用于生成错误提示中的子命令名称（如 "run"、"list"、"clean"）。
-func runWithConfig(cmd *cobra.Command, commandName string, handler func(cmd *cobra.Command, cfg *config.Config, domains []*ddns.Domain, p ddns.DNSProvider) error) error {
+func loadConfig(commandName string) (*config.Config, error) {
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("cannot load config: %w\n\nUse 'ddns6 init' to create a config file, or specify a provider: ddns6 %s <provider> --help", err, commandName)
+		return nil, fmt.Errorf("cannot load config: %w\n\nUse 'ddns6 init' to create a config file, or specify a provider: ddns6 %s <provider> --help", err, commandName)
 	}
+	return cfg, nil
+}
 
-	domains := buildDomains(cfg.Domain, cfg.Subdomains, cfg.GetTTL())
-	p, err := createProviderFromConfig(cfg)
+// retryPolicy 合并配置文件和命令行参数（命令行参数优先），返回服务商 API 调用的重试策略。
+//
+// 结果通过 providerFactory 的 run/fromConfig 和 notify.WithRetryPolicy 传给各客户端；
+// retry.DefaultPolicy 只作为只读的默认值，不被修改。cfg 为 nil 表示 CLI 模式，仅使用命令行参数和默认值。
+func retryPolicy(cmd *cobra.Command, cfg *config.Config) (retry.Policy, error) {
+	var rc *config.RetryConfig
+	if cfg != nil {
+		rc = cfg.Retry
+	}
+	p, err := rc.Apply(retry.DefaultPolicy)
+	if err != nil {
+		return retry.Policy{}, err
+	}
+	if cmd != nil {
+		if cmd.Flags().Changed("retry-attempts") {
+			if v, err := cmd.Flags().GetInt("retry-attempts"); err == nil {
+				p.Attempts = v
+			}
+		}
+		if cmd.Flags().Changed("retry-base-delay") {
+			if v, err := cmd.Flags().GetDuration("retry-base-delay"); err == nil {
+				p.BaseDelay = v
+			}
+		}
+		if cmd.Flags().Changed("retry-max-delay") {
+			if v, err := cmd.Flags().GetDuration("retry-max-delay"); err == nil {
+				p.MaxDelay = v
+			}
+		}
+	}
+	if p.Attempts < 1 {
+		return retry.Policy{}, fmt.Errorf("invalid --retry-attempts %d: must be >= 1", p.Attempts)
+	}
+	slog.Debug("API retry policy", "module", "cmd",
+		"attempts", p.Attempts, "base_delay", p.BaseDelay, "max_delay", p.MaxDelay)
+	return p, nil
+}
+
+// runWithConfig 从配置文件加载配置，为每个 profile 构造域名列表和 Provider，然后交给 handler 执行。
+//
+// 配置了多个 profile 时依次执行并在每个 profile 前打印标题，
+// 某个 profile 失败不影响其余 profile，所有错误合并后返回。
+func runWithConfig(cmd *cobra.Command, commandName string, handler func(cmd *cobra.Command, profile config.Profile, domains []*ddns.Domain, p ddns.DNSProvider) error) error {
+	cfg, err := loadConfig(commandName)
+	if err != nil {
+		return err
+	}
+	policy, err := retryPolicy(cmd, cfg)
 	if err != nil {
 		return err
 	}
 
-	return handler(cmd, cfg, domains, p)
+	profiles := cfg.GetProfiles()
+	var errs []error
+	for _, profile := range profiles {
+		if len(profiles) > 1 {
+			fmt.Printf("=== Profile: %s (%s) ===\n", profile.Name, profile.Provider)
+		}
+		p, err := createProviderFromProfile(profile, policy)
+		if err == nil {
+			err = handler(cmd, profile, buildProfileDomains(profile), p)
+		}
+		if err != nil {
+			if len(profiles) == 1 {
+				return err
+			}
+			errs = append(errs, fmt.Errorf("profile %s: %w", profile.Name, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
-// runServiceFromConfigHandler 是 runWithConfig 的 handler，将配置和命令行参数合并后启动 DDNS 服务。
-func runServiceFromConfigHandler(cmd *cobra.Command, cfg *config.Config, domains []*ddns.Domain, p ddns.DNSProvider) error {
+// runServiceFromConfig 从配置文件加载所有 profile，将配置和命令行参数合并后启动 DDNS 服务。
+func runServiceFromConfig(cmd *cobra.Command) error {
+	cfg, err := loadConfig("run")
+	if err != nil {
+		return err
+	}
+	policy, err := retryPolicy(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	profiles, err := buildServiceProfiles(cfg, policy)
+	if err != nil {
+		return err
+	}
+
 	// 合并配置与命令行参数（命令行参数优先）
 	interval, err := cfg.GetInterval()
 	if err != nil {
@@ -492,17 +599,437 @@ func runServiceFromConfigHandler(cmd *cobra.Command, cfg *config.Config, domains
 		}
 	}
 
-	return ddns.RunService(domains, p, interval, ddns.DefaultIPv6Fetchers, iface)
+	opts, err := serviceOptions(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	fetchers, err := ipv6Fetchers(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	// --dry-run：只打印计划，不发送通知、不执行钩子
+	if dryRun(cmd) {
+		return runPlan(profiles, fetchers, opts)
+	}
+
+	notifier, err := startNotifier(cfg, policy)
+	if err != nil {
+		return err
+	}
+	if notifier != nil {
+		defer closeNotifier(notifier)
+		opts = append(opts, ddns.WithNotifier(notifier))
+	}
+
+	hooks, err := cfg.Hooks.GetHooks()
+	if err != nil {
+		return err
+	}
+	if len(hooks.Pre) > 0 || len(hooks.Post) > 0 {
+		slog.Info("update hooks enabled", "module", "cmd",
+			"pre", len(hooks.Pre), "post", len(hooks.Post), "timeout", hooks.Timeout)
+		opts = append(opts, ddns.WithHooks(hooks))
+	}
+
+	// 配置文件模式支持热重载：SIGHUP，或启用 watch_config 时配置文件变化
+	watch := cfg.WatchConfig
+	if cmd != nil && cmd.Flags().Changed("watch-config") {
+		if v, err := cmd.Flags().GetBool("watch-config"); err == nil {
+			watch = v
+		}
+	}
+	var watchFile string
+	if watch {
+		if watchFile, err = config.ConfigPath(); err != nil {
+			return err
+		}
+	}
+	opts = append(opts, ddns.WithReload(reloadProfiles(cmd, cfg), watchFile))
+
+	return ddns.RunProfiles(profiles, interval, fetchers, iface, opts...)
 }
 
-// createProviderFromConfig 根据配置的 provider 类型和 auth 字段创建对应的 DNS 服务商。
-func createProviderFromConfig(cfg *config.Config) (ddns.DNSProvider, error) {
+// reloadProfiles 返回热重载时重新读取配置文件并创建 profiles 的函数。
+//
+// 只有 profiles（域名、服务商和凭据）与重试策略会热重载：新的重试策略只用于新创建的
+// 服务商，配置无效时整个重载被拒绝，运行中的服务商不受影响。其余全局设置与运行中的
+// 配置 running 不同时记录警告，需重启生效。
+func reloadProfiles(cmd *cobra.Command, running *config.Config) ddns.ReloadFunc {
+	return func() ([]ddns.Profile, error) {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, err
+		}
+		policy, err := retryPolicy(cmd, cfg)
+		if err != nil {
+			return nil, err
+		}
+		profiles, err := buildServiceProfiles(cfg, policy)
+		if err != nil {
+			return nil, err
+		}
+		if changed := restartRequiredChanges(running, cfg); len(changed) > 0 {
+			slog.Warn("config settings changed that require a restart to take effect", "module", "cmd",
+				"settings", changed)
+		}
+		return profiles, nil
+	}
+}
+
+// restartRequiredChanges 返回 old 与 cfg 之间不同、且不支持热重载的全局设置名称。
+func restartRequiredChanges(old, cfg *config.Config) []string {
+	fields := []struct {
+		name     string
+		old, new any
+	}{
+		{"interval", old.Interval, cfg.Interval},
+		{"interface", old.Interface, cfg.Interface},
+		{"state_file", old.StateFile, cfg.StateFile},
+		{"reconcile_window", old.ReconcileWindow, cfg.ReconcileWindow},
+		{"reconcile_interval", old.ReconcileInterval, cfg.ReconcileInterval},
+		{"status_addr", old.StatusAddr, cfg.StatusAddr},
+		{"health_threshold", old.HealthThreshold, cfg.HealthThreshold},
+		{"shutdown_timeout", old.ShutdownTimeout, cfg.ShutdownTimeout},
+		{"watch_config", old.WatchConfig, cfg.WatchConfig},
+		{"netlink", old.Netlink, cfg.Netlink},
+		{"fetchers", old.Fetchers, cfg.Fetchers},
+		{"quorum", old.Quorum, cfg.Quorum},
+		{"verify", old.Verify, cfg.Verify},
+		{"hooks", old.Hooks, cfg.Hooks},
+		{"notify", old.Notify, cfg.Notify},
+	}
+	var changed []string
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.old, f.new) {
+			changed = append(changed, f.name)
+		}
+	}
+	return changed
+}
+
+// notifierCloseTimeout 服务退出时等待未发送通知的最长时间。
+const notifierCloseTimeout = 10 * time.Second
+
+// startNotifier 按配置文件的 notify 段启动 webhook 通知，发送失败按 policy 重试；未配置 webhook 时返回 nil。
+func startNotifier(cfg *config.Config, policy retry.Policy) (*notify.Dispatcher, error) {
+	webhooks, err := cfg.Notify.GetWebhooks()
+	if err != nil || len(webhooks) == 0 {
+		return nil, err
+	}
+	slog.Info("webhook notifications enabled", "module", "cmd",
+		"webhooks", len(webhooks), "rate_limit", cfg.Notify.GetRateLimit())
+	return notify.New(webhooks, notify.WithRateLimit(cfg.Notify.GetRateLimit()), notify.WithRetryPolicy(policy))
+}
+
+// closeNotifier 等待已入队的通知发送完成（最多 notifierCloseTimeout）。
+func closeNotifier(n *notify.Dispatcher) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifierCloseTimeout)
+	defer cancel()
+	if err := n.Close(ctx); err != nil {
+		slog.Warn("webhook notifications not flushed", "module", "cmd", "err", err)
+	}
+}
+
+// serviceOptions 合并配置文件和命令行参数（命令行参数优先），构造 DDNS 服务的可选配置。
+//
+// cfg 为 nil 表示 CLI 模式，仅使用命令行参数和默认值。
+func serviceOptions(cmd *cobra.Command, cfg *config.Config) ([]ddns.Option, error) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	opts, err := stateOptions(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	reconcileInterval, err := cfg.GetReconcileInterval()
+	if err != nil {
+		return nil, err
+	}
+	if cmd != nil && cmd.Flags().Changed("reconcile-interval") {
+		if v, err := cmd.Flags().GetDuration("reconcile-interval"); err == nil {
+			reconcileInterval = v
+		}
+	}
+	if reconcileInterval > 0 {
+		opts = append(opts, ddns.WithReconcileInterval(reconcileInterval))
+	}
+
+	statusAddr := cfg.StatusAddr
+	if cmd != nil && cmd.Flags().Changed("status-addr") {
+		statusAddr = getString(cmd, "status-addr")
+	}
+	if statusAddr != "" {
+		threshold, err := cfg.GetHealthThreshold()
+		if err != nil {
+			return nil, err
+		}
+		if cmd != nil && cmd.Flags().Changed("health-threshold") {
+			if v, err := cmd.Flags().GetDuration("health-threshold"); err == nil {
+				threshold = v
+			}
+		}
+		opts = append(opts, ddns.WithStatusServer(statusAddr, threshold))
+	}
+
+	shutdownTimeout, err := cfg.GetShutdownTimeout()
+	if err != nil {
+		return nil, err
+	}
+	if cmd != nil && cmd.Flags().Changed("shutdown-timeout") {
+		if v, err := cmd.Flags().GetDuration("shutdown-timeout"); err == nil {
+			shutdownTimeout = v
+		}
+	}
+	opts = append(opts, ddns.WithShutdownTimeout(shutdownTimeout))
+
+	trigger, err := triggerConfig(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, ddns.WithTrigger(trigger))
+
+	fetchOpts, err := fetchOptions(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, fetchOpts...)
+
+	quorum, err := cfg.Quorum.GetQuorum()
+	if err != nil {
+		return nil, err
+	}
+	if cmd != nil && cmd.Flags().Changed("quorum") {
+		if v, err := cmd.Flags().GetInt("quorum"); err == nil {
+			quorum.N = v
+		}
+	}
+	if cmd != nil && cmd.Flags().Changed("quorum-match") {
+		if quorum.Match, err = ipaddr.ParseQuorumMatch(getString(cmd, "quorum-match")); err != nil {
+			return nil, err
+		}
+	}
+	if quorum.Enabled() {
+		slog.Info("IPv6 fetch quorum enabled", "module", "cmd", "quorum", quorum.N, "match", quorum.Match)
+		opts = append(opts, ddns.WithIPv6Quorum(quorum))
+	}
+
+	verify, err := verifyConfig(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if verify != nil {
+		opts = append(opts, ddns.WithVerify(*verify))
+	}
+	return opts, nil
+}
+
+// verifyConfig 合并配置文件 verify 段和 --verify、--verify-timeout（命令行参数优先），
+// 未启用传播验证时返回 nil。
+func verifyConfig(cmd *cobra.Command, cfg *config.Config) (*ddns.VerifyConfig, error) {
+	verify, enabled, err := cfg.Verify.GetVerify()
+	if err != nil {
+		return nil, err
+	}
+	if cmd != nil {
+		flags := cmd.Flags()
+		if flags.Changed("verify") {
+			if v, err := flags.GetBool("verify"); err == nil {
+				enabled = v
+			}
+		}
+		if flags.Changed("verify-timeout") {
+			if v, err := flags.GetDuration("verify-timeout"); err == nil {
+				if v <= 0 {
+					return nil, fmt.Errorf("invalid --verify-timeout %s: must be a positive duration", v)
+				}
+				verify.Timeout = v
+			}
+		}
+	}
+	if !enabled {
+		return nil, nil
+	}
+	return &verify, nil
+}
+
+// ipv6Fetchers 返回 IPv6 地址获取器：--fetcher 优先，其次配置文件 fetchers.ipv6，都未设置时为内置列表。
+// cfg 为 nil 表示 CLI 模式。
+func ipv6Fetchers(cmd *cobra.Command, cfg *config.Config) ([]ipaddr.IPv6Fetcher, error) {
+	if cmd != nil && cmd.Flags().Changed("fetcher") {
+		specs, err := fetcherSpecs(cmd, "fetcher")
+		if err != nil {
+			return nil, err
+		}
+		return config.BuildIPv6Fetchers(specs)
+	}
+	if cfg == nil {
+		return ddns.DefaultIPv6Fetchers, nil
+	}
+	return cfg.Fetchers.GetIPv6Fetchers()
+}
+
+// fetchOptions 构造 IPv4 地址获取器和获取总超时选项（命令行参数优先于配置文件）。
+func fetchOptions(cmd *cobra.Command, cfg *config.Config) ([]ddns.Option, error) {
+	timeout, err := cfg.Fetchers.GetTimeout()
+	if err != nil {
+		return nil, err
+	}
+	if cmd != nil && cmd.Flags().Changed("fetch-timeout") {
+		if v, err := cmd.Flags().GetDuration("fetch-timeout"); err == nil {
+			if v <= 0 {
+				return nil, fmt.Errorf("invalid --fetch-timeout %s: must be a positive duration", v)
+			}
+			timeout = v
+		}
+	}
+	opts := []ddns.Option{ddns.WithFetchTimeout(timeout)}
+
+	var v4 []ipaddr.IPv4Fetcher
+	if cmd != nil && cmd.Flags().Changed("fetcher-ipv4") {
+		specs, err := fetcherSpecs(cmd, "fetcher-ipv4")
+		if err != nil {
+			return nil, err
+		}
+		if v4, err = config.BuildIPv4Fetchers(specs); err != nil {
+			return nil, err
+		}
+	} else if cfg.Fetchers != nil && len(cfg.Fetchers.IPv4) > 0 {
+		if v4, err = cfg.Fetchers.GetIPv4Fetchers(); err != nil {
+			return nil, err
+		}
+	}
+	if v4 != nil {
+		opts = append(opts, ddns.WithIPv4Fetchers(v4...))
+	}
+	return opts, nil
+}
+
+// fetcherSpecs 解析 name 参数（--fetcher 或 --fetcher-ipv4）中的获取器描述。
+func fetcherSpecs(cmd *cobra.Command, name string) ([]config.FetcherConfig, error) {
+	values, err := cmd.Flags().GetStringArray(name)
+	if err != nil {
+		return nil, err
+	}
+	specs := make([]config.FetcherConfig, 0, len(values))
+	for _, v := range values {
+		f, err := config.ParseFetcherSpec(v)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %w", name, err)
+		}
+		specs = append(specs, f)
+	}
+	return specs, nil
+}
+
+// triggerConfig 合并配置文件 netlink 段和命令行参数（命令行参数优先），构造 Netlink 触发源配置。
+func triggerConfig(cmd *cobra.Command, cfg *config.Config) (ddns.TriggerConfig, error) {
+	trigger, err := cfg.Netlink.GetTrigger()
+	if err != nil {
+		return trigger, err
+	}
+	if cmd == nil {
+		return trigger, nil
+	}
+	flags := cmd.Flags()
+	for _, f := range []struct {
+		name string
+		dst  *time.Duration
+	}{
+		{"debounce", &trigger.Debounce},
+		{"debounce-max-wait", &trigger.MaxWait},
+	} {
+		if !flags.Changed(f.name) {
+			continue
+		}
+		if v, err := flags.GetDuration(f.name); err == nil {
+			if v <= 0 {
+				return trigger, fmt.Errorf("invalid --%s %s: must be a positive duration", f.name, v)
+			}
+			*f.dst = v
+		}
+	}
+	if flags.Changed("include-ula") {
+		if v, err := flags.GetBool("include-ula"); err == nil {
+			trigger.IncludeULA = v
+		}
+	}
+	if flags.Changed("watch-interface") {
+		if v, err := flags.GetStringArray("watch-interface"); err == nil {
+			if err := ddns.ValidateInterfacePatterns(v); err != nil {
+				return trigger, err
+			}
+			trigger.Interfaces = v
+		}
+	}
+	return trigger, nil
+}
+
+// stateOptions 构造状态持久化选项（命令行参数优先于配置文件）。
+//
+// 状态文件被禁用时返回空列表；已有状态文件损坏时只记录警告，以空状态启动。
+func stateOptions(cmd *cobra.Command, cfg *config.Config) ([]ddns.Option, error) {
+	c := config.Config{StateFile: cfg.StateFile, ReconcileWindow: cfg.ReconcileWindow}
+	if cmd != nil && cmd.Flags().Changed("state-file") {
+		c.StateFile = getString(cmd, "state-file")
+	}
+
+	path, err := c.GetStateFile()
+	if err != nil {
+		return nil, err
+	}
+	window, err := c.GetReconcileWindow()
+	if err != nil {
+		return nil, err
+	}
+	if cmd != nil && cmd.Flags().Changed("reconcile-window") {
+		if v, err := cmd.Flags().GetDuration("reconcile-window"); err == nil {
+			window = v
+		}
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	store := ddns.NewStateStore(path)
+	if err := store.Load(); err != nil {
+		slog.Warn("failed to load state, starting without it", "module", "cmd", "err", err)
+	}
+	return []ddns.Option{ddns.WithStateStore(store, window)}, nil
+}
+
+// buildServiceProfiles 为配置中的每个 profile 创建 DNSProvider（使用重试策略 policy）和域名列表。
+func buildServiceProfiles(cfg *config.Config, policy retry.Policy) ([]ddns.Profile, error) {
+	var profiles []ddns.Profile
+	for _, profile := range cfg.GetProfiles() {
+		p, err := createProviderFromProfile(profile, policy)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+		domains := buildProfileDomains(profile)
+		for _, d := range domains {
+			if d.OnWithdraw == ddns.WithdrawDisable && !ddns.SupportsDisable(p) {
+				return nil, fmt.Errorf("profile %s: provider %s does not support on_withdraw %q", profile.Name, profile.Provider, d.OnWithdraw)
+			}
+		}
+		profiles = append(profiles, ddns.Profile{
+			Name:     profile.Name,
+			Provider: p,
+			Domains:  domains,
+		})
+	}
+	return profiles, nil
+}
+
+// createProviderFromProfile 根据 profile 的 provider 类型和 auth 字段创建对应的 DNS 服务商，
+// API 调用按 policy 重试。
+func createProviderFromProfile(profile config.Profile, policy retry.Policy) (ddns.DNSProvider, error) {
 	for _, p := range providerFactories {
-		if p.name == cfg.Provider {
-			return p.fromConfig(cfg)
+		if p.name == profile.Provider {
+			return p.fromConfig(profile, policy)
 		}
 	}
-	return nil, fmt.Errorf("unsupported provider: %s", cfg.Provider)
+	return nil, fmt.Errorf("unsupported provider: %s", profile.Provider)
 }
 
 // ============================================================
@@ -555,19 +1082,62 @@ func createDomainConfigs(cmd *cobra.Command) ([]*ddns.Domain, error) {
 		return nil, fmt.Errorf("invalid --ttl flag: %w", err)
 	}
 
-	return buildDomains(domainName, subdomains, ttl), nil
+	// --record-type 未注册时（如测试中的裸命令）使用默认 AAAA
+	var recordTypes []string
+	if types, err := cmd.Flags().GetStringArray("record-type"); err == nil {
+		recordTypes, err = config.NormalizeRecordTypes(types)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --record-type flag: %w", err)
+		}
+	}
+
+	return buildDomains(domainName, subdomains, ttl, recordTypes...), nil
 }
 
 // buildDomains 根据根域名、子域名列表和 TTL 创建 Domain 列表。
-func buildDomains(domain string, subdomains []string, ttl int) []*ddns.Domain {
-	domains := make([]*ddns.Domain, len(subdomains))
-	for i, sd := range subdomains {
-		domains[i] = &ddns.Domain{
-			Type:      "AAAA",
-			Domain:    domain,
-			SubDomain: sd,
-			TTL:       ttl,
+//
+// 每个子域名按 recordTypes 各生成一个 Domain（如同时指定 A 和 AAAA 则生成两个），
+// 未指定 recordTypes 时默认只生成 AAAA。
+func buildDomains(domain string, subdomains []string, ttl int, recordTypes ...string) []*ddns.Domain {
+	if len(recordTypes) == 0 {
+		recordTypes = []string{ddns.RecordTypeAAAA}
+	}
+	domains := make([]*ddns.Domain, 0, len(subdomains)*len(recordTypes))
+	for _, sd := range subdomains {
+		for _, t := range recordTypes {
+			domains = append(domains, &ddns.Domain{
+				Type:      t,
+				Domain:    domain,
+				SubDomain: sd,
+				TTL:       ttl,
+			})
+		}
+	}
+	return domains
+}
+
+// buildProfileDomains 根据配置文件中的 profile 创建 Domain 列表，支持子域名级别的记录类型、
+// 局域网主机后缀、地址撤回策略和地址策略（均已在 config.Load 中校验）。
+func buildProfileDomains(profile config.Profile) []*ddns.Domain {
+	var domains []*ddns.Domain
+	for _, sd := range profile.Subdomains {
+		built := buildDomains(profile.Domain, []string{sd.Name}, profile.GetTTL(), profile.GetRecordTypes(sd)...)
+		if suffix, prefixLen, err := sd.GetSuffix(); err == nil && suffix != nil {
+			for _, d := range built {
+				d.Suffix, d.PrefixLength = suffix, prefixLen
+			}
+		}
+		if policy, fallback, err := profile.GetWithdraw(sd); err == nil {
+			for _, d := range built {
+				d.OnWithdraw, d.Fallback = policy, fallback
+			}
+		}
+		if policy, err := profile.GetPolicy(sd); err == nil {
+			for _, d := range built {
+				d.Policy = policy
+			}
 		}
+		domains = append(domains, built...)
 	}
 	return domains
 }
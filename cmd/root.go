@@ -7,6 +7,7 @@
 //	├── version   显示版本信息
 //	├── help      查看命令帮助（cobra 内置）
 //	├── check     验证配置和 API 连通性
+//	├── healthcheck 探测运行中服务的 /healthz 端点
 //	├── completion 生成 Shell 自动补全脚本
 //	├── run       运行 DDNS 服务
 //	│   ├── tencent      腾讯云 DNSPod (API v3)
@@ -22,16 +23,19 @@
 //	│   ├── digitalocean DigitalOcean DNS API
 //	│   ├── baiducloud   百度云 DNS
 //	│   └── dnspod       DNSPod (旧版 API)
+//	├── update   [provider] 执行一次同步后退出（provider 子命令与 run 相同）
 //	├── list     [provider] 列出 DNS 记录
 //	└── clean   [provider] 删除 DNS 记录
 //
 // 使用方式：
 //   - 临时运行: ddns6 run tencent --domain example.com --subdomain www --secret-id xxx --secret-key yyy
 //   - 长期运行: ddns6 init tencent --domain example.com --secret-id xxx --secret-key yyy -> ddns6 run
+//   - 定时任务: ddns6 update（退出码见 ddns6 update --help）
 //   - 查看帮助: ddns6 run tencent --help
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -44,6 +48,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/notes-bin/ddns6/internal/config"
+	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 var (
@@ -102,8 +109,8 @@ var rootCmd = &cobra.Command{
 			return
 		}
 
-		// version 和 init 命令不需要初始化日志
-		if cmd.Name() == "version" || cmd.Name() == "init" {
+		// version、init 和 healthcheck 命令不需要初始化日志
+		if cmd.Name() == "version" || cmd.Name() == "init" || cmd.Name() == "healthcheck" {
 			return
 		}
 
@@ -192,13 +199,21 @@ var initCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("invalid --interface flag: %w", err)
 		}
+		recordTypes, err := cmd.Flags().GetStringArray("record-type")
+		if err != nil {
+			return fmt.Errorf("invalid --record-type flag: %w", err)
+		}
+		if recordTypes, err = config.NormalizeRecordTypes(recordTypes); err != nil {
+			return fmt.Errorf("invalid --record-type flag: %w", err)
+		}
 
 		params := config.InitParams{
-			Domain:     domain,
-			Subdomains: subdomains,
-			TTL:        ttl,
-			Interval:   interval,
-			Interface:  iface,
+			Domain:      domain,
+			Subdomains:  subdomains,
+			TTL:         ttl,
+			Interval:    interval,
+			Interface:   iface,
+			RecordTypes: recordTypes,
 		}
 
 		// 如果指定了 provider 名称，收集对应的认证参数
@@ -242,12 +257,78 @@ var runCmd = &cobra.Command{
 触发机制:
   Linux   内核 Netlink 事件驱动。检测到新的全局单播 IPv6 地址后，
           等待 10 秒防抖（应对 PPPoE 重拨等不稳定场景），再执行更新。
+          --debounce 调整防抖时间，--debounce-max-wait（默认 1m）限制持续事件下的最长等待；
+          --include-ula 使 ULA 地址事件也触发同步；--watch-interface 'ppp*' 按名称模式监听多个接口。
   macOS/Windows  定时轮询（默认 5 分钟，通过 --interval 调整）。
 
 多子域名支持:
   可通过多次 --subdomain 指定多个子域名，一次命令更新所有子域名。
   例如: --subdomain www --subdomain @ --subdomain api
 
+双栈支持:
+  默认只发布 AAAA 记录。通过 --record-type A --record-type AAAA 同时发布 A 记录，
+  IPv4 地址通过独立的 IPv4 获取源查询，与 AAAA 记录走同一同步流程。
+
+状态持久化:
+  每个域名最近发布的地址、记录 ID 和时间戳保存在 ~/.ddns6/state.json（--state-file）。
+  重启后在对账窗口（--reconcile-window，默认 24h）内信任该状态，地址未变化时不再逐个
+  查询服务商记录；超过窗口后重新核对一次。
+
+漂移对账:
+  --reconcile-interval 1h 定期忽略缓存重新读取服务商记录，与 Netlink 事件并行工作。
+  记录在控制台被修改或删除时自动修复，每次修复记录一条 drift 事件日志。
+
+API 重试:
+  每次服务商 API 调用遇到网络错误、429、5xx 或限流错误码（如腾讯云 RequestLimitExceeded、
+  阿里云 Throttling）时按指数退避重试，遵循 Retry-After。
+  通过 --retry-attempts、--retry-base-delay、--retry-max-delay 或配置文件 retry 段调整。
+
+失败重试:
+  启动后同步失败的子域名按指数退避（30s 起，最长 30m）在后台重试，
+  直到同步成功或地址再次变化，不必等待下一次 Netlink 事件。
+
+状态端点:
+  --status-addr 127.0.0.1:9876 启动 HTTP 状态端点：GET /status 返回各域名当前发布的地址、
+  最近同步时间和错误、触发模式及地址获取器结果（JSON）；GET /healthz 在任一域名持续同步失败
+  超过 --health-threshold（默认 15m）时返回 503。ddns6 healthcheck 探测该端点。
+  GET /metrics 导出 Prometheus 指标（同步次数、API 延迟、获取器结果、Netlink 事件等）。
+
+优雅关闭:
+  收到 SIGINT/SIGTERM 后不再开始新的同步、对账和重试，进行中的同步继续执行，全部结束后立即退出；
+  最多等待 --shutdown-timeout（默认 5s），超时后取消仍在进行的 API 调用，日志列出被中断的域名。
+
+热重载:
+  配置文件模式下，收到 SIGHUP（kill -HUP <pid>）时重新读取配置文件，新增或删除的域名和
+  profile 立即生效，Netlink 监听不中断；新配置无效时记录错误并继续使用当前配置。
+  --watch-config（或配置文件 watch_config: true）在配置文件变化时自动重载。
+  interval、interface、netlink、fetchers、quorum、verify、state_file、status_addr、hooks、notify 等全局设置需重启生效。
+
+地址获取器:
+  默认并发请求内置的 HTTP 回显服务和 DNS 服务器，取最先返回的地址。--fetcher 或配置文件 fetchers
+  段取代内置列表，格式为 TYPE[@TIMEOUT]:VALUE：http（URL，可省略 http: 前缀）、dns（服务器地址）、
+  dnsquery（真实 DNS 查询：opendns、google、akamai 或 NAME/TYPE@SERVER，NAT66 后也能得到公网地址）、
+  interface（本机接口，仅 Linux）、command（外部命令，取输出中的第一个地址）。
+  --fetch-timeout 调整每次获取的总超时（默认 5s）。
+
+IPv6 共识模式:
+  默认采用最快返回的获取器结果。--quorum 2 要求至少 2 个获取器的结果一致才发布，
+  避免单个异常或被劫持的回显服务导致发布错误地址；--quorum-match prefix 只要求同一 /64 前缀。
+  结果分歧时记录一条列出各地址及其来源的警告日志；无法达成共识时不修改记录。
+
+传播验证:
+  --verify（或配置文件 verify 段）在每次修改或新增记录后查询根域名的权威服务器（NS 记录），
+  直接向每台服务器查询记录，直到全部返回新值，日志记录所用时间。超过 --verify-timeout（默认 5m）
+  仍未全部生效时记录警告、发送 propagation_timeout 通知，并通过失败重试重新写入记录。
+
+计划模式:
+  --dry-run 获取一次地址，按与正常运行相同的同步逻辑查询服务商记录后退出，不写入任何记录。
+  每条记录输出一行：modify（将修改）、add（将新增）或 unchanged（保持不变），
+  包含记录 ID、当前值、新值和 TTL。计划总是查询服务商，不使用状态文件中的缓存地址。
+
+多 Profile:
+  配置文件中使用 profiles 列表可在一个进程中管理多个运营商、账号或根域名，
+  所有 profile 共享一次地址获取和一个 Netlink 监听，各自使用自己的凭据同步。
+
 配置优先级（从高到低）:
   1. 命令行参数（最高）
   2. 环境变量 DDNS6_*（如 DDNS6_DOMAIN、DDNS6_SUBDOMAIN）
@@ -275,6 +356,9 @@ var runCmd = &cobra.Command{
   # 临时运行（多子域名）
   ddns6 run cloudflare --domain example.com --subdomain www --subdomain @ --api-token xxx
 
+  # 双栈（同时更新 A 和 AAAA 记录）
+  ddns6 run cloudflare --domain example.com --subdomain www --record-type A --record-type AAAA --api-token xxx
+
   # 指定网络接口
   ddns6 run duckdns --domain example.com --interface ppp0 --token xxx
 
@@ -295,11 +379,7 @@ var runCmd = &cobra.Command{
 			cmd.Help()
 			return nil
 		}
-		if err := runWithConfig(cmd, "run", runServiceFromConfigHandler); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
-			os.Exit(1)
-		}
-		return nil
+		return runServiceFromConfig(cmd)
 	},
 }
 
@@ -331,8 +411,30 @@ var persistentFlags = []struct {
 	{"domain", "string", "", "要更新的域名（如 example.com）", "DDNS6_DOMAIN"},
 	{"subdomain", "stringArray", []string{"@"}, "子域名名称，可多次指定（默认 @，如 --subdomain www --subdomain @）", "DDNS6_SUBDOMAIN"},
 	{"ttl", "int", 600, "DNS 记录 TTL，单位秒（默认 600）", "DDNS6_TTL"},
+	{"record-type", "stringArray", []string{"AAAA"}, "记录类型 A 或 AAAA，可多次指定实现双栈（默认 AAAA，如 --record-type A --record-type AAAA）", "DDNS6_RECORD_TYPE"},
 	{"interface", "string", "", "监听的网络接口（仅 Linux Netlink 模式，如 --interface ppp0）", "DDNS6_INTERFACE"},
 	{"log-file", "string", "ddns6.log", "日志文件路径，设为空字符串仅输出到 stderr", "DDNS6_LOG_FILE"},
+	{"state-file", "string", "", "状态文件路径（默认 ~/.ddns6/state.json），设为 none 禁用状态持久化", "DDNS6_STATE_FILE"},
+	{"reconcile-window", "duration", ddns.DefaultReconcileWindow, "持久化状态的信任时长，超过后重新向服务商核对记录（默认 24h，0 表示不信任）", "DDNS6_RECONCILE_WINDOW"},
+	{"reconcile-interval", "duration", time.Duration(0), "周期性漂移对账间隔，重新读取服务商记录并修复漂移（默认 0 禁用，如 --reconcile-interval 1h）", "DDNS6_RECONCILE_INTERVAL"},
+	{"retry-attempts", "int", retry.DefaultPolicy.Attempts, "服务商 API 调用的最大尝试次数（含首次，默认 3，1 表示不重试）", "DDNS6_RETRY_ATTEMPTS"},
+	{"retry-base-delay", "duration", retry.DefaultPolicy.BaseDelay, "API 重试的基础退避时间，每次翻倍（默认 500ms）", "DDNS6_RETRY_BASE_DELAY"},
+	{"retry-max-delay", "duration", retry.DefaultPolicy.MaxDelay, "API 重试的单次等待上限，Retry-After 超过此值时放弃（默认 30s）", "DDNS6_RETRY_MAX_DELAY"},
+	{"status-addr", "string", "", "HTTP 状态端点监听地址，提供 /status、/healthz 和 /metrics（默认不启动，如 --status-addr 127.0.0.1:9876）", "DDNS6_STATUS_ADDR"},
+	{"health-threshold", "duration", ddns.DefaultHealthThreshold, "域名持续同步失败超过此时长时 /healthz 报告失败（默认 15m）", "DDNS6_HEALTH_THRESHOLD"},
+	{"shutdown-timeout", "duration", ddns.DefaultShutdownTimeout, "收到退出信号后等待进行中同步完成的最长时间（默认 5s）", "DDNS6_SHUTDOWN_TIMEOUT"},
+	{"watch-config", "bool", false, "配置文件变化时自动热重载（仅配置文件模式，SIGHUP 始终触发重载）", "DDNS6_WATCH_CONFIG"},
+	{"debounce", "duration", ddns.DefaultDebounce, "Netlink 地址事件的防抖等待时间（仅 Linux，默认 10s）", "DDNS6_DEBOUNCE"},
+	{"debounce-max-wait", "duration", ddns.DefaultDebounceMaxWait, "自第一个地址事件起的最长等待时间，持续事件不会无限推迟同步（仅 Linux，默认 1m）", "DDNS6_DEBOUNCE_MAX_WAIT"},
+	{"include-ula", "bool", false, "ULA（fc00::/7）地址事件也触发同步（仅 Linux，默认忽略）", "DDNS6_INCLUDE_ULA"},
+	{"fetcher", "stringArray", []string(nil), "IPv6 地址获取器 TYPE[@TIMEOUT]:VALUE，可多次指定，取代内置列表（type: http、dns、dnsquery、interface、command，如 --fetcher https://6.ipw.cn --fetcher interface:ppp0）", "DDNS6_FETCHER"},
+	{"fetcher-ipv4", "stringArray", []string(nil), "IPv4 地址获取器，格式同 --fetcher（不支持 interface），可多次指定", "DDNS6_FETCHER_IPV4"},
+	{"fetch-timeout", "duration", ipaddr.DefaultFetchTimeout, "每次地址获取的总超时时间（默认 5s）", "DDNS6_FETCH_TIMEOUT"},
+	{"quorum", "int", 0, "IPv6 地址获取的共识数量，至少该数量的获取器结果一致才采用（默认 0 表示最快者胜出，如 --quorum 2）", "DDNS6_QUORUM"},
+	{"quorum-match", "string", string(ipaddr.MatchAddress), "共识的判定方式：address（完整地址）或 prefix（同一 /64 前缀）", "DDNS6_QUORUM_MATCH"},
+	{"verify", "bool", false, "记录更新后向权威服务器确认新值已生效，超时则通知并重写记录（默认不验证）", "DDNS6_VERIFY"},
+	{"verify-timeout", "duration", ddns.DefaultVerifyTimeout, "所有权威服务器返回新值的截止时间（默认 5m）", "DDNS6_VERIFY_TIMEOUT"},
+	{"watch-interface", "stringArray", []string(nil), "监听的接口名模式，可多次指定，设置后取代 --interface（仅 Linux，如 --watch-interface 'ppp*'）", "DDNS6_WATCH_INTERFACE"},
 }
 
 // initRootCmd 初始化根命令，注册所有 flag 和子命令。
@@ -359,7 +461,7 @@ func initRootCmd() {
 	// 注册全局持久化参数
 	for _, f := range persistentFlags {
 		switch f.name {
-		case "debug":
+		case "debug", "watch-config", "include-ula", "verify":
 			rootCmd.PersistentFlags().Bool(f.name, f.defaultValue.(bool), f.usage)
 		case "interval":
 			rootCmd.PersistentFlags().Duration(f.name, f.defaultValue.(time.Duration), f.usage)
@@ -367,12 +469,17 @@ func initRootCmd() {
 			rootCmd.PersistentFlags().String(f.name, f.defaultValue.(string), f.usage)
 		case "subdomain":
 			rootCmd.PersistentFlags().StringArray(f.name, f.defaultValue.([]string), f.usage)
-		case "ttl":
+		case "ttl", "retry-attempts", "quorum":
 			rootCmd.PersistentFlags().Int(f.name, f.defaultValue.(int), f.usage)
+		case "record-type", "watch-interface", "fetcher", "fetcher-ipv4":
+			rootCmd.PersistentFlags().StringArray(f.name, f.defaultValue.([]string), f.usage)
 		case "interface":
 			rootCmd.PersistentFlags().String(f.name, f.defaultValue.(string), f.usage)
-		case "log-file":
+		case "log-file", "state-file", "status-addr", "quorum-match":
 			rootCmd.PersistentFlags().String(f.name, f.defaultValue.(string), f.usage)
+		case "reconcile-window", "reconcile-interval", "retry-base-delay", "retry-max-delay", "health-threshold", "shutdown-timeout",
+			"debounce", "debounce-max-wait", "fetch-timeout", "verify-timeout":
+			rootCmd.PersistentFlags().Duration(f.name, f.defaultValue.(time.Duration), f.usage)
 		}
 	}
 
@@ -415,6 +522,9 @@ func initRootCmd() {
 		},
 	})
 
+	// run 子命令的参数（provider 子命令继承）
+	runCmd.PersistentFlags().Bool("dry-run", false, "只查询服务商并打印计划的修改（记录 ID、旧值、新值、TTL），不写入任何记录")
+
 	// 注册子命令
 	// init 子命令的本地参数（预填值到配置文件）
 	initCmd.Flags().String("domain", "", "根域名, 预填入配置文件")
@@ -422,6 +532,7 @@ func initRootCmd() {
 	initCmd.Flags().Int("ttl", 0, "DNS 记录 TTL, 单位秒, 预填入配置文件")
 	initCmd.Flags().String("interval", "", "轮询间隔, 如 10m, 预填入配置文件")
 	initCmd.Flags().String("interface", "", "网络接口, 预填入配置文件")
+	initCmd.Flags().StringArray("record-type", nil, "记录类型 A/AAAA, 可多次指定, 预填入配置文件")
 
 	// 注册所有 provider 的认证参数到 init 命令（如 --secret-id、--api-token）
 	// 使用 map 去重，确保同一 flag 名只注册一次
@@ -438,14 +549,24 @@ func initRootCmd() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(updateCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(cleanCmd)
 	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(healthcheckCmd)
 
 	// 数据驱动注册所有运营商命令
 	registerProviders()
+	registerUpdateCommands()
 	registerListCommands()
 	registerCleanCommands()
+	registerHealthcheckCommand()
+
+	// 参数解析和位置参数错误视为用法错误（退出码 ExitUsage），须在所有命令注册之后设置
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &usageError{err: err}
+	})
+	usageArgs(rootCmd)
 }
 
 // applyEnvOverrides 检查 DDNS6_* 环境变量并覆盖持久化 flag 的默认值。
@@ -482,18 +603,114 @@ func applyEnvOverrides() {
 	}
 }
 
-// Execute 是 CLI 入口，由 main.go 调用。
+// 进程退出码，main 通过 ExitCode 取得。
+const (
+	ExitOK      = 0  // 成功（ddns6 update：没有记录被修改）
+	ExitFailure = 1  // 命令执行失败
+	ExitUsage   = 2  // 未知命令、无效参数等用法错误
+	ExitChanged = 10 // ddns6 update：所有域名同步成功，且至少一条记录被修改或新增
+)
+
+// ExitError 指定进程退出码的错误。Err 为 nil 表示不属于失败的退出状态（如 ExitChanged）。
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// ExitCode 返回 Execute 的返回值对应的进程退出码：nil 为 ExitOK，*ExitError 为其 Code，其余为 ExitFailure。
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var ee *ExitError
+	if errors.As(err, &ee) {
+		return ee.Code
+	}
+	return ExitFailure
+}
+
+// usageError 命令行用法错误：参数解析失败（由 cobra 的 FlagErrorFunc 包装）、位置参数不符
+// （由 usageArgs 包装）、未知 provider 或缺少必填参数。
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+
+func (e *usageError) Unwrap() error { return e.err }
+
+// isUsageError 判断 err 是否为用法错误。
+func isUsageError(err error) bool {
+	var ue *usageError
+	return errors.As(err, &ue)
+}
+
+// usageArgs 将 cmd 及其子命令的位置参数校验错误（参数个数不符、未知子命令）包装为 usageError。
+//
+// 未设置 Args 的命令保持 cobra 的默认行为：根命令拒绝未知子命令，其余命令接受任意参数。
+func usageArgs(cmd *cobra.Command) {
+	validate := cmd.Args
+	if validate == nil {
+		validate = cobra.ArbitraryArgs
+		if !cmd.HasParent() {
+			validate = unknownCommand
+		}
+	}
+	cmd.Args = func(c *cobra.Command, args []string) error {
+		if err := validate(c, args); err != nil {
+			return &usageError{err: err}
+		}
+		return nil
+	}
+	for _, sub := range cmd.Commands() {
+		usageArgs(sub)
+	}
+}
+
+// unknownCommand 拒绝任何位置参数，错误信息与 cobra 对未知子命令的提示相同（含拼写建议）。
+func unknownCommand(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("unknown command %q for %q", args[0], cmd.CommandPath())
+	if suggestions := cmd.SuggestionsFor(args[0]); len(suggestions) > 0 {
+		msg += "\n\nDid you mean this?\n\t" + strings.Join(suggestions, "\n\t")
+	}
+	return errors.New(msg)
+}
+
+// Execute 是 CLI 入口，由 main.go 调用，main 按 ExitCode 的返回值退出。
+//
+// 失败时错误信息已打印到 stderr，用法错误还会打印对应命令的帮助。
 func Execute() error {
 	initRootCmd()
-	if err := rootCmd.Execute(); err != nil {
-		errStr := err.Error()
-		// 未知命令、无效 flag、参数错误等用户侧错误，显示帮助后优雅退出
-		if strings.Contains(errStr, "unknown") || strings.Contains(errStr, "flag") {
-			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
-			rootCmd.Help()
-			return nil
-		}
-		return fmt.Errorf("Command failed: %w", err)
+	return execute()
+}
+
+// execute 执行已初始化的 rootCmd，按错误类型打印错误信息并返回对应退出码的错误。
+func execute() error {
+	cmd, err := rootCmd.ExecuteC()
+	if err == nil {
+		return nil
+	}
+	var ee *ExitError
+	if errors.As(err, &ee) && ee.Err == nil {
+		return err
+	}
+	if isUsageError(err) {
+		fmt.Fprintf(rootCmd.ErrOrStderr(), "Error: %v\n\n", err)
+		cmd.Help()
+		return &ExitError{Code: ExitUsage, Err: err}
 	}
-	return nil
+	fmt.Fprintf(rootCmd.ErrOrStderr(), "Error: %v\n", err)
+	return err
 }
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// updateCmd 执行一次地址获取和同步后退出。
+var updateCmd = &cobra.Command{
+	Use:   "update [provider]",
+	Short: "执行一次 DDNS 更新后退出",
+	Long: `获取一次本机地址，同步所有域名的 DNS 记录后退出，适用于 cron 和 systemd timer。
+
+不指定 provider 子命令时，从 ~/.ddns6/config.yaml 读取配置（含多 profile）；
+指定 provider 则使用命令行参数，参数与 ddns6 run 相同。
+
+与 ddns6 run 共用同步流程、状态文件、地址获取器、共识模式、钩子和通知，
+不启动 Netlink 监听、失败重试、状态端点和传播验证。
+
+输出:
+  每个域名一行：unchanged（记录已是目标地址）、modified（修改了记录）、
+  added（新增了记录）或 failed（同步失败或未获取到地址），末尾为各状态的数量。
+
+退出码:
+  0   所有域名同步成功，没有记录被修改
+  10  所有域名同步成功，至少一条记录被修改或新增
+  1   至少一个域名同步失败（或配置错误等其他错误）
+  2   命令行用法错误（未知命令、provider 或参数，缺少必填参数）
+
+示例:
+  # 使用配置文件
+  ddns6 update
+
+  # 使用命令行参数
+  ddns6 update cloudflare --domain example.com --subdomain www --api-token xxx
+
+  # 记录变化时执行后续操作
+  ddns6 update; [ $? -eq 10 ] && systemctl reload nginx
+
+  # cron：每 5 分钟执行一次
+  */5 * * * * /usr/local/bin/ddns6 update --log-file ""`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// ddns6 update help - 显示帮助
+		if len(args) > 0 && args[0] == "help" {
+			cmd.Help()
+			return nil
+		}
+		if len(args) > 0 {
+			return &usageError{err: fmt.Errorf("unknown provider %q (see ddns6 update --help)", args[0])}
+		}
+		return runUpdateFromConfig(cmd)
+	},
+}
+
+// registerUpdateCommands 注册 update 命令的 provider 子命令，参数与 run 的 provider 子命令相同。
+func registerUpdateCommands() {
+	for i := range providerFactories {
+		p := &providerFactories[i]
+		cmd := &cobra.Command{
+			Use:   p.name,
+			Short: p.short,
+			Long: fmt.Sprintf(`%s provider for DDNS6
+
+使用方式:
+  ddns6 update %s [flags]
+
+必填参数:
+%s
+全局参数与 ddns6 run %s 相同，退出码见 ddns6 update --help。
+
+示例:
+  ddns6 update %s --domain example.com --subdomain www %s`,
+				p.name, p.name,
+				formatProviderFlags(p.flags),
+				p.name,
+				p.name, formatSampleFlags(p.flags)),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := requireFlags(cmd, p.flags); err != nil {
+					return err
+				}
+				policy, err := retryPolicy(cmd, nil)
+				if err != nil {
+					return err
+				}
+				domains, provider, err := p.run(cmd, policy)
+				if err != nil {
+					return err
+				}
+				opts, err := serviceOptions(cmd, nil)
+				if err != nil {
+					return err
+				}
+				fetchers, err := ipv6Fetchers(cmd, nil)
+				if err != nil {
+					return err
+				}
+				profiles := []ddns.Profile{{Name: "default", Provider: provider, Domains: domains}}
+				return runUpdate(profiles, fetchers, opts)
+			},
+		}
+		for _, f := range p.flags {
+			cmd.Flags().String(f.name, "", f.usage)
+		}
+		updateCmd.AddCommand(cmd)
+	}
+}
+
+// runUpdateFromConfig 从配置文件加载所有 profile，执行一次同步。
+func runUpdateFromConfig(cmd *cobra.Command) error {
+	cfg, err := loadConfig("update")
+	if err != nil {
+		return err
+	}
+	policy, err := retryPolicy(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	profiles, err := buildServiceProfiles(cfg, policy)
+	if err != nil {
+		return err
+	}
+	opts, err := serviceOptions(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	fetchers, err := ipv6Fetchers(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	notifier, err := startNotifier(cfg, policy)
+	if err != nil {
+		return err
+	}
+	if notifier != nil {
+		defer closeNotifier(notifier)
+		opts = append(opts, ddns.WithNotifier(notifier))
+	}
+	hooks, err := cfg.Hooks.GetHooks()
+	if err != nil {
+		return err
+	}
+	if len(hooks.Pre) > 0 || len(hooks.Post) > 0 {
+		opts = append(opts, ddns.WithHooks(hooks))
+	}
+	return runUpdate(profiles, fetchers, opts)
+}
+
+// runUpdate 执行一次同步并打印结果，按结果返回 ExitChanged 或同步失败的错误。
+// 收到 SIGINT/SIGTERM 时取消进行中的 API 调用。
+func runUpdate(profiles []ddns.Profile, fetchers []ipaddr.IPv6Fetcher, opts []ddns.Option) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	results, err := ddns.UpdateOnce(ctx, profiles, fetchers, opts...)
+	if err != nil {
+		return err
+	}
+	fmt.Print(ddns.FormatUpdateResults(results))
+
+	failed, changed := 0, false
+	for _, r := range results {
+		if r.Status == ddns.UpdateFailed {
+			failed++
+		}
+		changed = changed || r.Changed()
+	}
+	slog.Info("one-shot update finished", "module", "cmd", "domains", len(results), "failed", failed, "changed", changed)
+	switch {
+	case failed > 0:
+		return fmt.Errorf("%d of %d domains failed to update", failed, len(results))
+	case changed:
+		return &ExitError{Code: ExitChanged}
+	}
+	return nil
+}
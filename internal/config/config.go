@@ -10,9 +10,91 @@
 //	subdomains:                # 必须：子域名列表
 //	  - www
 //	  - @
+//	  - name: nas              # 也可写成映射，单独指定记录类型
+//	    record_types: [A, AAAA]
+//	  - name: printer          # 局域网主机：下发前缀 + 主机接口标识（仅 AAAA）
+//	    suffix: "::1234"       #   IPv6 后缀或 MAC 地址（按 EUI-64 转换）
+//	    prefix_length: 64      #   下发前缀长度（默认 64）
+//	record_types: [AAAA]       # 可选：默认记录类型（A、AAAA，默认仅 AAAA）
 //	interval: 10m              # 可选：非 Linux 轮询间隔（默认 5m）
 //	interface: ppp0            # 可选：监听的网络接口（仅 Linux Netlink）
 //	ttl: 600                   # 可选：DNS 记录 TTL（默认 600）
+//	on_withdraw: keep          # 可选：本机全局 IPv6 地址撤回后 AAAA 记录的处理（keep、delete、fallback、disable，默认 keep）
+//	fallback: "2001:db8::1"    # 可选：on_withdraw 为 fallback 时记录改为此地址
+//	policy:                    # 可选：发布 AAAA 记录前的地址策略（profile、子域名可整体覆盖，默认不筛选）
+//	  reject_bogons: true      #   拒绝文档、ULA、链路本地、6to4 和 Teredo 地址
+//	  allow: ["2409::/16"]     #   只接受这些 CIDR 中的地址
+//	  deny: ["2409:8a00:dead::/48"]  # 拒绝这些 CIDR 中的地址
+//	  prefer: ["2409:8a00::/40"]     # 多个候选时优先选择（如运营商下发的前缀）
+//	  require_local: true      #   要求地址配置在本机网络接口上
+//	state_file: ~/.ddns6/state.json  # 可选：状态文件路径（默认如左，none 表示禁用）
+//	reconcile_window: 24h      # 可选：持久化状态的信任时长（默认 24h，0 表示不信任）
+//	reconcile_interval: 1h     # 可选：周期性漂移对账间隔（默认禁用）
+//	retry:                     # 可选：服务商 API 调用的重试策略
+//	  attempts: 3              #   最大尝试次数（含首次，默认 3，1 表示不重试）
+//	  base_delay: 500ms        #   基础退避时间（默认 500ms，每次翻倍）
+//	  max_delay: 30s           #   单次等待上限（默认 30s，Retry-After 超过此值时放弃）
+//	status_addr: 127.0.0.1:9876  # 可选：HTTP 状态端点（/status、/healthz、/metrics）监听地址（默认不启动）
+//	health_threshold: 15m      # 可选：域名持续同步失败超过此时长时 /healthz 报告失败（默认 15m）
+//	shutdown_timeout: 5s       # 可选：收到退出信号后等待进行中同步完成的最长时间（默认 5s）
+//	watch_config: true         # 可选：配置文件变化时自动热重载（默认仅 SIGHUP 触发）
+//	netlink:                   # 可选：Linux Netlink 触发源的防抖和事件过滤
+//	  debounce: 10s            #   最后一个地址事件后的等待时间（默认 10s）
+//	  max_wait: 1m             #   自第一个事件起的最长等待时间（默认 1m）
+//	  include_ula: false       #   ULA（fc00::/7）地址事件也触发同步（默认忽略）
+//	  interfaces: ["ppp*"]     #   监听的接口名模式，设置后取代 interface（默认所有接口）
+//	fetchers:                  # 可选：地址获取器（默认使用内置列表）
+//	  timeout: 5s              #   每次地址获取的总超时时间（默认 5s）
+//	  ipv6:                    #   TYPE[@TIMEOUT]:VALUE 字符串，或 http/dns/dnsquery/interface/command 映射
+//	    - https://6.ipw.cn
+//	    - dns@2s:2400:3200:baba::1
+//	    - dnsquery:opendns     #     向 DNS 服务器查询公网地址（opendns、google、akamai 或 NAME/TYPE@SERVER）
+//	    - {interface: ppp0}
+//	    - {command: "/usr/local/bin/myip", timeout: 3s}
+//	    - http: http://192.168.1.1/status   # http 映射还可设置 json / regex / field 提取器、headers、username / password、network
+//	      regex: 'WAN IPv6: ([0-9a-f:]+)'
+//	      username: admin
+//	      password: "xxx"
+//	    - {http: https://6.ipw.cn, bind_interface: ppp1}  # 只通过 ppp1 连接（仅 Linux），也可用 source 指定源地址
+//	  ipv4: [https://4.ipw.cn] #   IPv4 获取器（仅存在 A 记录时使用，不支持 interface）
+//	quorum:                    # 可选：IPv6 地址获取的共识模式（默认最快者胜出）
+//	  agree: 2                 #   至少需要多少个获取器的结果一致
+//	  match: prefix            #   address（完整地址，默认）或 prefix（同一 /64 前缀）
+//	hooks:                     # 可选：记录更新前后执行的本地命令（通过 sh -c 执行）
+//	  timeout: 30s             #   单个命令的超时时间（默认 30s）
+//	  pre: ["/usr/local/bin/check-maintenance"]  # 写入前执行，非零退出则否决本次更新
+//	  post: ["systemctl reload nginx"]           # 写入后执行
+//	verify:                    # 可选：记录更新后向权威服务器确认新值已生效（默认不验证）
+//	  enabled: true
+//	  timeout: 5m              #   所有权威服务器返回新值的截止时间，超时则通知并重写记录（默认 5m）
+//	  interval: 10s            #   查询间隔（默认 10s）
+//	notify:                    # 可选：webhook 通知（地址变化、记录更新、同步失败和恢复、传播超时）
+//	  rate_limit: 10           #   每个 webhook 每分钟最多发送的通知数（默认 10，0 表示不限）
+//	  webhooks:
+//	    - name: dingtalk       #   名称，用于日志（可选）
+//	      url: https://oapi.dingtalk.com/robot/send?access_token=xxx  # 请求 URL（text/template 模板）
+//	      method: POST         #   HTTP 方法（默认 POST）
+//	      headers: {}          #   附加请求头（可选）
+//	      events: [record_updated, sync_failed, sync_recovered]  # 订阅的事件（默认全部）
+//	      body: '{"msgtype":"text","text":{"content":{{json .Message}}}}'  # 请求体模板（默认为事件 JSON）
+//
+// 需要同时管理多个运营商、账号或根域名时，改用 profiles 列表。
+// 此时顶层的 record_types、ttl、on_withdraw、fallback 和 policy 作为各 profile 的默认值，
+// interval 和 interface 为所有 profile 共用：
+//
+//	profiles:
+//	  - name: home             # 可选：profile 名称（默认为根域名）
+//	    provider: cloudflare
+//	    auth:
+//	      api_token: "xxx"
+//	    domain: example.com
+//	    subdomains: [www, "@"]
+//	  - provider: tencent
+//	    auth:
+//	      secret_id: "xxx"
+//	      secret_key: "xxx"
+//	    domain: example.cn
+//	    ttl: 300
 //
 // 配置文件通过 ddns6 init 生成模板，或手动创建。
 package config
@@ -20,25 +102,729 @@ package config
 import (
 	"bytes"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"text/template"
 	"time"
 
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/internal/notify"
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+	"github.com/notes-bin/ddns6/pkg/retry"
 	"gopkg.in/yaml.v3"
 )
 
 // Config 表示 ~/.ddns6/config.yaml 的完整配置结构。
 type Config struct {
-	Provider   string            `yaml:"provider"`            // DNS 运营商名称（如 tencent、cloudflare）
-	Auth       map[string]string `yaml:"auth"`                // 运营商认证凭据（不同运营商字段不同）
-	Domain     string            `yaml:"domain"`              // 根域名（如 example.com）
-	Subdomains []string          `yaml:"subdomains"`          // 子域名列表（如 ["www", "@"]）
-	Interval   string            `yaml:"interval"`            // 轮询间隔字符串（如 "10m"、"5m"）
-	Interface  string            `yaml:"interface,omitempty"` // 监听的网络接口（可选，仅 Linux）
-	TTL        int               `yaml:"ttl,omitempty"`       // DNS 记录 TTL（可选，默认 600）
+	Provider    string            `yaml:"provider"`               // DNS 运营商名称（如 tencent、cloudflare）
+	Auth        map[string]string `yaml:"auth"`                   // 运营商认证凭据（不同运营商字段不同）
+	Domain      string            `yaml:"domain"`                 // 根域名（如 example.com）
+	Subdomains  []Subdomain       `yaml:"subdomains"`             // 子域名列表（如 ["www", "@"]）
+	RecordTypes []string          `yaml:"record_types,omitempty"` // 默认记录类型（可选，A/AAAA，默认 [AAAA]）
+	Interval    string            `yaml:"interval"`               // 轮询间隔字符串（如 "10m"、"5m"）
+	Interface   string            `yaml:"interface,omitempty"`    // 监听的网络接口（可选，仅 Linux）
+	TTL         int               `yaml:"ttl,omitempty"`          // DNS 记录 TTL（可选，默认 600）
+	OnWithdraw  string            `yaml:"on_withdraw,omitempty"`  // 本机地址撤回后的处理策略（可选，默认 keep）
+	Fallback    string            `yaml:"fallback,omitempty"`     // on_withdraw 为 fallback 时使用的备用 IPv6 地址
+	Policy      *PolicyConfig     `yaml:"policy,omitempty"`       // 发布前的地址策略（可选，默认不筛选）
+	Profiles    []Profile         `yaml:"profiles,omitempty"`     // 多 profile 配置（可选，设置后不能再使用顶层 provider/domain）
+
+	StateFile       string `yaml:"state_file,omitempty"`       // 状态文件路径（可选，默认 ~/.ddns6/state.json，"none" 表示禁用）
+	ReconcileWindow string `yaml:"reconcile_window,omitempty"` // 持久化状态的信任时长（可选，默认 24h）
+
+	ReconcileInterval string `yaml:"reconcile_interval,omitempty"` // 周期性漂移对账间隔（可选，默认禁用）
+
+	Retry *RetryConfig `yaml:"retry,omitempty"` // 服务商 API 调用的重试策略（可选）
+
+	StatusAddr      string `yaml:"status_addr,omitempty"`      // HTTP 状态端点监听地址（可选，默认不启动）
+	HealthThreshold string `yaml:"health_threshold,omitempty"` // 健康阈值（可选，默认 15m）
+
+	ShutdownTimeout string `yaml:"shutdown_timeout,omitempty"` // 优雅关闭的最长等待时间（可选，默认 5s）
+
+	WatchConfig bool `yaml:"watch_config,omitempty"` // 配置文件变化时自动热重载（可选，默认仅 SIGHUP 触发）
+
+	Netlink *NetlinkConfig `yaml:"netlink,omitempty"` // Linux Netlink 触发源的防抖和事件过滤（可选）
+	Quorum  *QuorumConfig  `yaml:"quorum,omitempty"`  // IPv6 地址获取的共识模式（可选，默认最快者胜出）
+
+	Fetchers *FetchersConfig `yaml:"fetchers,omitempty"` // 地址获取器列表和超时（可选，默认使用内置获取器）
+
+	Verify *VerifyConfig `yaml:"verify,omitempty"` // 记录更新后的传播验证（可选，默认不验证）
+
+	Hooks  *HooksConfig  `yaml:"hooks,omitempty"`  // 记录更新前后执行的钩子命令（可选）
+	Notify *NotifyConfig `yaml:"notify,omitempty"` // webhook 通知（可选）
+}
+
+// HooksConfig 记录更新前后执行的钩子命令配置，语义见 ddns.Hooks。
+type HooksConfig struct {
+	Pre     []string `yaml:"pre,omitempty"`     // 写入服务商前执行的命令，非零退出则否决更新
+	Post    []string `yaml:"post,omitempty"`    // 写入服务商后执行的命令
+	Timeout string   `yaml:"timeout,omitempty"` // 单个命令的超时时间（默认 30s）
+}
+
+// GetHooks 将配置转换为 ddns.Hooks。h 为 nil 时返回零值（不执行钩子）。
+func (h *HooksConfig) GetHooks() (ddns.Hooks, error) {
+	if h == nil {
+		return ddns.Hooks{}, nil
+	}
+	hooks := ddns.Hooks{Pre: h.Pre, Post: h.Post, Timeout: ddns.DefaultHookTimeout}
+	for _, cmds := range [][]string{h.Pre, h.Post} {
+		for _, c := range cmds {
+			if strings.TrimSpace(c) == "" {
+				return ddns.Hooks{}, fmt.Errorf("hooks: command must not be empty")
+			}
+		}
+	}
+	if h.Timeout != "" {
+		d, err := time.ParseDuration(h.Timeout)
+		if err != nil || d <= 0 {
+			return ddns.Hooks{}, fmt.Errorf("invalid hooks.timeout '%s': must be a positive duration", h.Timeout)
+		}
+		hooks.Timeout = d
+	}
+	return hooks, nil
+}
+
+// NotifyConfig webhook 通知配置。
+type NotifyConfig struct {
+	RateLimit *int            `yaml:"rate_limit,omitempty"` // 每个 webhook 每分钟最多发送的通知数（默认 10，0 表示不限）
+	Webhooks  []WebhookConfig `yaml:"webhooks"`             // webhook 列表
+}
+
+// WebhookConfig 单个 webhook 的配置，URL 和 Body 为 Go text/template 模板，数据为 ddns.Event。
+type WebhookConfig struct {
+	Name    string            `yaml:"name,omitempty"`    // 名称，用于日志（可选，默认为 URL 主机名）
+	URL     string            `yaml:"url"`               // 请求 URL
+	Method  string            `yaml:"method,omitempty"`  // HTTP 方法（默认 POST）
+	Headers map[string]string `yaml:"headers,omitempty"` // 附加请求头
+	Events  []string          `yaml:"events,omitempty"`  // 订阅的事件类型（默认全部）
+	Body    string            `yaml:"body,omitempty"`    // 请求体模板（默认为事件 JSON）
+}
+
+// GetWebhooks 将配置转换为 notify.Webhook 列表并校验模板和事件类型。n 为 nil 时返回空列表。
+func (n *NotifyConfig) GetWebhooks() ([]notify.Webhook, error) {
+	if n == nil {
+		return nil, nil
+	}
+	webhooks := make([]notify.Webhook, len(n.Webhooks))
+	for i, w := range n.Webhooks {
+		hook := notify.Webhook{Name: w.Name, URL: w.URL, Method: w.Method, Headers: w.Headers, Body: w.Body}
+		for _, e := range w.Events {
+			hook.Events = append(hook.Events, ddns.EventType(strings.ToLower(strings.TrimSpace(e))))
+		}
+		if err := hook.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid notify.webhooks[%d]: %w", i, err)
+		}
+		webhooks[i] = hook
+	}
+	return webhooks, nil
+}
+
+// GetRateLimit 返回每个 webhook 每分钟最多发送的通知数，未设置时返回 notify.DefaultRateLimit。
+func (n *NotifyConfig) GetRateLimit() int {
+	if n == nil || n.RateLimit == nil {
+		return notify.DefaultRateLimit
+	}
+	return *n.RateLimit
+}
+
+// NetlinkConfig Linux Netlink 触发源的防抖和事件过滤配置，语义见 ddns.TriggerConfig。
+type NetlinkConfig struct {
+	Debounce   string   `yaml:"debounce,omitempty"`    // 最后一个事件后的防抖等待时间（默认 10s）
+	MaxWait    string   `yaml:"max_wait,omitempty"`    // 自第一个事件起的最长等待时间（默认 1m）
+	IncludeULA bool     `yaml:"include_ula,omitempty"` // ULA（fc00::/7）地址事件也触发同步（默认忽略）
+	Interfaces []string `yaml:"interfaces,omitempty"`  // 监听的接口名模式（如 ppp*），设置后取代 interface
+}
+
+// GetTrigger 将配置转换为 ddns.TriggerConfig。n 为 nil 时返回零值（使用默认值）。
+func (n *NetlinkConfig) GetTrigger() (ddns.TriggerConfig, error) {
+	if n == nil {
+		return ddns.TriggerConfig{}, nil
+	}
+	cfg := ddns.TriggerConfig{IncludeULA: n.IncludeULA, Interfaces: n.Interfaces}
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"netlink.debounce", n.Debounce, &cfg.Debounce},
+		{"netlink.max_wait", n.MaxWait, &cfg.MaxWait},
+	} {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil || d <= 0 {
+			return ddns.TriggerConfig{}, fmt.Errorf("invalid %s '%s': must be a positive duration", f.name, f.value)
+		}
+		*f.dst = d
+	}
+	if err := ddns.ValidateInterfacePatterns(n.Interfaces); err != nil {
+		return ddns.TriggerConfig{}, fmt.Errorf("netlink.interfaces: %w", err)
+	}
+	return cfg, nil
+}
+
+// QuorumConfig IPv6 地址获取的共识配置，语义见 ipaddr.Quorum。
+type QuorumConfig struct {
+	Agree int    `yaml:"agree,omitempty"` // 至少需要多少个获取器的结果一致（默认 0，<= 1 表示最快者胜出）
+	Match string `yaml:"match,omitempty"` // 一致的判定方式：address（完整地址，默认）或 prefix（同一 /64 前缀）
+}
+
+// GetQuorum 将配置转换为 ipaddr.Quorum。q 为 nil 时返回零值（最快者胜出）。
+func (q *QuorumConfig) GetQuorum() (ipaddr.Quorum, error) {
+	if q == nil {
+		return ipaddr.Quorum{}, nil
+	}
+	if q.Agree < 0 {
+		return ipaddr.Quorum{}, fmt.Errorf("invalid quorum.agree %d: must be >= 0", q.Agree)
+	}
+	match, err := ipaddr.ParseQuorumMatch(q.Match)
+	if err != nil {
+		return ipaddr.Quorum{}, fmt.Errorf("quorum.match: %w", err)
+	}
+	return ipaddr.Quorum{N: q.Agree, Match: match}, nil
+}
+
+// VerifyConfig 记录更新后的传播验证配置，语义见 ddns.WithVerify。
+type VerifyConfig struct {
+	Enabled  bool   `yaml:"enabled,omitempty"`  // 是否启用
+	Timeout  string `yaml:"timeout,omitempty"`  // 所有权威服务器返回新值的截止时间（默认 5m）
+	Interval string `yaml:"interval,omitempty"` // 查询间隔（默认 10s）
+}
+
+// GetVerify 将配置转换为 ddns.VerifyConfig，并返回是否启用（v 为 nil 时不启用）。
+// 未启用时也校验时长格式，以便命令行 --verify 沿用配置文件中的时长。
+func (v *VerifyConfig) GetVerify() (ddns.VerifyConfig, bool, error) {
+	var cfg ddns.VerifyConfig
+	if v == nil {
+		return cfg, false, nil
+	}
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"verify.timeout", v.Timeout, &cfg.Timeout},
+		{"verify.interval", v.Interval, &cfg.Interval},
+	} {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil || d <= 0 {
+			return cfg, false, fmt.Errorf("invalid %s '%s': must be a positive duration", f.name, f.value)
+		}
+		*f.dst = d
+	}
+	return cfg, v.Enabled, nil
+}
+
+// 地址获取器类型。
+const (
+	FetcherHTTP      = "http"      // HTTP 回显服务 URL
+	FetcherDNS       = "dns"       // DNS 服务器地址（取连接该服务器时的本机源地址）
+	FetcherDNSQuery  = "dnsquery"  // 向 DNS 服务器查询"我的 IP"（opendns、google、akamai 或 NAME/TYPE@SERVER）
+	FetcherInterface = "interface" // 本机网络接口（仅 Linux，仅 IPv6）
+	FetcherCommand   = "command"   // 外部命令（通过系统 shell 执行），从输出中取第一个地址
+)
+
+// FetchersConfig 地址获取器配置。未设置的列表使用内置的默认获取器。
+type FetchersConfig struct {
+	Timeout string          `yaml:"timeout,omitempty"` // 每次地址获取的总超时时间（默认 5s）
+	IPv6    []FetcherConfig `yaml:"ipv6,omitempty"`    // IPv6 获取器
+	IPv4    []FetcherConfig `yaml:"ipv4,omitempty"`    // IPv4 获取器（仅存在 A 记录时使用）
+}
+
+// FetcherConfig 单个地址获取器，http、dns、dnsquery、interface、command 五者设置其一。
+//
+// 也可写成与 --fetcher 相同的字符串，见 ParseFetcherSpec。
+type FetcherConfig struct {
+	HTTP      string `yaml:"http,omitempty"`      // HTTP 回显服务 URL
+	DNS       string `yaml:"dns,omitempty"`       // DNS 服务器地址
+	DNSQuery  string `yaml:"dnsquery,omitempty"`  // DNS 查询服务（opendns、google、akamai 或 NAME/TYPE@SERVER）
+	Interface string `yaml:"interface,omitempty"` // 网络接口名
+	Command   string `yaml:"command,omitempty"`   // 外部命令
+	Timeout   string `yaml:"timeout,omitempty"`   // 该获取器的超时时间（可选，仍受总超时限制）
+
+	// 以下仅用于 http 类型。json、regex、field 三者至多设置其一，都未设置时整个响应体即为地址。
+	// 主机名只通过获取器的地址族（IPv6 获取器为 tcp6）建立连接，IP 字面量使用其自身的地址族，可用 network 覆盖。
+	JSON          string            `yaml:"json,omitempty"`           // JSON 路径，如 ip、data.ipv6、$.addrs[0]
+	Regex         string            `yaml:"regex,omitempty"`          // 正则表达式，有捕获组时取第一个捕获组
+	Field         string            `yaml:"field,omitempty"`          // key=value 文本中的字段名（如 Cloudflare /cdn-cgi/trace 的 ip）
+	Headers       map[string]string `yaml:"headers,omitempty"`        // 附加的请求头
+	Username      string            `yaml:"username,omitempty"`       // HTTP Basic 认证用户名
+	Password      string            `yaml:"password,omitempty"`       // HTTP Basic 认证密码
+	Source        string            `yaml:"source,omitempty"`         // 连接使用的本机源地址
+	BindInterface string            `yaml:"bind_interface,omitempty"` // 连接绑定的网络接口（仅 Linux，SO_BINDTODEVICE），多出口时获取指定出口的地址
+	Network       string            `yaml:"network,omitempty"`        // 建立连接使用的网络：tcp、tcp4 或 tcp6（tcp 不限地址族并使用代理设置）
+}
+
+// UnmarshalYAML 支持字符串和映射两种写法。
+func (f *FetcherConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		parsed, err := ParseFetcherSpec(value.Value)
+		if err != nil {
+			return err
+		}
+		*f = parsed
+		return nil
+	}
+	type plain FetcherConfig
+	return value.Decode((*plain)(f))
+}
+
+// ParseFetcherSpec 解析 --fetcher 格式的获取器描述：TYPE[@TIMEOUT]:VALUE，
+// 如 http:https://6.ipw.cn、dns@2s:2400:3200:baba::1、dnsquery:opendns、interface:ppp0、command:/usr/local/bin/myip。
+// 以 http:// 或 https:// 开头的描述直接视为 HTTP URL。
+func ParseFetcherSpec(spec string) (FetcherConfig, error) {
+	if strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://") {
+		return FetcherConfig{HTTP: spec}, nil
+	}
+	kind, value, ok := strings.Cut(spec, ":")
+	if !ok {
+		return FetcherConfig{}, fmt.Errorf("invalid fetcher %q: expected TYPE[@TIMEOUT]:VALUE", spec)
+	}
+	var f FetcherConfig
+	kind, f.Timeout, _ = strings.Cut(kind, "@")
+	switch kind {
+	case FetcherHTTP:
+		f.HTTP = value
+	case FetcherDNS:
+		f.DNS = value
+	case FetcherDNSQuery:
+		f.DNSQuery = value
+	case FetcherInterface:
+		f.Interface = value
+	case FetcherCommand:
+		f.Command = value
+	default:
+		return FetcherConfig{}, fmt.Errorf("invalid fetcher %q: unknown type %q (supported: %s, %s, %s, %s, %s)",
+			spec, kind, FetcherHTTP, FetcherDNS, FetcherDNSQuery, FetcherInterface, FetcherCommand)
+	}
+	if value == "" {
+		return FetcherConfig{}, fmt.Errorf("invalid fetcher %q: %s value is required", spec, kind)
+	}
+	return f, nil
+}
+
+// kind 返回获取器类型和值，未设置或设置了多个类型时返回错误。
+func (f FetcherConfig) kind() (string, string, error) {
+	var kind, value string
+	for _, c := range []struct{ kind, value string }{
+		{FetcherHTTP, f.HTTP}, {FetcherDNS, f.DNS}, {FetcherDNSQuery, f.DNSQuery},
+		{FetcherInterface, f.Interface}, {FetcherCommand, f.Command},
+	} {
+		if c.value == "" {
+			continue
+		}
+		if kind != "" {
+			return "", "", fmt.Errorf("fetcher sets both %s and %s, only one is allowed", kind, c.kind)
+		}
+		kind, value = c.kind, c.value
+	}
+	if kind == "" {
+		return "", "", fmt.Errorf("fetcher must set one of %s, %s, %s, %s, %s",
+			FetcherHTTP, FetcherDNS, FetcherDNSQuery, FetcherInterface, FetcherCommand)
+	}
+	return kind, value, nil
+}
+
+// httpOptions 返回地址族为 family（4 或 6）的 http 类型获取器的可选配置；其他类型设置了 http 专用字段时返回错误。
+func (f FetcherConfig) httpOptions(kind string, family int, timeout time.Duration) ([]ipaddr.HttpOption, error) {
+	hasHTTPFields := f.JSON != "" || f.Regex != "" || f.Field != "" || len(f.Headers) > 0 ||
+		f.Username != "" || f.Password != "" || f.Source != "" || f.BindInterface != "" || f.Network != ""
+	if kind != FetcherHTTP {
+		if hasHTTPFields {
+			return nil, fmt.Errorf("json, regex, field, headers, username, password, source, bind_interface and network only apply to %s fetchers", FetcherHTTP)
+		}
+		return nil, nil
+	}
+
+	var opts []ipaddr.HttpOption
+	if timeout > 0 {
+		opts = append(opts, ipaddr.WithHttpTimeout(timeout))
+	}
+	set := 0
+	for _, v := range []string{f.JSON, f.Regex, f.Field} {
+		if v != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("fetcher %s sets more than one of json, regex and field", f.HTTP)
+	}
+	var extractor ipaddr.Extractor
+	var err error
+	switch {
+	case f.JSON != "":
+		extractor, err = ipaddr.NewJSONPathExtractor(f.JSON)
+	case f.Regex != "":
+		extractor, err = ipaddr.NewRegexExtractor(f.Regex)
+	case f.Field != "":
+		extractor, err = ipaddr.NewKeyValueExtractor(f.Field)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if extractor != nil {
+		opts = append(opts, ipaddr.WithExtractor(extractor))
+	}
+	for key, value := range f.Headers {
+		opts = append(opts, ipaddr.WithHttpHeader(key, value))
+	}
+	if f.Username != "" || f.Password != "" {
+		opts = append(opts, ipaddr.WithBasicAuth(f.Username, f.Password))
+	}
+	// 源地址须与连接的地址族一致：network 限定了地址族时按 network，tcp 不限，未设置时按获取器
+	sourceFamily := family
+	switch f.Network {
+	case "":
+	case "tcp":
+		sourceFamily = 0
+	case "tcp4":
+		sourceFamily = 4
+	case "tcp6":
+		sourceFamily = 6
+	default:
+		return nil, fmt.Errorf("invalid fetcher network '%s': must be tcp, tcp4 or tcp6", f.Network)
+	}
+	if f.Network != "" {
+		opts = append(opts, ipaddr.WithNetwork(f.Network))
+	}
+	if f.Source != "" {
+		ip := net.ParseIP(f.Source)
+		if ip == nil || (sourceFamily != 0 && (ip.To4() != nil) != (sourceFamily == 4)) {
+			if sourceFamily == 0 {
+				return nil, fmt.Errorf("invalid fetcher source '%s': must be an IP address", f.Source)
+			}
+			return nil, fmt.Errorf("invalid fetcher source '%s': must be an IPv%d address", f.Source, sourceFamily)
+		}
+		opts = append(opts, ipaddr.WithSourceAddr(ip))
+	}
+	if f.BindInterface != "" {
+		opts = append(opts, ipaddr.WithBindInterface(f.BindInterface))
+	}
+	return opts, nil
+}
+
+// timeout 解析获取器的超时时间，未设置时返回 0。
+func (f FetcherConfig) timeout() (time.Duration, error) {
+	if f.Timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(f.Timeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid fetcher timeout '%s': must be a positive duration", f.Timeout)
+	}
+	return d, nil
+}
+
+// IPv6Fetcher 创建对应的 IPv6 获取器。
+func (f FetcherConfig) IPv6Fetcher() (ipaddr.IPv6Fetcher, error) {
+	kind, value, err := f.kind()
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := f.timeout()
+	if err != nil {
+		return nil, err
+	}
+	httpOpts, err := f.httpOptions(kind, 6, timeout)
+	if err != nil {
+		return nil, err
+	}
+	var fetcher ipaddr.IPv6Fetcher
+	switch kind {
+	case FetcherHTTP:
+		fetcher = ipaddr.NewHttpIPv6Fetcher(value, httpOpts...)
+	case FetcherDNS:
+		fetcher = ipaddr.NewDnsFetcher(value)
+	case FetcherDNSQuery:
+		svc, err := ipaddr.ParseDnsQueryService(value)
+		if err != nil {
+			return nil, err
+		}
+		fetcher = svc.IPv6Fetcher()
+	case FetcherInterface:
+		fetcher = ipaddr.NewInterfaceFetcher(value)
+	case FetcherCommand:
+		fetcher = ipaddr.NewCommandIPv6Fetcher(value)
+	}
+	return withFetcherTimeout(fetcher, timeout), nil
+}
+
+// IPv4Fetcher 创建对应的 IPv4 获取器（不支持 interface）。
+func (f FetcherConfig) IPv4Fetcher() (ipaddr.IPv4Fetcher, error) {
+	kind, value, err := f.kind()
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := f.timeout()
+	if err != nil {
+		return nil, err
+	}
+	httpOpts, err := f.httpOptions(kind, 4, timeout)
+	if err != nil {
+		return nil, err
+	}
+	var fetcher ipaddr.IPv4Fetcher
+	switch kind {
+	case FetcherHTTP:
+		fetcher = ipaddr.NewHttpIPv4Fetcher(value, httpOpts...)
+	case FetcherDNS:
+		fetcher = ipaddr.NewDnsIPv4Fetcher(value)
+	case FetcherDNSQuery:
+		svc, err := ipaddr.ParseDnsQueryService(value)
+		if err != nil {
+			return nil, err
+		}
+		fetcher = svc.IPv4Fetcher()
+	case FetcherCommand:
+		fetcher = ipaddr.NewCommandIPv4Fetcher(value)
+	default:
+		return nil, fmt.Errorf("fetcher type %s does not support IPv4", kind)
+	}
+	return withFetcherTimeout(fetcher, timeout), nil
+}
+
+// withFetcherTimeout 在 timeout > 0 时为获取器设置独立超时。
+func withFetcherTimeout(f ipaddr.IPv6Fetcher, timeout time.Duration) ipaddr.IPv6Fetcher {
+	if timeout <= 0 {
+		return f
+	}
+	return ipaddr.NewTimeoutFetcher(f, timeout)
+}
+
+// GetTimeout 解析每次地址获取的总超时时间，未设置时返回 ipaddr.DefaultFetchTimeout。
+func (c *FetchersConfig) GetTimeout() (time.Duration, error) {
+	if c == nil || c.Timeout == "" {
+		return ipaddr.DefaultFetchTimeout, nil
+	}
+	d, err := time.ParseDuration(c.Timeout)
+	if err != nil || d <= 0 {
+		return ipaddr.DefaultFetchTimeout, fmt.Errorf("invalid fetchers.timeout '%s': must be a positive duration", c.Timeout)
+	}
+	return d, nil
+}
+
+// GetIPv6Fetchers 创建 IPv6 获取器列表，未配置时返回 ddns.DefaultIPv6Fetchers。
+func (c *FetchersConfig) GetIPv6Fetchers() ([]ipaddr.IPv6Fetcher, error) {
+	if c == nil || len(c.IPv6) == 0 {
+		return ddns.DefaultIPv6Fetchers, nil
+	}
+	return BuildIPv6Fetchers(c.IPv6)
+}
+
+// GetIPv4Fetchers 创建 IPv4 获取器列表，未配置时返回 ddns.DefaultIPv4Fetchers。
+func (c *FetchersConfig) GetIPv4Fetchers() ([]ipaddr.IPv4Fetcher, error) {
+	if c == nil || len(c.IPv4) == 0 {
+		return ddns.DefaultIPv4Fetchers, nil
+	}
+	return BuildIPv4Fetchers(c.IPv4)
+}
+
+// BuildIPv6Fetchers 按配置创建 IPv6 获取器列表。
+func BuildIPv6Fetchers(configs []FetcherConfig) ([]ipaddr.IPv6Fetcher, error) {
+	fetchers := make([]ipaddr.IPv6Fetcher, 0, len(configs))
+	for i, fc := range configs {
+		f, err := fc.IPv6Fetcher()
+		if err != nil {
+			return nil, fmt.Errorf("fetchers.ipv6[%d]: %w", i, err)
+		}
+		fetchers = append(fetchers, f)
+	}
+	return fetchers, nil
+}
+
+// BuildIPv4Fetchers 按配置创建 IPv4 获取器列表。
+func BuildIPv4Fetchers(configs []FetcherConfig) ([]ipaddr.IPv4Fetcher, error) {
+	fetchers := make([]ipaddr.IPv4Fetcher, 0, len(configs))
+	for i, fc := range configs {
+		f, err := fc.IPv4Fetcher()
+		if err != nil {
+			return nil, fmt.Errorf("fetchers.ipv4[%d]: %w", i, err)
+		}
+		fetchers = append(fetchers, f)
+	}
+	return fetchers, nil
+}
+
+// validate 校验获取器配置。
+func (c *FetchersConfig) validate() error {
+	if _, err := c.GetTimeout(); err != nil {
+		return err
+	}
+	if _, err := c.GetIPv6Fetchers(); err != nil {
+		return err
+	}
+	_, err := c.GetIPv4Fetchers()
+	return err
+}
+
+// RetryConfig 服务商 API 调用的重试配置，未设置的字段使用默认值。
+type RetryConfig struct {
+	Attempts  int    `yaml:"attempts,omitempty"`   // 最大尝试次数（含首次，1 表示不重试）
+	BaseDelay string `yaml:"base_delay,omitempty"` // 基础退避时间（如 "500ms"）
+	MaxDelay  string `yaml:"max_delay,omitempty"`  // 单次等待上限（如 "30s"）
+}
+
+// Apply 将已设置的字段覆盖到 base 上返回，未设置的字段保持 base 的值。r 为 nil 时返回 base。
+func (r *RetryConfig) Apply(base retry.Policy) (retry.Policy, error) {
+	if r == nil {
+		return base, nil
+	}
+	p := base
+	if r.Attempts < 0 {
+		return base, fmt.Errorf("invalid retry.attempts %d: must be >= 1", r.Attempts)
+	}
+	if r.Attempts > 0 {
+		p.Attempts = r.Attempts
+	}
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"retry.base_delay", r.BaseDelay, &p.BaseDelay},
+		{"retry.max_delay", r.MaxDelay, &p.MaxDelay},
+	} {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil || d < 0 {
+			return base, fmt.Errorf("invalid %s '%s': must be a non-negative duration", f.name, f.value)
+		}
+		*f.dst = d
+	}
+	return p, nil
+}
+
+// StateFileDisabled state_file 取此值时禁用状态持久化。
+const StateFileDisabled = "none"
+
+// Profile 一组独立的运营商、凭据和域名配置。
+//
+// 多个 profile 由同一进程服务，共享地址获取和 Netlink 监听。
+type Profile struct {
+	Name        string            `yaml:"name,omitempty"`         // profile 名称（可选，默认为根域名）
+	Provider    string            `yaml:"provider"`               // DNS 运营商名称
+	Auth        map[string]string `yaml:"auth"`                   // 运营商认证凭据
+	Domain      string            `yaml:"domain"`                 // 根域名
+	Subdomains  []Subdomain       `yaml:"subdomains"`             // 子域名列表（默认 ["@"]）
+	RecordTypes []string          `yaml:"record_types,omitempty"` // 记录类型（可选，默认继承顶层 record_types）
+	TTL         int               `yaml:"ttl,omitempty"`          // DNS 记录 TTL（可选，默认继承顶层 ttl）
+	OnWithdraw  string            `yaml:"on_withdraw,omitempty"`  // 本机地址撤回后的处理策略（可选，默认继承顶层 on_withdraw）
+	Fallback    string            `yaml:"fallback,omitempty"`     // 备用 IPv6 地址（可选，默认继承顶层 fallback）
+	Policy      *PolicyConfig     `yaml:"policy,omitempty"`       // 地址策略（可选，默认继承顶层 policy）
+}
+
+// Subdomain 单个子域名的配置。
+//
+// YAML 中既可以直接写子域名字符串（"www"），也可以写成映射以覆盖记录类型：
+//
+//	subdomains:
+//	  - www
+//	  - name: nas
+//	    record_types: [A, AAAA]
+//
+// 设置 suffix 后，该子域名的 AAAA 记录发布局域网主机地址：本机检测到的 IPv6 地址的前
+// prefix_length 位（运营商下发前缀）加上 suffix（主机接口标识，如 ::1234 或 MAC 地址）：
+//
+//	subdomains:
+//	  - name: printer
+//	    suffix: "::1234"
+//	    prefix_length: 56
+//
+// on_withdraw 和 fallback 覆盖 profile 的地址撤回策略，如只在撤回时删除某个子域名的记录；
+// policy 整体覆盖 profile 的地址策略。
+type Subdomain struct {
+	Name         string        `yaml:"name"`                    // 子域名标签（"@" 表示根域名）
+	RecordTypes  []string      `yaml:"record_types,omitempty"`  // 记录类型（可选，为空时使用全局 record_types）
+	Suffix       string        `yaml:"suffix,omitempty"`        // 局域网主机接口标识（可选，IPv6 后缀或 MAC 地址）
+	PrefixLength int           `yaml:"prefix_length,omitempty"` // 下发前缀长度（可选，默认 64，仅与 suffix 一起使用）
+	OnWithdraw   string        `yaml:"on_withdraw,omitempty"`   // 本机地址撤回后的处理策略（可选，默认继承 profile）
+	Fallback     string        `yaml:"fallback,omitempty"`      // 备用 IPv6 地址（可选，默认继承 profile）
+	Policy       *PolicyConfig `yaml:"policy,omitempty"`        // 地址策略（可选，默认继承 profile）
+}
+
+// PolicyConfig 发布 AAAA 记录前对候选地址的筛选规则，见 ddns.AddrPolicy。
+//
+// 设置后每次获取等待所有 IPv6 获取器，它们返回的不同地址都作为候选，
+// 按 prefer 排序后取第一个通过所有规则的地址。
+type PolicyConfig struct {
+	RejectBogons bool     `yaml:"reject_bogons,omitempty"` // 拒绝文档、ULA、链路本地、6to4 和 Teredo 地址
+	Allow        []string `yaml:"allow,omitempty"`         // 只接受这些 CIDR 中的地址（可选）
+	Deny         []string `yaml:"deny,omitempty"`          // 拒绝这些 CIDR 中的地址（可选，优先于 allow）
+	Prefer       []string `yaml:"prefer,omitempty"`        // 优先选择这些 CIDR（如运营商下发的前缀）中的地址（可选）
+	RequireLocal bool     `yaml:"require_local,omitempty"` // 要求地址配置在本机网络接口上
+}
+
+// GetPolicy 解析地址策略，p 为 nil 时返回零值（不筛选）。
+func (p *PolicyConfig) GetPolicy() (ddns.AddrPolicy, error) {
+	if p == nil {
+		return ddns.AddrPolicy{}, nil
+	}
+	policy := ddns.AddrPolicy{RejectBogons: p.RejectBogons, RequireLocal: p.RequireLocal}
+	var err error
+	if policy.Allow, err = parseIPv6CIDRs("allow", p.Allow); err != nil {
+		return ddns.AddrPolicy{}, err
+	}
+	if policy.Deny, err = parseIPv6CIDRs("deny", p.Deny); err != nil {
+		return ddns.AddrPolicy{}, err
+	}
+	if policy.Prefer, err = parseIPv6CIDRs("prefer", p.Prefer); err != nil {
+		return ddns.AddrPolicy{}, err
+	}
+	return policy, nil
+}
+
+// parseIPv6CIDRs 解析 policy.field 中的 IPv6 CIDR 列表。
+func parseIPv6CIDRs(field string, cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, c := range cidrs {
+		ip, n, err := net.ParseCIDR(c)
+		if err != nil || ip.To4() != nil {
+			return nil, fmt.Errorf("invalid policy.%s entry %q: must be an IPv6 CIDR", field, c)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// GetSuffix 返回解析后的局域网主机接口标识和前缀长度。未设置 suffix 时返回 nil。
+func (s Subdomain) GetSuffix() (net.IP, int, error) {
+	if s.Suffix == "" {
+		if s.PrefixLength != 0 {
+			return nil, 0, fmt.Errorf("prefix_length requires suffix")
+		}
+		return nil, 0, nil
+	}
+	iid, err := ipaddr.ParseInterfaceID(s.Suffix)
+	if err != nil {
+		return nil, 0, err
+	}
+	prefixLen := s.PrefixLength
+	if prefixLen == 0 {
+		prefixLen = ipaddr.DefaultPrefixLength
+	}
+	if prefixLen < 1 || prefixLen > 127 {
+		return nil, 0, fmt.Errorf("invalid prefix_length %d: must be between 1 and 127", s.PrefixLength)
+	}
+	return iid, prefixLen, nil
+}
+
+// UnmarshalYAML 支持字符串和映射两种写法。
+func (s *Subdomain) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		s.Name = value.Value
+		return nil
+	}
+	type plain Subdomain
+	return value.Decode((*plain)(s))
 }
 
 // ConfigDir 返回配置目录路径 ~/.ddns6。
@@ -59,6 +845,15 @@ func ConfigPath() (string, error) {
 	return filepath.Join(dir, "config.yaml"), nil
 }
 
+// StatePath 返回默认状态文件路径 ~/.ddns6/state.json。
+func StatePath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state.json"), nil
+}
+
 // Load 读取并解析 ~/.ddns6/config.yaml，返回 Config 结构体。
 //
 // 如果文件不存在或格式错误，返回错误。
@@ -92,6 +887,41 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("cannot parse config file %s: %w", path, err)
 	}
 
+	if _, err := cfg.Retry.Apply(retry.DefaultPolicy); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.Hooks.GetHooks(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.Notify.GetWebhooks(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.Netlink.GetTrigger(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.Quorum.GetQuorum(); err != nil {
+		return nil, err
+	}
+	if _, _, err := cfg.Verify.GetVerify(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Fetchers.validate(); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Profiles) > 0 {
+		if err := cfg.validateProfiles(); err != nil {
+			return nil, err
+		}
+		if err := cfg.validateWithdraw(); err != nil {
+			return nil, err
+		}
+		if err := cfg.validatePolicies(); err != nil {
+			return nil, err
+		}
+		return &cfg, nil
+	}
+
 	// 必填字段校验
 	if cfg.Provider == "" {
 		return nil, fmt.Errorf("config field 'provider' is required")
@@ -100,15 +930,266 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("config field 'domain' is required")
 	}
 	if len(cfg.Subdomains) == 0 {
-		cfg.Subdomains = []string{"@"} // 默认根域名
+		cfg.Subdomains = []Subdomain{{Name: "@"}} // 默认根域名
 	}
 	if cfg.Auth == nil {
 		cfg.Auth = make(map[string]string)
 	}
+	if err := cfg.normalizeRecordTypes(); err != nil {
+		return nil, err
+	}
+	if err := cfg.validateWithdraw(); err != nil {
+		return nil, err
+	}
+	if err := cfg.validatePolicies(); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
 
+// normalizeRecordTypes 将全局和子域名级别的记录类型统一为大写并校验。
+func (c *Config) normalizeRecordTypes() error {
+	var err error
+	if c.RecordTypes, err = NormalizeRecordTypes(c.RecordTypes); err != nil {
+		return err
+	}
+	return normalizeSubdomains(c.Subdomains)
+}
+
+// normalizeSubdomains 校验子域名名称，并将子域名级别的记录类型统一为大写。
+func normalizeSubdomains(subdomains []Subdomain) error {
+	var err error
+	for i := range subdomains {
+		sd := &subdomains[i]
+		if sd.Name == "" {
+			return fmt.Errorf("config field 'subdomains[%d].name' is required", i)
+		}
+		if sd.RecordTypes, err = NormalizeRecordTypes(sd.RecordTypes); err != nil {
+			return fmt.Errorf("subdomain %q: %w", sd.Name, err)
+		}
+		if _, _, err := sd.GetSuffix(); err != nil {
+			return fmt.Errorf("subdomain %q: %w", sd.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateProfiles 校验 profiles 列表并填充默认值。
+//
+// 使用 profiles 时不允许再设置顶层 provider、auth、domain、subdomains，
+// 避免同一份配置存在两种含义。
+func (c *Config) validateProfiles() error {
+	if c.Provider != "" || c.Domain != "" || len(c.Auth) > 0 || len(c.Subdomains) > 0 {
+		return fmt.Errorf("top-level 'provider', 'auth', 'domain' and 'subdomains' cannot be combined with 'profiles'")
+	}
+	var err error
+	if c.RecordTypes, err = NormalizeRecordTypes(c.RecordTypes); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for i := range c.Profiles {
+		p := &c.Profiles[i]
+		if p.Provider == "" {
+			return fmt.Errorf("config field 'profiles[%d].provider' is required", i)
+		}
+		if p.Domain == "" {
+			return fmt.Errorf("config field 'profiles[%d].domain' is required", i)
+		}
+		if p.Name == "" {
+			p.Name = p.Domain
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("duplicate profile name %q (set 'name' to distinguish profiles with the same domain)", p.Name)
+		}
+		seen[p.Name] = true
+
+		if len(p.Subdomains) == 0 {
+			p.Subdomains = []Subdomain{{Name: "@"}}
+		}
+		if p.Auth == nil {
+			p.Auth = make(map[string]string)
+		}
+		if p.RecordTypes, err = NormalizeRecordTypes(p.RecordTypes); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+		if err := normalizeSubdomains(p.Subdomains); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateWithdraw 校验每个子域名实际生效的地址撤回策略和备用地址。
+func (c *Config) validateWithdraw() error {
+	for _, p := range c.GetProfiles() {
+		for _, sd := range p.Subdomains {
+			if _, _, err := p.GetWithdraw(sd); err != nil {
+				return fmt.Errorf("profile %q: subdomain %q: %w", p.Name, sd.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validatePolicies 校验每个子域名实际生效的地址策略。
+func (c *Config) validatePolicies() error {
+	for _, p := range c.GetProfiles() {
+		for _, sd := range p.Subdomains {
+			if _, err := p.GetPolicy(sd); err != nil {
+				return fmt.Errorf("profile %q: subdomain %q: %w", p.Name, sd.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// GetProfiles 返回实际生效的 profile 列表。
+//
+// 未配置 profiles 时，顶层字段构成唯一的隐式 profile；
+// 否则返回 profiles 列表，未设置的 record_types、ttl、on_withdraw、fallback 和 policy 继承顶层值。
+func (c *Config) GetProfiles() []Profile {
+	if len(c.Profiles) == 0 {
+		return []Profile{{
+			Name:        c.Domain,
+			Provider:    c.Provider,
+			Auth:        c.Auth,
+			Domain:      c.Domain,
+			Subdomains:  c.Subdomains,
+			RecordTypes: c.RecordTypes,
+			TTL:         c.TTL,
+			OnWithdraw:  c.OnWithdraw,
+			Fallback:    c.Fallback,
+			Policy:      c.Policy,
+		}}
+	}
+	profiles := make([]Profile, len(c.Profiles))
+	for i, p := range c.Profiles {
+		if len(p.RecordTypes) == 0 {
+			p.RecordTypes = c.RecordTypes
+		}
+		if p.TTL <= 0 {
+			p.TTL = c.TTL
+		}
+		if p.OnWithdraw == "" {
+			p.OnWithdraw = c.OnWithdraw
+		}
+		if p.Fallback == "" {
+			p.Fallback = c.Fallback
+		}
+		if p.Policy == nil {
+			p.Policy = c.Policy
+		}
+		profiles[i] = p
+	}
+	return profiles
+}
+
+// NormalizeRecordTypes 将记录类型转为大写、去重并校验，只允许 A 和 AAAA。
+func NormalizeRecordTypes(types []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+	for _, t := range types {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t != ddns.RecordTypeA && t != ddns.RecordTypeAAAA {
+			return nil, fmt.Errorf("unsupported record type %q (supported: A, AAAA)", t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// SubdomainNames 返回所有子域名标签。
+func (c *Config) SubdomainNames() []string {
+	return subdomainNames(c.Subdomains)
+}
+
+// GetRecordTypes 返回子域名实际使用的记录类型。
+//
+// 优先级：子域名 record_types > 全局 record_types > 默认 [AAAA]。
+func (c *Config) GetRecordTypes(sd Subdomain) []string {
+	return recordTypesFor(sd, c.RecordTypes)
+}
+
+// SubdomainNames 返回 profile 的所有子域名标签。
+func (p *Profile) SubdomainNames() []string {
+	return subdomainNames(p.Subdomains)
+}
+
+// GetRecordTypes 返回子域名实际使用的记录类型。
+//
+// 优先级：子域名 record_types > profile record_types > 默认 [AAAA]。
+func (p *Profile) GetRecordTypes(sd Subdomain) []string {
+	return recordTypesFor(sd, p.RecordTypes)
+}
+
+// GetTTL 返回 profile 的 TTL 值，未设置时返回默认值。
+func (p *Profile) GetTTL() int {
+	if p.TTL <= 0 {
+		return ddns.DefaultTTL
+	}
+	return p.TTL
+}
+
+// GetWithdraw 返回子域名 sd 实际生效的地址撤回策略和备用地址，子域名的设置优先于 profile。
+//
+// 备用地址必须是 IPv6 地址；策略为 fallback 时必须设置备用地址。
+func (p *Profile) GetWithdraw(sd Subdomain) (ddns.WithdrawPolicy, net.IP, error) {
+	policy, fallback := p.OnWithdraw, p.Fallback
+	if sd.OnWithdraw != "" {
+		policy = sd.OnWithdraw
+	}
+	if sd.Fallback != "" {
+		fallback = sd.Fallback
+	}
+	pol, err := ddns.ParseWithdrawPolicy(policy)
+	if err != nil {
+		return "", nil, err
+	}
+	var ip net.IP
+	if fallback != "" {
+		if ip = net.ParseIP(fallback); ip == nil || ip.To4() != nil {
+			return "", nil, fmt.Errorf("invalid fallback %q: must be an IPv6 address", fallback)
+		}
+	}
+	if pol == ddns.WithdrawFallback && ip == nil {
+		return "", nil, fmt.Errorf("on_withdraw %q requires 'fallback'", pol)
+	}
+	return pol, ip, nil
+}
+
+// GetPolicy 返回子域名 sd 实际生效的地址策略，子域名的 policy 整体覆盖 profile 的 policy。
+func (p *Profile) GetPolicy(sd Subdomain) (ddns.AddrPolicy, error) {
+	if sd.Policy != nil {
+		return sd.Policy.GetPolicy()
+	}
+	return p.Policy.GetPolicy()
+}
+
+// subdomainNames 提取子域名标签列表。
+func subdomainNames(subdomains []Subdomain) []string {
+	names := make([]string, len(subdomains))
+	for i, sd := range subdomains {
+		names[i] = sd.Name
+	}
+	return names
+}
+
+// recordTypesFor 按子域名 > 默认值 > [AAAA] 的优先级返回记录类型。
+func recordTypesFor(sd Subdomain, defaults []string) []string {
+	if len(sd.RecordTypes) > 0 {
+		return sd.RecordTypes
+	}
+	if len(defaults) > 0 {
+		return defaults
+	}
+	return []string{ddns.RecordTypeAAAA}
+}
+
 // GetInterval 解析轮询间隔字符串为 time.Duration。
 // 如果未设置或解析失败，返回默认值 5 分钟并附带解析错误。
 func (c *Config) GetInterval() (time.Duration, error) {
@@ -122,6 +1203,74 @@ func (c *Config) GetInterval() (time.Duration, error) {
 	return d, nil
 }
 
+// GetStateFile 返回状态文件路径。
+// 未设置时返回默认路径 ~/.ddns6/state.json，设置为 "none" 时返回空字符串表示禁用，
+// 以 "~/" 开头的路径展开为用户主目录。
+func (c *Config) GetStateFile() (string, error) {
+	switch {
+	case c.StateFile == "":
+		return StatePath()
+	case c.StateFile == StateFileDisabled:
+		return "", nil
+	case strings.HasPrefix(c.StateFile, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot determine home directory: %w", err)
+		}
+		return filepath.Join(home, c.StateFile[2:]), nil
+	}
+	return c.StateFile, nil
+}
+
+// GetReconcileWindow 解析持久化状态的信任时长。
+// 如果未设置或解析失败，返回默认值 24 小时并附带解析错误。
+func (c *Config) GetReconcileWindow() (time.Duration, error) {
+	if c.ReconcileWindow == "" {
+		return ddns.DefaultReconcileWindow, nil
+	}
+	d, err := time.ParseDuration(c.ReconcileWindow)
+	if err != nil {
+		return ddns.DefaultReconcileWindow, fmt.Errorf("invalid reconcile_window '%s': %w, using default %s", c.ReconcileWindow, err, ddns.DefaultReconcileWindow)
+	}
+	return d, nil
+}
+
+// GetReconcileInterval 解析周期性漂移对账间隔，未设置时返回 0（禁用）。
+func (c *Config) GetReconcileInterval() (time.Duration, error) {
+	if c.ReconcileInterval == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(c.ReconcileInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid reconcile_interval '%s': %w", c.ReconcileInterval, err)
+	}
+	return d, nil
+}
+
+// GetHealthThreshold 解析健康阈值，未设置时返回 ddns.DefaultHealthThreshold。
+func (c *Config) GetHealthThreshold() (time.Duration, error) {
+	if c.HealthThreshold == "" {
+		return ddns.DefaultHealthThreshold, nil
+	}
+	d, err := time.ParseDuration(c.HealthThreshold)
+	if err != nil || d <= 0 {
+		return ddns.DefaultHealthThreshold, fmt.Errorf("invalid health_threshold '%s': must be a positive duration", c.HealthThreshold)
+	}
+	return d, nil
+}
+
+// GetShutdownTimeout 解析优雅关闭的最长等待时间，未设置时返回 ddns.DefaultShutdownTimeout。
+func (c *Config) GetShutdownTimeout() (time.Duration, error) {
+	if c.ShutdownTimeout == "" {
+		return ddns.DefaultShutdownTimeout, nil
+	}
+	d, err := time.ParseDuration(c.ShutdownTimeout)
+	if err != nil || d < 0 {
+		return ddns.DefaultShutdownTimeout, fmt.Errorf("invalid shutdown_timeout '%s': must be a non-negative duration", c.ShutdownTimeout)
+	}
+	return d, nil
+}
+
 // GetTTL 返回 TTL 值，未设置时返回默认值。
 func (c *Config) GetTTL() int {
 	if c.TTL <= 0 {
@@ -133,13 +1282,14 @@ func (c *Config) GetTTL() int {
 // InitParams ddns6 init 命令的可选预填参数。
 // 空值/零值表示不预填，相应字段在配置文件中保持注释状态。
 type InitParams struct {
-	Provider   string            // DNS 运营商名称（如 tencent）
-	Auth       map[string]string // 认证凭据（如 secret_id, secret_key）
-	Domain     string
-	Subdomains []string
-	TTL        int
-	Interval   string
-	Interface  string
+	Provider    string            // DNS 运营商名称（如 tencent）
+	Auth        map[string]string // 认证凭据（如 secret_id, secret_key）
+	Domain      string
+	Subdomains  []string
+	TTL         int
+	Interval    string
+	Interface   string
+	RecordTypes []string // 默认记录类型（如 [A AAAA]），为空时保留为注释
 }
 
 // configTemplate 配置模板，使用 text/template 渲染。
@@ -176,6 +1326,17 @@ domain: "{{.Domain}}"
 subdomains:{{if .Subdomains}}{{range .Subdomains}}
   - "{{.}}"{{end}}{{else}}
   - "@"{{end}}
+# 子域名也可写成映射，单独指定记录类型：
+#  - name: "nas"
+#    record_types: [A, AAAA]
+# 在路由器上为局域网主机发布 AAAA 记录：运营商下发前缀 + 主机接口标识（IPv6 后缀或 MAC 地址）
+#  - name: "printer"
+#    suffix: "::1234"
+#    prefix_length: 64        # 下发前缀长度（默认 64，如 /56 前缀下的子网需写 56）
+
+# 可选：默认发布的记录类型，A 为 IPv4，AAAA 为 IPv6（默认仅 AAAA）
+{{if .RecordTypes}}record_types:{{range .RecordTypes}}
+  - {{.}}{{end}}{{else}}# record_types: [A, AAAA]{{end}}
 
 # 可选：非 Linux 平台的轮询间隔
 # 格式：数字+单位（s=秒, m=分, h=时），默认 5m
@@ -187,8 +1348,153 @@ subdomains:{{if .Subdomains}}{{range .Subdomains}}
 # 不指定则监听所有接口
 {{if .Interface}}interface: {{.Interface}}{{else}}# interface: ppp0{{end}}
 
+# 可选：Netlink 触发源的防抖和事件过滤（仅 Linux）
+# netlink:
+#   debounce: 10s          # 最后一个地址事件后等待的时间，合并 PPPoE 重拨等短时间内的多个事件
+#   max_wait: 1m           # 自第一个事件起的最长等待时间，持续不断的事件不会无限推迟同步
+#   include_ula: false     # ULA（fc00::/7）地址事件也触发同步（默认忽略）
+#   interfaces: ["ppp*"]   # 监听的接口名模式（* 和 ? 通配），设置后取代 interface
+
+# 可选：地址获取器（默认使用内置的 HTTP 和 DNS 获取器）
+# 每项写成 TYPE[@TIMEOUT]:VALUE（与 --fetcher 相同），或 http/dns/interface/command 映射
+# http：回显服务 URL；dns：DNS 服务器地址（取本机出口地址）；
+# dnsquery：向 DNS 服务器查询"我的 IP"（opendns、google、akamai 或 NAME/TYPE@SERVER，NAT66 / NPTv6 后也能得到公网地址）；
+# interface：本机接口（仅 Linux，仅 IPv6）；command：外部命令，从输出中取第一个地址
+# http 映射可设置提取器（json：JSON 路径；regex：正则表达式，取第一个捕获组；field：key=value 字段名，三选一）、
+# headers（请求头）和 username / password（HTTP Basic 认证），适用于 JSON 接口和需要登录的路由器状态页；
+# source（本机源地址）或 bind_interface（绑定网络接口，仅 Linux）用于多出口时获取指定出口的地址；
+# 主机名只通过获取器的地址族连接，IP 字面量（如路由器的 192.168.1.1）使用其自身的地址族，
+# network（tcp、tcp4 或 tcp6）可覆盖，如只有 IPv4 地址的路由器主机名设置 network: tcp4
+# fetchers:
+#   timeout: 5s                       # 每次地址获取的总超时时间
+#   ipv6:
+#     - https://6.ipw.cn
+#     - dns@2s:2400:3200:baba::1
+#     - dnsquery:opendns
+#     - {interface: ppp0}
+#     - {command: "/usr/local/bin/myip", timeout: 3s}
+#     - {http: "https://api64.ipify.org?format=json", json: ip}
+#     - {http: "https://[2606:4700:4700::1111]/cdn-cgi/trace", field: ip}
+#     - http: http://192.168.1.1/status
+#       regex: 'WAN IPv6: ([0-9a-f:]+)'
+#       headers: {X-Requested-With: ddns6}
+#       username: admin
+#       password: "your-password"
+#     - {http: https://6.ipw.cn, bind_interface: ppp1}
+#   ipv4:
+#     - https://4.ipw.cn
+
+# 可选：IPv6 地址获取的共识模式（默认最快返回的获取器胜出）
+# 启用后等待至少 agree 个获取器的结果一致，单个异常或被劫持的回显服务无法决定发布的地址
+# match: address 要求完整地址相同，prefix 只要求同一 /64 前缀
+# quorum:
+#   agree: 2
+#   match: address
+
 # 可选：DNS 记录 TTL，单位秒，默认 600
 {{if .TTL}}ttl: {{.TTL}}{{else}}# ttl: 600{{end}}
+
+# 可选：本机全局 IPv6 地址被撤回（PPPoE 断线、前缀弃用或生命周期到期）且无法获取新地址时 AAAA 记录的处理
+# keep 保留记录（默认），delete 删除记录，fallback 改为 fallback 地址，
+# disable 暂停记录（仅 tencent、alicloud 支持）；地址恢复后自动恢复记录
+# 子域名和 profile 可单独设置 on_withdraw 和 fallback
+# on_withdraw: keep
+# fallback: "2001:db8::1"
+
+# 可选：发布 AAAA 记录前的地址策略（默认不筛选，直接使用最快获取到的地址）
+# 设置后等待所有获取器，返回的不同地址都作为候选，按 prefer 排序后取第一个通过所有规则的地址；
+# 被拒绝的候选记录原因，没有候选通过时跳过本次同步。子域名和 profile 可单独设置 policy（整体覆盖）
+# policy:
+#   reject_bogons: true          # 拒绝文档、ULA、链路本地、6to4 和 Teredo 地址
+#   allow: ["2409::/16"]         # 只接受这些 CIDR 中的地址
+#   deny: ["2409:8a00:dead::/48"]
+#   prefer: ["2409:8a00::/40"]   # 多个候选时优先选择（如运营商下发的前缀）
+#   require_local: true          # 要求地址配置在本机网络接口上
+
+# 可选：状态文件，保存每个域名最近发布的地址和记录 ID，重启后无需逐个查询服务商
+# 默认 ~/.ddns6/state.json，设为 none 禁用
+# state_file: ~/.ddns6/state.json
+
+# 可选：持久化状态的信任时长，超过后重新向服务商核对记录（默认 24h，0 表示不信任）
+# reconcile_window: 24h
+
+# 可选：周期性漂移对账间隔，与 Netlink 事件并行工作（默认禁用）
+# 每次对账忽略内存缓存，重新读取服务商记录，修复在控制台被修改或删除的记录
+# reconcile_interval: 1h
+
+# 可选：服务商 API 调用的重试策略
+# 网络错误、429、5xx 和服务商限流错误码（如腾讯云 RequestLimitExceeded、阿里云 Throttling）自动重试
+# retry:
+#   attempts: 3       # 最大尝试次数（含首次，1 表示不重试）
+#   base_delay: 500ms # 基础退避时间，每次翻倍
+#   max_delay: 30s    # 单次等待上限，Retry-After 超过此值时放弃
+
+# 可选：HTTP 状态端点，GET /status 返回各域名同步状态（JSON），GET /healthz 用于健康检查，
+# GET /metrics 导出 Prometheus 指标
+# ddns6 healthcheck 命令探测该端点，可作为 Docker HEALTHCHECK
+# status_addr: 127.0.0.1:9876
+# 域名持续同步失败超过此时长时 /healthz 返回 503（默认 15m）
+# health_threshold: 15m
+
+# 可选：收到退出信号后等待进行中同步完成的最长时间，全部完成后立即退出（默认 5s）
+# shutdown_timeout: 5s
+
+# 可选：配置文件变化时自动热重载（默认 false，仅在收到 SIGHUP 时重载）
+# 热重载只更新 profiles（域名、服务商和凭据）与 retry，其余全局设置需重启生效
+# watch_config: true
+
+# 可选：记录更新前后执行的本地命令（通过 sh -c 执行，输出记录到日志），仅在服务商记录需要修改或新增时运行
+# 环境变量：DDNS6_HOOK（pre/post）、DDNS6_HOOK_PROFILE、DDNS6_HOOK_DOMAIN、DDNS6_HOOK_ZONE、
+# DDNS6_HOOK_SUBDOMAIN、DDNS6_HOOK_RECORD_TYPE、DDNS6_HOOK_OLD_ADDR、DDNS6_HOOK_NEW_ADDR，
+# post 钩子另有 DDNS6_HOOK_RESULT（success/failure）和 DDNS6_HOOK_ERROR
+# hooks:
+#   timeout: 30s      # 单个命令的超时时间
+#   pre:              # 写入前执行，非零退出或超时则否决本次更新（稍后重试时再次执行）
+#     - /usr/local/bin/check-maintenance
+#   post:             # 写入后执行
+#     - /usr/local/bin/update-firewall "$DDNS6_HOOK_NEW_ADDR"
+#     - systemctl reload nginx
+
+# 可选：记录更新后的传播验证（默认不验证，服务商 API 返回成功即视为完成）
+# 启用后查询根域名的权威服务器（NS 记录），直接向每台服务器查询记录，直到全部返回新值并记录所用时间；
+# 超过 timeout 仍未全部生效时记录警告、发送 propagation_timeout 通知并重新写入记录
+# verify:
+#   enabled: true
+#   timeout: 5m       # 所有权威服务器返回新值的截止时间
+#   interval: 10s     # 查询间隔
+
+# 可选：webhook 通知，在地址变化（address_changed）、记录更新（record_updated）、
+# 同步失败（sync_failed，连续失败只通知一次）、恢复（sync_recovered）
+# 和传播超时（propagation_timeout，需启用 verify）时发送
+# url 和 body 为 Go text/template 模板，可用字段：.Type .Time .Profile .Domain .RecordType
+# .Family .OldAddr .NewAddr .Action .RecordID .Error .Message；json 函数将值编码为 JSON 字符串
+# body 为空时 POST 发送事件的 JSON
+# notify:
+#   rate_limit: 10    # 每个 webhook 每分钟最多发送的通知数（0 表示不限）
+#   webhooks:
+#     - name: dingtalk
+#       url: https://oapi.dingtalk.com/robot/send?access_token=xxx
+#       events: [record_updated, sync_failed, sync_recovered]
+#       body: '{"msgtype":"text","text":{"content":{{"{{"}}json .Message{{"}}"}}}}'
+#     - name: bark
+#       method: GET
+#       url: https://api.day.app/your-key/{{"{{"}}urlquery .Message{{"}}"}}
+
+# 可选：多 profile 模式，在一个进程中管理多个运营商/账号/根域名
+# 使用时删除上方的 provider、auth、domain、subdomains，改为以下列表
+# 顶层 record_types、ttl、on_withdraw、fallback、policy 作为各 profile 的默认值
+# profiles:
+#   - name: home
+#     provider: cloudflare
+#     auth:
+#       api_token: "your-api-token"
+#     domain: example.com
+#     subdomains: [www, "@"]
+#   - provider: tencent
+#     auth:
+#       secret_id: "your-secret-id"
+#       secret_key: "your-secret-key"
+#     domain: example.cn
 `
 
 // Generate 创建 ~/.ddns6/ 目录并写入 config.yaml。
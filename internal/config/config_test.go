@@ -2,10 +2,20 @@
 package config
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/internal/notify"
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 // configDirForTest 在测试中临时替换 HOME 来获取配置目录。
@@ -168,11 +178,523 @@ func TestLoad_DefaultSubdomains(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Load() 不应返回错误: %v", err)
 	}
-	if len(cfg.Subdomains) != 1 || cfg.Subdomains[0] != "@" {
+	if len(cfg.Subdomains) != 1 || cfg.Subdomains[0].Name != "@" {
 		t.Errorf("Subdomains 默认应为 [@], 得到 %v", cfg.Subdomains)
 	}
 }
 
+func TestLoad_SubdomainRecordTypes(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"provider: tencent",
+		"domain: example.com",
+		"record_types: [aaaa, A, AAAA]",
+		"subdomains:",
+		"  - www",
+		"  - name: nas",
+		"    record_types: [AAAA]",
+	))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() 不应返回错误: %v", err)
+	}
+	if got := cfg.SubdomainNames(); len(got) != 2 || got[0] != "www" || got[1] != "nas" {
+		t.Errorf("SubdomainNames() = %v, 期望 [www nas]", got)
+	}
+	if got := cfg.GetRecordTypes(cfg.Subdomains[0]); len(got) != 2 || got[0] != "AAAA" || got[1] != "A" {
+		t.Errorf("www 应继承全局 record_types [AAAA A], 得到 %v", got)
+	}
+	if got := cfg.GetRecordTypes(cfg.Subdomains[1]); len(got) != 1 || got[0] != "AAAA" {
+		t.Errorf("nas 应使用自身 record_types [AAAA], 得到 %v", got)
+	}
+}
+
+func TestLoad_SubdomainSuffix(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"provider: tencent",
+		"domain: example.com",
+		"subdomains:",
+		"  - name: printer",
+		`    suffix: "::1234"`,
+		"    prefix_length: 56",
+		"  - name: nas",
+		`    suffix: "00:11:22:33:44:55"`,
+	))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() 不应返回错误: %v", err)
+	}
+	iid, prefixLen, err := cfg.Subdomains[0].GetSuffix()
+	if err != nil || !iid.Equal(net.ParseIP("::1234")) || prefixLen != 56 {
+		t.Errorf("printer 后缀解析不符: %v /%d err=%v", iid, prefixLen, err)
+	}
+	iid, prefixLen, err = cfg.Subdomains[1].GetSuffix()
+	if err != nil || !iid.Equal(net.ParseIP("::211:22ff:fe33:4455")) || prefixLen != 64 {
+		t.Errorf("MAC 地址应按 EUI-64 转换且默认前缀长度为 64: %v /%d err=%v", iid, prefixLen, err)
+	}
+}
+
+func TestLoad_InvalidSubdomainSuffix(t *testing.T) {
+	for _, sd := range []string{
+		`{name: nas, suffix: "192.168.1.10"}`,
+		`{name: nas, suffix: "::1", prefix_length: 128}`,
+		`{name: nas, prefix_length: 64}`,
+	} {
+		tmpDir := t.TempDir()
+		configDirForTest(t, tmpDir)
+		writeConfig(t, tmpDir, "provider: tencent\ndomain: example.com\nsubdomains:\n  - "+sd+"\n")
+		if _, err := Load(); err == nil {
+			t.Errorf("无效的局域网主机配置应返回错误: %s", sd)
+		}
+	}
+}
+
+func TestLoad_WithdrawPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"on_withdraw: fallback",
+		`fallback: "2001:db8::ffff"`,
+		"profiles:",
+		"  - provider: tencent",
+		"    domain: example.com",
+		"    subdomains:",
+		"      - www",
+		"      - {name: nas, on_withdraw: delete}",
+		"  - provider: alicloud",
+		"    domain: example.cn",
+		"    on_withdraw: disable",
+	))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load 不应返回错误: %v", err)
+	}
+	profiles := cfg.GetProfiles()
+	for _, tt := range []struct {
+		profile, sd int
+		want        ddns.WithdrawPolicy
+	}{
+		{0, 0, ddns.WithdrawFallback},
+		{0, 1, ddns.WithdrawDelete},
+		{1, 0, ddns.WithdrawDisable},
+	} {
+		p := profiles[tt.profile]
+		policy, fallback, err := p.GetWithdraw(p.Subdomains[tt.sd])
+		if err != nil || policy != tt.want {
+			t.Errorf("%s/%s: 期望策略 %q, 得到 %q (%v)", p.Name, p.Subdomains[tt.sd].Name, tt.want, policy, err)
+		}
+		if !fallback.Equal(net.ParseIP("2001:db8::ffff")) {
+			t.Errorf("%s: fallback 应继承顶层值, 得到 %v", p.Name, fallback)
+		}
+	}
+}
+
+func TestLoad_InvalidWithdrawPolicy(t *testing.T) {
+	for _, extra := range []string{
+		"on_withdraw: remove",
+		"on_withdraw: fallback",
+		"on_withdraw: fallback\nfallback: 192.0.2.1",
+	} {
+		tmpDir := t.TempDir()
+		configDirForTest(t, tmpDir)
+		writeConfig(t, tmpDir, "provider: tencent\ndomain: example.com\n"+extra+"\n")
+		if _, err := Load(); err == nil {
+			t.Errorf("无效的撤回策略配置应返回错误: %s", extra)
+		}
+	}
+}
+
+func TestLoad_AddrPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"policy:",
+		"  reject_bogons: true",
+		`  prefer: ["2409:8a00::/40"]`,
+		"profiles:",
+		"  - provider: tencent",
+		"    domain: example.com",
+		"    subdomains:",
+		"      - www",
+		`      - {name: lab, policy: {allow: ["240e::/16"], require_local: true}}`,
+		"  - provider: alicloud",
+		"    domain: example.cn",
+		`    policy: {deny: ["2409:8a00:dead::/48"]}`,
+	))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load 不应返回错误: %v", err)
+	}
+	profiles := cfg.GetProfiles()
+
+	www, err := profiles[0].GetPolicy(profiles[0].Subdomains[0])
+	if err != nil || !www.RejectBogons || len(www.Prefer) != 1 || www.Prefer[0].String() != "2409:8a00::/40" {
+		t.Errorf("www 应继承顶层 policy, 得到 %+v, %v", www, err)
+	}
+	lab, _ := profiles[0].GetPolicy(profiles[0].Subdomains[1])
+	if lab.RejectBogons || !lab.RequireLocal || len(lab.Allow) != 1 {
+		t.Errorf("子域名的 policy 应整体覆盖, 得到 %+v", lab)
+	}
+	cn, _ := profiles[1].GetPolicy(profiles[1].Subdomains[0])
+	if cn.RejectBogons || len(cn.Deny) != 1 {
+		t.Errorf("profile 的 policy 应覆盖顶层, 得到 %+v", cn)
+	}
+}
+
+func TestLoad_InvalidAddrPolicy(t *testing.T) {
+	for _, extra := range []string{
+		"policy: {allow: [2409::]}",
+		"policy: {deny: [192.0.2.0/24]}",
+		"subdomains: [{name: www, policy: {prefer: [nope]}}]",
+	} {
+		tmpDir := t.TempDir()
+		configDirForTest(t, tmpDir)
+		writeConfig(t, tmpDir, "provider: tencent\ndomain: example.com\n"+extra+"\n")
+		if _, err := Load(); err == nil {
+			t.Errorf("无效的地址策略应返回错误: %s", extra)
+		}
+	}
+}
+
+func TestLoad_Netlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"provider: tencent",
+		"domain: example.com",
+		"netlink:",
+		"  debounce: 3s",
+		"  include_ula: true",
+		`  interfaces: ["ppp*", eth0]`,
+	))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load 不应返回错误: %v", err)
+	}
+	trigger, err := cfg.Netlink.GetTrigger()
+	if err != nil {
+		t.Fatalf("GetTrigger 不应返回错误: %v", err)
+	}
+	if trigger.Debounce != 3*time.Second || trigger.MaxWait != 0 || !trigger.IncludeULA || len(trigger.Interfaces) != 2 {
+		t.Errorf("netlink 配置解析不符（未设置的 max_wait 应为 0 表示默认值）: %+v", trigger)
+	}
+
+	for _, extra := range []string{
+		"netlink: {debounce: soon}",
+		"netlink: {max_wait: -1m}",
+		`netlink: {interfaces: ["ppp[0-"]}`,
+	} {
+		writeConfig(t, tmpDir, "provider: tencent\ndomain: example.com\n"+extra+"\n")
+		if _, err := Load(); err == nil {
+			t.Errorf("无效的 netlink 配置应返回错误: %s", extra)
+		}
+	}
+}
+
+func TestLoad_Quorum(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, "provider: tencent\ndomain: example.com\nquorum: {agree: 2, match: prefix}\n")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load 不应返回错误: %v", err)
+	}
+	q, err := cfg.Quorum.GetQuorum()
+	if err != nil || q.N != 2 || q.Match != ipaddr.MatchPrefix {
+		t.Errorf("quorum 配置解析不符: %+v, %v", q, err)
+	}
+
+	for _, extra := range []string{"quorum: {agree: -1}", "quorum: {agree: 2, match: subnet}"} {
+		writeConfig(t, tmpDir, "provider: tencent\ndomain: example.com\n"+extra+"\n")
+		if _, err := Load(); err == nil {
+			t.Errorf("无效的 quorum 配置应返回错误: %s", extra)
+		}
+	}
+}
+
+func TestLoad_Fetchers(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"provider: tencent",
+		"domain: example.com",
+		"fetchers:",
+		"  timeout: 8s",
+		"  ipv6:",
+		"    - https://6.ipw.cn",
+		"    - dns@2s:2400:3200:baba::1",
+		"    - {interface: ppp0}",
+		`    - {command: "echo 2001:db8::1", timeout: 3s}`,
+		"    - dnsquery:opendns",
+		"    - dnsquery:whoami.example.net/txt@[2001:db8::53]:5353",
+		"  ipv4: [command:echo 192.0.2.1, dnsquery:google]",
+	))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load 不应返回错误: %v", err)
+	}
+	if timeout, err := cfg.Fetchers.GetTimeout(); err != nil || timeout != 8*time.Second {
+		t.Errorf("总超时应为 8s, 得到 %v, %v", timeout, err)
+	}
+	v6, err := cfg.Fetchers.GetIPv6Fetchers()
+	if err != nil || len(v6) != 6 {
+		t.Fatalf("应创建 6 个 IPv6 获取器: %d, %v", len(v6), err)
+	}
+	want := []string{"https://6.ipw.cn", "2400:3200:baba::1", "interface:ppp0", "command:echo 2001:db8::1",
+		"dnsquery:myip.opendns.com/AAAA@resolver1.opendns.com", "dnsquery:whoami.example.net/TXT@[2001:db8::53]:5353"}
+	for i, f := range v6 {
+		if name := ipaddr.FetcherName(f); name != want[i] {
+			t.Errorf("第 %d 个获取器应为 %s, 得到 %s", i, want[i], name)
+		}
+	}
+	if _, ok := v6[1].(*ipaddr.TimeoutFetcher); !ok {
+		t.Errorf("设置了 timeout 的获取器应被 TimeoutFetcher 包装, 得到 %T", v6[1])
+	}
+	if v4, err := cfg.Fetchers.GetIPv4Fetchers(); err != nil || len(v4) != 2 {
+		t.Errorf("应创建 2 个 IPv4 获取器: %d, %v", len(v4), err)
+	}
+
+	var empty *FetchersConfig
+	if v6, _ := empty.GetIPv6Fetchers(); len(v6) != len(ddns.DefaultIPv6Fetchers) {
+		t.Error("未配置 fetchers 时应使用内置列表")
+	}
+}
+
+func TestLoad_HTTPFetcherExtractor(t *testing.T) {
+	// HTTP IPv6 获取器只通过 tcp6 连接
+	ln, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" || r.Header.Get("X-Token") != "t1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data": {"ipv6": "2001:db8::7"}}`))
+	}))
+	server.Listener.Close()
+	server.Listener = ln
+	server.Start()
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"provider: tencent",
+		"domain: example.com",
+		"fetchers:",
+		"  ipv6:",
+		"    - http: "+server.URL,
+		"      json: data.ipv6",
+		"      headers: {X-Token: t1}",
+		"      username: admin",
+		"      password: secret",
+		"      source: ::1",
+	))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load 不应返回错误: %v", err)
+	}
+	v6, err := cfg.Fetchers.GetIPv6Fetchers()
+	if err != nil || len(v6) != 1 {
+		t.Fatalf("应创建 1 个 IPv6 获取器: %d, %v", len(v6), err)
+	}
+	if name := ipaddr.FetcherName(v6[0]); name != server.URL+" (json:data.ipv6)" {
+		t.Errorf("获取器名称应包含提取器, 得到 %s", name)
+	}
+	ip, err := v6[0].Fetch(context.Background())
+	if err != nil || ip.String() != "2001:db8::7" {
+		t.Errorf("应通过认证并从 JSON 中取得 2001:db8::7, 得到 %v, %v", ip, err)
+	}
+}
+
+func TestLoad_InvalidFetchers(t *testing.T) {
+	for _, extra := range []string{
+		"fetchers: {ipv6: [ftp:example.com]}",
+		"fetchers: {ipv6: [dns:]}",
+		"fetchers: {ipv6: [{http: https://6.ipw.cn, dns: 2400:3200:baba::1}]}",
+		"fetchers: {ipv6: [{timeout: 2s}]}",
+		"fetchers: {ipv6: [dns@soon:2400:3200:baba::1]}",
+		"fetchers: {ipv4: [interface:ppp0]}",
+		"fetchers: {ipv6: [dnsquery:cloudflare]}",
+		"fetchers: {ipv6: [{http: https://example.com, json: ip, field: ip}]}",
+		"fetchers: {ipv6: [{http: https://example.com, json: 'a[x]'}]}",
+		"fetchers: {ipv6: [{http: https://example.com, regex: '('}]}",
+		"fetchers: {ipv6: [{dns: 2400:3200:baba::1, field: ip}]}",
+		"fetchers: {ipv6: [{http: https://example.com, source: 192.0.2.1}]}",
+		"fetchers: {ipv4: [{http: https://example.com, source: '2001:db8::1'}]}",
+		"fetchers: {ipv6: [{dnsquery: opendns, bind_interface: ppp1}]}",
+		"fetchers: {ipv6: [{http: https://example.com, network: udp}]}",
+		"fetchers: {ipv6: [{http: https://example.com, network: tcp4, source: '::1'}]}",
+		"fetchers: {ipv6: [{dns: 2400:3200:baba::1, network: tcp}]}",
+		"fetchers: {ipv6: [dnsquery:myip.opendns.com/MX@resolver1.opendns.com]}",
+		"fetchers: {timeout: 0s}",
+	} {
+		tmpDir := t.TempDir()
+		configDirForTest(t, tmpDir)
+		writeConfig(t, tmpDir, "provider: tencent\ndomain: example.com\n"+extra+"\n")
+		if _, err := Load(); err == nil {
+			t.Errorf("无效的 fetchers 配置应返回错误: %s", extra)
+		}
+	}
+}
+
+func TestLoad_InvalidRecordType(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, "provider: tencent\ndomain: example.com\nrecord_types: [CNAME]\n")
+
+	if _, err := Load(); err == nil {
+		t.Fatal("不支持的记录类型应返回错误")
+	}
+}
+
+func TestLoad_Profiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"record_types: [A, AAAA]",
+		"ttl: 300",
+		"profiles:",
+		"  - provider: cloudflare",
+		"    auth:",
+		"      api_token: token",
+		"    domain: example.com",
+		"    subdomains: [www]",
+		"  - name: cn",
+		"    provider: tencent",
+		"    domain: example.cn",
+		"    record_types: [aaaa]",
+		"    ttl: 120",
+	))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() 不应返回错误: %v", err)
+	}
+	profiles := cfg.GetProfiles()
+	if len(profiles) != 2 {
+		t.Fatalf("期望 2 个 profile, 得到 %d", len(profiles))
+	}
+
+	cf := profiles[0]
+	if cf.Name != "example.com" {
+		t.Errorf("未设置 name 时应默认为根域名, 得到 %q", cf.Name)
+	}
+	if cf.Auth["api_token"] != "token" {
+		t.Errorf("Auth.api_token = %q, 期望 %q", cf.Auth["api_token"], "token")
+	}
+	if got := cf.GetRecordTypes(cf.Subdomains[0]); len(got) != 2 {
+		t.Errorf("应继承顶层 record_types [A AAAA], 得到 %v", got)
+	}
+	if cf.GetTTL() != 300 {
+		t.Errorf("应继承顶层 ttl 300, 得到 %d", cf.GetTTL())
+	}
+
+	cn := profiles[1]
+	if cn.Name != "cn" {
+		t.Errorf("Name = %q, 期望 %q", cn.Name, "cn")
+	}
+	if len(cn.Subdomains) != 1 || cn.Subdomains[0].Name != "@" {
+		t.Errorf("Subdomains 默认应为 [@], 得到 %v", cn.Subdomains)
+	}
+	if cn.Auth == nil {
+		t.Error("Auth 不应为 nil, Load 应初始化为空 map")
+	}
+	if got := cn.GetRecordTypes(cn.Subdomains[0]); len(got) != 1 || got[0] != "AAAA" {
+		t.Errorf("profile 自身 record_types 应优先, 得到 %v", got)
+	}
+	if cn.GetTTL() != 120 {
+		t.Errorf("TTL = %d, 期望 120", cn.GetTTL())
+	}
+}
+
+func TestLoad_ProfilesMixedWithTopLevel(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"provider: tencent",
+		"domain: example.com",
+		"profiles:",
+		"  - provider: cloudflare",
+		"    domain: example.net",
+	))
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("profiles 与顶层 provider/domain 同时存在时应返回错误")
+	}
+	if !strings.Contains(err.Error(), "profiles") {
+		t.Errorf("错误信息应提示 profiles, 得到: %v", err)
+	}
+}
+
+func TestLoad_ProfileMissingProvider(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"profiles:",
+		"  - domain: example.com",
+	))
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("profile 缺少 provider 时应返回错误")
+	}
+	if !strings.Contains(err.Error(), "profiles[0].provider") {
+		t.Errorf("错误信息应指出缺失字段, 得到: %v", err)
+	}
+}
+
+func TestLoad_ProfileDuplicateName(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"profiles:",
+		"  - provider: cloudflare",
+		"    domain: example.com",
+		"  - provider: tencent",
+		"    domain: example.com",
+	))
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("profile 名称重复时应返回错误")
+	}
+	if !strings.Contains(err.Error(), "duplicate profile name") {
+		t.Errorf("错误信息应提示名称重复, 得到: %v", err)
+	}
+}
+
+func TestGetProfiles_ImplicitProfile(t *testing.T) {
+	c := &Config{
+		Provider:   "tencent",
+		Domain:     "example.com",
+		Subdomains: []Subdomain{{Name: "www"}},
+		TTL:        300,
+	}
+	profiles := c.GetProfiles()
+	if len(profiles) != 1 {
+		t.Fatalf("未配置 profiles 时应返回 1 个隐式 profile, 得到 %d", len(profiles))
+	}
+	p := profiles[0]
+	if p.Provider != "tencent" || p.Domain != "example.com" || p.GetTTL() != 300 {
+		t.Errorf("隐式 profile 应来自顶层字段, 得到 %+v", p)
+	}
+}
+
 func TestLoad_DefaultAuth(t *testing.T) {
 	tmpDir := t.TempDir()
 	configDirForTest(t, tmpDir)
@@ -353,4 +875,264 @@ func TestGenerate_DefaultParams(t *testing.T) {
 	if !strings.Contains(content, "example.com") {
 		t.Error("生成内容应包含 example.com")
 	}
+	if !strings.Contains(content, "{{json .Message}}") {
+		t.Error("生成内容应原样保留 webhook 模板示例")
+	}
+}
+
+// ============================================================
+// GetStateFile / GetReconcileWindow 测试
+// ============================================================
+
+func TestGetStateFile_Default(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgDir := configDirForTest(t, tmpDir)
+	c := &Config{}
+	path, err := c.GetStateFile()
+	if err != nil {
+		t.Fatalf("GetStateFile 不应返回错误: %v", err)
+	}
+	if want := filepath.Join(cfgDir, "state.json"); path != want {
+		t.Errorf("默认状态文件路径 = %q, 期望 %q", path, want)
+	}
+}
+
+func TestGetStateFile_Disabled(t *testing.T) {
+	c := &Config{StateFile: StateFileDisabled}
+	path, err := c.GetStateFile()
+	if err != nil {
+		t.Fatalf("GetStateFile 不应返回错误: %v", err)
+	}
+	if path != "" {
+		t.Errorf("state_file 为 none 时应返回空路径, 得到 %q", path)
+	}
+}
+
+func TestGetStateFile_HomeExpansion(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	c := &Config{StateFile: "~/ddns/state.json"}
+	path, err := c.GetStateFile()
+	if err != nil {
+		t.Fatalf("GetStateFile 不应返回错误: %v", err)
+	}
+	if want := filepath.Join(tmpDir, "ddns", "state.json"); path != want {
+		t.Errorf("路径 = %q, 期望 %q", path, want)
+	}
+}
+
+func TestGetReconcileWindow(t *testing.T) {
+	if d, err := (&Config{}).GetReconcileWindow(); err != nil || d != 24*time.Hour {
+		t.Errorf("默认值应为 24h, 得到 %v (err=%v)", d, err)
+	}
+	if d, err := (&Config{ReconcileWindow: "2h"}).GetReconcileWindow(); err != nil || d != 2*time.Hour {
+		t.Errorf("期望 2h, 得到 %v (err=%v)", d, err)
+	}
+	if _, err := (&Config{ReconcileWindow: "soon"}).GetReconcileWindow(); err == nil {
+		t.Error("无效的 reconcile_window 应返回错误")
+	}
+}
+
+func TestGetReconcileInterval(t *testing.T) {
+	if d, err := (&Config{}).GetReconcileInterval(); err != nil || d != 0 {
+		t.Errorf("未设置时应为 0（禁用）, 得到 %v (err=%v)", d, err)
+	}
+	if d, err := (&Config{ReconcileInterval: "1h"}).GetReconcileInterval(); err != nil || d != time.Hour {
+		t.Errorf("期望 1h, 得到 %v (err=%v)", d, err)
+	}
+	if _, err := (&Config{ReconcileInterval: "often"}).GetReconcileInterval(); err == nil {
+		t.Error("无效的 reconcile_interval 应返回错误")
+	}
+}
+
+func TestGetHealthThreshold(t *testing.T) {
+	if d, err := (&Config{}).GetHealthThreshold(); err != nil || d != ddns.DefaultHealthThreshold {
+		t.Errorf("未设置时应为默认值, 得到 %v (err=%v)", d, err)
+	}
+	if d, err := (&Config{HealthThreshold: "5m"}).GetHealthThreshold(); err != nil || d != 5*time.Minute {
+		t.Errorf("期望 5m, 得到 %v (err=%v)", d, err)
+	}
+	for _, v := range []string{"soon", "0s"} {
+		if _, err := (&Config{HealthThreshold: v}).GetHealthThreshold(); err == nil {
+			t.Errorf("无效的 health_threshold %q 应返回错误", v)
+		}
+	}
+}
+
+func TestGetShutdownTimeout(t *testing.T) {
+	if d, err := (&Config{}).GetShutdownTimeout(); err != nil || d != ddns.DefaultShutdownTimeout {
+		t.Errorf("未设置时应为默认值, 得到 %v (err=%v)", d, err)
+	}
+	if d, err := (&Config{ShutdownTimeout: "30s"}).GetShutdownTimeout(); err != nil || d != 30*time.Second {
+		t.Errorf("期望 30s, 得到 %v (err=%v)", d, err)
+	}
+	if _, err := (&Config{ShutdownTimeout: "-1s"}).GetShutdownTimeout(); err == nil {
+		t.Error("负数的 shutdown_timeout 应返回错误")
+	}
+}
+
+func TestRetryConfig_Apply(t *testing.T) {
+	base := retry.Policy{Attempts: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+	var nilCfg *RetryConfig
+	if p, err := nilCfg.Apply(base); err != nil || p != base {
+		t.Errorf("未配置 retry 时应返回默认策略, 得到 %+v (err=%v)", p, err)
+	}
+
+	p, err := (&RetryConfig{Attempts: 5, MaxDelay: "1m"}).Apply(base)
+	if err != nil {
+		t.Fatalf("不应返回错误: %v", err)
+	}
+	want := retry.Policy{Attempts: 5, BaseDelay: 500 * time.Millisecond, MaxDelay: time.Minute}
+	if p != want {
+		t.Errorf("期望 %+v, 得到 %+v", want, p)
+	}
+
+	for _, rc := range []RetryConfig{{Attempts: -1}, {BaseDelay: "soon"}, {MaxDelay: "-1s"}} {
+		if _, err := rc.Apply(base); err == nil {
+			t.Errorf("无效配置 %+v 应返回错误", rc)
+		}
+	}
+}
+
+func TestLoad_Retry(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"provider: tencent",
+		"domain: example.com",
+		"subdomains: [www]",
+		"retry:",
+		"  attempts: 1",
+		"  base_delay: 1s",
+	))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load 不应返回错误: %v", err)
+	}
+	if cfg.Retry == nil || cfg.Retry.Attempts != 1 || cfg.Retry.BaseDelay != "1s" {
+		t.Errorf("retry 配置解析不符: %+v", cfg.Retry)
+	}
+}
+
+func TestLoad_InvalidRetry(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"provider: tencent",
+		"domain: example.com",
+		"subdomains: [www]",
+		"retry:",
+		"  max_delay: forever",
+	))
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "retry.max_delay") {
+		t.Errorf("无效的 retry.max_delay 应返回错误, 得到 %v", err)
+	}
+}
+
+func TestLoad_Notify(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"provider: tencent",
+		"domain: example.com",
+		"notify:",
+		"  rate_limit: 0",
+		"  webhooks:",
+		"    - url: https://hooks.example.com/{{urlquery .Domain}}",
+		"      events: [Record_Updated, sync_failed]",
+		"      body: '{\"text\": {{json .Message}}}'",
+	))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load 不应返回错误: %v", err)
+	}
+	hooks, err := cfg.Notify.GetWebhooks()
+	if err != nil || len(hooks) != 1 {
+		t.Fatalf("应解析出 1 个 webhook, 得到 %+v (err=%v)", hooks, err)
+	}
+	if hooks[0].Events[0] != ddns.EventRecordUpdated || hooks[0].Body == "" {
+		t.Errorf("webhook 配置解析不符: %+v", hooks[0])
+	}
+	if n := cfg.Notify.GetRateLimit(); n != 0 {
+		t.Errorf("显式设置 rate_limit: 0 应表示不限流, 得到 %d", n)
+	}
+	if n := (*NotifyConfig)(nil).GetRateLimit(); n != notify.DefaultRateLimit {
+		t.Errorf("未配置时应使用默认限流, 得到 %d", n)
+	}
+}
+
+func TestLoad_InvalidNotify(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"provider: tencent",
+		"domain: example.com",
+		"notify:",
+		"  webhooks:",
+		"    - url: https://hooks.example.com",
+		"      body: '{{.Message'",
+	))
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "notify.webhooks[0]") {
+		t.Errorf("无效的 webhook 模板应返回错误, 得到 %v", err)
+	}
+}
+
+func TestLoad_Verify(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDirForTest(t, tmpDir)
+	writeConfig(t, tmpDir, yamlLines(
+		"provider: tencent",
+		"domain: example.com",
+		"verify:",
+		"  enabled: true",
+		"  timeout: 2m",
+	))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load 不应返回错误: %v", err)
+	}
+	v, enabled, err := cfg.Verify.GetVerify()
+	if err != nil || !enabled {
+		t.Fatalf("应启用传播验证, 得到 %+v (err=%v)", v, err)
+	}
+	if v.Timeout != 2*time.Minute || v.Interval != 0 {
+		t.Errorf("未设置的 interval 应留空使用默认值: %+v", v)
+	}
+	if v, enabled, _ := (&VerifyConfig{Timeout: "1m"}).GetVerify(); enabled || v.Timeout != time.Minute {
+		t.Error("未设置 enabled 时不应启用传播验证，但仍应解析时长")
+	}
+	if _, enabled, _ := (*VerifyConfig)(nil).GetVerify(); enabled {
+		t.Error("未配置时不应启用传播验证")
+	}
+
+	writeConfig(t, tmpDir, yamlLines(
+		"provider: tencent",
+		"domain: example.com",
+		"verify:",
+		"  interval: 0s",
+	))
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "verify.interval") {
+		t.Errorf("无效的 verify.interval 应返回错误, 得到 %v", err)
+	}
+}
+
+func TestHooksConfig_GetHooks(t *testing.T) {
+	if h, err := (*HooksConfig)(nil).GetHooks(); err != nil || len(h.Pre)+len(h.Post) != 0 {
+		t.Errorf("未配置时应返回空钩子, 得到 %+v (err=%v)", h, err)
+	}
+	h, err := (&HooksConfig{Pre: []string{"check"}, Post: []string{"reload"}}).GetHooks()
+	if err != nil || h.Timeout != ddns.DefaultHookTimeout || h.Pre[0] != "check" || h.Post[0] != "reload" {
+		t.Errorf("钩子配置转换不符: %+v (err=%v)", h, err)
+	}
+	for _, hc := range []HooksConfig{{Timeout: "soon"}, {Timeout: "0s"}, {Post: []string{" "}}} {
+		if _, err := hc.GetHooks(); err == nil {
+			t.Errorf("无效配置 %+v 应返回错误", hc)
+		}
+	}
 }
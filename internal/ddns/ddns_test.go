@@ -2,9 +2,14 @@ package ddns
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
 )
 
 // ============================================================
@@ -117,21 +122,27 @@ func TestRecordInfoKey_EmptyID(t *testing.T) {
 
 // mockProvider 实现 DNSProvider 接口，用于测试。
 type mockProvider struct {
-	records []RecordInfo
-	addErr  error
-	modErr  error
-	getErr  error
+	records  []RecordInfo
+	addErr   error
+	modErr   error
+	getErr   error
+	getCalls int // GetRecords 调用次数
+	addCalls int // AddRecord 调用次数
+	modCalls int // ModifyRecord 调用次数
 }
 
 func (m *mockProvider) GetRecords(_ context.Context, _, _ string) ([]RecordInfo, error) {
+	m.getCalls++
 	return m.records, m.getErr
 }
 
 func (m *mockProvider) AddRecord(_ context.Context, _ RecordInfo) error {
+	m.addCalls++
 	return m.addErr
 }
 
 func (m *mockProvider) ModifyRecord(_ context.Context, _ RecordInfo) error {
+	m.modCalls++
 	return m.modErr
 }
 
@@ -388,3 +399,218 @@ func TestSyncRecord_CtxCancelled(t *testing.T) {
 		t.Fatal("上下文取消时 SyncRecord 应返回错误")
 	}
 }
+
+func TestSyncRecord_TypeMismatch(t *testing.T) {
+	ctx := context.Background()
+	d := &Domain{
+		Domain: "example.com", SubDomain: "www", Type: RecordTypeA, TTL: 600,
+	}
+	m := &mockProvider{}
+
+	err := SyncRecord(ctx, d, net.ParseIP("2001:db8::1"), m)
+	if err == nil {
+		t.Fatal("IPv6 地址写入 A 记录时 SyncRecord 应返回错误")
+	}
+	if d.Addr != nil {
+		t.Errorf("类型不匹配时不应更新缓存, 得到 %v", d.Addr)
+	}
+}
+
+// ============================================================
+// 双栈地址选择测试
+// ============================================================
+
+func TestRequiredFamilies(t *testing.T) {
+	tests := []struct {
+		name           string
+		types          []string
+		wantV4, wantV6 bool
+	}{
+		{"仅 AAAA", []string{RecordTypeAAAA}, false, true},
+		{"仅 A", []string{RecordTypeA}, true, false},
+		{"双栈", []string{RecordTypeA, RecordTypeAAAA}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var domains []*Domain
+			for _, typ := range tt.types {
+				domains = append(domains, &Domain{Domain: "example.com", SubDomain: "www", Type: typ})
+			}
+			v4, v6 := requiredFamilies(domains)
+			if v4 != tt.wantV4 || v6 != tt.wantV6 {
+				t.Errorf("requiredFamilies = (%v, %v), 期望 (%v, %v)", v4, v6, tt.wantV4, tt.wantV6)
+			}
+		})
+	}
+}
+
+func TestAddrSet_ForType(t *testing.T) {
+	set := addrSet{ipv4: net.ParseIP("192.0.2.1"), ipv6: net.ParseIP("2001:db8::1")}
+	if got := set.forType(RecordTypeA); !got.Equal(set.ipv4) {
+		t.Errorf("A 记录应使用 IPv4 地址, 得到 %v", got)
+	}
+	if got := set.forType(RecordTypeAAAA); !got.Equal(set.ipv6) {
+		t.Errorf("AAAA 记录应使用 IPv6 地址, 得到 %v", got)
+	}
+}
+
+func TestDomain_TargetAddr(t *testing.T) {
+	set := addrSet{ipv4: net.ParseIP("192.0.2.1"), ipv6: net.ParseIP("2001:db8:1:2:aaaa:bbbb:cccc:dddd")}
+	tests := []struct {
+		name string
+		d    *Domain
+		want string
+	}{
+		{"本机地址", &Domain{Type: RecordTypeAAAA}, "2001:db8:1:2:aaaa:bbbb:cccc:dddd"},
+		{"默认 /64 前缀", &Domain{Type: RecordTypeAAAA, Suffix: net.ParseIP("::1234")}, "2001:db8:1:2::1234"},
+		{"/56 前缀", &Domain{Type: RecordTypeAAAA, Suffix: net.ParseIP("::3:0:0:0:10"), PrefixLength: 56}, "2001:db8:1:3::10"},
+		{"A 记录忽略后缀", &Domain{Type: RecordTypeA, Suffix: net.ParseIP("::1234")}, "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		if got := tt.d.targetAddr(set); !got.Equal(net.ParseIP(tt.want)) {
+			t.Errorf("%s: targetAddr = %v, 期望 %s", tt.name, got, tt.want)
+		}
+	}
+	if got := (&Domain{Type: RecordTypeAAAA, Suffix: net.ParseIP("::1")}).targetAddr(addrSet{}); got != nil {
+		t.Errorf("未获取到 IPv6 地址时应返回 nil, 得到 %v", got)
+	}
+}
+
+func TestSyncAllDomains_LANHostUsesHostAddr(t *testing.T) {
+	nas := &Domain{Domain: "example.com", SubDomain: "nas", Type: RecordTypeAAAA, Suffix: net.ParseIP("::1234")}
+	pr := Profile{Name: "home", Provider: &mockProvider{}, Domains: []*Domain{nas}}
+
+	outcomes, err := syncAllDomains(context.Background(), pr, addrSet{ipv6: net.ParseIP("2001:db8:1:2::1")}, syncRecord, true)
+	if err != nil {
+		t.Fatalf("syncAllDomains 不应返回错误: %v", err)
+	}
+	want := net.ParseIP("2001:db8:1:2::1234")
+	if len(outcomes) != 1 || !outcomes[0].addr.Equal(want) || !nas.Addr.Equal(want) {
+		t.Errorf("局域网主机应发布前缀 + 后缀组合的地址 %s, 得到 %+v", want, outcomes)
+	}
+}
+
+// ============================================================
+// 多 Profile 同步测试
+// ============================================================
+
+func TestSyncProfiles_EachProfileUsesOwnProvider(t *testing.T) {
+	ctx := context.Background()
+	d1 := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	d2 := &Domain{Domain: "example.net", SubDomain: "@", Type: RecordTypeAAAA, TTL: 600}
+	profiles := []Profile{
+		{Name: "a", Provider: &mockProvider{}, Domains: []*Domain{d1}},
+		{Name: "b", Provider: &mockProvider{}, Domains: []*Domain{d2}},
+	}
+	addrs := addrSet{ipv6: net.ParseIP("2001:db8::1")}
+
+	if _, err := syncProfiles(ctx, profiles, addrs, true); err != nil {
+		t.Fatalf("syncProfiles 不应返回错误: %v", err)
+	}
+	for _, d := range []*Domain{d1, d2} {
+		if d.Addr == nil || !d.Addr.Equal(addrs.ipv6) {
+			t.Errorf("%s 的 Addr 应更新为 %v, 得到 %v", d.FullDomain(), addrs.ipv6, d.Addr)
+		}
+	}
+}
+
+func TestSyncProfiles_FailFastReportsProfile(t *testing.T) {
+	ctx := context.Background()
+	profiles := []Profile{
+		{Name: "ok", Provider: &mockProvider{}, Domains: []*Domain{
+			{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA},
+		}},
+		{Name: "broken", Provider: &mockProvider{getErr: errors.New("auth failed")}, Domains: []*Domain{
+			{Domain: "example.net", SubDomain: "www", Type: RecordTypeAAAA},
+		}},
+	}
+
+	_, err := syncProfiles(ctx, profiles, addrSet{ipv6: net.ParseIP("2001:db8::1")}, true)
+	if err == nil {
+		t.Fatal("某个 profile 失败时 syncProfiles 应返回错误")
+	}
+	if !strings.Contains(err.Error(), "profile broken") {
+		t.Errorf("错误信息应包含 profile 名称, 得到: %v", err)
+	}
+}
+
+func TestRunProfiles_Empty(t *testing.T) {
+	if err := RunProfiles(nil, time.Minute, nil, ""); err == nil {
+		t.Fatal("profiles 为空时 RunProfiles 应返回错误")
+	}
+}
+
+func TestRunProfiles_QuorumNeedsEnoughFetchers(t *testing.T) {
+	profiles := []Profile{{Name: "p", Provider: &mockProvider{}, Domains: []*Domain{{Domain: "example.com", SubDomain: "www"}}}}
+	fetchers := []ipaddr.IPv6Fetcher{ipaddr.NewHttpIPv6Fetcher("http://127.0.0.1:1")}
+	err := RunProfiles(profiles, time.Minute, fetchers, "", WithIPv6Quorum(ipaddr.Quorum{N: 2}))
+	if err == nil || !strings.Contains(err.Error(), "quorum") {
+		t.Fatalf("获取器数量少于共识数量时应在启动前返回错误, 得到: %v", err)
+	}
+}
+
+// ============================================================
+// ReconcileRecord 漂移对账测试
+// ============================================================
+
+func TestReconcileRecord_ModifiedInConsole_Repaired(t *testing.T) {
+	ctx := context.Background()
+	addr := net.ParseIP("2001:db8::1")
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	d.CheckAndSetAddr(addr) // 缓存认为记录已是最新
+
+	m := &mockProvider{records: []RecordInfo{
+		{ID: "1", Name: "www.example.com", Type: RecordTypeAAAA, Value: "2001:db8::dead"},
+	}}
+	if err := ReconcileRecord(ctx, d, addr, m); err != nil {
+		t.Fatalf("ReconcileRecord 不应返回错误: %v", err)
+	}
+	if m.getCalls != 1 {
+		t.Errorf("对账应忽略缓存查询服务商, GetRecords 调用 %d 次", m.getCalls)
+	}
+	if m.modCalls != 1 {
+		t.Errorf("被外部修改的记录应被修复, ModifyRecord 调用 %d 次", m.modCalls)
+	}
+}
+
+func TestReconcileRecord_DeletedInConsole_Recreated(t *testing.T) {
+	ctx := context.Background()
+	addr := net.ParseIP("2001:db8::1")
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	d.CheckAndSetAddr(addr)
+
+	m := &mockProvider{records: []RecordInfo{}}
+	if err := ReconcileRecord(ctx, d, addr, m); err != nil {
+		t.Fatalf("ReconcileRecord 不应返回错误: %v", err)
+	}
+	if m.addCalls != 1 {
+		t.Errorf("被删除的记录应重新创建, AddRecord 调用 %d 次", m.addCalls)
+	}
+}
+
+func TestReconcileRecord_NoDrift(t *testing.T) {
+	ctx := context.Background()
+	addr := net.ParseIP("2001:db8::1")
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	d.CheckAndSetAddr(addr)
+
+	m := &mockProvider{records: []RecordInfo{
+		{ID: "1", Name: "www.example.com", Type: RecordTypeAAAA, Value: "2001:db8::1"},
+	}}
+	if err := ReconcileRecord(ctx, d, addr, m); err != nil {
+		t.Fatalf("ReconcileRecord 不应返回错误: %v", err)
+	}
+	if m.modCalls != 0 || m.addCalls != 0 {
+		t.Errorf("无漂移时不应修改记录, modify=%d add=%d", m.modCalls, m.addCalls)
+	}
+}
+
+func TestReconcileRecord_GetRecordsError(t *testing.T) {
+	ctx := context.Background()
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	m := &mockProvider{getErr: fmt.Errorf("API error")}
+
+	if err := ReconcileRecord(ctx, d, net.ParseIP("2001:db8::1"), m); err == nil {
+		t.Fatal("GetRecords 失败时 ReconcileRecord 应返回错误")
+	}
+}
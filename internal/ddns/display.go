@@ -31,3 +31,78 @@ func FormatRecords(records []RecordInfo) string {
 	w.Flush()
 	return b.String()
 }
+
+// FormatUpdateResults 将单次同步（UpdateOnce）的结果格式化为表格文本，末尾附各结果类型的数量。
+//
+// 输出格式：
+//
+//	Profile    Domain             Type    Status       Address        Error
+//	default    www.example.com    AAAA    modified     240e:xxx::1
+//
+//	0 unchanged, 1 modified, 0 added, 0 failed
+func FormatUpdateResults(results []UpdateResult) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Profile\tDomain\tType\tStatus\tAddress\tError")
+
+	counts := make(map[UpdateStatus]int)
+	for _, r := range results {
+		counts[r.Status]++
+		addr, errStr := "-", ""
+		if r.Addr != nil {
+			addr = r.Addr.String()
+		}
+		if r.Err != nil {
+			errStr = r.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Profile, r.Domain, r.Type, r.Status, addr, errStr)
+	}
+	w.Flush()
+
+	fmt.Fprintf(&b, "\n%d unchanged, %d modified, %d added, %d failed\n",
+		counts[UpdateUnchanged], counts[UpdateModified], counts[UpdateAdded], counts[UpdateFailed])
+	return b.String()
+}
+
+// FormatPlan 将计划模式（Plan）的结果格式化为表格文本，末尾附各处理类型的数量。
+//
+// TTL 将被修改时显示为 "旧值 -> 新值"。输出格式：
+//
+//	Profile    Domain             Type    Action       Record ID    Old Value      New Value      TTL    Error
+//	default    www.example.com    AAAA    modify       123          240e:xxx::1    240e:xxx::2    600
+//	default    api.example.com    AAAA    add          -            -              240e:xxx::2    600
+//
+//	1 to modify, 1 to add, 0 unchanged, 0 failed
+func FormatPlan(changes []PlannedChange) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Profile\tDomain\tType\tAction\tRecord ID\tOld Value\tNew Value\tTTL\tError")
+
+	counts := make(map[PlanAction]int)
+	for _, c := range changes {
+		counts[c.Action]++
+		ttl := fmt.Sprint(c.TTL)
+		if c.Action == PlanModify && c.OldTTL != c.TTL {
+			ttl = fmt.Sprintf("%d -> %d", c.OldTTL, c.TTL)
+		}
+		errStr := ""
+		if c.Err != nil {
+			errStr = c.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.Profile, c.Domain, c.Type, c.Action,
+			orDash(c.RecordID), orDash(c.OldValue), orDash(c.NewValue), ttl, errStr)
+	}
+	w.Flush()
+
+	fmt.Fprintf(&b, "\n%d to modify, %d to add, %d unchanged, %d failed\n",
+		counts[PlanModify], counts[PlanAdd], counts[PlanUnchanged], counts[PlanFailed])
+	return b.String()
+}
+
+// orDash 空字符串显示为 "-"。
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -1,6 +1,8 @@
 package ddns
 
 import (
+	"errors"
+	"net"
 	"strings"
 	"testing"
 )
@@ -103,3 +105,31 @@ func TestFormatRecords_DifferentTypes(t *testing.T) {
 		t.Error("结果应包含 MX 类型")
 	}
 }
+
+func TestFormatUpdateResults(t *testing.T) {
+	results := []UpdateResult{
+		{Profile: "default", Domain: "www.example.com", Type: "AAAA", Addr: net.ParseIP("2001:db8::1"), Status: UpdateModified},
+		{Profile: "default", Domain: "www.example.com", Type: "A", Status: UpdateFailed, Err: errors.New("no IPv4 address")},
+	}
+	result := FormatUpdateResults(results)
+	if !strings.Contains(result, "2001:db8::1") || !strings.Contains(result, "no IPv4 address") {
+		t.Errorf("结果应包含地址和失败原因:\n%s", result)
+	}
+	if !strings.Contains(result, "0 unchanged, 1 modified, 0 added, 1 failed") {
+		t.Errorf("结果应包含各状态的数量:\n%s", result)
+	}
+}
+
+func TestFormatPlan(t *testing.T) {
+	changes := []PlannedChange{
+		{Profile: "default", Domain: "www.example.com", Type: "AAAA", Action: PlanModify,
+			RecordID: "123", OldValue: "2001:db8::1", NewValue: "2001:db8::2", OldTTL: 300, TTL: 600},
+		{Profile: "default", Domain: "api.example.com", Type: "AAAA", Action: PlanAdd, NewValue: "2001:db8::2", TTL: 600},
+	}
+	result := FormatPlan(changes)
+	for _, want := range []string{"123", "2001:db8::1", "300 -> 600", "1 to modify, 1 to add, 0 unchanged, 0 failed"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("结果应包含 %q:\n%s", want, result)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultHookTimeout 单个钩子命令的默认超时时间。
+const DefaultHookTimeout = 30 * time.Second
+
+// hookOutputLimit 日志中记录的钩子输出的最大字节数。
+const hookOutputLimit = 4 << 10
+
+// ErrUpdateVetoed pre 钩子以非零状态退出（或超时）时，同步返回包装了此错误的错误，记录保持不变。
+var ErrUpdateVetoed = errors.New("update vetoed by pre-hook")
+
+// Hooks 记录更新前后执行的本地命令，如重新生成防火墙规则、重启反向代理、更新 radvd 配置。
+//
+// 钩子只在服务商记录确实需要修改、新增或按撤回策略处理时运行（地址未变化、记录已一致时不运行）：
+//   - Pre：写入服务商前依次执行，任一命令以非零状态退出或超时则否决本次更新，
+//     该域名按同步失败处理（进入重试队列，重试时再次执行 pre 钩子）
+//   - Post：写入完成后（无论成功或失败）依次执行，失败只记录日志
+//
+// 命令通过 /bin/sh -c（Windows 下为 cmd /C）执行，stdout 和 stderr 记录到日志。
+// 除继承的环境变量外，还会设置：
+//
+//	DDNS6_HOOK              pre 或 post
+//	DDNS6_HOOK_PROFILE      profile 名称
+//	DDNS6_HOOK_DOMAIN       完整域名（如 www.example.com）
+//	DDNS6_HOOK_ZONE         根域名
+//	DDNS6_HOOK_SUBDOMAIN    子域名（@ 表示根域名）
+//	DDNS6_HOOK_RECORD_TYPE  A 或 AAAA
+//	DDNS6_HOOK_OLD_ADDR     记录原值（新增记录时为空）
+//	DDNS6_HOOK_NEW_ADDR     新地址（撤回时删除或暂停记录为空）
+//	DDNS6_HOOK_RESULT       仅 post：success 或 failure
+//	DDNS6_HOOK_ERROR        仅 post：失败时的错误信息
+type Hooks struct {
+	Pre     []string      // 更新前执行的命令
+	Post    []string      // 更新后执行的命令
+	Timeout time.Duration // 单个命令的超时时间（<= 0 时使用 DefaultHookTimeout）
+}
+
+// WithHooks 设置记录更新前后执行的钩子命令（默认不执行），详见 Hooks。
+func WithHooks(h Hooks) Option {
+	return func(o *serviceOptions) {
+		o.hooks = h
+	}
+}
+
+// hookRunner 为某个 Profile 的域名执行钩子命令。nil 表示未配置钩子。
+type hookRunner struct {
+	hooks   Hooks
+	profile string
+}
+
+// newHookRunner 创建 profile 的钩子执行器，未配置任何命令时返回 nil。
+func newHookRunner(h Hooks, profile string) *hookRunner {
+	if len(h.Pre) == 0 && len(h.Post) == 0 {
+		return nil
+	}
+	if h.Timeout <= 0 {
+		h.Timeout = DefaultHookTimeout
+	}
+	return &hookRunner{hooks: h, profile: profile}
+}
+
+// pre 执行 pre 钩子，任一命令失败时返回包装了 ErrUpdateVetoed 的错误。
+func (h *hookRunner) pre(ctx context.Context, d *Domain, oldAddr, newAddr string) error {
+	if h == nil {
+		return nil
+	}
+	env := h.env("pre", d, oldAddr, newAddr)
+	for _, command := range h.hooks.Pre {
+		if err := h.run(ctx, "pre", command, env, d); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrUpdateVetoed, command, err)
+		}
+	}
+	return nil
+}
+
+// post 执行 post 钩子，syncErr 为本次写入的结果。命令失败只记录日志。
+func (h *hookRunner) post(ctx context.Context, d *Domain, oldAddr, newAddr string, syncErr error) {
+	if h == nil {
+		return
+	}
+	env := h.env("post", d, oldAddr, newAddr)
+	if syncErr != nil {
+		env = append(env, "DDNS6_HOOK_RESULT=failure", "DDNS6_HOOK_ERROR="+syncErr.Error())
+	} else {
+		env = append(env, "DDNS6_HOOK_RESULT=success")
+	}
+	// 写入失败可能是因为 ctx 已取消（服务退出），post 钩子仍应有机会执行
+	ctx = context.WithoutCancel(ctx)
+	for _, command := range h.hooks.Post {
+		h.run(ctx, "post", command, env, d)
+	}
+}
+
+// env 构造钩子命令的环境变量。
+func (h *hookRunner) env(stage string, d *Domain, oldAddr, newAddr string) []string {
+	sub := d.SubDomain
+	if sub == "" {
+		sub = "@"
+	}
+	return append(os.Environ(),
+		"DDNS6_HOOK="+stage,
+		"DDNS6_HOOK_PROFILE="+h.profile,
+		"DDNS6_HOOK_DOMAIN="+d.FullDomain(),
+		"DDNS6_HOOK_ZONE="+d.Domain,
+		"DDNS6_HOOK_SUBDOMAIN="+sub,
+		"DDNS6_HOOK_RECORD_TYPE="+d.Type,
+		"DDNS6_HOOK_OLD_ADDR="+oldAddr,
+		"DDNS6_HOOK_NEW_ADDR="+newAddr,
+	)
+}
+
+// run 在超时限制内执行一条钩子命令，并将输出记录到日志。
+func (h *hookRunner) run(ctx context.Context, stage, command string, env []string, d *Domain) error {
+	ctx, cancel := context.WithTimeout(ctx, h.hooks.Timeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, command)
+	cmd.Env = env
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	// 命令被杀死后，仍持有输出管道的子进程不应让 Wait 无限阻塞
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", h.hooks.Timeout)
+	}
+	args := []any{"module", "ddns", "hook", stage, "command", command,
+		"profile", h.profile, "domain", d.Domain, "subdomain", d.SubDomain, "type", d.Type,
+		"duration", time.Since(start).Round(time.Millisecond), "output", hookOutput(out.Bytes())}
+	if err != nil {
+		slog.Warn("hook command failed", append(args, "err", err)...)
+		return err
+	}
+	slog.Info("hook command completed", args...)
+	return nil
+}
+
+// shellCommand 通过系统 shell 执行命令。
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "/bin/sh", "-c", command)
+}
+
+// hookOutput 返回用于日志的命令输出，超过 hookOutputLimit 时截断。
+func hookOutput(b []byte) string {
+	s := strings.TrimSpace(string(b))
+	if len(s) > hookOutputLimit {
+		s = s[:hookOutputLimit] + "...(truncated)"
+	}
+	return s
+}
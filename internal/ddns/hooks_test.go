@@ -0,0 +1,140 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ============================================================
+// 更新钩子测试
+// ============================================================
+
+// hookDomain 返回配置了钩子的测试域名，钩子命令通过 /bin/sh 执行。
+func hookDomain(t *testing.T, h Hooks) *Domain {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("钩子测试依赖 /bin/sh")
+	}
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	d.hooks = newHookRunner(h, "home")
+	return d
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func TestHooks_PreAndPostReceiveEnv(t *testing.T) {
+	dir := t.TempDir()
+	pre, post := filepath.Join(dir, "pre"), filepath.Join(dir, "post")
+	d := hookDomain(t, Hooks{
+		Pre:  []string{`echo "$DDNS6_HOOK $DDNS6_HOOK_PROFILE $DDNS6_HOOK_DOMAIN $DDNS6_HOOK_RECORD_TYPE $DDNS6_HOOK_OLD_ADDR $DDNS6_HOOK_NEW_ADDR" > ` + pre},
+		Post: []string{`echo "$DDNS6_HOOK $DDNS6_HOOK_RESULT" > ` + post},
+	})
+	m := &mockProvider{records: []RecordInfo{
+		{ID: "1", Name: "www.example.com", Type: "AAAA", Value: "2001:db8::1"},
+	}}
+
+	if err := SyncRecord(context.Background(), d, net.ParseIP("2001:db8::2"), m); err != nil {
+		t.Fatalf("SyncRecord 不应返回错误: %v", err)
+	}
+	if got := strings.TrimSpace(readFile(t, pre)); got != "pre home www.example.com AAAA 2001:db8::1 2001:db8::2" {
+		t.Errorf("pre 钩子环境变量不符: %q", got)
+	}
+	if got := strings.TrimSpace(readFile(t, post)); got != "post success" {
+		t.Errorf("post 钩子应在更新成功后执行: %q", got)
+	}
+}
+
+func TestHooks_NotRunWhenRecordMatches(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	d := hookDomain(t, Hooks{Pre: []string{"touch " + marker}, Post: []string{"touch " + marker}})
+	m := &mockProvider{records: []RecordInfo{
+		{ID: "1", Name: "www.example.com", Type: "AAAA", Value: "2001:db8::1"},
+	}}
+
+	if err := SyncRecord(context.Background(), d, net.ParseIP("2001:db8::1"), m); err != nil {
+		t.Fatalf("SyncRecord 不应返回错误: %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("记录已一致时不应执行钩子")
+	}
+}
+
+func TestHooks_PreVetoesUpdate(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "post")
+	d := hookDomain(t, Hooks{Pre: []string{"echo maintenance; exit 3"}, Post: []string{"touch " + marker}})
+	d.CheckAndSetAddr(net.ParseIP("2001:db8::1"))
+	m := &mockProvider{records: []RecordInfo{
+		{ID: "1", Name: "www.example.com", Type: "AAAA", Value: "2001:db8::1"},
+	}}
+
+	err := SyncRecord(context.Background(), d, net.ParseIP("2001:db8::2"), m)
+	if !errors.Is(err, ErrUpdateVetoed) {
+		t.Fatalf("pre 钩子非零退出应否决更新, 得到 %v", err)
+	}
+	if m.modCalls != 0 {
+		t.Error("被否决的更新不应调用 ModifyRecord")
+	}
+	if d.Addr != nil {
+		t.Errorf("被否决后应清空缓存地址以便重试, 得到 %v", d.Addr)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("被否决的更新不应执行 post 钩子")
+	}
+}
+
+func TestHooks_PreTimeoutVetoes(t *testing.T) {
+	d := hookDomain(t, Hooks{Pre: []string{"sleep 5"}, Timeout: 50 * time.Millisecond})
+	m := &mockProvider{}
+
+	start := time.Now()
+	err := SyncRecord(context.Background(), d, net.ParseIP("2001:db8::2"), m)
+	if !errors.Is(err, ErrUpdateVetoed) || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("pre 钩子超时应否决更新, 得到 %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("超时的钩子应被终止")
+	}
+	if m.addCalls != 0 {
+		t.Error("被否决的更新不应调用 AddRecord")
+	}
+}
+
+func TestHooks_PostReportsFailure(t *testing.T) {
+	post := filepath.Join(t.TempDir(), "post")
+	d := hookDomain(t, Hooks{Post: []string{`echo "$DDNS6_HOOK_RESULT $DDNS6_HOOK_ERROR" > ` + post}})
+	m := &mockProvider{addErr: errors.New("api error")}
+
+	if err := SyncRecord(context.Background(), d, net.ParseIP("2001:db8::2"), m); err == nil {
+		t.Fatal("AddRecord 失败时应返回错误")
+	}
+	if got := readFile(t, post); !strings.HasPrefix(got, "failure ") || !strings.Contains(got, "api error") {
+		t.Errorf("post 钩子应收到失败结果和错误信息: %q", got)
+	}
+}
+
+func TestSyncAllDomains_VetoDoesNotFailFast(t *testing.T) {
+	d := hookDomain(t, Hooks{Pre: []string{"exit 1"}})
+	pr := Profile{Name: "home", Provider: &mockProvider{}, Domains: []*Domain{d}}
+
+	outcomes, err := syncAllDomains(context.Background(), pr, addrSet{ipv6: net.ParseIP("2001:db8::1")}, syncRecord, true)
+	if err != nil {
+		t.Fatalf("被 pre 钩子否决的更新不应终止启动: %v", err)
+	}
+	if len(outcomes) != 1 || !errors.Is(outcomes[0].err, ErrUpdateVetoed) {
+		t.Errorf("同步结果应记录否决错误以便重试: %+v", outcomes)
+	}
+}
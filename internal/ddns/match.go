@@ -7,19 +7,32 @@ import (
 	"strings"
 )
 
-// hasAddressChanged 检查 IPv6 地址是否改变
+// hasAddressChanged 检查地址是否改变
 func hasAddressChanged(cached net.IP, newAddr net.IP) bool {
 	changed := cached == nil || !cached.Equal(newAddr)
 	if cached == nil {
 		slog.Debug("no cached address, update needed", "module", "ddns")
 	} else if changed {
-		slog.Debug("IPv6 address has changed", "module", "ddns",
+		slog.Debug("address has changed", "module", "ddns",
 			"old_addr", cached.String(), "new_addr", newAddr.String())
 	}
 	return changed
 }
 
-// ipv6Equal 比较两个 IPv6 地址是否相等。
+// addrMatchesType 判断地址族是否与记录类型匹配（A -> IPv4，AAAA -> IPv6）。
+//
+// 其他记录类型不做限制。
+func addrMatchesType(addr net.IP, recordType string) bool {
+	switch recordType {
+	case RecordTypeA:
+		return addr.To4() != nil
+	case RecordTypeAAAA:
+		return addr.To4() == nil && addr.To16() != nil
+	}
+	return true
+}
+
+// ipv6Equal 比较两个 IP 地址是否相等（同样适用于 IPv4）。
 //
 // 参数 a 为已解析的 IP 地址，b 为 IP 字符串。
 // 当两边都无法解析为有效 IP 时，回退到字符串比较。
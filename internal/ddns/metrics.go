@@ -0,0 +1,187 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/metrics"
+)
+
+// Prometheus 指标，通过状态端点的 GET /metrics 导出（见 WithStatusServer）。
+var (
+	syncAttempts = metrics.NewCounterVec("ddns6_sync_attempts_total",
+		"Number of DNS record sync attempts.", "profile", "domain", "type", "provider")
+	syncSuccesses = metrics.NewCounterVec("ddns6_sync_successes_total",
+		"Number of successful DNS record syncs.", "profile", "domain", "type", "provider")
+	syncFailures = metrics.NewCounterVec("ddns6_sync_failures_total",
+		"Number of failed DNS record syncs.", "profile", "domain", "type", "provider")
+
+	providerDuration = metrics.NewHistogramVec("ddns6_provider_request_duration_seconds",
+		"Latency of DNS provider API calls by DNSProvider method, including client-side retries.",
+		nil, "provider", "method")
+	providerErrors = metrics.NewCounterVec("ddns6_provider_request_errors_total",
+		"Number of failed DNS provider API calls by DNSProvider method.", "provider", "method")
+
+	fetcherSuccesses = metrics.NewCounterVec("ddns6_fetcher_successes_total",
+		"Number of successful address fetches per fetcher.", "fetcher", "family")
+	fetcherFailures = metrics.NewCounterVec("ddns6_fetcher_failures_total",
+		"Number of failed address fetches per fetcher (fetchers canceled after losing the race are not counted).",
+		"fetcher", "family")
+	fetcherDuration = metrics.NewHistogramVec("ddns6_fetcher_duration_seconds",
+		"Latency of completed address fetches per fetcher.", nil, "fetcher", "family")
+
+	netlinkEventsReceived = metrics.NewCounterVec("ddns6_netlink_events_received_total",
+		"Number of relevant netlink address events received.")
+	netlinkEventsDebounced = metrics.NewCounterVec("ddns6_netlink_events_debounced_total",
+		"Number of syncs triggered after the netlink debounce window settled.")
+
+	lastAddrChange = metrics.NewGaugeVec("ddns6_last_address_change_timestamp_seconds",
+		"Unix time of the last detected public address change.", "family")
+)
+
+// observeSync 记录一个域名同步结果的指标。因服务退出取消的同步不记录。
+func observeSync(o syncOutcome) {
+	if errors.Is(o.err, context.Canceled) {
+		return
+	}
+	labels := []string{o.profile, o.domain.FullDomain(), o.domain.Type, providerName(o.provider)}
+	syncAttempts.WithLabelValues(labels...).Inc()
+	if o.err != nil {
+		syncFailures.WithLabelValues(labels...).Inc()
+		return
+	}
+	syncSuccesses.WithLabelValues(labels...).Inc()
+}
+
+// observeFetcher 记录单个地址获取器一次 Fetch 的指标。
+func observeFetcher(fs FetcherStatus, d time.Duration) {
+	if fs.Canceled {
+		return
+	}
+	fetcherDuration.WithLabelValues(fs.Name, fs.Family).Observe(d.Seconds())
+	if fs.Error != "" {
+		fetcherFailures.WithLabelValues(fs.Name, fs.Family).Inc()
+		return
+	}
+	fetcherSuccesses.WithLabelValues(fs.Name, fs.Family).Inc()
+}
+
+// addrChangeTracker 记录最近获取到的地址，地址变化时更新 ddns6_last_address_change_timestamp_seconds。
+type addrChangeTracker struct {
+	mu   sync.Mutex
+	last addrSet
+}
+
+// addrChange 一个地址族的地址变化，首次获取时 old 为 nil。
+type addrChange struct {
+	old, new net.IP
+}
+
+// observe 比较新获取的地址与上一次的结果，返回发生变化的地址族，首次获取也视为变化。
+// 未获取到的地址族不参与比较。
+func (t *addrChangeTracker) observe(addrs addrSet) []addrChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var changes []addrChange
+	for _, f := range []struct {
+		family    string
+		cur, prev *net.IP
+	}{
+		{"ipv4", &addrs.ipv4, &t.last.ipv4},
+		{"ipv6", &addrs.ipv6, &t.last.ipv6},
+	} {
+		if *f.cur == nil || f.cur.Equal(*f.prev) {
+			continue
+		}
+		changes = append(changes, addrChange{old: *f.prev, new: *f.cur})
+		*f.prev = *f.cur
+		lastAddrChange.WithLabelValues(f.family).SetToCurrentTime()
+	}
+	return changes
+}
+
+// instrumentedProvider 记录每次 API 调用的延迟和错误的 DNSProvider 包装。
+type instrumentedProvider struct {
+	name string
+	next DNSProvider
+}
+
+// instrumentProvider 包装 p，记录 API 调用指标。
+func instrumentProvider(p DNSProvider) DNSProvider {
+	if _, ok := p.(*instrumentedProvider); ok {
+		return p
+	}
+	return &instrumentedProvider{name: providerName(p), next: p}
+}
+
+// observe 记录一次 API 调用。
+func (p *instrumentedProvider) observe(method string, start time.Time, err error) {
+	providerDuration.WithLabelValues(p.name, method).ObserveDuration(start)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		providerErrors.WithLabelValues(p.name, method).Inc()
+	}
+}
+
+func (p *instrumentedProvider) GetRecords(ctx context.Context, domain, recordType string) ([]RecordInfo, error) {
+	start := time.Now()
+	records, err := p.next.GetRecords(ctx, domain, recordType)
+	p.observe("GetRecords", start, err)
+	return records, err
+}
+
+func (p *instrumentedProvider) AddRecord(ctx context.Context, record RecordInfo) error {
+	start := time.Now()
+	err := p.next.AddRecord(ctx, record)
+	p.observe("AddRecord", start, err)
+	return err
+}
+
+func (p *instrumentedProvider) ModifyRecord(ctx context.Context, record RecordInfo) error {
+	start := time.Now()
+	err := p.next.ModifyRecord(ctx, record)
+	p.observe("ModifyRecord", start, err)
+	return err
+}
+
+func (p *instrumentedProvider) DeleteRecord(ctx context.Context, record RecordInfo) error {
+	start := time.Now()
+	err := p.next.DeleteRecord(ctx, record)
+	p.observe("DeleteRecord", start, err)
+	return err
+}
+
+// SetRecordEnabled 实现 RecordDisabler，被包装的 DNSProvider 不支持时返回错误（见 SupportsDisable）。
+func (p *instrumentedProvider) SetRecordEnabled(ctx context.Context, record RecordInfo, enabled bool) error {
+	dis, ok := p.next.(RecordDisabler)
+	if !ok {
+		return fmt.Errorf("provider %s does not support disabling records", p.name)
+	}
+	start := time.Now()
+	err := dis.SetRecordEnabled(ctx, record, enabled)
+	p.observe("SetRecordEnabled", start, err)
+	return err
+}
+
+// providerName 返回 DNSProvider 的名称，取实现类型所在包名（如 tencent、cloudflare）。
+func providerName(p DNSProvider) string {
+	if ip, ok := p.(*instrumentedProvider); ok {
+		return ip.name
+	}
+	t := reflect.TypeOf(p)
+	if t == nil {
+		return "unknown"
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.PkgPath() == "" {
+		return t.String()
+	}
+	return path.Base(t.PkgPath())
+}
@@ -0,0 +1,102 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// ============================================================
+// 指标测试（指标为包级全局变量，各测试使用不同的标签值互不干扰）
+// ============================================================
+
+func TestProviderName(t *testing.T) {
+	if got := providerName(&mockProvider{}); got != "ddns" {
+		t.Errorf("providerName 应返回实现类型的包名, 得到 %q", got)
+	}
+	if got := providerName(instrumentProvider(&mockProvider{})); got != "ddns" {
+		t.Errorf("包装后的 provider 应保留原名称, 得到 %q", got)
+	}
+}
+
+func TestInstrumentedProvider_ObservesCalls(t *testing.T) {
+	m := &mockProvider{getErr: errors.New("api error")}
+	p := &instrumentedProvider{name: "test-instrumented", next: m}
+
+	p.GetRecords(context.Background(), "www.example.com", "AAAA")
+	p.AddRecord(context.Background(), RecordInfo{})
+
+	if n := providerDuration.WithLabelValues("test-instrumented", "GetRecords").Count(); n != 1 {
+		t.Errorf("GetRecords 延迟应记录 1 次, 得到 %d", n)
+	}
+	if n := providerErrors.WithLabelValues("test-instrumented", "GetRecords").Value(); n != 1 {
+		t.Errorf("GetRecords 错误应记录 1 次, 得到 %v", n)
+	}
+	if n := providerErrors.WithLabelValues("test-instrumented", "AddRecord").Value(); n != 0 {
+		t.Errorf("成功的调用不应计入错误, 得到 %v", n)
+	}
+}
+
+func TestObserveSync(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "metrics", Type: RecordTypeAAAA}
+	o := syncOutcome{profile: "metrics-test", domain: d, provider: &mockProvider{}, addr: net.ParseIP("2001:db8::1")}
+	observeSync(o)
+	o.err = errors.New("api error")
+	observeSync(o)
+	o.err = context.Canceled
+	observeSync(o)
+
+	labels := []string{"metrics-test", "metrics.example.com", "AAAA", "ddns"}
+	if n := syncAttempts.WithLabelValues(labels...).Value(); n != 2 {
+		t.Errorf("同步次数应为 2（取消的不计）, 得到 %v", n)
+	}
+	if n := syncSuccesses.WithLabelValues(labels...).Value(); n != 1 {
+		t.Errorf("成功次数应为 1, 得到 %v", n)
+	}
+	if n := syncFailures.WithLabelValues(labels...).Value(); n != 1 {
+		t.Errorf("失败次数应为 1, 得到 %v", n)
+	}
+}
+
+func TestAddrChangeTracker(t *testing.T) {
+	var tr addrChangeTracker
+	g := lastAddrChange.WithLabelValues("ipv6")
+
+	tr.observe(addrSet{ipv6: net.ParseIP("2001:db8::1")})
+	first := g.Value()
+	if first == 0 {
+		t.Fatal("首次获取地址应设置变化时间")
+	}
+
+	g.Set(1)
+	tr.observe(addrSet{ipv6: net.ParseIP("2001:db8::1")})
+	tr.observe(addrSet{}) // 获取失败不视为变化
+	if g.Value() != 1 {
+		t.Error("地址未变化时不应更新变化时间")
+	}
+	tr.observe(addrSet{ipv6: net.ParseIP("2001:db8::2")})
+	if g.Value() == 1 {
+		t.Error("地址变化时应更新变化时间")
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	s, _ := newTestStatus(0)
+	f := s.wrapIPv6Fetchers([]ipaddr.IPv6Fetcher{&mockFetcher{ip: net.ParseIP("2001:db8::1")}})[0]
+	f.Fetch(context.Background())
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/metrics 应返回 200, 得到 %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `ddns6_fetcher_successes_total{fetcher="*ddns.mockFetcher",family="IPv6"}`) {
+		t.Errorf("指标输出应包含获取器成功次数:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,229 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// EventType 通知事件类型。
+type EventType string
+
+// 通知事件类型。
+const (
+	EventAddressChanged EventType = "address_changed" // 获取到的公网地址发生变化
+	EventRecordUpdated  EventType = "record_updated"  // 服务商记录被修改或新增
+	EventSyncFailed     EventType = "sync_failed"     // 域名同步失败（连续失败只通知第一次）
+	EventSyncRecovered  EventType = "sync_recovered"  // 失败的域名重新同步成功
+
+	EventPropagationTimeout EventType = "propagation_timeout" // 更新后的记录未在截止时间内传播到所有权威服务器（见 WithVerify）
+)
+
+// EventTypes 所有通知事件类型。
+var EventTypes = []EventType{EventAddressChanged, EventRecordUpdated, EventSyncFailed, EventSyncRecovered, EventPropagationTimeout}
+
+// Event 一次通知事件，作为 webhook 模板的数据。
+//
+// address_changed 事件只有 Family、OldAddr 和 NewAddr，不对应具体域名。
+type Event struct {
+	Type       EventType `json:"type"`
+	Time       time.Time `json:"time"`
+	Profile    string    `json:"profile,omitempty"`
+	Domain     string    `json:"domain,omitempty"`      // 完整域名（如 www.example.com）
+	RecordType string    `json:"record_type,omitempty"` // A 或 AAAA
+	Family     string    `json:"family,omitempty"`      // IPv4 或 IPv6
+	OldAddr    string    `json:"old_addr,omitempty"`    // 变化前的地址（未知或新增记录时为空）
+	NewAddr    string    `json:"new_addr,omitempty"`    // 变化后（或尝试发布）的地址，记录被删除或暂停时为空
+	Action     string    `json:"action,omitempty"`      // record_updated：modified、added、deleted、disabled 或 enabled
+	RecordID   string    `json:"record_id,omitempty"`   // record_updated：被修改记录的 ID
+	Error      string    `json:"error,omitempty"`       // sync_failed、propagation_timeout：错误信息
+}
+
+// Message 返回事件的单行中文描述，便于在模板中直接使用（{{.Message}}）。
+func (e Event) Message() string {
+	old, cur := e.OldAddr, e.NewAddr
+	if old == "" {
+		old = "(none)"
+	}
+	if cur == "" {
+		cur = "(none)"
+	}
+	switch e.Type {
+	case EventAddressChanged:
+		return fmt.Sprintf("[ddns6] 公网 %s 地址变化: %s -> %s", e.Family, old, e.NewAddr)
+	case EventRecordUpdated:
+		return fmt.Sprintf("[ddns6] %s %s 记录已更新: %s -> %s", e.Domain, e.RecordType, old, cur)
+	case EventSyncFailed:
+		return fmt.Sprintf("[ddns6] %s %s 同步失败（当前 %s，目标 %s）: %s", e.Domain, e.RecordType, old, e.NewAddr, e.Error)
+	case EventSyncRecovered:
+		return fmt.Sprintf("[ddns6] %s %s 同步已恢复: %s", e.Domain, e.RecordType, e.NewAddr)
+	case EventPropagationTimeout:
+		return fmt.Sprintf("[ddns6] %s %s 记录未生效（目标 %s），将重新写入: %s", e.Domain, e.RecordType, e.NewAddr, e.Error)
+	}
+	return fmt.Sprintf("[ddns6] %s", e.Type)
+}
+
+// Notifier 通知事件的接收方（如 webhook）。
+//
+// Notify 在同步流程中调用，不能阻塞；发送、限流和重试由实现自行异步处理。
+type Notifier interface {
+	Notify(e Event)
+}
+
+// WithNotifier 设置通知事件的接收方（默认不发送通知）。
+//
+// 地址变化、记录更新、同步失败和恢复时调用 n.Notify，事件类型见 EventType。
+func WithNotifier(n Notifier) Option {
+	return func(o *serviceOptions) {
+		o.notifier = n
+	}
+}
+
+// eventEmitter 根据地址变化和同步结果生成通知事件。
+//
+// 同步失败按域名做边沿检测：连续失败只产生一次 sync_failed，
+// 之后的首次成功产生 sync_recovered。
+type eventEmitter struct {
+	n         Notifier
+	mu        sync.Mutex
+	failing   map[*Domain]bool
+	published map[*Domain]string // 各域名最近一次成功发布的地址
+}
+
+// newEventEmitter 创建事件生成器，已发布地址取自域名当前状态（如持久化状态）。
+// n 为 nil 时返回 nil，其方法均为空操作。
+func newEventEmitter(n Notifier, domains []*Domain) *eventEmitter {
+	if n == nil {
+		return nil
+	}
+	e := &eventEmitter{
+		n:         n,
+		failing:   make(map[*Domain]bool),
+		published: make(map[*Domain]string),
+	}
+	for _, d := range domains {
+		e.published[d] = d.state().Addr
+	}
+	return e
+}
+
+// setDomains 更新跟踪的域名列表（热重载后调用）：新增域名的已发布地址取自其当前状态，已移除的域名不再跟踪。
+func (e *eventEmitter) setDomains(domains []*Domain) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	keep := make(map[*Domain]bool, len(domains))
+	for _, d := range domains {
+		keep[d] = true
+		if _, ok := e.published[d]; !ok {
+			e.published[d] = d.state().Addr // 新增域名尚未同步，不会持有域名锁
+		}
+	}
+	for d := range e.published {
+		if !keep[d] {
+			delete(e.published, d)
+			delete(e.failing, d)
+		}
+	}
+}
+
+// addrChanged 为每个地址族的变化产生 address_changed 事件。首次获取（无旧地址）不通知。
+func (e *eventEmitter) addrChanged(changes []addrChange) {
+	if e == nil {
+		return
+	}
+	for _, c := range changes {
+		if c.old == nil {
+			continue
+		}
+		e.n.Notify(Event{
+			Type:    EventAddressChanged,
+			Time:    time.Now(),
+			Family:  familyName(c.new),
+			OldAddr: c.old.String(),
+			NewAddr: c.new.String(),
+		})
+	}
+}
+
+// outcome 根据一个域名的同步结果产生 record_updated、sync_failed 或 sync_recovered 事件。
+// 因服务退出取消的同步不产生事件。
+func (e *eventEmitter) outcome(o syncOutcome) {
+	if e == nil || errors.Is(o.err, context.Canceled) {
+		return
+	}
+	base := Event{
+		Time:       time.Now(),
+		Profile:    o.profile,
+		Domain:     o.domain.FullDomain(),
+		RecordType: o.domain.Type,
+		NewAddr:    addrString(o.addr),
+	}
+
+	e.mu.Lock()
+	published := e.published[o.domain]
+	wasFailing := e.failing[o.domain]
+	if o.err != nil {
+		e.failing[o.domain] = true
+	} else {
+		delete(e.failing, o.domain)
+		e.published[o.domain] = base.NewAddr
+	}
+	e.mu.Unlock()
+
+	if o.err != nil {
+		if !wasFailing {
+			ev := base
+			ev.Type, ev.OldAddr, ev.Error = EventSyncFailed, published, o.err.Error()
+			e.n.Notify(ev)
+		}
+		return
+	}
+	for _, c := range o.changes {
+		ev := base
+		ev.Type, ev.OldAddr, ev.Action, ev.RecordID = EventRecordUpdated, c.oldValue, c.action, c.recordID
+		e.n.Notify(ev)
+	}
+	if wasFailing {
+		ev := base
+		ev.Type, ev.OldAddr = EventSyncRecovered, published
+		e.n.Notify(ev)
+	}
+}
+
+// propagationTimeout 产生 propagation_timeout 事件。
+func (e *eventEmitter) propagationTimeout(o syncOutcome, err error) {
+	if e == nil {
+		return
+	}
+	e.n.Notify(Event{
+		Type:       EventPropagationTimeout,
+		Time:       time.Now(),
+		Profile:    o.profile,
+		Domain:     o.domain.FullDomain(),
+		RecordType: o.domain.Type,
+		NewAddr:    addrString(o.addr),
+		Error:      err.Error(),
+	})
+}
+
+// addrString 返回地址的字符串表示，nil 时返回空字符串。
+func addrString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// familyName 返回地址的地址族名称（IPv4 或 IPv6）。
+func familyName(ip net.IP) string {
+	if ip.To4() != nil {
+		return "IPv4"
+	}
+	return "IPv6"
+}
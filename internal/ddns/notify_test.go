@@ -0,0 +1,134 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ============================================================
+// eventEmitter 通知事件测试
+// ============================================================
+
+// recordingNotifier 记录收到的事件。
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (n *recordingNotifier) Notify(e Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, e)
+}
+
+func (n *recordingNotifier) take() []Event {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	events := n.events
+	n.events = nil
+	return events
+}
+
+func TestEventEmitter_RecordUpdated(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	n := &recordingNotifier{}
+	e := newEventEmitter(n, []*Domain{d})
+
+	e.outcome(syncOutcome{profile: "home", domain: d, addr: net.ParseIP("2001:db8::2"),
+		changes: []recordChange{{action: changeModified, recordID: "1", oldValue: "2001:db8::1"}}})
+	events := n.take()
+	if len(events) != 1 {
+		t.Fatalf("记录修改应产生 1 个事件, 得到 %d", len(events))
+	}
+	ev := events[0]
+	if ev.Type != EventRecordUpdated || ev.Domain != "www.example.com" || ev.Profile != "home" {
+		t.Errorf("事件内容不符: %+v", ev)
+	}
+	if ev.OldAddr != "2001:db8::1" || ev.NewAddr != "2001:db8::2" || ev.RecordID != "1" {
+		t.Errorf("事件应携带新旧地址和记录 ID: %+v", ev)
+	}
+
+	// 地址未变化（无修改）不产生事件
+	e.outcome(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::2")})
+	if events := n.take(); len(events) != 0 {
+		t.Errorf("无修改时不应产生事件, 得到 %+v", events)
+	}
+}
+
+func TestEventEmitter_FailedAndRecovered(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	d.CheckAndSetAddr(net.ParseIP("2001:db8::1"))
+	n := &recordingNotifier{}
+	e := newEventEmitter(n, []*Domain{d})
+
+	failed := syncOutcome{domain: d, addr: net.ParseIP("2001:db8::2"), err: errors.New("api error")}
+	e.outcome(failed)
+	e.outcome(failed)
+	events := n.take()
+	if len(events) != 1 || events[0].Type != EventSyncFailed {
+		t.Fatalf("连续失败只应通知一次 sync_failed, 得到 %+v", events)
+	}
+	if events[0].OldAddr != "2001:db8::1" || events[0].NewAddr != "2001:db8::2" || events[0].Error != "api error" {
+		t.Errorf("sync_failed 应携带已发布地址、目标地址和错误: %+v", events[0])
+	}
+
+	e.outcome(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::2"),
+		changes: []recordChange{{action: changeModified, oldValue: "2001:db8::1"}}})
+	events = n.take()
+	if len(events) != 2 || events[0].Type != EventRecordUpdated || events[1].Type != EventSyncRecovered {
+		t.Fatalf("恢复时应依次通知 record_updated 和 sync_recovered, 得到 %+v", events)
+	}
+
+	e.outcome(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::2"), err: context.Canceled})
+	if events := n.take(); len(events) != 0 {
+		t.Errorf("因退出取消的同步不应产生事件, 得到 %+v", events)
+	}
+}
+
+func TestEventEmitter_AddressChanged(t *testing.T) {
+	n := &recordingNotifier{}
+	e := newEventEmitter(n, nil)
+	var tracker addrChangeTracker
+
+	e.addrChanged(tracker.observe(addrSet{ipv6: net.ParseIP("2001:db8::1")}))
+	if events := n.take(); len(events) != 0 {
+		t.Errorf("首次获取不应通知地址变化, 得到 %+v", events)
+	}
+	e.addrChanged(tracker.observe(addrSet{ipv6: net.ParseIP("2001:db8::2"), ipv4: net.ParseIP("192.0.2.1")}))
+	events := n.take()
+	if len(events) != 1 {
+		t.Fatalf("只有 IPv6 地址发生变化, 得到 %+v", events)
+	}
+	if ev := events[0]; ev.Type != EventAddressChanged || ev.Family != "IPv6" ||
+		ev.OldAddr != "2001:db8::1" || ev.NewAddr != "2001:db8::2" {
+		t.Errorf("address_changed 事件内容不符: %+v", ev)
+	}
+}
+
+func TestEventEmitter_PropagationTimeout(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	n := &recordingNotifier{}
+	e := newEventEmitter(n, []*Domain{d})
+
+	err := &propagationError{timeout: time.Minute, pending: []string{"ns2.example.net"}}
+	e.propagationTimeout(syncOutcome{profile: "home", domain: d, addr: net.ParseIP("2001:db8::2")}, err)
+	events := n.take()
+	if len(events) != 1 || events[0].Type != EventPropagationTimeout {
+		t.Fatalf("应产生 1 个 propagation_timeout 事件, 得到 %+v", events)
+	}
+	if ev := events[0]; ev.Domain != "www.example.com" || ev.NewAddr != "2001:db8::2" || !strings.Contains(ev.Error, "ns2.example.net") {
+		t.Errorf("事件应携带域名、目标地址和未生效的权威服务器: %+v", ev)
+	}
+}
+
+func TestEventEmitter_NilNotifier(t *testing.T) {
+	d := &Domain{Domain: "example.com", Type: RecordTypeAAAA}
+	e := newEventEmitter(nil, []*Domain{d})
+	e.outcome(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::1"), err: errors.New("api error")})
+	e.addrChanged([]addrChange{{old: net.ParseIP("2001:db8::1"), new: net.ParseIP("2001:db8::2")}})
+}
@@ -0,0 +1,202 @@
+package ddns
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// PlanAction 计划模式（Plan）中对一条记录的处理。
+type PlanAction string
+
+// 计划模式的处理类型。
+const (
+	PlanUnchanged PlanAction = "unchanged" // 记录已是目标值，不会写入
+	PlanModify    PlanAction = "modify"    // 将修改已有记录
+	PlanAdd       PlanAction = "add"       // 将新增缺失的记录
+	PlanFailed    PlanAction = "failed"    // 无法生成计划（查询记录失败或未获取到可发布的地址）
+)
+
+// PlannedChange 计划模式中对一条服务商记录的处理。
+type PlannedChange struct {
+	Profile  string
+	Domain   string // 完整域名（如 www.example.com）
+	Type     string // A 或 AAAA
+	Action   PlanAction
+	RecordID string // 服务商记录 ID（新增时为空）
+	OldValue string // 当前记录值（新增时为空）
+	NewValue string // 同步后的记录值（失败时可能为空）
+	OldTTL   int    // 当前 TTL（新增时为 0）
+	TTL      int    // 同步后的 TTL
+	Err      error  // Action 为 PlanFailed 时的原因
+}
+
+// Plan 执行一次与 UpdateOnce 相同的地址获取和同步流程，但不写入服务商，返回计划做出的修改。
+//
+// 同步逻辑与正常运行完全相同（applyDNSRecord），服务商被包装为只读：GetRecords 正常查询，
+// AddRecord、ModifyRecord 只记录不执行。为如实反映服务商当前的记录，Plan 忽略缓存地址和
+// 状态文件，总是查询服务商；钩子、通知、状态持久化等选项被忽略，传入的域名不被修改。
+// 结果按 Profile 和域名的配置顺序排列，每个域名的每条匹配记录一条；返回的 error 仅表示
+// 无法开始（如 profiles 为空）。
+func Plan(ctx context.Context, profiles []Profile, fetchers []ipaddr.IPv6Fetcher, opts ...Option) ([]PlannedChange, error) {
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no profiles to plan")
+	}
+	o, err := onceOptions(fetchers, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用域名副本和只读服务商，计划不影响调用方的域名状态
+	planned := make([]Profile, len(profiles))
+	for i, pr := range profiles {
+		domains := make([]*Domain, len(pr.Domains))
+		for j, d := range pr.Domains {
+			domains[j] = d.planCopy()
+		}
+		planned[i] = Profile{Name: pr.Name, Provider: newPlanProvider(pr.Provider), Domains: domains}
+	}
+	domains := allDomains(planned)
+	needV4, needV6 := requiredFamilies(domains)
+
+	slog.Info("planning sync without writing to providers", "module", "ddns",
+		"profile_count", len(planned), "domain_count", len(domains), "ipv4", needV4, "ipv6", needV6)
+
+	addrs, fetchErr := fetchAddrs(ctx, needV4, needV6, policyEnabled(domains), o.ipv4Fetchers, fetchers, o.fetchPolicy)
+	if fetchErr != nil {
+		slog.Error("address fetch failed", "module", "ddns", "err", fetchErr)
+	}
+
+	outcomes := make(map[*Domain]syncOutcome, len(domains))
+	if addrs.ipv4 != nil || addrs.ipv6 != nil {
+		results, _ := applyProfiles(ctx, planned, addrs, syncRecord, false)
+		for _, r := range results {
+			outcomes[r.domain] = r
+		}
+	}
+
+	var changes []PlannedChange
+	for _, pr := range planned {
+		pp := pr.Provider.(*planProvider)
+		for _, d := range pr.Domains {
+			r, ok := outcomes[d]
+			switch {
+			case !ok:
+				changes = append(changes, PlannedChange{Profile: pr.Name, Domain: d.FullDomain(), Type: d.Type,
+					Action: PlanFailed, TTL: d.TTL, Err: noAddrError(d, addrs, fetchErr)})
+			case r.err != nil:
+				c := PlannedChange{Profile: pr.Name, Domain: d.FullDomain(), Type: d.Type,
+					Action: PlanFailed, TTL: d.TTL, Err: r.err}
+				if r.addr != nil {
+					c.NewValue = r.addr.String()
+				}
+				changes = append(changes, c)
+			default:
+				changes = append(changes, pp.changes(pr.Name, d)...)
+			}
+		}
+	}
+	return changes, nil
+}
+
+// planCopy 返回只含配置字段的域名副本（不含缓存地址、同步元数据和钩子），供 Plan 使用。
+func (d *Domain) planCopy() *Domain {
+	return &Domain{
+		Domain: d.Domain, SubDomain: d.SubDomain, Type: d.Type, TTL: d.TTL,
+		Suffix: d.Suffix, PrefixLength: d.PrefixLength,
+		OnWithdraw: d.OnWithdraw, Fallback: d.Fallback, Policy: d.Policy,
+	}
+}
+
+// planProvider 计划模式的 DNSProvider 包装：查询转发给被包装的服务商，写入只记录不执行。
+type planProvider struct {
+	next DNSProvider
+
+	mu       sync.Mutex
+	queried  map[string][]RecordInfo // GetRecords 的结果，按 fqdn|type
+	modified map[string][]RecordInfo // 计划的 ModifyRecord，按 fqdn|type
+	added    map[string][]RecordInfo // 计划的 AddRecord，按 fqdn|type
+}
+
+// newPlanProvider 包装 p，使其不执行写入。
+func newPlanProvider(p DNSProvider) *planProvider {
+	return &planProvider{
+		next:     p,
+		queried:  make(map[string][]RecordInfo),
+		modified: make(map[string][]RecordInfo),
+		added:    make(map[string][]RecordInfo),
+	}
+}
+
+// planKey 返回按域名和记录类型归类的键。
+func planKey(fqdn, recordType string) string {
+	return fqdn + "|" + recordType
+}
+
+func (p *planProvider) GetRecords(ctx context.Context, domain, recordType string) ([]RecordInfo, error) {
+	records, err := p.next.GetRecords(ctx, domain, recordType)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.queried[planKey(domain, recordType)] = records
+	p.mu.Unlock()
+	return records, nil
+}
+
+func (p *planProvider) AddRecord(_ context.Context, record RecordInfo) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := planKey(record.Name, record.Type)
+	p.added[key] = append(p.added[key], record)
+	return nil
+}
+
+func (p *planProvider) ModifyRecord(_ context.Context, record RecordInfo) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := planKey(record.Name, record.Type)
+	p.modified[key] = append(p.modified[key], record)
+	return nil
+}
+
+// DeleteRecord 同步流程不删除记录，计划模式下为空操作。
+func (p *planProvider) DeleteRecord(context.Context, RecordInfo) error {
+	return nil
+}
+
+// changes 返回域名 d 的计划：每条匹配的现有记录为 PlanModify 或 PlanUnchanged，另加计划新增的记录。
+//
+// 计划的修改按记录 ID 与查询结果对应，同一 ID 只对应一次。
+func (p *planProvider) changes(profile string, d *Domain) []PlannedChange {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fqdn := d.FullDomain()
+	key := planKey(fqdn, d.Type)
+
+	pending := append([]RecordInfo(nil), p.modified[key]...)
+	var changes []PlannedChange
+	for _, r := range p.queried[key] {
+		if !RecordNameMatches(r.Name, fqdn, d.SubDomain) || r.Type != d.Type {
+			continue
+		}
+		c := PlannedChange{Profile: profile, Domain: fqdn, Type: d.Type, Action: PlanUnchanged,
+			RecordID: r.ID, OldValue: r.Value, NewValue: r.Value, OldTTL: r.TTL, TTL: r.TTL}
+		for i, m := range pending {
+			if m.ID == r.ID {
+				c.Action, c.NewValue, c.TTL = PlanModify, m.Value, m.TTL
+				pending = append(pending[:i], pending[i+1:]...)
+				break
+			}
+		}
+		changes = append(changes, c)
+	}
+	for _, a := range p.added[key] {
+		changes = append(changes, PlannedChange{Profile: profile, Domain: fqdn, Type: d.Type, Action: PlanAdd,
+			NewValue: a.Value, TTL: a.TTL})
+	}
+	return changes
+}
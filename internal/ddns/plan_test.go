@@ -0,0 +1,56 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// ============================================================
+// Plan 计划模式测试
+// ============================================================
+
+func TestPlan_NoWrites(t *testing.T) {
+	addr := "2001:db8::2"
+	existing := &mockProvider{records: []RecordInfo{
+		{ID: "1", Name: "www.example.com", Type: "AAAA", Value: addr, TTL: 600},
+		{ID: "2", Name: "www.example.com", Type: "AAAA", Value: "2001:db8::1", TTL: 300},
+	}}
+	empty := &mockProvider{}
+	www := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	profiles := []Profile{
+		{Name: "old", Provider: existing, Domains: []*Domain{www}},
+		{Name: "new", Provider: empty, Domains: []*Domain{
+			{Domain: "example.org", SubDomain: "nas", Type: RecordTypeAAAA, TTL: 600},
+			{Domain: "example.org", SubDomain: "nas", Type: RecordTypeA, TTL: 600},
+		}},
+	}
+	fetchers := []ipaddr.IPv6Fetcher{&mockFetcher{ip: net.ParseIP(addr)}}
+	v4 := WithIPv4Fetchers(&mockFetcher{err: errors.New("no route")})
+
+	changes, err := Plan(context.Background(), profiles, fetchers, v4)
+	if err != nil {
+		t.Fatalf("Plan 不应返回错误: %v", err)
+	}
+	want := []PlanAction{PlanUnchanged, PlanModify, PlanAdd, PlanFailed}
+	if len(changes) != len(want) {
+		t.Fatalf("每条匹配记录和每个失败域名应有一条计划, 得到 %+v", changes)
+	}
+	for i, c := range changes {
+		if c.Action != want[i] {
+			t.Errorf("%s %s %s: 期望 %s, 得到 %s (err=%v)", c.Domain, c.Type, c.RecordID, want[i], c.Action, c.Err)
+		}
+	}
+	if c := changes[1]; c.RecordID != "2" || c.OldValue != "2001:db8::1" || c.NewValue != addr || c.OldTTL != 300 || c.TTL != 600 {
+		t.Errorf("修改计划应包含记录 ID、旧值、新值和 TTL: %+v", c)
+	}
+	if existing.modCalls != 0 || existing.addCalls != 0 || empty.addCalls != 0 {
+		t.Errorf("计划模式不应写入服务商: mod=%d add=%d", existing.modCalls, existing.addCalls+empty.addCalls)
+	}
+	if www.Addr != nil {
+		t.Error("计划模式不应修改传入的域名")
+	}
+}
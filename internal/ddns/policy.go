@@ -0,0 +1,160 @@
+package ddns
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// errNoCandidate 获取到了地址，但没有候选地址满足域名的地址策略。
+var errNoCandidate = errors.New("no candidate address satisfies policy")
+
+// bogonPrefixes AddrPolicy.RejectBogons 拒绝的 IPv6 地址段及其名称。
+var bogonPrefixes = []struct {
+	name string
+	net  *net.IPNet
+}{
+	{"documentation", mustCIDR("2001:db8::/32")},
+	{"documentation", mustCIDR("3fff::/20")},
+	{"ULA", mustCIDR("fc00::/7")},
+	{"link-local", mustCIDR("fe80::/10")},
+	{"6to4", mustCIDR("2002::/16")},
+	{"Teredo", mustCIDR("2001::/32")},
+}
+
+// mustCIDR 解析常量 CIDR，格式错误时 panic。
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// AddrPolicy 发布 AAAA 记录前对候选地址的筛选规则，零值表示不筛选（直接使用最快获取到的地址）。
+//
+// 规则作用于获取到的本机地址（设置了 Domain.Suffix 时为组合主机地址之前的地址）。
+// 任一域名设置了策略时，地址获取等待所有 IPv6 获取器的结果作为候选，每个域名按自己的策略选择：
+// 候选先按 Prefer 排序，再依次检查，第一个通过所有规则的地址被发布；
+// 被拒绝的候选记录一条带原因的日志，没有候选通过时该域名记为同步失败，记录保持不变。
+// InterfaceFetcher 贡献接口上所有可发布的地址（见 ipaddr.CandidateFetcher）。
+type AddrPolicy struct {
+	RejectBogons bool         // 拒绝文档（2001:db8::/32、3fff::/20）、ULA、链路本地、6to4 和 Teredo 地址
+	Allow        []*net.IPNet // 非空时只接受其中的地址
+	Deny         []*net.IPNet // 拒绝其中的地址（优先于 Allow）
+	Prefer       []*net.IPNet // 优先选择其中的地址（如运营商下发的前缀），按列表顺序
+	RequireLocal bool         // 要求地址配置在本机网络接口上
+}
+
+// Enabled 返回是否设置了任一规则。
+func (p AddrPolicy) Enabled() bool {
+	return p.RejectBogons || len(p.Allow) > 0 || len(p.Deny) > 0 || len(p.Prefer) > 0 || p.RequireLocal
+}
+
+// isLocalAddr 返回 ip 是否配置在本机接口上，检查本身失败时视为不在本机。
+var isLocalAddr = func(ip net.IP) bool {
+	ok, err := ipaddr.IsLocalAddr(ip)
+	if err != nil {
+		slog.Debug("cannot check local addresses", "module", "ddns", "err", err)
+		return false
+	}
+	return ok
+}
+
+// reject 返回 ip 不符合策略的原因，符合时返回空字符串。
+func (p AddrPolicy) reject(ip net.IP) string {
+	if ip.To4() != nil {
+		return "not an IPv6 address"
+	}
+	if p.RejectBogons {
+		for _, b := range bogonPrefixes {
+			if b.net.Contains(ip) {
+				return fmt.Sprintf("%s address (%s)", b.name, b.net)
+			}
+		}
+	}
+	if n := containing(p.Deny, ip); n != nil {
+		return fmt.Sprintf("denied by %s", n)
+	}
+	if len(p.Allow) > 0 && containing(p.Allow, ip) == nil {
+		return "not in allow list"
+	}
+	if p.RequireLocal && !isLocalAddr(ip) {
+		return "not assigned to a local interface"
+	}
+	return ""
+}
+
+// order 返回按 Prefer 排序后的候选：落在靠前的优先前缀中的地址在前，其余保持原顺序。
+func (p AddrPolicy) order(candidates []net.IP) []net.IP {
+	if len(p.Prefer) == 0 {
+		return candidates
+	}
+	rank := func(ip net.IP) int {
+		for i, n := range p.Prefer {
+			if n.Contains(ip) {
+				return i
+			}
+		}
+		return len(p.Prefer)
+	}
+	out := make([]net.IP, 0, len(candidates))
+	for r := 0; r <= len(p.Prefer); r++ {
+		for _, ip := range candidates {
+			if rank(ip) == r {
+				out = append(out, ip)
+			}
+		}
+	}
+	return out
+}
+
+// containing 返回 nets 中第一个包含 ip 的网段，没有时返回 nil。
+func containing(nets []*net.IPNet, ip net.IP) *net.IPNet {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return n
+		}
+	}
+	return nil
+}
+
+// addrPolicy 返回域名的地址策略（线程安全，热重载时在锁内更新）。
+func (d *Domain) addrPolicy() AddrPolicy {
+	d.lock()
+	defer d.unlock()
+	return d.Policy
+}
+
+// selectAddr 按域名的地址策略从候选中选择要使用的 IPv6 地址，没有候选通过时返回 nil。
+// 未设置策略时返回第一个（最快到达的）候选。
+func (d *Domain) selectAddr(candidates []net.IP) net.IP {
+	policy := d.addrPolicy()
+	if !policy.Enabled() {
+		return candidates[0]
+	}
+	for _, ip := range policy.order(candidates) {
+		reason := policy.reject(ip)
+		if reason == "" {
+			return ip
+		}
+		slog.Warn("address rejected by policy, trying next candidate", "module", "ddns",
+			"domain", d.Domain, "subdomain", d.SubDomain, "addr", ip.String(), "reason", reason)
+	}
+	slog.Warn("no candidate address satisfies policy", "module", "ddns",
+		"domain", d.Domain, "subdomain", d.SubDomain, "candidates", candidates)
+	return nil
+}
+
+// policyEnabled 返回 domains 中是否有 AAAA 记录设置了地址策略（此时需要获取全部候选地址）。
+func policyEnabled(domains []*Domain) bool {
+	for _, d := range domains {
+		if d.Type != RecordTypeA && d.addrPolicy().Enabled() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// ============================================================
+// 地址策略测试
+// ============================================================
+
+func TestAddrPolicy_Reject(t *testing.T) {
+	orig := isLocalAddr
+	isLocalAddr = func(ip net.IP) bool { return ip.Equal(net.ParseIP("2409:8a00::1")) }
+	defer func() { isLocalAddr = orig }()
+
+	p := AddrPolicy{
+		RejectBogons: true,
+		Allow:        []*net.IPNet{mustCIDR("2409::/16"), mustCIDR("240e::/16")},
+		Deny:         []*net.IPNet{mustCIDR("240e:1::/32")},
+		RequireLocal: true,
+	}
+	tests := []struct {
+		addr   string
+		reject bool
+	}{
+		{"2001:db8::1", true},      // 文档
+		{"3fff::1", true},          // 文档
+		{"fd00::1", true},          // ULA
+		{"fe80::1", true},          // 链路本地
+		{"2002:c000:204::1", true}, // 6to4
+		{"2001:0:4136::1", true},   // Teredo
+		{"2400:3200::1", true},     // 不在允许列表
+		{"240e:1::1", true},        // 拒绝列表
+		{"240e:2::1", true},        // 不在本机
+		{"2409:8a00::1", false},
+	}
+	for _, tt := range tests {
+		reason := p.reject(net.ParseIP(tt.addr))
+		if (reason != "") != tt.reject {
+			t.Errorf("%s: 期望拒绝=%v, 得到原因 %q", tt.addr, tt.reject, reason)
+		}
+	}
+
+	if (AddrPolicy{}).Enabled() {
+		t.Error("零值策略不应启用")
+	}
+	if reason := (AddrPolicy{}).reject(net.ParseIP("fd00::1")); reason != "" {
+		t.Errorf("零值策略不应拒绝任何 IPv6 地址, 得到 %q", reason)
+	}
+}
+
+func TestDomain_TargetAddrFallsBackToNextCandidate(t *testing.T) {
+	addrs := addrSet{
+		ipv6: net.ParseIP("fd00::1"),
+		ipv6Candidates: []net.IP{
+			net.ParseIP("fd00::1"), net.ParseIP("2408:8000::1"), net.ParseIP("240e:1::1"),
+		},
+	}
+
+	// 第一个候选是 ULA，应回落到下一个候选
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, Policy: AddrPolicy{RejectBogons: true}}
+	if ip := d.targetAddr(addrs); !ip.Equal(net.ParseIP("2408:8000::1")) {
+		t.Errorf("应回落到 2408:8000::1, 得到 %s", ip)
+	}
+
+	// 优先前缀：即使较晚到达也优先选择
+	d.Policy.Prefer = []*net.IPNet{mustCIDR("240e::/16")}
+	if ip := d.targetAddr(addrs); !ip.Equal(net.ParseIP("240e:1::1")) {
+		t.Errorf("应优先选择 240e::/16 中的地址, 得到 %s", ip)
+	}
+
+	// 策略作用于组合主机地址之前的本机地址
+	d.Suffix, d.PrefixLength = net.ParseIP("::1234"), 64
+	if ip := d.targetAddr(addrs); !ip.Equal(net.ParseIP("240e:1::1234")) {
+		t.Errorf("应由选中的地址组合主机地址, 得到 %s", ip)
+	}
+
+	// 没有候选通过时跳过
+	d.Policy = AddrPolicy{Deny: []*net.IPNet{mustCIDR("::/0")}}
+	if ip := d.targetAddr(addrs); ip != nil {
+		t.Errorf("没有候选通过时应返回 nil, 得到 %s", ip)
+	}
+
+	// 未设置策略的域名使用第一个候选
+	plain := &Domain{Domain: "example.com", SubDomain: "api", Type: RecordTypeAAAA}
+	if ip := plain.targetAddr(addrs); !ip.Equal(net.ParseIP("fd00::1")) {
+		t.Errorf("未设置策略时应使用第一个候选, 得到 %s", ip)
+	}
+}
+
+func TestSyncAllDomains_NoCandidateSatisfiesPolicy(t *testing.T) {
+	addrs := addrSet{
+		ipv6:           net.ParseIP("fd00::1"),
+		ipv6Candidates: []net.IP{net.ParseIP("fd00::1")},
+	}
+	denied := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA,
+		Policy: AddrPolicy{RejectBogons: true}}
+	v4 := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeA}
+	m := &mockProvider{}
+	pr := Profile{Name: "default", Provider: m, Domains: []*Domain{denied, v4}}
+
+	outcomes, err := syncAllDomains(context.Background(), pr, addrs, syncRecord, true)
+	if err != nil {
+		t.Fatalf("没有候选满足地址策略不应终止启动: %v", err)
+	}
+	if len(outcomes) != 1 || outcomes[0].domain != denied {
+		t.Fatalf("应只为设置了策略的域名返回结果（未获取到 IPv4 的 A 记录跳过）, 得到 %d 个", len(outcomes))
+	}
+	if !errors.Is(outcomes[0].err, errNoCandidate) {
+		t.Errorf("没有候选满足地址策略时应记为失败, 得到 %v", outcomes[0].err)
+	}
+	if m.getCalls != 0 {
+		t.Errorf("没有可发布的地址时不应调用服务商, GetRecords 调用 %d 次", m.getCalls)
+	}
+
+	// 失败不入重试队列，并移除已排队的旧地址
+	q := newRetryQueue(DefaultRetryBackoff)
+	q.record(syncOutcome{domain: denied, addr: net.ParseIP("2001:db8::1"), err: errors.New("api error")})
+	q.record(outcomes[0])
+	if q.len() != 0 {
+		t.Errorf("没有候选满足地址策略时应移除重试队列中的旧地址, 队列长度 %d", q.len())
+	}
+}
+
+func TestFetchAddrs_CollectsCandidates(t *testing.T) {
+	fetchers := []ipaddr.IPv6Fetcher{
+		&mockFetcher{ip: net.ParseIP("2408:8000::1")},
+		&mockFetcher{ip: net.ParseIP("2408:8000::1")},
+		&mockFetcher{ip: net.ParseIP("240e:1::1")},
+	}
+	addrs, err := fetchAddrs(context.Background(), false, true, true, nil, fetchers, ipaddr.FetchPolicy{})
+	if err != nil {
+		t.Fatalf("fetchAddrs 不应返回错误: %v", err)
+	}
+	if len(addrs.ipv6Candidates) != 2 {
+		t.Fatalf("应得到 2 个去重后的候选, 得到 %v", addrs.ipv6Candidates)
+	}
+	if !addrs.ipv6.Equal(addrs.ipv6Candidates[0]) {
+		t.Errorf("ipv6 应为第一个候选, 得到 %s", addrs.ipv6)
+	}
+
+	domains := []*Domain{
+		{Type: RecordTypeA, Policy: AddrPolicy{RejectBogons: true}},
+		{Type: RecordTypeAAAA},
+	}
+	if policyEnabled(domains) {
+		t.Error("只有 A 记录设置策略时不需要获取全部候选")
+	}
+	domains[1].Policy.RequireLocal = true
+	if !policyEnabled(domains) {
+		t.Error("AAAA 记录设置策略时应获取全部候选")
+	}
+}
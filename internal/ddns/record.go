@@ -5,7 +5,7 @@
 //	Linux: Netlink 监听地址变化 -> debounce 10s -> 获取 IPv6 -> 同步 DNS 记录
 //	其他:   cron 定时轮询 -> 获取 IPv6 -> 同步 DNS 记录
 //
-// 同步策略：
+// 同步策略（A 与 AAAA 记录相同，以下以 AAAA 为例）：
 //  1. 查询目标子域名的所有 AAAA 记录
 //  2. 遍历匹配的子域名记录，IP 相同则跳过，不同则修改
 //  3. 目标子域名无 AAAA 记录则新增
@@ -17,52 +17,145 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"time"
 )
 
-// SyncRecord 同步 DNS 记录与当前 IPv6 地址一致。
+// SyncRecord 同步 DNS 记录与当前地址一致。
 //
-// 先检查地址是否变化，若无变化则跳过更新。变化则调用 syncDNSRecord 执行同步。
+// 先检查地址是否变化，若无变化则跳过更新。变化则调用 applyDNSRecord 执行同步。
+// 缓存地址来自持久化状态且已超过对账窗口时，即使地址未变化也会执行一次同步核对。
 // 使用 d.lock()/d.unlock() 保护 Domain 的并发访问。
 //
 // 参数:
 //   - ctx: 上下文，取消时中止操作
 //   - d: 域名配置（含子域名、记录类型等）
-//   - ipv6: 当前本机 IPv6 地址
+//   - addr: 当前本机地址，地址族必须与 d.Type 一致（A -> IPv4，AAAA -> IPv6）
 //   - p: DNS 服务商实现
-func SyncRecord(ctx context.Context, d *Domain, ipv6 net.IP, p DNSProvider) error {
+func SyncRecord(ctx context.Context, d *Domain, addr net.IP, p DNSProvider) error {
+	_, err := syncRecord(ctx, d, addr, p)
+	return err
+}
+
+// syncRecord 与 SyncRecord 相同，另外返回对服务商记录做出的修改。
+func syncRecord(ctx context.Context, d *Domain, addr net.IP, p DNSProvider) ([]recordChange, error) {
 	d.lock()
 	defer d.unlock()
+	return syncRecordLocked(ctx, d, addr, p)
+}
 
+// syncRecordLocked SyncRecord 的实现，调用方必须持有 d 的锁。
+func syncRecordLocked(ctx context.Context, d *Domain, addr net.IP, p DNSProvider) ([]recordChange, error) {
 	// 检查 context 是否已取消
 	select {
 	case <-ctx.Done():
 		slog.Info("sync task cancelled", "module", "ddns", "domain", d.Domain, "subdomain", d.SubDomain)
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
 	}
 
+	// 地址族必须与记录类型匹配，防止把 IPv6 写入 A 记录（或反之）
+	if !addrMatchesType(addr, d.Type) {
+		return nil, fmt.Errorf("address %s does not match record type %s", addr, d.Type)
+	}
+
 	// 地址未变化则跳过，避免无效的 API 调用
-	if !hasAddressChanged(d.Addr, ipv6) {
-		slog.Info("IPv6 address unchanged, skipping update", "module", "ddns",
-			"domain", d.Domain, "subdomain", d.SubDomain)
-		return nil
+	if !hasAddressChanged(d.Addr, addr) {
+		if d.trustUntil.IsZero() || time.Now().Before(d.trustUntil) {
+			slog.Info("address unchanged, skipping update", "module", "ddns",
+				"domain", d.Domain, "subdomain", d.SubDomain, "type", d.Type)
+			return nil, nil
+		}
+		slog.Info("persisted state outside reconciliation window, reconciling", "module", "ddns",
+			"domain", d.Domain, "subdomain", d.SubDomain, "type", d.Type,
+			"verified_at", d.verifiedAt)
 	}
 
-	return syncDNSRecord(ctx, d, p, ipv6)
+	return applyDNSRecord(ctx, d, p, addr)
 }
 
-// syncDNSRecord 执行实际的 DNS 记录同步。
+// ReconcileRecord 忽略缓存地址，重新查询服务商记录并修复漂移。
+//
+// 与 SyncRecord 不同，即使地址与缓存一致也会调用 GetRecords 核对。
+// 缓存地址与当前地址一致、但服务商记录被修改或删除（如在控制台手动编辑）时，
+// 修复后每处修改都记录为一条独立的 drift 事件日志。
+func ReconcileRecord(ctx context.Context, d *Domain, addr net.IP, p DNSProvider) error {
+	_, err := reconcileRecord(ctx, d, addr, p)
+	return err
+}
+
+// reconcileRecord 与 ReconcileRecord 相同，另外返回对服务商记录做出的修改。
+func reconcileRecord(ctx context.Context, d *Domain, addr net.IP, p DNSProvider) ([]recordChange, error) {
+	d.lock()
+	defer d.unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if !addrMatchesType(addr, d.Type) {
+		return nil, fmt.Errorf("address %s does not match record type %s", addr, d.Type)
+	}
+
+	// 缓存与当前地址一致时，记录本应已是最新，此时的修改才算漂移
+	expected := d.Addr != nil && d.Addr.Equal(addr)
+
+	changes, err := applyDNSRecord(ctx, d, p, addr)
+	if err != nil || !expected {
+		return changes, err
+	}
+	for _, c := range changes {
+		slog.Warn("DNS record drift repaired", "module", "ddns", "event", "drift",
+			"domain", d.Domain, "subdomain", d.SubDomain, "type", d.Type,
+			"action", c.action, "record_id", c.recordID,
+			"found", c.oldValue, "expected", addr.String())
+	}
+	if len(changes) == 0 {
+		slog.Debug("no drift detected", "module", "ddns",
+			"domain", d.Domain, "subdomain", d.SubDomain, "type", d.Type)
+	}
+	return changes, nil
+}
+
+// 记录变更动作。
+const (
+	changeModified = "modified" // 修改已有记录
+	changeAdded    = "added"    // 新增缺失的记录
+	changeDeleted  = "deleted"  // 本机地址撤回后删除记录
+	changeDisabled = "disabled" // 本机地址撤回后暂停记录
+	changeEnabled  = "enabled"  // 本机地址恢复后重新启用记录
+)
+
+// recordChange 一次同步中对服务商记录做出的修改。
+type recordChange struct {
+	action   string // changeModified、changeAdded 等
+	recordID string // 被修改记录的 ID（新增时为空）
+	oldValue string // 修改前的记录值（新增时为空）
+}
+
+// syncDNSRecord 执行实际的 DNS 记录同步，详见 applyDNSRecord。
+func syncDNSRecord(ctx context.Context, d *Domain, p DNSProvider, addr net.IP) error {
+	_, err := applyDNSRecord(ctx, d, p, addr)
+	return err
+}
+
+// applyDNSRecord 执行实际的 DNS 记录同步，返回对服务商记录做出的修改。
 //
 // 工作流程：
 //  1. 通过 p.GetRecords() 查询目标子域名下所有记录
-//  2. 遍历记录，只处理匹配当前子域名且类型为 AAAA 的记录
+//  2. 遍历记录，只处理匹配当前子域名且类型为 d.Type 的记录
 //  3. 同 IP 则跳过，不同 IP 则修改
-//  4. 目标子域名下无 AAAA 记录则新增
+//  4. 目标子域名下无该类型记录则新增
 //
-// 同一个子域名下存在多个 AAAA 记录时全部处理（continue 而非 return）。
-func syncDNSRecord(ctx context.Context, d *Domain, p DNSProvider, addr net.IP) error {
+// 同一个子域名下存在多个同类型记录时全部处理（continue 而非 return）。
+// 配置了钩子（WithHooks）时，第一次写入服务商前执行 pre 钩子，写入结束后执行 post 钩子。
+// 记录此前因本机地址撤回被处理过（见 withdrawRecord）时，同步成功后恢复记录（如重新启用）。
+// 失败（含 pre 钩子否决）时清空缓存地址（部分记录可能已更新），确保下次同步重新核对全部记录。
+// 调用方必须持有 d 的锁。
+func applyDNSRecord(ctx context.Context, d *Domain, p DNSProvider, addr net.IP) (changes []recordChange, err error) {
 	fqdn := d.FullDomain()
-	ipv6Str := addr.String()
+	value := addr.String()
 
 	slog.Debug("querying existing DNS records", "module", "ddns",
 		"domain", d.Domain, "subdomain", d.SubDomain,
@@ -74,15 +167,35 @@ func syncDNSRecord(ctx context.Context, d *Domain, p DNSProvider, addr net.IP) e
 	if err != nil {
 		slog.Error("failed to query records", "module", "ddns",
 			"domain", d.Domain, "subdomain", d.SubDomain,
-			"ipv6", ipv6Str, "err", err)
-		return fmt.Errorf("failed to query records: %w", err)
+			"addr", value, "err", err)
+		d.Addr = nil
+		return nil, fmt.Errorf("failed to query records: %w", err)
 	}
 
 	slog.Debug("DNS records query completed", "module", "ddns",
 		"domain", d.Domain, "subdomain", d.SubDomain,
 		"record_count", len(records))
 
-	found := false // 是否找到匹配的子域名 AAAA 记录
+	// beforeWrite 在第一次写入服务商前执行 pre 钩子；pre 钩子通过后，返回前执行 post 钩子
+	hooked, oldValue := false, ""
+	beforeWrite := func(old string) error {
+		if hooked {
+			return nil
+		}
+		if err := d.hooks.pre(ctx, d, old, value); err != nil {
+			return err
+		}
+		hooked, oldValue = true, old
+		return nil
+	}
+	defer func() {
+		if hooked {
+			d.hooks.post(ctx, d, oldValue, value, err)
+		}
+	}()
+
+	found := false // 是否找到匹配的子域名同类型记录
+	var ids []string
 
 	for _, r := range records {
 		// 过滤：只处理匹配目标子域名 + 目标类型的记录。
@@ -91,58 +204,95 @@ func syncDNSRecord(ctx context.Context, d *Domain, p DNSProvider, addr net.IP) e
 			continue
 		}
 		found = true
+		if r.ID != "" {
+			ids = append(ids, r.ID)
+		}
 
 		slog.Debug("comparing DNS record values", "module", "ddns",
 			"domain", d.Domain, "subdomain", d.SubDomain,
-			"existing_value", r.Value, "new_value", ipv6Str,
+			"existing_value", r.Value, "new_value", value,
 			"record_id", r.ID, "record_type", r.Type)
 
-		// IP 相同则更新缓存后跳过（多个 AAAA 记录时继续处理下一条）
-		if ipv6Equal(addr, r.Value) {
+		// IP 相同则更新缓存后跳过（多条记录时继续处理下一条）；
+		// 热重载修改了 TTL 时，TTL 不一致的记录仍需按新 TTL 重写；传播验证超时后的重试也需重写
+		if ipv6Equal(addr, r.Value) && !(d.ttlPending && r.TTL != d.TTL) && !d.rewritePending {
 			copyAddrToDomain(d, addr)
-			slog.Debug("IPv6 record already matches, no update needed", "module", "ddns",
+			slog.Debug("record already matches, no update needed", "module", "ddns",
 				"domain", d.Domain, "subdomain", d.SubDomain,
 				"record_id", r.ID)
 			continue
 		}
 
+		if err = beforeWrite(r.Value); err != nil {
+			d.Addr = nil
+			return changes, err
+		}
+
 		// IP 不同 -> 修改记录
 		err = p.ModifyRecord(ctx, RecordInfo{
-			ID: r.ID, Name: fqdn, Zone: d.Domain, Type: d.Type, Value: ipv6Str, TTL: d.TTL,
+			ID: r.ID, Name: fqdn, Zone: d.Domain, Type: d.Type, Value: value, TTL: d.TTL,
 		})
 		if err != nil {
 			slog.Error("failed to modify record", "module", "ddns",
 				"domain", d.Domain, "subdomain", d.SubDomain,
-				"ipv6", ipv6Str, "record_id", r.ID, "err", err)
-			return fmt.Errorf("failed to modify record: %w", err)
+				"addr", value, "record_id", r.ID, "err", err)
+			d.Addr = nil
+			return changes, fmt.Errorf("failed to modify record: %w", err)
 		}
 		copyAddrToDomain(d, addr)
-		slog.Info("IPv6 address changed, record modified", "module", "ddns",
+		changes = append(changes, recordChange{action: changeModified, recordID: r.ID, oldValue: r.Value})
+		slog.Info("address changed, record modified", "module", "ddns",
 			"domain", d.Domain, "subdomain", d.SubDomain,
-			"ipv6", ipv6Str, "record_id", r.ID)
+			"addr", value, "record_id", r.ID)
 	}
 
-	// 目标子域名下无 AAAA 记录 -> 新增
+	// 目标子域名下无同类型记录 -> 新增
 	if !found {
-		slog.Debug("no AAAA record found, adding new record", "module", "ddns",
+		slog.Debug("no matching record found, adding new record", "module", "ddns",
 			"domain", d.Domain, "subdomain", d.SubDomain,
-			"fqdn", fqdn, "ipv6", ipv6Str)
+			"fqdn", fqdn, "addr", value)
 
+		if err = beforeWrite(""); err != nil {
+			d.Addr = nil
+			return changes, err
+		}
 		err = p.AddRecord(ctx, RecordInfo{
-			Name: fqdn, Zone: d.Domain, Type: d.Type, Value: ipv6Str, TTL: d.TTL,
+			Name: fqdn, Zone: d.Domain, Type: d.Type, Value: value, TTL: d.TTL,
 		})
 		if err != nil {
 			slog.Error("failed to add record", "module", "ddns",
 				"domain", d.Domain, "subdomain", d.SubDomain,
-				"ipv6", ipv6Str, "err", err)
-			return fmt.Errorf("failed to add record: %w", err)
+				"addr", value, "err", err)
+			d.Addr = nil
+			return changes, fmt.Errorf("failed to add record: %w", err)
 		}
 		copyAddrToDomain(d, addr)
-		slog.Info("IPv6 address changed, record added", "module", "ddns",
-			"domain", d.Domain, "subdomain", d.SubDomain, "ipv6", ipv6Str)
+		changes = append(changes, recordChange{action: changeAdded})
+		slog.Info("address changed, record added", "module", "ddns",
+			"domain", d.Domain, "subdomain", d.SubDomain, "addr", value)
+	}
+
+	if d.withdrawn {
+		restored, rErr := restoreWithdrawn(ctx, d, p, ids)
+		changes = append(changes, restored...)
+		if rErr != nil {
+			err = rErr
+			d.Addr = nil
+			return changes, err
+		}
+	}
+
+	// 记录同步元数据，供状态持久化使用；已与服务商核对，不再需要对账
+	d.recordIDs = ids
+	d.verifiedAt = time.Now()
+	d.trustUntil = time.Time{}
+	d.ttlPending = false
+	d.rewritePending = false
+	if len(changes) > 0 {
+		d.updatedAt = d.verifiedAt
 	}
 
-	return nil
+	return changes, nil
 }
 
 // copyAddrToDomain 将 IP 地址拷贝到 Domain 的缓存字段（调用方必须持有 d 的锁）。
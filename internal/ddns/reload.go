@@ -0,0 +1,201 @@
+package ddns
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// configWatchInterval 检查配置文件是否变化的间隔。
+var configWatchInterval = 5 * time.Second
+
+// ReloadFunc 重新加载配置，返回新的 Profile 列表（DNSProvider 已创建）。
+// 返回错误时拒绝本次重载，当前配置继续运行。
+type ReloadFunc func() ([]Profile, error)
+
+// WithReload 启用配置热重载（默认不启用）：收到 SIGHUP 时调用 fn 获取新的 Profile 列表。
+//
+// watchFile 非空时，还会定期检查该文件的修改时间和大小，变化后同样触发重载。
+//
+// 新配置与运行中的域名逐一比对（按 profile 名称、服务商类型、完整域名和记录类型匹配）：
+// 匹配的域名保留缓存地址和同步状态（撤回策略和地址策略随新配置更新），仅 TTL 变化时在下次同步中按新 TTL 重写记录；
+// 新增域名从状态文件恢复后立即同步；删除的域名停止同步并移出重试队列。
+// 所有 Profile 的 DNSProvider 都替换为新创建的实例（凭据可能已变化）。
+// 重载不会重建地址变化触发源（Netlink 订阅保持不变）。
+func WithReload(fn ReloadFunc, watchFile string) Option {
+	return func(o *serviceOptions) {
+		o.reload = fn
+		o.watchFile = watchFile
+	}
+}
+
+// profileSet 运行中的 Profile 集合，热重载时整体替换。所有方法并发安全。
+type profileSet struct {
+	mu       sync.RWMutex
+	profiles []Profile
+	domains  []*Domain
+}
+
+// newProfileSet 创建包含 profiles 的集合。
+func newProfileSet(profiles []Profile) *profileSet {
+	return &profileSet{profiles: profiles, domains: allDomains(profiles)}
+}
+
+// get 返回当前的 Profile 列表和域名列表，调用方不得修改。
+func (s *profileSet) get() ([]Profile, []*Domain) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profiles, s.domains
+}
+
+// set 替换当前的 Profile 列表。
+func (s *profileSet) set(profiles []Profile) {
+	domains := allDomains(profiles)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles, s.domains = profiles, domains
+}
+
+// families 返回当前域名需要获取的地址族。
+func (s *profileSet) families() (needV4, needV6 bool) {
+	_, domains := s.get()
+	return requiredFamilies(domains)
+}
+
+// reloadDiff 一次热重载中新旧域名的差异。
+type reloadDiff struct {
+	added   []*Domain // 新增的域名
+	removed []*Domain // 删除的域名
+	updated []*Domain // 保留但 TTL 变化的域名
+}
+
+// changed 返回域名集合是否有变化。
+func (d reloadDiff) changed() bool {
+	return len(d.added) > 0 || len(d.removed) > 0 || len(d.updated) > 0
+}
+
+// domainKey 热重载时匹配新旧域名的键。局域网主机的后缀或前缀长度变化视为删除后新增。
+func domainKey(profile string, p DNSProvider, d *Domain) string {
+	key := profile + "|" + providerName(p) + "|" + d.FullDomain() + "|" + d.Type
+	if d.Suffix != nil {
+		key += fmt.Sprintf("|%s/%d", d.Suffix, d.PrefixLength)
+	}
+	return key
+}
+
+// mergeProfiles 将运行中的 cur 与新加载的 next 合并，返回合并后的 Profile 列表和差异。
+//
+// 合并结果沿用 next 的 Profile 和 DNSProvider；与 cur 匹配的域名沿用 cur 中的 *Domain，
+// 保留缓存地址和同步状态。TTL 变化的域名清空缓存地址并标记按新 TTL 重写记录。
+func mergeProfiles(cur, next []Profile) ([]Profile, reloadDiff) {
+	existing := make(map[string]*Domain)
+	for _, pr := range cur {
+		for _, d := range pr.Domains {
+			existing[domainKey(pr.Name, pr.Provider, d)] = d
+		}
+	}
+
+	var diff reloadDiff
+	merged := make([]Profile, len(next))
+	for i, pr := range next {
+		domains := make([]*Domain, 0, len(pr.Domains))
+		for _, d := range pr.Domains {
+			key := domainKey(pr.Name, pr.Provider, d)
+			old, ok := existing[key]
+			if !ok {
+				domains = append(domains, d)
+				diff.added = append(diff.added, d)
+				continue
+			}
+			delete(existing, key) // 同一配置中重复的域名只沿用一次
+			old.lock()
+			old.OnWithdraw, old.Fallback = d.OnWithdraw, d.Fallback
+			old.Policy = d.Policy
+			if old.TTL != d.TTL {
+				old.TTL = d.TTL
+				old.ttlPending = true
+				old.Addr = nil // 使下次同步重新核对服务商记录
+				diff.updated = append(diff.updated, old)
+			}
+			old.unlock()
+			domains = append(domains, old)
+		}
+		pr.Domains = domains
+		merged[i] = pr
+	}
+	for _, pr := range cur {
+		for _, d := range pr.Domains {
+			if _, ok := existing[domainKey(pr.Name, pr.Provider, d)]; ok {
+				diff.removed = append(diff.removed, d)
+			}
+		}
+	}
+	return merged, diff
+}
+
+// addedProfiles 返回 profiles 中只含 added 域名的子集，用于为热重载新增的域名恢复持久化状态。
+func addedProfiles(profiles []Profile, added []*Domain) []Profile {
+	set := make(map[*Domain]bool, len(added))
+	for _, d := range added {
+		set[d] = true
+	}
+	var result []Profile
+	for _, pr := range profiles {
+		var domains []*Domain
+		for _, d := range pr.Domains {
+			if set[d] {
+				domains = append(domains, d)
+			}
+		}
+		if len(domains) > 0 {
+			pr.Domains = domains
+			result = append(result, pr)
+		}
+	}
+	return result
+}
+
+// domainNames 返回用于日志的域名列表（如 www.example.com/AAAA）。
+func domainNames(domains []*Domain) []string {
+	names := make([]string, len(domains))
+	for i, d := range domains {
+		names[i] = d.FullDomain() + "/" + d.Type
+	}
+	return names
+}
+
+// watchConfigFile 定期检查 path 的修改时间和大小，变化时向 ch 发送信号，直到 ctx 取消。
+func watchConfigFile(ctx context.Context, path string, ch chan<- struct{}) {
+	stat := func() (time.Time, int64, bool) {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, 0, false
+		}
+		return fi.ModTime(), fi.Size(), true
+	}
+	modTime, size, _ := stat()
+	slog.Info("watching config file for changes", "module", "ddns", "path", path, "interval", configWatchInterval)
+
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		m, sz, ok := stat()
+		if !ok || (m.Equal(modTime) && sz == size) {
+			continue // 文件暂时不存在（如编辑器替换写入）时等待下次检查
+		}
+		modTime, size = m, sz
+		slog.Info("config file changed", "module", "ddns", "path", path)
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
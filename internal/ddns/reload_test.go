@@ -0,0 +1,149 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// ============================================================
+// 配置热重载测试
+// ============================================================
+
+func TestMergeProfiles(t *testing.T) {
+	www := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	api := &Domain{Domain: "example.com", SubDomain: "api", Type: RecordTypeAAAA, TTL: 600}
+	old := &Domain{Domain: "example.com", SubDomain: "old", Type: RecordTypeAAAA, TTL: 600}
+	www.CheckAndSetAddr(net.ParseIP("2001:db8::1"))
+	api.CheckAndSetAddr(net.ParseIP("2001:db8::1"))
+	cur := []Profile{{Name: "home", Provider: &mockProvider{}, Domains: []*Domain{www, api, old}}}
+
+	newProvider := &mockProvider{}
+	added := &Domain{Domain: "example.com", SubDomain: "new", Type: RecordTypeA, TTL: 600}
+	next := []Profile{{Name: "home", Provider: newProvider, Domains: []*Domain{
+		{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600},
+		{Domain: "example.com", SubDomain: "api", Type: RecordTypeAAAA, TTL: 300},
+		added,
+	}}}
+
+	merged, diff := mergeProfiles(cur, next)
+	if len(merged) != 1 || merged[0].Provider != newProvider {
+		t.Fatalf("合并结果应使用新的 DNSProvider: %+v", merged)
+	}
+	if want := []*Domain{www, api, added}; !reflect.DeepEqual(merged[0].Domains, want) {
+		t.Errorf("未变化的域名应沿用原 *Domain, 得到 %v", domainNames(merged[0].Domains))
+	}
+	if www.Addr == nil {
+		t.Error("未变化的域名应保留缓存地址")
+	}
+	if api.TTL != 300 || !api.ttlPending || api.Addr != nil {
+		t.Errorf("TTL 变化的域名应更新 TTL 并清空缓存: ttl=%d pending=%v addr=%v", api.TTL, api.ttlPending, api.Addr)
+	}
+	if !reflect.DeepEqual(diff.added, []*Domain{added}) || !reflect.DeepEqual(diff.removed, []*Domain{old}) ||
+		!reflect.DeepEqual(diff.updated, []*Domain{api}) {
+		t.Errorf("差异不符: added=%v removed=%v updated=%v",
+			domainNames(diff.added), domainNames(diff.removed), domainNames(diff.updated))
+	}
+}
+
+func TestMergeProfiles_ProfileRenameReplacesDomains(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	cur := []Profile{{Name: "home", Provider: &mockProvider{}, Domains: []*Domain{d}}}
+	next := []Profile{{Name: "office", Provider: &mockProvider{}, Domains: []*Domain{
+		{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA},
+	}}}
+
+	merged, diff := mergeProfiles(cur, next)
+	if merged[0].Domains[0] == d {
+		t.Error("profile 名称变化时不应沿用原 *Domain")
+	}
+	if len(diff.added) != 1 || len(diff.removed) != 1 {
+		t.Errorf("profile 名称变化应视为删除后新增: added=%d removed=%d", len(diff.added), len(diff.removed))
+	}
+}
+
+func TestSyncRecord_RewritesPendingTTL(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 300, ttlPending: true}
+	m := &mockProvider{records: []RecordInfo{
+		{ID: "1", Name: "www.example.com", Type: "AAAA", Value: "2001:db8::1", TTL: 600},
+	}}
+
+	if err := SyncRecord(context.Background(), d, net.ParseIP("2001:db8::1"), m); err != nil {
+		t.Fatalf("SyncRecord 不应返回错误: %v", err)
+	}
+	if m.modCalls != 1 {
+		t.Errorf("TTL 不一致时应按新 TTL 重写记录, ModifyRecord 调用 %d 次", m.modCalls)
+	}
+	if d.ttlPending {
+		t.Error("重写成功后应清除 TTL 待更新标记")
+	}
+}
+
+func TestRetryQueue_Reload(t *testing.T) {
+	q := newRetryQueue(DefaultRetryBackoff)
+	kept := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	removed := &Domain{Domain: "example.com", SubDomain: "old", Type: RecordTypeAAAA}
+	q.record(syncOutcome{domain: kept, provider: &mockProvider{}, err: errors.New("api error")})
+	q.record(syncOutcome{domain: removed, provider: &mockProvider{}, err: errors.New("api error")})
+
+	p := &mockProvider{}
+	if n := q.reload(map[*Domain]DNSProvider{kept: p}); n != 1 {
+		t.Errorf("应移除 1 个已删除的域名, 得到 %d", n)
+	}
+	if q.len() != 1 || q.entries[kept].provider != p {
+		t.Error("保留的域名应改用新的 DNSProvider")
+	}
+}
+
+func TestServiceStatus_SetProfiles(t *testing.T) {
+	kept := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	removed := &Domain{Domain: "example.com", SubDomain: "old", Type: RecordTypeAAAA}
+	s := newServiceStatus([]Profile{{Name: "home", Domains: []*Domain{kept, removed}}}, "polling", 0)
+	s.recordSync(syncOutcome{profile: "home", domain: kept, addr: net.ParseIP("2001:db8::1")})
+
+	added := &Domain{Domain: "example.com", SubDomain: "api", Type: RecordTypeAAAA}
+	s.setProfiles([]Profile{{Name: "home", Domains: []*Domain{added, kept}}})
+
+	r := s.report()
+	if len(r.Domains) != 2 || r.Domains[0].Domain != "api.example.com" || r.Domains[1].Domain != "www.example.com" {
+		t.Fatalf("域名列表应按新配置重建: %+v", r.Domains)
+	}
+	if r.Domains[1].Addr != "2001:db8::1" {
+		t.Errorf("保留的域名应保留同步状态: %+v", r.Domains[1])
+	}
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	defer func(d time.Duration) { configWatchInterval = d }(configWatchInterval)
+	configWatchInterval = 10 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("a: 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan struct{}, 1)
+	go watchConfigFile(ctx, path, ch)
+
+	time.Sleep(30 * time.Millisecond)
+	select {
+	case <-ch:
+		t.Fatal("文件未变化时不应触发重载")
+	default:
+	}
+
+	if err := os.WriteFile(path, []byte("a: 12\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatal("文件变化后应触发重载")
+	}
+}
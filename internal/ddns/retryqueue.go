@@ -0,0 +1,233 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/retry"
+)
+
+// DefaultRetryBackoff 同步失败后的默认重试退避策略：30 秒起，每次翻倍，最长 30 分钟。
+var DefaultRetryBackoff = retry.Backoff{Base: 30 * time.Second, Max: 30 * time.Minute}
+
+// syncOutcome 单个域名一次同步的结果。
+type syncOutcome struct {
+	profile  string
+	domain   *Domain
+	provider DNSProvider
+	addr     net.IP
+	changes  []recordChange // 对服务商记录做出的修改（修改或新增）
+	err      error
+}
+
+// retryEntry 重试队列中的一个待重试域名。
+type retryEntry struct {
+	syncOutcome
+	attempt  int       // 已失败的重试次数（首次失败为 0）
+	nextAt   time.Time // 下次重试时间
+	gen      uint64    // 入队代次，用于识别被新同步结果取代的重试
+	inFlight bool      // 是否正在重试
+}
+
+// retryQueue 同步失败域名的后台重试队列。
+//
+// 运行期同步失败的域名按指数退避重试，直到同步成功或被新的同步结果取代
+// （地址再次变化时的同步成功则出队，失败则按新地址重新开始退避）。
+// 队列的入队、重试和出队都会记录日志，附带当前队列长度。
+type retryQueue struct {
+	backoff retry.Backoff
+	mu      sync.Mutex
+	entries map[*Domain]*retryEntry
+	gen     uint64
+	wake    chan struct{}
+}
+
+// newRetryQueue 创建使用 backoff 策略的重试队列。
+func newRetryQueue(backoff retry.Backoff) *retryQueue {
+	return &retryQueue{
+		backoff: backoff,
+		entries: make(map[*Domain]*retryEntry),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// record 根据一次触发同步或对账的结果更新队列：失败入队（或刷新退避），成功出队。
+//
+// 因上下文取消（服务退出）导致的失败不入队；没有候选满足地址策略的失败不入队，
+// 并移除该域名已排队的旧地址，由地址变化后的同步重新处理。
+func (q *retryQueue) record(o syncOutcome) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	old, queued := q.entries[o.domain]
+	if o.err == nil {
+		if queued {
+			delete(q.entries, o.domain)
+			slog.Info("pending retry resolved by sync", "module", "ddns",
+				"profile", o.profile, "domain", o.domain.Domain, "subdomain", o.domain.SubDomain,
+				"type", o.domain.Type, "queue_size", len(q.entries))
+		}
+		return
+	}
+	if errors.Is(o.err, context.Canceled) {
+		return
+	}
+	if errors.Is(o.err, errNoCandidate) {
+		if queued {
+			delete(q.entries, o.domain)
+			slog.Info("pending retry dropped, no candidate satisfies policy", "module", "ddns",
+				"profile", o.profile, "domain", o.domain.Domain, "subdomain", o.domain.SubDomain,
+				"type", o.domain.Type, "queue_size", len(q.entries))
+		}
+		return
+	}
+
+	// 同一地址再次失败则累加退避，地址已变化则重新开始
+	attempt := 0
+	if queued && old.addr.Equal(o.addr) {
+		attempt = old.attempt + 1
+	}
+	q.push(o, attempt)
+}
+
+// push 以第 attempt 次退避入队（调用方必须持有 q.mu）。
+func (q *retryQueue) push(o syncOutcome, attempt int) {
+	q.gen++
+	delay := q.backoff.Delay(attempt)
+	q.entries[o.domain] = &retryEntry{
+		syncOutcome: o,
+		attempt:     attempt,
+		nextAt:      time.Now().Add(delay),
+		gen:         q.gen,
+	}
+	slog.Warn("sync failed, queued for retry", "module", "ddns",
+		"profile", o.profile, "domain", o.domain.Domain, "subdomain", o.domain.SubDomain,
+		"type", o.domain.Type, "addr", o.addr.String(),
+		"attempt", attempt+1, "retry_in", delay.Round(time.Second),
+		"queue_size", len(q.entries), "err", o.err)
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// reload 按热重载后的域名集合更新队列：移除已删除的域名，其余条目改用新的 DNSProvider。
+// providers 为当前所有域名到其服务商的映射，返回移除的条目数量。
+func (q *retryQueue) reload(providers map[*Domain]DNSProvider) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for d, e := range q.entries {
+		if p, ok := providers[d]; ok {
+			e.provider = p
+			continue
+		}
+		delete(q.entries, d)
+		n++
+		slog.Info("pending retry dropped, domain removed by reload", "module", "ddns",
+			"profile", e.profile, "domain", d.Domain, "subdomain", d.SubDomain,
+			"type", d.Type, "queue_size", len(q.entries))
+	}
+	return n
+}
+
+// len 返回队列中待重试的域名数量。
+func (q *retryQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// takeDue 取出所有到期且未在重试中的条目，并返回距下一个条目到期的等待时间。
+// 队列为空（或全部在重试中）时 ok 为 false。
+func (q *retryQueue) takeDue(now time.Time) (due []retryEntry, wait time.Duration, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, e := range q.entries {
+		if e.inFlight {
+			continue
+		}
+		if !e.nextAt.After(now) {
+			e.inFlight = true
+			due = append(due, *e)
+			continue
+		}
+		if d := e.nextAt.Sub(now); !ok || d < wait {
+			wait, ok = d, true
+		}
+	}
+	return due, wait, ok
+}
+
+// complete 记录一次重试的结果。条目已被新的同步结果取代时忽略。
+func (q *retryQueue) complete(e retryEntry, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cur, queued := q.entries[e.domain]
+	if !queued || cur.gen != e.gen {
+		return
+	}
+	if err == nil {
+		delete(q.entries, e.domain)
+		slog.Info("retry succeeded", "module", "ddns",
+			"profile", e.profile, "domain", e.domain.Domain, "subdomain", e.domain.SubDomain,
+			"type", e.domain.Type, "attempts", e.attempt+1, "queue_size", len(q.entries))
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		cur.inFlight = false
+		return
+	}
+	e.err = err
+	e.provider = cur.provider // 重试期间可能已热重载
+	q.push(e.syncOutcome, e.attempt+1)
+}
+
+// run 调度重试，直到 ctx 取消。fn 执行单个域名的重试同步。
+func (q *retryQueue) run(ctx context.Context, fn func(ctx context.Context, o syncOutcome) error) {
+	for {
+		due, wait, ok := q.takeDue(time.Now())
+		for _, e := range due {
+			slog.Info("retrying failed sync", "module", "ddns",
+				"profile", e.profile, "domain", e.domain.Domain, "subdomain", e.domain.SubDomain,
+				"type", e.domain.Type, "attempt", e.attempt+1)
+			q.complete(e, fn(ctx, e.syncOutcome))
+		}
+		if len(due) > 0 {
+			continue // 重试期间可能有新条目到期，重新计算等待时间
+		}
+
+		var timerC <-chan time.Time
+		if ok {
+			timerC = time.After(wait)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-timerC:
+		}
+	}
+}
+
+// retryRecord 对队列中的域名重新执行 SyncRecord。
+//
+// 同步失败时缓存地址被清空；若此时缓存地址非空，说明入队后已有其他同步
+// （新的触发或对账）成功更新了该域名，本次重试被取代，直接视为成功。
+func retryRecord(ctx context.Context, r syncOutcome) ([]recordChange, error) {
+	d := r.domain
+	d.lock()
+	defer d.unlock()
+	if d.Addr != nil {
+		slog.Debug("retry superseded by a later sync", "module", "ddns",
+			"domain", d.Domain, "subdomain", d.SubDomain, "type", d.Type, "addr", d.Addr.String())
+		return nil, nil
+	}
+	return syncRecordLocked(ctx, d, r.addr, r.provider)
+}
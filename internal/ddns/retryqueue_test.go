@@ -0,0 +1,179 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/retry"
+)
+
+// ============================================================
+// retryQueue 入队/出队测试
+// ============================================================
+
+func failedOutcome(d *Domain, addr string) syncOutcome {
+	return syncOutcome{profile: "default", domain: d, provider: &mockProvider{},
+		addr: net.ParseIP(addr), err: errors.New("api error")}
+}
+
+func TestRetryQueue_FailureQueuedSuccessRemoved(t *testing.T) {
+	q := newRetryQueue(retry.Backoff{Base: time.Hour})
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+
+	q.record(failedOutcome(d, "2001:db8::1"))
+	if q.len() != 1 {
+		t.Fatalf("失败的域名应入队, 队列长度 %d", q.len())
+	}
+
+	q.record(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::1")})
+	if q.len() != 0 {
+		t.Errorf("同步成功后应出队, 队列长度 %d", q.len())
+	}
+}
+
+func TestRetryQueue_CanceledNotQueued(t *testing.T) {
+	q := newRetryQueue(retry.Backoff{Base: time.Hour})
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+
+	o := failedOutcome(d, "2001:db8::1")
+	o.err = context.Canceled
+	q.record(o)
+	if q.len() != 0 {
+		t.Errorf("因退出取消的同步不应入队, 队列长度 %d", q.len())
+	}
+}
+
+func TestRetryQueue_AttemptResetOnNewAddr(t *testing.T) {
+	q := newRetryQueue(retry.Backoff{Base: time.Hour})
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+
+	q.record(failedOutcome(d, "2001:db8::1"))
+	q.record(failedOutcome(d, "2001:db8::1"))
+	if got := q.entries[d].attempt; got != 1 {
+		t.Errorf("同一地址再次失败 attempt 应为 1, 得到 %d", got)
+	}
+
+	q.record(failedOutcome(d, "2001:db8::2"))
+	e := q.entries[d]
+	if e.attempt != 0 || !e.addr.Equal(net.ParseIP("2001:db8::2")) {
+		t.Errorf("地址变化后应按新地址重新开始退避, 得到 attempt=%d addr=%v", e.attempt, e.addr)
+	}
+}
+
+func TestRetryQueue_CompleteReschedules(t *testing.T) {
+	q := newRetryQueue(retry.Backoff{Base: time.Minute, Max: time.Hour})
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	q.record(failedOutcome(d, "2001:db8::1"))
+
+	due, _, _ := q.takeDue(time.Now().Add(time.Hour))
+	if len(due) != 1 {
+		t.Fatalf("到期条目应为 1 个, 得到 %d", len(due))
+	}
+	if again, _, _ := q.takeDue(time.Now().Add(time.Hour)); len(again) != 0 {
+		t.Error("正在重试的条目不应再次取出")
+	}
+
+	q.complete(due[0], errors.New("still failing"))
+	e := q.entries[d]
+	if e.attempt != 1 || e.inFlight {
+		t.Errorf("重试失败后应递增 attempt 并重新调度, 得到 attempt=%d inFlight=%v", e.attempt, e.inFlight)
+	}
+	if wait := time.Until(e.nextAt); wait < time.Minute || wait > 2*time.Minute {
+		t.Errorf("第 2 次重试应在 [1m, 2m) 后, 得到 %v", wait)
+	}
+
+	due, _, _ = q.takeDue(time.Now().Add(time.Hour))
+	q.complete(due[0], nil)
+	if q.len() != 0 {
+		t.Errorf("重试成功后应出队, 队列长度 %d", q.len())
+	}
+}
+
+func TestRetryQueue_CompleteIgnoresSuperseded(t *testing.T) {
+	q := newRetryQueue(retry.Backoff{Base: time.Minute})
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	q.record(failedOutcome(d, "2001:db8::1"))
+	due, _, _ := q.takeDue(time.Now().Add(time.Hour))
+
+	// 重试进行中地址再次变化且同步失败
+	q.record(failedOutcome(d, "2001:db8::2"))
+	q.complete(due[0], nil)
+
+	e, ok := q.entries[d]
+	if !ok || !e.addr.Equal(net.ParseIP("2001:db8::2")) {
+		t.Error("旧地址的重试结果不应移除新地址的条目")
+	}
+}
+
+// ============================================================
+// retryQueue 调度测试
+// ============================================================
+
+func TestRetryQueue_RunRetriesUntilSuccess(t *testing.T) {
+	q := newRetryQueue(retry.Backoff{Base: time.Millisecond, Max: 10 * time.Millisecond})
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	m := &mockProvider{records: []RecordInfo{
+		{ID: "1", Name: "www.example.com", Type: "AAAA", Value: "2001:db8::1"},
+	}}
+	o := failedOutcome(d, "2001:db8::2")
+	o.provider = m
+	q.record(o)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.run(ctx, func(ctx context.Context, o syncOutcome) error {
+			_, err := retryRecord(ctx, o)
+			return err
+		})
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for q.len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if q.len() != 0 {
+		t.Fatal("重试成功后队列应为空")
+	}
+	if m.modCalls != 1 {
+		t.Errorf("应修改记录 1 次, 得到 %d", m.modCalls)
+	}
+	if d.Addr == nil || d.Addr.String() != "2001:db8::2" {
+		t.Errorf("Addr 应更新为 2001:db8::2, 得到 %v", d.Addr)
+	}
+}
+
+func TestRetryRecord_SupersededByLaterSync(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	d.CheckAndSetAddr(net.ParseIP("2001:db8::3")) // 入队后已有同步成功
+	m := &mockProvider{}
+	o := failedOutcome(d, "2001:db8::2")
+	o.provider = m
+
+	if _, err := retryRecord(context.Background(), o); err != nil {
+		t.Fatalf("被取代的重试不应返回错误: %v", err)
+	}
+	if m.getCalls != 0 || !d.Addr.Equal(net.ParseIP("2001:db8::3")) {
+		t.Error("被取代的重试不应调用服务商，也不应覆盖较新的地址")
+	}
+}
+
+func TestSyncRecord_FailureInvalidatesCache(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	d.CheckAndSetAddr(net.ParseIP("2001:db8::1"))
+	m := &mockProvider{getErr: errors.New("api error")}
+
+	if err := SyncRecord(context.Background(), d, net.ParseIP("2001:db8::2"), m); err == nil {
+		t.Fatal("GetRecords 失败时应返回错误")
+	}
+	if d.Addr != nil {
+		t.Errorf("同步失败后应清空缓存地址, 得到 %v", d.Addr)
+	}
+}
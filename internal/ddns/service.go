@@ -2,15 +2,20 @@
 //
 // 工作流程：
 //
-//	Linux: Netlink 事件监听 -> debounce 10s -> 获取 IPv6 -> 同步 DNS 记录
-//	其他:  cron 定时轮询 -> 获取 IPv6 -> 同步 DNS 记录
+//	Linux: Netlink 事件监听 -> debounce（见 WithTrigger）-> 获取地址 -> 同步 DNS 记录
+//	其他:  定时轮询 -> 获取地址 -> 同步 DNS 记录
 //
-// RunService 是唯一的公开入口，接受域名列表、DNS 服务商等参数。
-// 同一个进程可以管理同一根域名下的多个子域名。
+// 存在 A 记录（Domain.Type 为 "A"）时，同一流程中还会获取公网 IPv4 地址，
+// 并按记录类型分别同步（双栈）。对账、失败重试、通知、钩子、热重载、传播验证等
+// 行为通过 Option 启用，详见各 With* 选项。
+//
+// RunService 管理单个 DNS 服务商下的域名列表；RunProfiles 则在同一进程中
+// 管理多个 Profile（不同运营商、账号或根域名），共享一次地址获取和一个触发源。
 package ddns
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
@@ -21,6 +26,7 @@ import (
 	"time"
 
 	"github.com/notes-bin/ddns6/pkg/ipaddr"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 // DefaultIPv6Fetchers 默认的 IPv6 地址获取器列表。
@@ -37,6 +43,108 @@ var DefaultIPv6Fetchers = []ipaddr.IPv6Fetcher{
 	ipaddr.NewDnsFetcher("2606:4700:4700::1111"),
 }
 
+// DefaultIPv4Fetchers 默认的 IPv4 地址获取器列表，仅在存在 A 记录时使用。
+var DefaultIPv4Fetchers = []ipaddr.IPv4Fetcher{
+	ipaddr.NewHttpIPv4Fetcher("https://4.ipw.cn"),
+	ipaddr.NewHttpIPv4Fetcher("https://ipv4.icanhazip.com"),
+	ipaddr.NewHttpIPv4Fetcher("https://v4.ident.me"),
+	ipaddr.NewDnsIPv4Fetcher("223.5.5.5"),
+	ipaddr.NewDnsIPv4Fetcher("119.29.29.29"),
+	ipaddr.NewDnsIPv4Fetcher("8.8.8.8"),
+	ipaddr.NewDnsIPv4Fetcher("1.1.1.1"),
+}
+
+// Option RunService / RunProfiles 的可选配置项。
+type Option func(*serviceOptions)
+
+// serviceOptions RunService / RunProfiles 的可选配置集合。
+type serviceOptions struct {
+	ipv4Fetchers      []ipaddr.IPv4Fetcher
+	state             *StateStore
+	reconcileWindow   time.Duration
+	reconcileInterval time.Duration
+	retryBackoff      retry.Backoff
+	statusAddr        string
+	healthThreshold   time.Duration
+	notifier          Notifier
+	hooks             Hooks
+	shutdownTimeout   time.Duration
+	reload            ReloadFunc
+	watchFile         string
+	trigger           TriggerConfig
+	fetchPolicy       ipaddr.FetchPolicy
+	verify            *VerifyConfig
+}
+
+// WithIPv4Fetchers 设置 A 记录使用的 IPv4 地址获取器（默认 DefaultIPv4Fetchers）。
+func WithIPv4Fetchers(fetchers ...ipaddr.IPv4Fetcher) Option {
+	return func(o *serviceOptions) {
+		o.ipv4Fetchers = fetchers
+	}
+}
+
+// WithIPv6Quorum 启用 IPv6 地址获取的共识模式（q.N <= 1 表示最快者胜出，默认）。
+//
+// 启用后每次获取等待至少 q.N 个获取器的结果一致（完整地址或同一 /64 前缀），
+// 无法达成共识时按获取失败处理，不修改任何记录。
+func WithIPv6Quorum(q ipaddr.Quorum) Option {
+	return func(o *serviceOptions) {
+		o.fetchPolicy.Quorum = q
+	}
+}
+
+// WithFetchTimeout 设置每次地址获取的总超时时间（默认 ipaddr.DefaultFetchTimeout）。
+func WithFetchTimeout(d time.Duration) Option {
+	return func(o *serviceOptions) {
+		o.fetchPolicy.Timeout = d
+	}
+}
+
+// WithStateStore 启用状态持久化。
+//
+// 启动时，最近一次核对在 window 之内的持久化状态被直接用作缓存地址，
+// 地址未变化的域名不再调用 GetRecords；每次同步后将状态写回 store。
+func WithStateStore(store *StateStore, window time.Duration) Option {
+	return func(o *serviceOptions) {
+		o.state = store
+		o.reconcileWindow = window
+	}
+}
+
+// WithReconcileInterval 启用周期性漂移对账（interval <= 0 表示禁用，默认禁用）。
+//
+// 每隔 interval 重新获取地址，并对所有域名调用 ReconcileRecord：忽略内存缓存，
+// 通过 GetRecords 重新读取记录，修复被外部修改或删除的记录。
+func WithReconcileInterval(interval time.Duration) Option {
+	return func(o *serviceOptions) {
+		o.reconcileInterval = interval
+	}
+}
+
+// WithRetryBackoff 设置运行期同步失败后的重试退避策略（默认 DefaultRetryBackoff）。
+//
+// 失败的域名从 b.Base 开始按指数退避重试，间隔不超过 b.Max，不限次数，
+// 直到同步成功或该域名的地址再次变化（此时按新地址重新开始退避）。
+func WithRetryBackoff(b retry.Backoff) Option {
+	return func(o *serviceOptions) {
+		o.retryBackoff = b
+	}
+}
+
+// WithStatusServer 在 addr（如 127.0.0.1:9876）上启动 HTTP 状态端点（addr 为空表示不启动，默认不启动）。
+//
+//   - GET /status：各域名当前发布的地址、最近同步时间和错误、触发模式、地址获取器结果（JSON）
+//   - GET /healthz：任一域名持续同步失败超过 threshold 时返回 503，否则 200
+//   - GET /metrics：Prometheus 指标（同步次数、API 调用延迟、获取器结果、Netlink 事件等）
+//
+// threshold <= 0 时使用 DefaultHealthThreshold。
+func WithStatusServer(addr string, threshold time.Duration) Option {
+	return func(o *serviceOptions) {
+		o.statusAddr = addr
+		o.healthThreshold = threshold
+	}
+}
+
 // RunService 启动 DDNS 服务，持续监听 IPv6 地址变化并更新 DNS 记录。
 //
 // 参数:
@@ -44,53 +152,179 @@ var DefaultIPv6Fetchers = []ipaddr.IPv6Fetcher{
 //   - p: DNS 服务商实现
 //   - interval: 非 Linux 平台的轮询间隔（Linux 下由 Netlink 事件驱动，此参数无效）
 //   - fetchers: IPv6 地址获取器列表，每次触发时随机顺序逐个尝试
-//   - iface: 指定监听的网络接口（空字符串表示监听所有接口，仅 Linux Netlink 模式有效）
+//   - iface: 指定监听的网络接口（空字符串表示监听所有接口，仅 Linux Netlink 模式有效；WithTrigger 设置了接口名模式时以模式为准）
+//   - opts: 可选配置（如 WithIPv4Fetchers、WithStateStore、WithReconcileInterval、WithRetryBackoff、WithStatusServer、WithNotifier、WithHooks、WithShutdownTimeout、WithReload、WithTrigger、WithIPv6Quorum、WithFetchTimeout）
+//
+// 等价于只包含一个 Profile 的 RunProfiles，返回值和退出方式见 RunProfiles。
+func RunService(domains []*Domain, p DNSProvider, interval time.Duration, fetchers []ipaddr.IPv6Fetcher, iface string, opts ...Option) error {
+	return RunProfiles([]Profile{{Name: "default", Provider: p, Domains: domains}}, interval, fetchers, iface, opts...)
+}
+
+// RunProfiles 启动 DDNS 服务，为多个 Profile 持续同步 DNS 记录。
+//
+// 所有 Profile 共享同一次地址获取和同一个触发源（Netlink 或轮询），
+// 地址变化时各 Profile 使用各自的 DNSProvider 并发同步。
 //
 // 返回 error 仅在以下情况返回：
-//   - 首次启动获取 IPv6 地址失败
-//   - 首次同步 DNS 记录失败
+//   - profiles 为空
+//   - 启用共识模式（WithIPv6Quorum）但 IPv6 获取器数量不足
+//   - 状态端点监听失败
+//   - 首次启动获取 IPv6（或存在 A 记录时的 IPv4）地址失败
+//   - 任一 Profile 首次同步 DNS 记录失败
 //
-// 运行时错误（后续 Netlink 或轮询中的失败）仅记录日志，不影响服务运行。
+// 运行时错误（后续 Netlink 或轮询中的失败）仅记录日志，不影响服务运行；
+// 同步失败的域名进入重试队列按退避策略重试。
+// 启用热重载（WithReload）时，新配置无效只记录日志，当前配置继续运行。
 //
 // 退出方式：
 //   - 收到 SIGINT 或 SIGTERM 后优雅关闭
-//   - 先取消正在进行的操作，再等待最多 5 秒让当前同步完成
+//   - 先取消正在进行的操作，再等待进行中的同步和重试结束，
+//     最多等待 WithShutdownTimeout 设置的时间（默认 DefaultShutdownTimeout），超时时记录被中断的域名
 //   - 然后返回 nil
-func RunService(domains []*Domain, p DNSProvider, interval time.Duration, fetchers []ipaddr.IPv6Fetcher, iface string) error {
+func RunProfiles(profiles []Profile, interval time.Duration, fetchers []ipaddr.IPv6Fetcher, iface string, opts ...Option) error {
+	if len(profiles) == 0 {
+		return fmt.Errorf("no profiles to run")
+	}
+	o := serviceOptions{ipv4Fetchers: DefaultIPv4Fetchers, retryBackoff: DefaultRetryBackoff, shutdownTimeout: DefaultShutdownTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if q := o.fetchPolicy.Quorum; q.Enabled() && len(fetchers) < q.N {
+		return fmt.Errorf("IPv6 quorum of %d requires at least %d fetchers, got %d", q.N, q.N, len(fetchers))
+	}
+	profiles = instrumentProfiles(profiles)
+	attachHooks(profiles, o.hooks)
+	domains := allDomains(profiles)
+	needV4, needV6 := requiredFamilies(domains)
+
 	slog.Info("starting DDNS update service",
 		"module", "ddns",
+		"profile_count", len(profiles),
 		"domain_count", len(domains),
 		"interval", interval,
-		"interface", iface)
+		"interface", iface,
+		"ipv4", needV4, "ipv6", needV6)
 
-	// 创建一个可取消的 context 用于优雅关闭
-	// 收到 SIGTERM 时 cancel 会传播到所有正在进行的操作
+	// 优雅关闭使用两个 context：收到 SIGTERM 时 cancel 立即停止触发源、对账定时器、重试队列
+	// 和热重载等新工作；进行中的同步使用 syncCtx，仅在等待超时后取消
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	syncCtx, cancelSync := context.WithCancel(context.Background())
+	defer cancelSync()
 
 	// ============================================================
 	// 首次同步
 	// 启动时就进行一次完整的 IPv6 获取 + DNS 同步。
 	// 这样用户无需等待第一次 Netlink 事件或轮询周期。
 	// ============================================================
-	slog.Info("performing initial IPv6 address fetch", "module", "ddns")
-	ip, err := ipaddr.GetIPv6Addr(ctx, fetchers...)
+	if o.state != nil {
+		restoreState(profiles, o.state, o.reconcileWindow)
+	}
+
+	// 运行状态收集，状态端点在首次同步前启动，以便观察启动过程
+	status := newServiceStatus(profiles, platformTriggerMode(), o.healthThreshold)
+	var addrChanges addrChangeTracker
+	events := newEventEmitter(o.notifier, domains)
+	recordOutcome := func(r syncOutcome) {
+		status.recordSync(r)
+		observeSync(r)
+		events.outcome(r)
+	}
+	fetchers = status.wrapIPv6Fetchers(fetchers)
+	ipv4Fetchers := status.wrapIPv4Fetchers(o.ipv4Fetchers)
+	if o.statusAddr != "" {
+		stop, err := startStatusServer(o.statusAddr, status)
+		if err != nil {
+			return fmt.Errorf("cannot start status endpoint: %w", err)
+		}
+		defer stop()
+	}
+
+	slog.Info("performing initial address fetch", "module", "ddns")
+	addrs, err := fetchAddrs(syncCtx, needV4, needV6, policyEnabled(domains), ipv4Fetchers, fetchers, o.fetchPolicy)
+	status.recordFetch(addrs, err)
+	events.addrChanged(addrChanges.observe(addrs))
 	if err != nil {
-		return fmt.Errorf("initial IPv6 fetch failed: %w", err)
+		return fmt.Errorf("initial address fetch failed: %w", err)
 	}
-	slog.Info("initial IPv6 address obtained", "module", "ddns", "ipv6", ip.String())
+	slog.Info("initial address obtained", "module", "ddns", "ipv4", addrs.ipv4, "ipv6", addrs.ipv6)
 
-	// 并发同步所有子域名，任一失败则终止并返回第一个错误
-	if err := syncAllDomains(ctx, domains, ip, p, true); err != nil {
+	// 并发同步所有 Profile 的子域名，任一失败则终止并返回第一个错误
+	outcomes, err := syncProfiles(syncCtx, profiles, addrs, true)
+	if o.state != nil {
+		saveState(profiles, o.state)
+	}
+	for _, r := range outcomes {
+		recordOutcome(r)
+	}
+	if err != nil {
 		return err
 	}
 
+	// 按接口名模式监听多个接口时，撤回前检查本机所有接口
+	withdrawIface := iface
+	if len(o.trigger.Interfaces) > 0 {
+		withdrawIface = ""
+	}
+
 	// ============================================================
 	// 启动地址变化触发源
 	// Linux: Netlink 事件监听（实时）
 	// 其他: 定时轮询（简单可靠）
 	// ============================================================
-	triggerCh := startTrigger(ctx, interval, iface)
+	// 运行中的 Profile 集合，热重载时整体替换
+	set := newProfileSet(profiles)
+	trigger := o.trigger.normalize(iface)
+	triggerCh := startTrigger(ctx, interval, trigger, func() bool {
+		needV4, _ := set.families()
+		return needV4
+	}, status)
+
+	// 漂移对账定时器，未启用时 reconcileC 为 nil，select 永不命中
+	var reconcileC <-chan time.Time
+	if o.reconcileInterval > 0 {
+		ticker := time.NewTicker(o.reconcileInterval)
+		defer ticker.Stop()
+		reconcileC = ticker.C
+		slog.Info("drift reconciliation enabled", "module", "ddns", "reconcile_interval", o.reconcileInterval)
+	}
+
+	// 运行期的同步和重试都由 track 跟踪，优雅关闭时等待其结束
+	track := newSyncTracker()
+	syncFn, reconcileFn := track.wrap(syncRecord), track.wrap(reconcileRecord)
+
+	// 运行期同步失败的重试队列
+	retries := newRetryQueue(o.retryBackoff)
+	// 传播验证超时的域名清空缓存地址后入重试队列，重写记录
+	verifier := newPropagationVerifier(o.verify, func(r syncOutcome, err error) {
+		events.propagationTimeout(r, err)
+		if r.domain.markRewrite(r.addr) {
+			r.changes, r.err = nil, err
+			retries.record(r)
+		}
+	})
+	if o.verify != nil {
+		slog.Info("record propagation verification enabled", "module", "ddns",
+			"timeout", o.verify.Timeout, "interval", o.verify.Interval)
+	}
+	for _, r := range outcomes {
+		retries.record(r) // 首次同步中被 pre 钩子否决的域名
+		verifier.start(ctx, r)
+	}
+	track.goTask(func() {
+		retries.run(ctx, func(_ context.Context, r syncOutcome) error {
+			defer track.begin(r.domain)()
+			changes, err := retryRecord(syncCtx, r)
+			if o.state != nil {
+				profiles, _ := set.get()
+				saveState(profiles, o.state)
+			}
+			r.changes, r.err = changes, err
+			recordOutcome(r)
+			verifier.start(ctx, r)
+			return err
+		})
+	})
 
 	// ============================================================
 	// 信号处理
@@ -99,10 +333,23 @@ func RunService(domains []*Domain, p DNSProvider, interval time.Duration, fetche
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 
+	// 配置热重载：SIGHUP 或配置文件变化，未启用时 hupCh 和 reloadCh 为 nil，select 永不命中
+	var hupCh chan os.Signal
+	var reloadCh chan struct{}
+	if o.reload != nil {
+		hupCh = make(chan os.Signal, 1)
+		signal.Notify(hupCh, syscall.SIGHUP)
+		defer signal.Stop(hupCh)
+		if o.watchFile != "" {
+			reloadCh = make(chan struct{}, 1)
+			go watchConfigFile(ctx, o.watchFile, reloadCh)
+		}
+	}
+
 	slog.Info("ddns6 started successfully",
 		"module", "ddns",
 		"pid", os.Getpid(),
-		"domain_count", len(domains),
+		"profile_count", len(profiles),
 		"mode", platformTriggerMode())
 
 	// ============================================================
@@ -110,35 +357,121 @@ func RunService(domains []*Domain, p DNSProvider, interval time.Duration, fetche
 	// 等待触发器事件或退出信号。
 	// 同步操作通过 goroutine 解耦，确保 sigCh 始终可达。
 	// ============================================================
-	syncDoneCh := make(chan struct{}, 1)
+
+	// refresh 获取地址并执行一轮同步；reconcile=true 时忽略缓存核对服务商记录
+	refresh := func(reconcile bool) {
+		profiles, domains := set.get()
+		needV4, needV6 := requiredFamilies(domains)
+		addrs, err := fetchAddrs(syncCtx, needV4, needV6, policyEnabled(domains), ipv4Fetchers, fetchers, o.fetchPolicy)
+		if !errors.Is(err, context.Canceled) {
+			status.recordFetch(addrs, err)
+		}
+		events.addrChanged(addrChanges.observe(addrs))
+		if err != nil {
+			// 双栈时某一地址族失败不影响另一地址族的同步
+			slog.Error("failed to get address on trigger", "module", "ddns", "reconcile", reconcile, "err", err)
+		}
+		// 获取不到 IPv6 且本机已没有全局 IPv6 地址时，按撤回策略处理 AAAA 记录；
+		// 撤回失败的域名不入重试队列，下次触发时再次尝试
+		if needV6 && addrs.ipv6 == nil && syncCtx.Err() == nil && withdrawPending(domains) && localIPv6Gone(withdrawIface) {
+			slog.Warn("no global IPv6 address on local interfaces, withdrawing records", "module", "ddns", "interface", withdrawIface)
+			for _, r := range withdrawProfiles(syncCtx, profiles) {
+				if r.err == nil {
+					retries.record(r) // 丢弃按旧地址的待重试条目
+				}
+				recordOutcome(r)
+			}
+			if o.state != nil {
+				saveState(profiles, o.state)
+			}
+		}
+		if addrs.ipv4 != nil || addrs.ipv6 != nil {
+			var outcomes []syncOutcome
+			if reconcile {
+				outcomes, _ = applyProfiles(syncCtx, profiles, addrs, reconcileFn, false)
+			} else {
+				outcomes, _ = applyProfiles(syncCtx, profiles, addrs, syncFn, false)
+			}
+			if o.state != nil {
+				saveState(profiles, o.state)
+			}
+			for _, r := range outcomes {
+				retries.record(r)
+				recordOutcome(r)
+				verifier.start(ctx, r)
+			}
+		}
+	}
+
+	// reload 重新加载配置并与运行中的域名合并，新配置无效时保持当前配置；
+	// 域名有变化时立即执行一轮同步
+	var reloadMu sync.Mutex
+	reload := func() {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
+		next, err := o.reload()
+		if err == nil && len(next) == 0 {
+			err = fmt.Errorf("no profiles to run")
+		}
+		if err != nil {
+			slog.Error("config reload rejected, keeping current config", "module", "ddns", "err", err)
+			return
+		}
+		next = instrumentProfiles(next)
+		attachHooks(next, o.hooks)
+		cur, _ := set.get()
+		merged, diff := mergeProfiles(cur, next)
+		if o.state != nil && len(diff.added) > 0 {
+			restoreState(addedProfiles(merged, diff.added), o.state, o.reconcileWindow)
+		}
+
+		set.set(merged)
+		status.setProfiles(merged)
+		_, domains := set.get()
+		events.setDomains(domains)
+		providers := make(map[*Domain]DNSProvider, len(domains))
+		for _, pr := range merged {
+			for _, d := range pr.Domains {
+				providers[d] = pr.Provider
+			}
+		}
+		retries.reload(providers)
+
+		slog.Info("config reloaded", "module", "ddns",
+			"profile_count", len(merged), "domain_count", len(domains),
+			"added", domainNames(diff.added), "removed", domainNames(diff.removed),
+			"ttl_updated", domainNames(diff.updated))
+		if diff.changed() {
+			refresh(false)
+		}
+	}
 
 	for {
 		select {
 		case <-triggerCh:
-			// 触发器事件：异步获取 IPv6 并同步，不阻塞信号接收
-			go func() {
-				ip, err := ipaddr.GetIPv6Addr(ctx, fetchers...)
-				if err != nil {
-					slog.Error("failed to get IPv6 address on trigger", "module", "ddns", "err", err)
-					syncDoneCh <- struct{}{}
-					return
-				}
-				syncAllDomains(ctx, domains, ip, p, false)
-				syncDoneCh <- struct{}{}
-			}()
+			// 触发器事件：异步获取地址并同步，不阻塞信号接收
+			track.goTask(func() { refresh(false) })
+
+		case <-reconcileC:
+			// 对账定时器：异步重新读取服务商记录并修复漂移
+			slog.Debug("starting drift reconciliation", "module", "ddns")
+			track.goTask(func() { refresh(true) })
 
-		case <-syncDoneCh:
-			// 同步完成，继续等待下一个事件
+		case <-hupCh:
+			slog.Info("SIGHUP received, reloading config", "module", "ddns")
+			track.goTask(reload)
+
+		case <-reloadCh:
+			track.goTask(reload)
 
 		case <-sigCh:
 			// 收到退出信号，开始优雅关闭
-			slog.Info("shutdown signal received, initiating graceful shutdown...", "module", "ddns")
-			cancel() // 取消正在进行的操作
-
-			// 等待最多 5 秒让进行中的同步操作完成
-			select {
-			case <-time.After(5 * time.Second):
-				slog.Warn("graceful shutdown timed out", "module", "ddns")
+			slog.Info("shutdown signal received, initiating graceful shutdown...", "module", "ddns",
+				"timeout", o.shutdownTimeout)
+			// 停止接收新工作，等待进行中的同步结束，全部结束后立即返回
+			if interrupted, ok := gracefulShutdown(track, o.shutdownTimeout, cancel, cancelSync); !ok {
+				slog.Warn("graceful shutdown timed out, in-flight syncs interrupted", "module", "ddns",
+					"timeout", o.shutdownTimeout, "interrupted", interrupted)
 			}
 
 			signal.Stop(sigCh)
@@ -149,38 +482,203 @@ func RunService(domains []*Domain, p DNSProvider, interval time.Duration, fetche
 	}
 }
 
-// syncAllDomains 并发同步所有域名的 DNS 记录。
+// addrSet 一次获取得到的各地址族地址，nil 表示未获取或获取失败。
+type addrSet struct {
+	ipv4 net.IP
+	ipv6 net.IP
+
+	// ipv6Candidates 存在地址策略时获取到的全部 IPv6 候选（按到达顺序，ipv6 为其中第一个）
+	ipv6Candidates []net.IP
+}
+
+// forType 返回记录类型对应的地址（A -> IPv4，其余 -> IPv6）。
+func (a addrSet) forType(recordType string) net.IP {
+	if recordType == RecordTypeA {
+		return a.ipv4
+	}
+	return a.ipv6
+}
+
+// targetAddr 返回域名应发布的地址，对应地址族未获取到（或没有候选通过地址策略）时返回 nil。
 //
-// failFast=true 时遇错立即返回第一个错误；failFast=false 时遇错只记日志继续处理剩余域名。
-func syncAllDomains(ctx context.Context, domains []*Domain, ip net.IP, p DNSProvider, failFast bool) error {
-	var wg sync.WaitGroup
-	errCh := make(chan error, len(domains))
+// 设置了 Policy 的 AAAA 记录从全部候选中按策略选择；
+// 设置了 Suffix 的 AAAA 记录发布由本机 IPv6 地址前缀与 Suffix 组合得到的局域网主机地址。
+func (d *Domain) targetAddr(addrs addrSet) net.IP {
+	ip := addrs.forType(d.Type)
+	if ip == nil || d.Type == RecordTypeA {
+		return ip
+	}
+	if len(addrs.ipv6Candidates) > 0 {
+		if ip = d.selectAddr(addrs.ipv6Candidates); ip == nil {
+			return nil
+		}
+	}
+	if d.Suffix == nil {
+		return ip
+	}
+	prefixLen := d.PrefixLength
+	if prefixLen <= 0 {
+		prefixLen = ipaddr.DefaultPrefixLength
+	}
+	return ipaddr.HostAddr(ip, prefixLen, d.Suffix)
+}
+
+// requiredFamilies 根据域名的记录类型判断需要获取哪些地址族。
+func requiredFamilies(domains []*Domain) (needV4, needV6 bool) {
 	for _, d := range domains {
+		if d.Type == RecordTypeA {
+			needV4 = true
+		} else {
+			needV6 = true
+		}
+	}
+	return needV4, needV6
+}
+
+// fetchAddrs 按需获取 IPv4 / IPv6 地址。
+//
+// 两个地址族相互独立：某一族失败时，另一族的结果仍会返回，错误通过 errors.Join 合并。
+// 总超时和 IPv6 共识模式按 policy 执行（未启用共识时最快者胜出）。
+// candidates=true（存在地址策略）时等待所有 IPv6 获取器，保留全部候选地址。
+func fetchAddrs(ctx context.Context, needV4, needV6, candidates bool, v4 []ipaddr.IPv4Fetcher, v6 []ipaddr.IPv6Fetcher, policy ipaddr.FetchPolicy) (addrSet, error) {
+	var addrs addrSet
+	var errs []error
+	if needV6 && candidates {
+		ips, err := ipaddr.CollectIPv6(ctx, policy, v6...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("IPv6: %w", err))
+		} else {
+			addrs.ipv6, addrs.ipv6Candidates = ips[0], ips
+		}
+	} else if needV6 {
+		ip, err := ipaddr.FetchIPv6(ctx, policy, v6...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("IPv6: %w", err))
+		}
+		addrs.ipv6 = ip
+	}
+	if needV4 {
+		ip, err := ipaddr.FetchIPv4(ctx, policy, v4...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("IPv4: %w", err))
+		}
+		addrs.ipv4 = ip
+	}
+	return addrs, errors.Join(errs...)
+}
+
+// instrumentProfiles 返回 profiles 的副本，其中每个 DNSProvider 被包装以记录 API 调用指标。
+func instrumentProfiles(profiles []Profile) []Profile {
+	out := make([]Profile, len(profiles))
+	for i, pr := range profiles {
+		pr.Provider = instrumentProvider(pr.Provider)
+		out[i] = pr
+	}
+	return out
+}
+
+// attachHooks 为每个 Profile 的域名设置钩子执行器。
+func attachHooks(profiles []Profile, h Hooks) {
+	for _, pr := range profiles {
+		r := newHookRunner(h, pr.Name)
+		for _, d := range pr.Domains {
+			d.hooks = r
+		}
+	}
+}
+
+// allDomains 返回所有 Profile 的域名列表。
+func allDomains(profiles []Profile) []*Domain {
+	var domains []*Domain
+	for _, pr := range profiles {
+		domains = append(domains, pr.Domains...)
+	}
+	return domains
+}
+
+// recordSyncFunc 单个域名的同步函数（syncRecord 或 reconcileRecord），返回对服务商记录做出的修改。
+type recordSyncFunc func(ctx context.Context, d *Domain, addr net.IP, p DNSProvider) ([]recordChange, error)
+
+// syncProfiles 并发同步所有 Profile 的 DNS 记录，每个 Profile 使用自己的 DNSProvider。
+//
+// failFast 语义与 syncAllDomains 相同；failFast=true 时返回的错误带有 profile 名称。
+func syncProfiles(ctx context.Context, profiles []Profile, addrs addrSet, failFast bool) ([]syncOutcome, error) {
+	return applyProfiles(ctx, profiles, addrs, syncRecord, failFast)
+}
+
+// applyProfiles 并发地对每个 Profile 的域名执行 fn（如 syncRecord 或 reconcileRecord），
+// 返回所有域名的同步结果。failFast=true 时返回的错误带有 profile 名称。
+func applyProfiles(ctx context.Context, profiles []Profile, addrs addrSet, fn recordSyncFunc, failFast bool) ([]syncOutcome, error) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var outcomes []syncOutcome
+	errCh := make(chan error, len(profiles))
+	for _, pr := range profiles {
 		wg.Add(1)
-		go func(domain *Domain) {
+		go func(pr Profile) {
 			defer wg.Done()
-			if err := SyncRecord(ctx, domain, ip, p); err != nil {
-				if failFast {
-					errCh <- fmt.Errorf("sync failed for %s/%s: %w",
-						domain.Domain, domain.SubDomain, err)
-				} else {
-					slog.Error("sync failed on trigger",
-						"module", "ddns",
-						"domain", domain.Domain, "subdomain", domain.SubDomain, "err", err)
-				}
+			results, err := syncAllDomains(ctx, pr, addrs, fn, failFast)
+			mu.Lock()
+			outcomes = append(outcomes, results...)
+			mu.Unlock()
+			if err != nil {
+				errCh <- fmt.Errorf("profile %s: %w", pr.Name, err)
 			}
-		}(d)
+		}(pr)
 	}
 	wg.Wait()
 	close(errCh)
-	if failFast {
-		for err := range errCh {
-			if err != nil {
-				return err
+	return outcomes, <-errCh
+}
+
+// syncAllDomains 并发地对 Profile 的所有域名执行 fn（SyncRecord 或 ReconcileRecord）。
+//
+// 每个域名使用与其记录类型对应的地址（局域网主机为组合出的主机地址），该地址族未获取到时跳过；
+// 获取到了但没有候选地址满足域名的地址策略时，该域名记为失败（errNoCandidate）。
+// 返回每个已处理域名的同步结果。failFast=true 时还返回第一个错误（地址策略和 pre 钩子导致的失败除外）；
+// failFast=false 时遇错只记日志继续处理剩余域名。
+func syncAllDomains(ctx context.Context, pr Profile, addrs addrSet, fn recordSyncFunc, failFast bool) ([]syncOutcome, error) {
+	var wg sync.WaitGroup
+	resultCh := make(chan syncOutcome, len(pr.Domains))
+	for _, d := range pr.Domains {
+		ip := d.targetAddr(addrs)
+		if ip == nil && addrs.forType(d.Type) == nil {
+			slog.Debug("no address for record type, skipping", "module", "ddns",
+				"domain", d.Domain, "subdomain", d.SubDomain, "type", d.Type)
+			continue
+		}
+		if ip == nil {
+			// selectAddr 已记录被策略排除的候选
+			resultCh <- syncOutcome{profile: pr.Name, domain: d, provider: pr.Provider, err: noAddrError(d, addrs, nil)}
+			continue
+		}
+		wg.Add(1)
+		go func(domain *Domain, ip net.IP) {
+			defer wg.Done()
+			changes, err := fn(ctx, domain, ip, pr.Provider)
+			if err != nil && !failFast {
+				slog.Error("sync failed on trigger",
+					"module", "ddns",
+					"domain", domain.Domain, "subdomain", domain.SubDomain, "err", err)
 			}
+			resultCh <- syncOutcome{profile: pr.Name, domain: domain, provider: pr.Provider, addr: ip, changes: changes, err: err}
+		}(d, ip)
+	}
+	wg.Wait()
+	close(resultCh)
+
+	var outcomes []syncOutcome
+	var firstErr error
+	for r := range resultCh {
+		outcomes = append(outcomes, r)
+		// pre 钩子否决的更新不终止启动，由重试队列稍后重试；没有满足地址策略的候选时等待地址变化
+		if failFast && r.err != nil && firstErr == nil &&
+			!errors.Is(r.err, ErrUpdateVetoed) && !errors.Is(r.err, errNoCandidate) {
+			firstErr = fmt.Errorf("sync failed for %s/%s: %w",
+				r.domain.Domain, r.domain.SubDomain, r.err)
 		}
 	}
-	return nil
+	return outcomes, firstErr
 }
 
 // pollingLoop 定时轮询，向 triggerCh 发送信号。
@@ -5,29 +5,25 @@ package ddns
 import (
 	"context"
 	"log/slog"
-	"net"
 	"time"
 
 	"github.com/vishvananda/netlink"
 )
 
-// debounceDuration Netlink 事件的防抖等待时间。
-//
-// PPPoE 重拨场景下，新地址可能不会立即稳定：
-//   - 重拨后内核下发新地址（RTM_NEWADDR）
-//   - 但 SLAAC 可能还在进行中（临时地址生成）
-//   - 10 秒的窗口足够让绝大多数场景稳定
-const debounceDuration = 10 * time.Second
-
 // startTrigger 启动 Netlink 地址监听器。
 //
-// 监听内核 RTM_NEWADDR 事件，当检测到新的全局单播 IPv6 地址时，
-// 触发同步操作。通过 debounce 机制合并短时间内的多个事件。
+// 监听内核 RTM_NEWADDR 和 RTM_DELADDR 事件，当全局单播 IPv6 地址出现、变化（如前缀弃用）
+// 或被删除（含有效生命周期到期）时触发同步操作。通过 debounce 机制合并短时间内的多个事件，
+// PPPoE 重拨时的删除和新增通常合并为一次同步；地址删除后无新地址时，
+// 同步流程按域名的撤回策略（Domain.OnWithdraw）处理记录。
 //
-// 如果 iface 不为空，只处理该接口的地址事件。
+// 每个事件将防抖计时器重置为 cfg.Debounce，但自第一个事件起最多等待 cfg.MaxWait，
+// 持续不断的事件不会无限推迟同步。事件过滤规则见 addrFilter.match。
+// watchIPv4 返回 true 时（存在 A 记录），全局单播 IPv4 地址事件同样触发同步；
+// 每个事件都会重新调用，热重载增删 A 记录后无需重建订阅。
 //
-// 如果 Netlink 订阅失败（如权限不足），回退到定时轮询模式。
-func startTrigger(ctx context.Context, interval time.Duration, iface string) <-chan struct{} {
+// 如果 Netlink 订阅失败（如权限不足），回退到定时轮询模式，并在 status 中记录实际的触发模式。
+func startTrigger(ctx context.Context, interval time.Duration, cfg TriggerConfig, watchIPv4 func() bool, status *serviceStatus) <-chan struct{} {
 	triggerCh := make(chan struct{}, 1)
 
 	go func() {
@@ -37,31 +33,18 @@ func startTrigger(ctx context.Context, interval time.Duration, iface string) <-c
 			// Netlink 订阅失败（如权限不足），回退到定时轮询
 			slog.Warn("netlink subscribe failed, falling back to polling",
 				"module", "ddns", "err", err, "fallback_interval", interval)
-			fallbackPolling(ctx, triggerCh, interval)
+			fallbackPolling(ctx, triggerCh, interval, status)
 			return
 		}
 
 		slog.Info("netlink address listener started",
-			"module", "ddns", "interface", iface, "debounce", debounceDuration)
-
-		// 解析目标接口索引（如果指定了接口名）
-		var targetIndex int
-		if iface != "" {
-			ifi, err := net.InterfaceByName(iface)
-			if err != nil {
-				slog.Error("failed to resolve interface name, falling back to polling",
-					"module", "ddns", "interface", iface, "err", err,
-					"fallback_interval", interval)
-				fallbackPolling(ctx, triggerCh, interval)
-				return
-			}
-			targetIndex = ifi.Index
-			slog.Debug("filtering netlink events by interface",
-				"module", "ddns", "interface", iface, "index", targetIndex)
-		}
+			"module", "ddns", "interfaces", cfg.Interfaces, "include_ula", cfg.IncludeULA,
+			"debounce", cfg.Debounce, "max_wait", cfg.MaxWait)
 
+		filter := newAddrFilter(cfg, watchIPv4)
 		var debounceTimer *time.Timer
 		var timerC <-chan time.Time
+		var firstEvent time.Time // 本轮防抖的第一个事件时间
 
 		for {
 			select {
@@ -69,38 +52,19 @@ func startTrigger(ctx context.Context, interval time.Duration, iface string) <-c
 				if !ok {
 					slog.Warn("netlink update channel closed, falling back to polling",
 						"module", "ddns", "fallback_interval", interval)
-					fallbackPolling(ctx, triggerCh, interval)
+					fallbackPolling(ctx, triggerCh, interval, status)
 					return
 				}
-
-				// 只处理新地址事件（忽略地址删除事件）
-				if !update.NewAddr {
-					continue
-				}
-
-				// 必须是有效的网络层地址
-				if update.LinkAddress.IP == nil {
-					continue
-				}
-
-				// 必须是 IPv6 地址（忽略 IPv4 事件）
-				if update.LinkAddress.IP.To4() != nil {
-					continue
-				}
-
-				// 必须是全局单播地址（忽略 link-local、ULA 等）
-				if !update.LinkAddress.IP.IsGlobalUnicast() {
+				if !filter.match(update) {
 					continue
 				}
 
-				// 如果指定了接口，只处理该接口的事件
-				if targetIndex > 0 && update.LinkIndex != targetIndex {
-					continue
-				}
-
-				// 符合条件的新 IPv6 地址 - 重置 debounce 计时器
+				// 符合条件的地址事件 - 重置 debounce 计时器（不超过最长等待时间）
+				netlinkEventsReceived.WithLabelValues().Inc()
+				now := time.Now()
 				if debounceTimer == nil {
-					debounceTimer = time.NewTimer(debounceDuration)
+					firstEvent = now
+					debounceTimer = time.NewTimer(debounceWait(cfg, firstEvent, now))
 					timerC = debounceTimer.C
 				} else {
 					if !debounceTimer.Stop() {
@@ -110,19 +74,21 @@ func startTrigger(ctx context.Context, interval time.Duration, iface string) <-c
 						default:
 						}
 					}
-					debounceTimer.Reset(debounceDuration)
+					debounceTimer.Reset(debounceWait(cfg, firstEvent, now))
 				}
 
 				evtLog := slog.With(
 					"interface_index", update.LinkIndex,
 					"addr", update.LinkAddress.IP.String(),
+					"new", update.NewAddr,
 				)
-				evtLog.Debug("IPv6 address change detected, debounce timer reset")
+				evtLog.Debug("address change detected, debounce timer reset")
 
 			case <-timerC:
-				// Debounce 时间到，地址已稳定 - 触发同步
+				// Debounce 时间到，地址已稳定（或已达最长等待时间）- 触发同步
 				slog.Debug("debounce timer expired, triggering DNS sync",
-					"module", "ddns")
+					"module", "ddns", "waited", time.Since(firstEvent))
+				netlinkEventsDebounced.WithLabelValues().Inc()
 				select {
 				case triggerCh <- struct{}{}:
 				default:
@@ -144,8 +110,80 @@ func startTrigger(ctx context.Context, interval time.Duration, iface string) <-c
 	return triggerCh
 }
 
+// addrFilter 判断 Netlink 地址事件是否需要触发同步。
+type addrFilter struct {
+	includeULA bool
+	patterns   []string
+	watchIPv4  func() bool
+	linkName   func(index int) (string, bool) // 按接口索引查询接口名
+	names      map[int]string                 // 接口索引到名称的缓存，接口删除后的事件仍能匹配
+}
+
+// newAddrFilter 按 cfg 创建事件过滤器，cfg 须已填充默认值。
+func newAddrFilter(cfg TriggerConfig, watchIPv4 func() bool) *addrFilter {
+	return &addrFilter{
+		includeULA: cfg.IncludeULA,
+		patterns:   cfg.Interfaces,
+		watchIPv4:  watchIPv4,
+		linkName:   linkNameByIndex,
+		names:      make(map[int]string),
+	}
+}
+
+// match 返回事件 u 是否需要触发同步：
+//   - 必须是有效的网络层地址
+//   - 必须是 IPv6 地址，或在需要 A 记录时为 IPv4 地址
+//   - 必须是全局单播地址（忽略 link-local 等），ULA 仅在 includeULA 时接受，
+//     私有 IPv4 地址（RFC 1918，如局域网 DHCP 续租）总是忽略
+//   - 配置了接口模式时，事件所属接口的名称必须匹配其中之一
+func (f *addrFilter) match(u netlink.AddrUpdate) bool {
+	ip := u.LinkAddress.IP
+	if ip == nil {
+		return false
+	}
+	if ip.To4() != nil && !f.watchIPv4() {
+		return false
+	}
+	if !ip.IsGlobalUnicast() {
+		return false
+	}
+	if ip.IsPrivate() && (ip.To4() != nil || !f.includeULA) {
+		return false
+	}
+	if len(f.patterns) == 0 {
+		return true
+	}
+	name, ok := f.interfaceName(u.LinkIndex)
+	if !ok {
+		slog.Debug("cannot resolve interface of address event", "module", "ddns",
+			"interface_index", u.LinkIndex, "addr", ip.String())
+		return false
+	}
+	return matchInterface(name, f.patterns)
+}
+
+// interfaceName 返回接口索引对应的名称，查询失败（如接口已删除）时使用缓存。
+func (f *addrFilter) interfaceName(index int) (string, bool) {
+	if name, ok := f.linkName(index); ok {
+		f.names[index] = name
+		return name, true
+	}
+	name, ok := f.names[index]
+	return name, ok
+}
+
+// linkNameByIndex 通过 Netlink 查询接口索引对应的接口名。
+func linkNameByIndex(index int) (string, bool) {
+	link, err := netlink.LinkByIndex(index)
+	if err != nil {
+		return "", false
+	}
+	return link.Attrs().Name, true
+}
+
 // fallbackPolling 在 Netlink 不可用时使用定时轮询。
-func fallbackPolling(ctx context.Context, triggerCh chan<- struct{}, interval time.Duration) {
+func fallbackPolling(ctx context.Context, triggerCh chan<- struct{}, interval time.Duration, status *serviceStatus) {
+	status.setTriggerMode("polling")
 	slog.Info("using polling mode as fallback", "module", "ddns", "interval", interval)
 	pollingLoop(ctx, triggerCh, interval)
 }
@@ -0,0 +1,100 @@
+//go:build linux
+
+package ddns
+
+import (
+	"net"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+)
+
+// ============================================================
+// Netlink 事件过滤测试（不需要 root 权限）
+// ============================================================
+
+// addrUpdate 构造接口 index 上地址 addr 的事件。
+func addrUpdate(index int, addr string, isNew bool) netlink.AddrUpdate {
+	ip := net.ParseIP(addr)
+	var u netlink.AddrUpdate
+	u.LinkIndex = index
+	u.NewAddr = isNew
+	u.LinkAddress = net.IPNet{IP: ip}
+	return u
+}
+
+// testAddrFilter 创建使用固定接口表的过滤器，links 中不存在的索引视为已删除的接口。
+func testAddrFilter(cfg TriggerConfig, watchIPv4 bool, links map[int]string) *addrFilter {
+	f := newAddrFilter(cfg.normalize(""), func() bool { return watchIPv4 })
+	f.linkName = func(index int) (string, bool) {
+		name, ok := links[index]
+		return name, ok
+	}
+	return f
+}
+
+func TestAddrFilter_AddressKinds(t *testing.T) {
+	f := testAddrFilter(TriggerConfig{}, false, nil)
+	cases := []struct {
+		addr string
+		want bool
+	}{
+		{"2001:db8::1", true},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::1", false},
+		{"203.0.113.1", false},
+	}
+	for _, c := range cases {
+		if got := f.match(addrUpdate(2, c.addr, true)); got != c.want {
+			t.Errorf("match(%s) = %v, 期望 %v", c.addr, got, c.want)
+		}
+	}
+	if f.match(netlink.AddrUpdate{LinkIndex: 2}) {
+		t.Error("没有地址的事件不应触发同步")
+	}
+
+	// 删除事件同样触发同步
+	if !f.match(addrUpdate(2, "2001:db8::1", false)) {
+		t.Error("全局地址删除事件应触发同步")
+	}
+}
+
+func TestAddrFilter_IncludeULAAndIPv4(t *testing.T) {
+	f := testAddrFilter(TriggerConfig{IncludeULA: true}, true, nil)
+	if !f.match(addrUpdate(2, "fd00::1", true)) {
+		t.Error("启用 IncludeULA 时 ULA 地址事件应触发同步")
+	}
+	if !f.match(addrUpdate(2, "203.0.113.1", true)) {
+		t.Error("存在 A 记录时 IPv4 地址事件应触发同步")
+	}
+	if f.match(addrUpdate(2, "fe80::1", true)) {
+		t.Error("link-local 地址事件不应触发同步")
+	}
+	for _, addr := range []string{"192.168.1.10", "10.0.0.2", "172.16.5.1"} {
+		if f.match(addrUpdate(2, addr, true)) {
+			t.Errorf("私有 IPv4 地址 %s 的事件不应触发同步", addr)
+		}
+	}
+}
+
+func TestAddrFilter_InterfacePatterns(t *testing.T) {
+	links := map[int]string{2: "eth0", 5: "ppp0", 6: "ppp1"}
+	f := testAddrFilter(TriggerConfig{Interfaces: []string{"ppp*"}}, false, links)
+
+	if f.match(addrUpdate(2, "2001:db8::1", true)) {
+		t.Error("不匹配模式的接口不应触发同步")
+	}
+	if !f.match(addrUpdate(5, "2001:db8::1", true)) || !f.match(addrUpdate(6, "2001:db8::2", true)) {
+		t.Error("匹配 ppp* 的接口应触发同步")
+	}
+	if f.match(addrUpdate(9, "2001:db8::1", true)) {
+		t.Error("无法解析的接口不应触发同步")
+	}
+
+	// 接口删除后（如 PPPoE 断线）仍按缓存的名称匹配地址删除事件
+	delete(links, 5)
+	if !f.match(addrUpdate(5, "2001:db8::1", false)) {
+		t.Error("已删除接口的地址删除事件应按缓存名称匹配")
+	}
+}
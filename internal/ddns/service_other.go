@@ -12,7 +12,7 @@ import (
 //
 // 非 Linux 平台不支持 Netlink，使用 time.NewTicker 定期检查地址变化。
 // interval 由用户通过 --interval 参数控制，默认 5 分钟。
-func startTrigger(ctx context.Context, interval time.Duration, _ string) <-chan struct{} {
+func startTrigger(ctx context.Context, interval time.Duration, _ TriggerConfig, _ func() bool, _ *serviceStatus) <-chan struct{} {
 	triggerCh := make(chan struct{}, 1)
 
 	go func() {
@@ -0,0 +1,103 @@
+package ddns
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultShutdownTimeout 收到退出信号后等待进行中同步完成的默认最长时间。
+const DefaultShutdownTimeout = 5 * time.Second
+
+// WithShutdownTimeout 设置收到退出信号后等待进行中同步完成的最长时间（默认 DefaultShutdownTimeout）。
+//
+// 收到信号后不再开始新的同步，进行中的同步继续执行；全部完成后立即返回，
+// 超时时取消仍在进行的服务商 API 调用并记录被中断的域名。
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(o *serviceOptions) {
+		o.shutdownTimeout = d
+	}
+}
+
+// syncTracker 跟踪后台同步任务和正在同步的域名，用于优雅关闭时等待其完成。
+type syncTracker struct {
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	active map[*Domain]int // 正在同步的域名及其并发同步数
+}
+
+// newSyncTracker 创建同步跟踪器。
+func newSyncTracker() *syncTracker {
+	return &syncTracker{active: make(map[*Domain]int)}
+}
+
+// goTask 在新 goroutine 中执行 fn，并在 wait 中等待其结束。
+func (t *syncTracker) goTask(fn func()) {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		fn()
+	}()
+}
+
+// begin 标记域名 d 开始同步，返回结束标记函数。
+func (t *syncTracker) begin(d *Domain) (end func()) {
+	t.mu.Lock()
+	t.active[d]++
+	t.mu.Unlock()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.active[d]--; t.active[d] <= 0 {
+			delete(t.active, d)
+		}
+	}
+}
+
+// wrap 返回标记域名同步期间的 fn。
+func (t *syncTracker) wrap(fn recordSyncFunc) recordSyncFunc {
+	return func(ctx context.Context, d *Domain, addr net.IP, p DNSProvider) ([]recordChange, error) {
+		defer t.begin(d)()
+		return fn(ctx, d, addr, p)
+	}
+}
+
+// gracefulShutdown 先调用 stopWork 停止接收新的同步（触发源、对账定时器、重试队列、热重载），
+// 再等待进行中的同步结束，最多 timeout。
+//
+// 进行中的同步使用的 context 不随 stopWork 取消，可以正常完成；超时后才调用 cancelSync
+// 中止服务商 API 调用，并返回此时仍在同步的域名。
+func gracefulShutdown(track *syncTracker, timeout time.Duration, stopWork, cancelSync context.CancelFunc) (interrupted []string, ok bool) {
+	stopWork()
+	interrupted, ok = track.wait(timeout)
+	if !ok {
+		cancelSync()
+	}
+	return interrupted, ok
+}
+
+// wait 等待所有任务结束，最多 timeout。超时时返回仍在同步的域名（如 www.example.com/AAAA）。
+func (t *syncTracker) wait(timeout time.Duration) (interrupted []string, ok bool) {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil, true
+	case <-timer.C:
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for d := range t.active {
+		interrupted = append(interrupted, d.FullDomain()+"/"+d.Type)
+	}
+	sort.Strings(interrupted)
+	return interrupted, false
+}
@@ -0,0 +1,139 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// ============================================================
+// 优雅关闭测试
+// ============================================================
+
+func TestSyncTracker_WaitReturnsWhenTasksFinish(t *testing.T) {
+	track := newSyncTracker()
+	release := make(chan struct{})
+	track.goTask(func() { <-release })
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+	start := time.Now()
+	if interrupted, ok := track.wait(5 * time.Second); !ok || interrupted != nil {
+		t.Fatalf("任务结束后应正常返回, 得到 ok=%v interrupted=%v", ok, interrupted)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("任务结束后应立即返回, 实际等待 %s", elapsed)
+	}
+}
+
+func TestSyncTracker_WaitReportsInterruptedDomains(t *testing.T) {
+	track := newSyncTracker()
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	idle := &Domain{Domain: "example.com", SubDomain: "api", Type: RecordTypeAAAA}
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	fn := track.wrap(func(ctx context.Context, d *Domain, addr net.IP, p DNSProvider) ([]recordChange, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	track.goTask(func() { fn(context.Background(), d, net.ParseIP("2001:db8::1"), nil) })
+	track.begin(idle)() // 已结束的同步不应出现在中断列表中
+	<-started
+
+	interrupted, ok := track.wait(20 * time.Millisecond)
+	if ok {
+		t.Fatal("同步未结束时应超时")
+	}
+	if want := []string{"www.example.com/AAAA"}; !reflect.DeepEqual(interrupted, want) {
+		t.Errorf("被中断的域名应为 %v, 得到 %v", want, interrupted)
+	}
+}
+
+// slowModifyProvider ModifyRecord 耗时 delay，期间 ctx 被取消则返回 ctx.Err()。
+type slowModifyProvider struct {
+	mockProvider
+	delay time.Duration
+}
+
+func (p *slowModifyProvider) ModifyRecord(ctx context.Context, r RecordInfo) error {
+	select {
+	case <-time.After(p.delay):
+		return p.mockProvider.ModifyRecord(ctx, r)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestGracefulShutdown_InFlightSyncCompletes(t *testing.T) {
+	p := &slowModifyProvider{delay: 50 * time.Millisecond, mockProvider: mockProvider{records: []RecordInfo{
+		{ID: "1", Name: "www.example.com", Type: "AAAA", Value: "2001:db8::1", TTL: 600},
+	}}}
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	addr := net.ParseIP("2001:db8::2")
+
+	workCtx, stopWork := context.WithCancel(context.Background())
+	syncCtx, cancelSync := context.WithCancel(context.Background())
+	defer cancelSync()
+
+	track := newSyncTracker()
+	syncFn := track.wrap(syncRecord)
+	started := make(chan struct{})
+	var syncErr error
+	track.goTask(func() {
+		close(started)
+		_, syncErr = syncFn(syncCtx, d, addr, p)
+	})
+	<-started
+
+	if interrupted, ok := gracefulShutdown(track, 2*time.Second, stopWork, cancelSync); !ok {
+		t.Fatalf("同步在超时前完成时不应中断: %v", interrupted)
+	}
+	if workCtx.Err() == nil {
+		t.Error("关闭时应停止接收新工作")
+	}
+	if syncErr != nil || p.modCalls != 1 || !d.Addr.Equal(addr) {
+		t.Errorf("进行中的 ModifyRecord 应正常完成: err=%v modCalls=%d addr=%v", syncErr, p.modCalls, d.Addr)
+	}
+	if syncCtx.Err() != nil {
+		t.Error("同步全部完成时不应取消同步 context")
+	}
+}
+
+func TestGracefulShutdown_TimeoutCancelsSync(t *testing.T) {
+	p := &slowModifyProvider{delay: time.Minute, mockProvider: mockProvider{records: []RecordInfo{
+		{ID: "1", Name: "www.example.com", Type: "AAAA", Value: "2001:db8::1", TTL: 600},
+	}}}
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	syncCtx, cancelSync := context.WithCancel(context.Background())
+	defer cancelSync()
+
+	track := newSyncTracker()
+	syncFn := track.wrap(syncRecord)
+	done := make(chan error, 1)
+	track.goTask(func() {
+		_, err := syncFn(syncCtx, d, net.ParseIP("2001:db8::2"), p)
+		done <- err
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	interrupted, ok := gracefulShutdown(track, 30*time.Millisecond, func() {}, cancelSync)
+	if ok || len(interrupted) != 1 {
+		t.Fatalf("超时时应返回仍在同步的域名: ok=%v interrupted=%v", ok, interrupted)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("超时后进行中的调用应被取消, 得到 %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("超时后应取消同步 context")
+	}
+}
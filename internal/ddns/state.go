@@ -0,0 +1,216 @@
+package ddns
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultReconcileWindow 默认对账窗口。
+//
+// 持久化状态在最近一次核对后的这段时间内被视为可信，启动时直接作为缓存地址使用；
+// 超过窗口后首次同步会重新查询服务商。
+const DefaultReconcileWindow = 24 * time.Hour
+
+// DomainState 单个域名记录的持久化状态。
+type DomainState struct {
+	Addr       string    `json:"addr"`                 // 最近一次发布的地址
+	RecordIDs  []string  `json:"record_ids,omitempty"` // 服务商记录 ID（新增记录时可能为空）
+	UpdatedAt  time.Time `json:"updated_at,omitzero"`  // 最近一次修改/新增记录的时间
+	VerifiedAt time.Time `json:"verified_at"`          // 最近一次向服务商查询核对的时间
+	Withdrawn  bool      `json:"withdrawn,omitempty"`  // 记录已因本机地址撤回被处理（删除、替换或暂停），尚未恢复
+}
+
+// stateFile 状态文件的 JSON 结构，键为 "<profile>/<完整域名>/<记录类型>"。
+//
+// 旧版本的键不含 profile（"<完整域名>/<记录类型>"），读取时仍然识别，见 StateStore.Get。
+type stateFile struct {
+	Domains map[string]DomainState `json:"domains"`
+}
+
+// StateStore 基于 JSON 文件的域名状态持久化（如 ~/.ddns6/state.json）。
+//
+// 用于在进程重启后跳过对每个子域名的 GetRecords 查询，节省 API 配额。
+// 所有方法并发安全。
+type StateStore struct {
+	path    string
+	mu      sync.Mutex
+	domains map[string]DomainState
+}
+
+// NewStateStore 创建指向 path 的状态存储，需调用 Load 读取已有内容。
+func NewStateStore(path string) *StateStore {
+	return &StateStore{path: path, domains: make(map[string]DomainState)}
+}
+
+// Path 返回状态文件路径。
+func (s *StateStore) Path() string {
+	return s.path
+}
+
+// Load 从文件读取状态。文件不存在视为空状态；文件损坏时返回错误并保持空状态。
+func (s *StateStore) Load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("cannot read state file %s: %w", s.path, err)
+	}
+
+	var f stateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("cannot parse state file %s: %w", s.path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.Domains != nil {
+		s.domains = f.Domains
+	}
+	return nil
+}
+
+// Save 将状态写入文件。先写临时文件再重命名，避免写入中断导致文件损坏。
+//
+// 写入全程持锁，防止并发的同步任务同时写同一个临时文件。
+func (s *StateStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.MarshalIndent(stateFile{Domains: s.domains}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("cannot create state directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("cannot write state file %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("cannot replace state file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Get 返回 profile 中域名的持久化状态。
+//
+// 只有旧版本不含 profile 的键时返回该状态，但清空核对时间：同一域名可能属于多个 profile，
+// 无法确定该状态属于哪一个，因此不信任，首次同步重新向服务商核对。
+func (s *StateStore) Get(profile string, d *Domain) (DomainState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.domains[stateKey(profile, d)]; ok {
+		return st, true
+	}
+	st, ok := s.domains[legacyStateKey(d)]
+	st.VerifiedAt = time.Time{}
+	return st, ok
+}
+
+// Set 更新 profile 中域名的持久化状态（仅内存，需调用 Save 落盘），同时移除旧版本的键。
+func (s *StateStore) Set(profile string, d *Domain, st DomainState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.domains[stateKey(profile, d)] = st
+	delete(s.domains, legacyStateKey(d))
+}
+
+// distrust 清空 profile 中域名已保存状态的核对时间，重启后不再信任该状态（仅内存）。
+func (s *StateStore) distrust(profile string, d *Domain) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := stateKey(profile, d)
+	if st, ok := s.domains[key]; ok && !st.VerifiedAt.IsZero() {
+		st.VerifiedAt = time.Time{}
+		s.domains[key] = st
+	}
+}
+
+// stateKey 返回 profile 中域名在状态文件中的键。同一域名可以属于多个 profile（如同一 zone 由两个服务商托管），
+// 键包含 profile 名称，各 profile 的记录 ID 和信任窗口互不覆盖。
+func stateKey(profile string, d *Domain) string {
+	return profile + "/" + d.FullDomain() + "/" + d.Type
+}
+
+// legacyStateKey 返回旧版本状态文件中不含 profile 的键。
+func legacyStateKey(d *Domain) string {
+	return d.FullDomain() + "/" + d.Type
+}
+
+// restoreState 用持久化状态预填域名的缓存地址，并设置信任截止时间。
+//
+// 信任截止时间为最近一次核对时间加 window：在此之前 SyncRecord 对地址未变化的域名
+// 直接跳过；超过后即使地址未变化也会重新查询服务商核对一次。
+// 地址无效或与记录类型不匹配的状态被忽略。window <= 0 表示不信任任何持久化状态。
+// 已撤回的记录（见 WithdrawPolicy）不论 window 都恢复撤回标记，不预填缓存地址，
+// 使地址恢复后的首次同步恢复记录。
+func restoreState(profiles []Profile, store *StateStore, window time.Duration) {
+	if window <= 0 {
+		slog.Info("reconciliation window disabled, ignoring persisted state", "module", "ddns", "path", store.Path())
+	}
+	restored, total := 0, 0
+	for _, pr := range profiles {
+		total += len(pr.Domains)
+		for _, d := range pr.Domains {
+			if restoreDomain(pr.Name, d, store, window) {
+				restored++
+			}
+		}
+	}
+	if window > 0 {
+		slog.Info("state restored", "module", "ddns",
+			"path", store.Path(), "restored", restored, "domain_count", total)
+	}
+}
+
+// restoreDomain 用持久化状态预填单个域名，返回是否预填了缓存地址。
+func restoreDomain(profile string, d *Domain, store *StateStore, window time.Duration) bool {
+	st, ok := store.Get(profile, d)
+	if !ok {
+		return false
+	}
+	if st.Withdrawn {
+		d.markWithdrawn()
+		return false
+	}
+	if window <= 0 {
+		return false
+	}
+	ip := net.ParseIP(st.Addr)
+	if ip == nil || !addrMatchesType(ip, d.Type) {
+		slog.Warn("invalid address in state, ignoring", "module", "ddns",
+			"domain", d.Domain, "subdomain", d.SubDomain, "type", d.Type, "addr", st.Addr)
+		return false
+	}
+	d.restore(ip, st, st.VerifiedAt.Add(window))
+	return true
+}
+
+// saveState 将所有已同步域名的状态写入存储并落盘，失败只记录日志。
+//
+// 同步失败的域名缓存地址被清空（见 applyDNSRecord），此时不覆盖已保存的地址，
+// 但清空其核对时间：重启后不再信任该状态，未完成的修改（漂移修复、传播超时后的重写、
+// 热重载后的 TTL 重写等）在首次同步时重新核对。
+func saveState(profiles []Profile, store *StateStore) {
+	for _, pr := range profiles {
+		for _, d := range pr.Domains {
+			st := d.state()
+			if st.Addr == "" && !st.Withdrawn {
+				store.distrust(pr.Name, d)
+				continue
+			}
+			store.Set(pr.Name, d, st)
+		}
+	}
+	if err := store.Save(); err != nil {
+		slog.Warn("failed to save state", "module", "ddns", "path", store.Path(), "err", err)
+	}
+}
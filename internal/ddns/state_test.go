@@ -0,0 +1,229 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// ============================================================
+// StateStore 读写测试
+// ============================================================
+
+func TestStateStore_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "state.json")
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	verified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	store := NewStateStore(path)
+	store.Set("default", d, DomainState{Addr: "2001:db8::1", RecordIDs: []string{"42"}, VerifiedAt: verified})
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save 不应返回错误: %v", err)
+	}
+
+	loaded := NewStateStore(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load 不应返回错误: %v", err)
+	}
+	st, ok := loaded.Get("default", d)
+	if !ok {
+		t.Fatal("应能读取到已保存的状态")
+	}
+	if st.Addr != "2001:db8::1" || len(st.RecordIDs) != 1 || st.RecordIDs[0] != "42" {
+		t.Errorf("状态内容不符: %+v", st)
+	}
+	if !st.VerifiedAt.Equal(verified) {
+		t.Errorf("VerifiedAt = %v, 期望 %v", st.VerifiedAt, verified)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("状态文件权限应为 0600, 得到 %03o", fi.Mode().Perm())
+	}
+}
+
+func TestStateStore_LoadMissingFile(t *testing.T) {
+	store := NewStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err := store.Load(); err != nil {
+		t.Fatalf("文件不存在时 Load 不应返回错误: %v", err)
+	}
+}
+
+func TestStateStore_LoadCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store := NewStateStore(path)
+	if err := store.Load(); err == nil {
+		t.Fatal("文件损坏时 Load 应返回错误")
+	}
+	if _, ok := store.Get("default", &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}); ok {
+		t.Error("文件损坏时应保持空状态")
+	}
+}
+
+// ============================================================
+// 状态恢复与对账窗口测试
+// ============================================================
+
+func TestRestoreState_WithinWindow_SkipsQuery(t *testing.T) {
+	addr := net.ParseIP("2001:db8::1")
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	store := NewStateStore(filepath.Join(t.TempDir(), "state.json"))
+	store.Set("default", d, DomainState{Addr: addr.String(), VerifiedAt: time.Now().Add(-time.Hour)})
+
+	restoreState(stateProfiles(d), store, 24*time.Hour)
+
+	m := &mockProvider{}
+	if err := SyncRecord(context.Background(), d, addr, m); err != nil {
+		t.Fatalf("SyncRecord 不应返回错误: %v", err)
+	}
+	if m.getCalls != 0 {
+		t.Errorf("对账窗口内地址未变化时不应查询服务商, GetRecords 调用 %d 次", m.getCalls)
+	}
+}
+
+func TestRestoreState_OutsideWindow_Reconciles(t *testing.T) {
+	addr := net.ParseIP("2001:db8::1")
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	store := NewStateStore(filepath.Join(t.TempDir(), "state.json"))
+	store.Set("default", d, DomainState{Addr: addr.String(), VerifiedAt: time.Now().Add(-48 * time.Hour)})
+
+	restoreState(stateProfiles(d), store, 24*time.Hour)
+
+	m := &mockProvider{records: []RecordInfo{
+		{ID: "1", Name: "www.example.com", Type: RecordTypeAAAA, Value: addr.String()},
+	}}
+	if err := SyncRecord(context.Background(), d, addr, m); err != nil {
+		t.Fatalf("SyncRecord 不应返回错误: %v", err)
+	}
+	if m.getCalls != 1 {
+		t.Errorf("超过对账窗口时应查询服务商一次, GetRecords 调用 %d 次", m.getCalls)
+	}
+
+	// 核对完成后恢复为普通缓存，后续地址未变化时不再查询
+	if err := SyncRecord(context.Background(), d, addr, m); err != nil {
+		t.Fatalf("SyncRecord 不应返回错误: %v", err)
+	}
+	if m.getCalls != 1 {
+		t.Errorf("核对后地址未变化不应再次查询, GetRecords 调用 %d 次", m.getCalls)
+	}
+}
+
+func TestRestoreState_WindowDisabled(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	store := NewStateStore(filepath.Join(t.TempDir(), "state.json"))
+	store.Set("default", d, DomainState{Addr: "2001:db8::1", VerifiedAt: time.Now()})
+
+	restoreState(stateProfiles(d), store, 0)
+	if d.Addr != nil {
+		t.Errorf("window 为 0 时不应恢复缓存地址, 得到 %v", d.Addr)
+	}
+}
+
+func TestRestoreState_TypeMismatchIgnored(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeA}
+	store := NewStateStore(filepath.Join(t.TempDir(), "state.json"))
+	store.Set("default", d, DomainState{Addr: "2001:db8::1", VerifiedAt: time.Now()})
+
+	restoreState(stateProfiles(d), store, time.Hour)
+	if d.Addr != nil {
+		t.Errorf("地址族与记录类型不匹配的状态应被忽略, 得到 %v", d.Addr)
+	}
+}
+
+func TestSaveState_RecordsSyncResult(t *testing.T) {
+	addr := net.ParseIP("2001:db8::2")
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	unsynced := &Domain{Domain: "example.com", SubDomain: "api", Type: RecordTypeAAAA}
+	m := &mockProvider{records: []RecordInfo{
+		{ID: "7", Name: "www.example.com", Type: RecordTypeAAAA, Value: "2001:db8::1"},
+	}}
+	if err := SyncRecord(context.Background(), d, addr, m); err != nil {
+		t.Fatalf("SyncRecord 不应返回错误: %v", err)
+	}
+
+	store := NewStateStore(filepath.Join(t.TempDir(), "state.json"))
+	saveState(stateProfiles(d, unsynced), store)
+
+	st, ok := store.Get("default", d)
+	if !ok {
+		t.Fatal("已同步域名应写入状态")
+	}
+	if st.Addr != addr.String() || len(st.RecordIDs) != 1 || st.RecordIDs[0] != "7" {
+		t.Errorf("状态内容不符: %+v", st)
+	}
+	if st.UpdatedAt.IsZero() || st.VerifiedAt.IsZero() {
+		t.Errorf("记录被修改后 UpdatedAt/VerifiedAt 应非零: %+v", st)
+	}
+	if _, ok := store.Get("default", unsynced); ok {
+		t.Error("未同步过的域名不应写入状态")
+	}
+}
+
+// stateProfiles 返回只含一个名为 default 的 Profile 的列表。
+func stateProfiles(domains ...*Domain) []Profile {
+	return []Profile{{Name: "default", Domains: domains}}
+}
+
+func TestSaveState_FailedSyncNotTrustedAfterRestart(t *testing.T) {
+	addr := net.ParseIP("2001:db8::1")
+	path := filepath.Join(t.TempDir(), "state.json")
+	store := NewStateStore(path)
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	store.Set("default", d, DomainState{Addr: addr.String(), VerifiedAt: time.Now().Add(-time.Hour)})
+	restoreState(stateProfiles(d), store, 24*time.Hour)
+
+	// 对账时查询失败，缓存地址被清空
+	failing := &mockProvider{getErr: errors.New("timeout")}
+	if _, err := reconcileRecord(context.Background(), d, addr, failing); err == nil {
+		t.Fatal("查询失败时应返回错误")
+	}
+	saveState(stateProfiles(d), store)
+
+	// 重启后仍在对账窗口内，但失败后的状态不应被信任
+	restarted := NewStateStore(path)
+	if err := restarted.Load(); err != nil {
+		t.Fatal(err)
+	}
+	d2 := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}
+	restoreState(stateProfiles(d2), restarted, 24*time.Hour)
+	m := &mockProvider{records: []RecordInfo{{ID: "1", Name: "www.example.com", Type: RecordTypeAAAA, Value: addr.String(), TTL: 600}}}
+	if err := SyncRecord(context.Background(), d2, addr, m); err != nil {
+		t.Fatalf("SyncRecord 不应返回错误: %v", err)
+	}
+	if m.getCalls != 1 {
+		t.Errorf("同步失败后重启应重新核对服务商记录, GetRecords 调用 %d 次", m.getCalls)
+	}
+}
+
+func TestStateStore_KeyedByProfile(t *testing.T) {
+	store := NewStateStore(filepath.Join(t.TempDir(), "state.json"))
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	store.Set("cloudflare", d, DomainState{Addr: "2001:db8::1", RecordIDs: []string{"cf-1"}, VerifiedAt: time.Now()})
+	store.Set("dnspod", d, DomainState{Addr: "2001:db8::1", RecordIDs: []string{"42"}, VerifiedAt: time.Now()})
+
+	if st, _ := store.Get("cloudflare", d); len(st.RecordIDs) != 1 || st.RecordIDs[0] != "cf-1" {
+		t.Errorf("不同 profile 的同一域名状态不应互相覆盖: %+v", st)
+	}
+
+	// 旧版本不含 profile 的键仍可读取，但不被信任
+	legacy := NewStateStore(filepath.Join(t.TempDir(), "state.json"))
+	legacy.domains[legacyStateKey(d)] = DomainState{Addr: "2001:db8::1", VerifiedAt: time.Now()}
+	st, ok := legacy.Get("default", d)
+	if !ok || st.Addr != "2001:db8::1" || !st.VerifiedAt.IsZero() {
+		t.Errorf("旧键应读取为不可信的状态: ok=%v %+v", ok, st)
+	}
+	legacy.Set("default", d, st)
+	if _, ok := legacy.domains[legacyStateKey(d)]; ok {
+		t.Error("写入新键后应移除旧键")
+	}
+}
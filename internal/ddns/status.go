@@ -0,0 +1,344 @@
+package ddns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+	"github.com/notes-bin/ddns6/pkg/metrics"
+)
+
+// DefaultHealthThreshold 默认健康阈值：域名持续同步失败超过此时长时 /healthz 报告失败。
+const DefaultHealthThreshold = 15 * time.Minute
+
+// StatusReport 状态端点（GET /status）返回的服务运行状态。
+type StatusReport struct {
+	StartedAt   time.Time       `json:"started_at"`
+	TriggerMode string          `json:"trigger_mode"`         // 当前触发模式（netlink+debounce 或 polling）
+	Healthy     bool            `json:"healthy"`              // 是否所有域名都健康
+	LastFetch   *FetchStatus    `json:"last_fetch,omitempty"` // 最近一次地址获取结果
+	Fetchers    []FetcherStatus `json:"fetchers"`             // 各地址获取器最近一次的结果
+	Domains     []DomainStatus  `json:"domains"`
+}
+
+// DomainStatus 单个域名记录的同步状态。
+type DomainStatus struct {
+	Profile      string    `json:"profile"`
+	Domain       string    `json:"domain"` // 完整域名
+	Type         string    `json:"type"`
+	Addr         string    `json:"addr,omitempty"`         // 当前发布的地址
+	UpdatedAt    time.Time `json:"updated_at,omitzero"`    // 最近一次修改/新增记录的时间
+	LastSync     time.Time `json:"last_sync,omitzero"`     // 最近一次同步（无论成败）的时间
+	LastSuccess  time.Time `json:"last_success,omitzero"`  // 最近一次同步成功的时间
+	LastError    string    `json:"last_error,omitempty"`   // 最近一次同步失败的错误，成功后清空
+	FailingSince time.Time `json:"failing_since,omitzero"` // 连续失败的起始时间
+	Healthy      bool      `json:"healthy"`                // 未持续失败超过健康阈值
+}
+
+// FetchStatus 一次地址获取的结果。
+type FetchStatus struct {
+	At    time.Time `json:"at"`
+	IPv4  string    `json:"ipv4,omitempty"`
+	IPv6  string    `json:"ipv6,omitempty"`
+	Error string    `json:"error,omitempty"`
+}
+
+// FetcherStatus 单个地址获取器最近一次的结果。
+//
+// 获取器并发竞速，先返回地址者胜出，其余被取消（Canceled 为 true）。
+type FetcherStatus struct {
+	Name       string    `json:"name"`
+	Family     string    `json:"family"`
+	At         time.Time `json:"at"`
+	DurationMS int64     `json:"duration_ms"`
+	Addr       string    `json:"addr,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Canceled   bool      `json:"canceled,omitempty"`
+}
+
+// serviceStatus 收集服务运行状态，供状态端点查询。所有方法并发安全。
+type serviceStatus struct {
+	threshold time.Duration
+
+	mu        sync.Mutex
+	startedAt time.Time
+	mode      string
+	domains   []DomainStatus  // 按 Profile 和域名的配置顺序排列
+	index     map[*Domain]int // 域名 -> domains 下标
+	lastFetch *FetchStatus
+	fetchers  []FetcherStatus
+	fetcherAt map[*statusFetcher]int // 获取器 -> fetchers 下标
+}
+
+// newServiceStatus 为 profiles 中的所有域名创建状态收集器。threshold <= 0 时使用 DefaultHealthThreshold。
+//
+// 应在 restoreState 之后调用，以便尚未同步的域名显示从状态文件恢复的地址。
+func newServiceStatus(profiles []Profile, mode string, threshold time.Duration) *serviceStatus {
+	if threshold <= 0 {
+		threshold = DefaultHealthThreshold
+	}
+	s := &serviceStatus{
+		threshold: threshold,
+		startedAt: time.Now(),
+		mode:      mode,
+		index:     make(map[*Domain]int),
+		fetcherAt: make(map[*statusFetcher]int),
+	}
+	s.setProfiles(profiles)
+	return s
+}
+
+// setProfiles 按 profiles 重建域名列表（热重载后调用）。
+//
+// 仍在列表中的域名保留已有状态，新增域名显示从状态文件恢复的地址，已移除的域名不再显示。
+func (s *serviceStatus) setProfiles(profiles []Profile) {
+	// 在 s.mu 之外读取新增域名的状态；已有域名可能正在同步（持有域名锁），不读取
+	s.mu.Lock()
+	known := make(map[*Domain]bool, len(s.index))
+	for d := range s.index {
+		known[d] = true
+	}
+	s.mu.Unlock()
+	restored := make(map[*Domain]DomainState)
+	for _, pr := range profiles {
+		for _, d := range pr.Domains {
+			if !known[d] {
+				restored[d] = d.state() // 从状态文件恢复的地址和更新时间
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var domains []DomainStatus
+	index := make(map[*Domain]int)
+	for _, pr := range profiles {
+		for _, d := range pr.Domains {
+			index[d] = len(domains)
+			if i, ok := s.index[d]; ok {
+				domains = append(domains, s.domains[i])
+				continue
+			}
+			st := restored[d]
+			domains = append(domains, DomainStatus{Profile: pr.Name, Domain: d.FullDomain(), Type: d.Type,
+				Addr: st.Addr, UpdatedAt: st.UpdatedAt})
+		}
+	}
+	s.domains, s.index = domains, index
+}
+
+// setTriggerMode 记录当前触发模式（如 Netlink 不可用回退到轮询时）。
+func (s *serviceStatus) setTriggerMode(mode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mode = mode
+}
+
+// recordSync 记录一个域名的同步结果。因服务退出取消的同步不记录。
+func (s *serviceStatus) recordSync(o syncOutcome) {
+	if errors.Is(o.err, context.Canceled) {
+		return
+	}
+	st := o.domain.state() // 在 s.mu 之外读取，避免与持有域名锁的同步相互等待
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, ok := s.index[o.domain]
+	if !ok {
+		return
+	}
+	ds := &s.domains[i]
+	now := time.Now()
+	ds.LastSync = now
+	ds.UpdatedAt = st.UpdatedAt
+	if o.err != nil {
+		ds.LastError = o.err.Error()
+		if ds.FailingSince.IsZero() {
+			ds.FailingSince = now
+		}
+		return
+	}
+	ds.Addr = addrString(o.addr)
+	ds.LastSuccess = now
+	ds.LastError = ""
+	ds.FailingSince = time.Time{}
+}
+
+// recordFetch 记录一次地址获取的结果。
+func (s *serviceStatus) recordFetch(addrs addrSet, err error) {
+	fs := &FetchStatus{At: time.Now()}
+	if addrs.ipv4 != nil {
+		fs.IPv4 = addrs.ipv4.String()
+	}
+	if addrs.ipv6 != nil {
+		fs.IPv6 = addrs.ipv6.String()
+	}
+	if err != nil {
+		fs.Error = err.Error()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastFetch = fs
+}
+
+// recordFetcher 记录获取器 f 的结果。
+func (s *serviceStatus) recordFetcher(f *statusFetcher, fs FetcherStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i, ok := s.fetcherAt[f]; ok {
+		s.fetchers[i] = fs
+		return
+	}
+	s.fetcherAt[f] = len(s.fetchers)
+	s.fetchers = append(s.fetchers, fs)
+}
+
+// report 返回当前状态的快照。
+func (s *serviceStatus) report() StatusReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	r := StatusReport{
+		StartedAt:   s.startedAt,
+		TriggerMode: s.mode,
+		Healthy:     true,
+		Fetchers:    append([]FetcherStatus{}, s.fetchers...),
+		Domains:     make([]DomainStatus, len(s.domains)),
+	}
+	if s.lastFetch != nil {
+		fs := *s.lastFetch
+		r.LastFetch = &fs
+	}
+	for i, ds := range s.domains {
+		ds.Healthy = ds.FailingSince.IsZero() || now.Sub(ds.FailingSince) < s.threshold
+		if !ds.Healthy {
+			r.Healthy = false
+		}
+		r.Domains[i] = ds
+	}
+	return r
+}
+
+// handler 返回状态端点的 HTTP 处理器：
+//   - GET /status（及 /）：StatusReport JSON
+//   - GET /healthz：所有域名健康时返回 200，否则 503 并列出持续失败的域名
+//   - GET /metrics：Prometheus 指标（metrics.Default）
+func (s *serviceStatus) handler() http.Handler {
+	mux := http.NewServeMux()
+	serveStatus := func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.report())
+	}
+	mux.HandleFunc("GET /{$}", serveStatus)
+	mux.HandleFunc("GET /status", serveStatus)
+	mux.Handle("GET /metrics", metrics.Default.Handler())
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		rep := s.report()
+		var failing []DomainStatus
+		for _, ds := range rep.Domains {
+			if !ds.Healthy {
+				failing = append(failing, ds)
+			}
+		}
+		if len(failing) > 0 {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "unhealthy", "failing": failing})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+	})
+	return mux
+}
+
+// writeJSON 以 JSON 格式写入响应。
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		slog.Debug("failed to write status response", "module", "ddns", "err", err)
+	}
+}
+
+// startStatusServer 在 addr 上启动状态端点，返回的 stop 函数关闭监听。
+//
+// 监听失败（如端口被占用）时返回错误，由调用方终止启动。
+func startStatusServer(addr string, s *serviceStatus) (stop func(), err error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Handler: s.handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("status server stopped", "module", "ddns", "addr", addr, "err", err)
+		}
+	}()
+	slog.Info("status endpoint listening", "module", "ddns", "addr", ln.Addr().String())
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}, nil
+}
+
+// statusFetcher 记录被包装获取器每次 Fetch 的结果和指标，同时满足 IPv6Fetcher 与 IPv4Fetcher。
+type statusFetcher struct {
+	name   string
+	family string
+	next   interface {
+		Fetch(ctx context.Context) (net.IP, error)
+	}
+	status *serviceStatus
+}
+
+// String 返回被包装获取器的名称，使 ipaddr 的日志仍显示原始获取器。
+func (f *statusFetcher) String() string {
+	return f.name
+}
+
+// Fetch 调用被包装的获取器并记录结果。
+func (f *statusFetcher) Fetch(ctx context.Context) (net.IP, error) {
+	start := time.Now()
+	ip, err := f.next.Fetch(ctx)
+	elapsed := time.Since(start)
+	fs := FetcherStatus{
+		Name:       f.name,
+		Family:     f.family,
+		At:         start,
+		DurationMS: elapsed.Milliseconds(),
+	}
+	switch {
+	case err == nil:
+		fs.Addr = ip.String()
+	case errors.Is(err, context.Canceled):
+		fs.Canceled = true
+	default:
+		fs.Error = err.Error()
+	}
+	f.status.recordFetcher(f, fs)
+	observeFetcher(fs, elapsed)
+	return ip, err
+}
+
+// wrapIPv6Fetchers 包装 IPv6 获取器，记录各自的结果。
+func (s *serviceStatus) wrapIPv6Fetchers(fetchers []ipaddr.IPv6Fetcher) []ipaddr.IPv6Fetcher {
+	wrapped := make([]ipaddr.IPv6Fetcher, len(fetchers))
+	for i, f := range fetchers {
+		wrapped[i] = &statusFetcher{name: ipaddr.FetcherName(f), family: "IPv6", next: f, status: s}
+	}
+	return wrapped
+}
+
+// wrapIPv4Fetchers 包装 IPv4 获取器，记录各自的结果。
+func (s *serviceStatus) wrapIPv4Fetchers(fetchers []ipaddr.IPv4Fetcher) []ipaddr.IPv4Fetcher {
+	wrapped := make([]ipaddr.IPv4Fetcher, len(fetchers))
+	for i, f := range fetchers {
+		wrapped[i] = &statusFetcher{name: ipaddr.FetcherName(f), family: "IPv4", next: f, status: s}
+	}
+	return wrapped
+}
@@ -0,0 +1,161 @@
+package ddns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// ============================================================
+// serviceStatus 状态收集测试
+// ============================================================
+
+// mockFetcher 返回固定地址或错误的地址获取器。
+type mockFetcher struct {
+	ip  net.IP
+	err error
+}
+
+func (m *mockFetcher) Fetch(_ context.Context) (net.IP, error) {
+	return m.ip, m.err
+}
+
+func newTestStatus(threshold time.Duration) (*serviceStatus, *Domain) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	s := newServiceStatus([]Profile{{Name: "home", Domains: []*Domain{d}}}, "polling", threshold)
+	return s, d
+}
+
+func TestServiceStatus_RecordSync(t *testing.T) {
+	s, d := newTestStatus(time.Hour)
+
+	s.recordSync(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::1")})
+	ds := s.report().Domains[0]
+	if ds.Profile != "home" || ds.Domain != "www.example.com" || ds.Addr != "2001:db8::1" {
+		t.Errorf("同步成功后状态不符: %+v", ds)
+	}
+	if ds.LastSuccess.IsZero() || ds.LastError != "" || !ds.Healthy {
+		t.Errorf("同步成功后应记录成功时间且健康: %+v", ds)
+	}
+
+	s.recordSync(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::2"), err: errors.New("api error")})
+	ds = s.report().Domains[0]
+	if ds.LastError != "api error" || ds.FailingSince.IsZero() {
+		t.Errorf("同步失败后应记录错误和失败起始时间: %+v", ds)
+	}
+	if ds.Addr != "2001:db8::1" {
+		t.Errorf("同步失败不应改变已发布地址, 得到 %s", ds.Addr)
+	}
+	if !ds.Healthy {
+		t.Error("失败未超过阈值时应仍为健康")
+	}
+}
+
+func TestServiceStatus_UnhealthyBeyondThreshold(t *testing.T) {
+	s, d := newTestStatus(time.Minute)
+	s.recordSync(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::1"), err: errors.New("api error")})
+	s.domains[0].FailingSince = time.Now().Add(-2 * time.Minute)
+
+	if s.report().Healthy {
+		t.Fatal("持续失败超过阈值时应报告不健康")
+	}
+
+	// 再次失败不应重置失败起始时间，成功后恢复健康
+	s.recordSync(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::1"), err: errors.New("api error")})
+	if s.report().Healthy {
+		t.Error("连续失败不应重置失败起始时间")
+	}
+	s.recordSync(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::1")})
+	if !s.report().Healthy {
+		t.Error("同步成功后应恢复健康")
+	}
+}
+
+func TestServiceStatus_CanceledIgnored(t *testing.T) {
+	s, d := newTestStatus(time.Minute)
+	s.recordSync(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::1"), err: context.Canceled})
+	if ds := s.report().Domains[0]; !ds.LastSync.IsZero() {
+		t.Errorf("因退出取消的同步不应记录, 得到 %+v", ds)
+	}
+}
+
+func TestServiceStatus_FetcherResults(t *testing.T) {
+	s, _ := newTestStatus(time.Minute)
+	fetchers := s.wrapIPv6Fetchers([]ipaddr.IPv6Fetcher{
+		&mockFetcher{ip: net.ParseIP("2001:db8::1")},
+		&mockFetcher{err: errors.New("timeout")},
+	})
+	for _, f := range fetchers {
+		f.Fetch(context.Background())
+	}
+
+	rep := s.report()
+	if len(rep.Fetchers) != 2 {
+		t.Fatalf("应记录 2 个获取器结果, 得到 %d", len(rep.Fetchers))
+	}
+	if rep.Fetchers[0].Addr != "2001:db8::1" || rep.Fetchers[0].Family != "IPv6" {
+		t.Errorf("成功的获取器结果不符: %+v", rep.Fetchers[0])
+	}
+	if rep.Fetchers[1].Error != "timeout" {
+		t.Errorf("失败的获取器应记录错误: %+v", rep.Fetchers[1])
+	}
+}
+
+// ============================================================
+// 状态端点 HTTP 测试
+// ============================================================
+
+func TestStatusHandler(t *testing.T) {
+	s, d := newTestStatus(time.Minute)
+	s.recordFetch(addrSet{ipv6: net.ParseIP("2001:db8::1")}, nil)
+	s.recordSync(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::1")})
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var rep StatusReport
+	if err := json.NewDecoder(resp.Body).Decode(&rep); err != nil {
+		t.Fatalf("状态响应应为 JSON: %v", err)
+	}
+	if rep.TriggerMode != "polling" || rep.LastFetch == nil || rep.LastFetch.IPv6 != "2001:db8::1" {
+		t.Errorf("状态响应内容不符: %+v", rep)
+	}
+	if len(rep.Domains) != 1 || rep.Domains[0].Addr != "2001:db8::1" {
+		t.Errorf("状态响应应包含域名地址: %+v", rep.Domains)
+	}
+}
+
+func TestHealthzHandler(t *testing.T) {
+	s, d := newTestStatus(time.Minute)
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+
+	get := func() int {
+		resp, err := http.Get(ts.URL + "/healthz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get(); code != http.StatusOK {
+		t.Errorf("尚未同步时应健康, 得到 %d", code)
+	}
+	s.recordSync(syncOutcome{domain: d, addr: net.ParseIP("2001:db8::1"), err: errors.New("api error")})
+	s.domains[0].FailingSince = time.Now().Add(-time.Hour)
+	if code := get(); code != http.StatusServiceUnavailable {
+		t.Errorf("持续失败超过阈值时应返回 503, 得到 %d", code)
+	}
+}
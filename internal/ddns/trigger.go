@@ -0,0 +1,90 @@
+package ddns
+
+import (
+	"fmt"
+	"path"
+	"time"
+)
+
+// DefaultDebounce Netlink 事件的默认防抖等待时间。
+//
+// PPPoE 重拨场景下，新地址可能不会立即稳定：
+//   - 重拨后内核下发新地址（RTM_NEWADDR）
+//   - 但 SLAAC 可能还在进行中（临时地址生成）
+//   - 10 秒的窗口足够让绝大多数场景稳定
+const DefaultDebounce = 10 * time.Second
+
+// DefaultDebounceMaxWait 自第一个事件起的默认最长等待时间，持续不断的事件不会无限推迟同步。
+const DefaultDebounceMaxWait = time.Minute
+
+// TriggerConfig Linux Netlink 触发源的防抖和事件过滤配置，零值表示使用默认值。
+// 非 Linux 平台使用定时轮询，此配置无效。
+type TriggerConfig struct {
+	Debounce   time.Duration // 最后一个事件后等待的时间（<= 0 时使用 DefaultDebounce）
+	MaxWait    time.Duration // 自第一个事件起的最长等待时间（<= 0 时使用 DefaultDebounceMaxWait，小于 Debounce 时按 Debounce）
+	IncludeULA bool          // ULA（fc00::/7）地址事件也触发同步（默认忽略）
+	Interfaces []string      // 监听的接口名模式（path.Match 语法，如 ppp*），为空时只监听 iface 参数指定的接口
+}
+
+// WithTrigger 设置 Netlink 触发源的防抖时间、最长等待时间和事件过滤（默认见 TriggerConfig）。
+func WithTrigger(cfg TriggerConfig) Option {
+	return func(o *serviceOptions) {
+		o.trigger = cfg
+	}
+}
+
+// normalize 填充默认值并将 iface 并入接口模式（cfg.Interfaces 为空时）。
+func (cfg TriggerConfig) normalize(iface string) TriggerConfig {
+	if cfg.Debounce <= 0 {
+		cfg.Debounce = DefaultDebounce
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = DefaultDebounceMaxWait
+	}
+	if cfg.MaxWait < cfg.Debounce {
+		cfg.MaxWait = cfg.Debounce
+	}
+	if len(cfg.Interfaces) == 0 && iface != "" {
+		cfg.Interfaces = []string{iface}
+	}
+	return cfg
+}
+
+// ValidateInterfacePatterns 检查接口名模式是否为合法的 path.Match 模式。
+func ValidateInterfacePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if p == "" {
+			return fmt.Errorf("interface pattern must not be empty")
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid interface pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// matchInterface 返回接口名 name 是否匹配 patterns 中任一模式（patterns 为空时匹配所有接口）。
+func matchInterface(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// debounceWait 返回 now 收到事件后防抖计时器应等待的时间：
+// 通常为 cfg.Debounce，但不超过自第一个事件 first 起的 cfg.MaxWait。
+func debounceWait(cfg TriggerConfig, first, now time.Time) time.Duration {
+	wait := cfg.Debounce
+	if remaining := cfg.MaxWait - now.Sub(first); remaining < wait {
+		wait = remaining
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
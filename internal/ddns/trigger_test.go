@@ -0,0 +1,59 @@
+package ddns
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// ============================================================
+// 触发源配置测试
+// ============================================================
+
+func TestTriggerConfig_Normalize(t *testing.T) {
+	cfg := TriggerConfig{}.normalize("ppp0")
+	if cfg.Debounce != DefaultDebounce || cfg.MaxWait != DefaultDebounceMaxWait {
+		t.Errorf("零值应使用默认防抖时间: %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.Interfaces, []string{"ppp0"}) {
+		t.Errorf("未设置接口模式时应使用 iface: %v", cfg.Interfaces)
+	}
+
+	cfg = TriggerConfig{Debounce: 30 * time.Second, MaxWait: 5 * time.Second, Interfaces: []string{"ppp*"}}.normalize("eth0")
+	if cfg.MaxWait != 30*time.Second {
+		t.Errorf("最长等待时间小于防抖时间时应按防抖时间: %v", cfg.MaxWait)
+	}
+	if !reflect.DeepEqual(cfg.Interfaces, []string{"ppp*"}) {
+		t.Errorf("设置了接口模式时不应并入 iface: %v", cfg.Interfaces)
+	}
+}
+
+func TestDebounceWait_CappedByMaxWait(t *testing.T) {
+	cfg := TriggerConfig{Debounce: 10 * time.Second, MaxWait: time.Minute}
+	first := time.Now()
+
+	if w := debounceWait(cfg, first, first); w != 10*time.Second {
+		t.Errorf("第一个事件应等待完整防抖时间, 得到 %v", w)
+	}
+	if w := debounceWait(cfg, first, first.Add(55*time.Second)); w != 5*time.Second {
+		t.Errorf("接近最长等待时间时应只等待剩余时间, 得到 %v", w)
+	}
+	if w := debounceWait(cfg, first, first.Add(2*time.Minute)); w != 0 {
+		t.Errorf("超过最长等待时间时应立即触发, 得到 %v", w)
+	}
+}
+
+func TestMatchInterface(t *testing.T) {
+	patterns := []string{"ppp*", "eth0"}
+	for name, want := range map[string]bool{"ppp0": true, "ppp12": true, "eth0": true, "eth1": false, "wlan0": false} {
+		if got := matchInterface(name, patterns); got != want {
+			t.Errorf("matchInterface(%q) = %v, 期望 %v", name, got, want)
+		}
+	}
+	if !matchInterface("any", nil) {
+		t.Error("未设置模式时应匹配所有接口")
+	}
+	if err := ValidateInterfacePatterns([]string{"ppp[0-"}); err == nil {
+		t.Error("非法模式应返回错误")
+	}
+}
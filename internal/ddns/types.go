@@ -1,7 +1,8 @@
 // Package ddns 提供动态域名解析（DDNS）服务编排
 //
 // 本包定义了 DDNS 核心类型：RecordInfo（通用 DNS 记录）、DNSProvider（服务商接口）、
-// Domain（域名配置），以及服务编排的入口 RunService。
+// Domain（域名配置）、Profile（运营商 + 域名分组），以及服务编排的入口
+// RunService / RunProfiles。
 //
 // 使用示例（作为库调用）：
 //
@@ -9,6 +10,7 @@
 //	domains := []*ddns.Domain{
 //	    {Domain: "example.com", SubDomain: "www", Type: "AAAA", TTL: 600},
 //	    {Domain: "example.com", SubDomain: "@", Type: "AAAA", TTL: 600},
+//	    {Domain: "example.com", SubDomain: "@", Type: "A", TTL: 600}, // 双栈：同时发布 A 记录
 //	}
 //
 //	// 2. 创建 Provider（以 tencent 为例）
@@ -17,6 +19,12 @@
 //	// 3. 启动服务
 //	err := ddns.RunService(domains, p, 5*time.Minute, ddns.DefaultIPv6Fetchers, "")
 //
+//	// 多个运营商/账号时，每组构造一个 Profile，由同一个服务统一管理
+//	err = ddns.RunProfiles([]ddns.Profile{
+//	    {Name: "example.com", Provider: p, Domains: domains},
+//	    {Name: "example.net", Provider: cf, Domains: otherDomains},
+//	}, 5*time.Minute, ddns.DefaultIPv6Fetchers, "")
+//
 // 新增 DNS 运营商需实现 DNSProvider 接口（4 个方法），然后在
 // cmd/providers.go 的 providerFactories 列表中注册。
 package ddns
@@ -26,6 +34,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"time"
 )
 
 // RecordInfo 通用 DNS 记录类型
@@ -65,9 +74,16 @@ type DNSProvider interface {
 	DeleteRecord(ctx context.Context, record RecordInfo) error
 }
 
+// 支持的记录类型。
+const (
+	RecordTypeA    = "A"    // IPv4 地址记录
+	RecordTypeAAAA = "AAAA" // IPv6 地址记录
+)
+
 // Domain 表示一个域名及其相关配置
 //
-// 包含域名、子域名、记录类型、TTL 和缓存的 IP 地址。内嵌 sync.Mutex 保护并发访问。
+// 包含域名、子域名、记录类型（A 或 AAAA）、TTL 和缓存的 IP 地址。内嵌 sync.Mutex 保护并发访问。
+// 同一子域名需要双栈时，为 A 和 AAAA 各创建一个 Domain。
 type Domain struct {
 	Domain    string
 	SubDomain string
@@ -75,6 +91,40 @@ type Domain struct {
 	TTL       int
 	Addr      net.IP
 	mu        sync.Mutex
+
+	// Suffix 局域网主机的接口标识（可选，仅作用于 AAAA 记录）。设置后发布的地址为
+	// 本机 IPv6 地址的前 PrefixLength 位（运营商下发前缀）与 Suffix 组合得到的主机地址，
+	// 用于在路由器上为 NAS、打印机等局域网主机发布 AAAA 记录。
+	Suffix       net.IP
+	PrefixLength int // 下发前缀长度（<= 0 时使用 ipaddr.DefaultPrefixLength）
+
+	// OnWithdraw 本机全局 IPv6 地址被撤回后对记录的处理策略（仅作用于 AAAA 记录，空表示 WithdrawKeep），
+	// Fallback 为 WithdrawFallback 策略使用的备用地址。
+	OnWithdraw WithdrawPolicy
+	Fallback   net.IP
+
+	// Policy 发布 AAAA 记录前对候选地址的筛选规则（零值表示不筛选），见 AddrPolicy。
+	Policy AddrPolicy
+
+	recordIDs      []string  // 最近一次同步涉及的服务商记录 ID
+	updatedAt      time.Time // 最近一次修改/新增记录的时间
+	verifiedAt     time.Time // 最近一次向服务商查询核对的时间
+	trustUntil     time.Time // 持久化状态的信任截止时间，零值表示缓存地址始终可信
+	ttlPending     bool      // 热重载修改了 TTL，下次同步需按新 TTL 重写记录
+	rewritePending bool      // 传播验证超时，下次同步需重写记录（即使值未变化）
+	withdrawn      bool      // 记录已按 OnWithdraw 处理，地址恢复后的同步需恢复记录
+
+	hooks *hookRunner // 记录更新前后执行的钩子（nil 表示未配置），由 RunProfiles 设置
+}
+
+// Profile 一组使用同一 DNSProvider 的域名。
+//
+// 多个 Profile 可由同一个 RunProfiles 服务管理，共享地址获取和触发源，
+// 每个 Profile 使用各自的运营商凭据和根域名。
+type Profile struct {
+	Name     string      // profile 名称，用于日志和错误信息
+	Provider DNSProvider // DNS 服务商实现
+	Domains  []*Domain   // 该 profile 管理的域名列表
 }
 
 // DefaultTTL DNS 记录默认 TTL（秒）
@@ -96,7 +146,7 @@ func (d *Domain) FullDomain() string {
 	return fmt.Sprintf("%s.%s", d.SubDomain, d.Domain)
 }
 
-// CheckAndSetAddr 检查并更新缓存的 IPv6 地址，返回地址是否发生变化（线程安全）。
+// CheckAndSetAddr 检查并更新缓存的 IP 地址，返回地址是否发生变化（线程安全）。
 //
 // 若新地址与缓存地址相同则返回 false，否则更新缓存并返回 true。
 // 用于 SyncRecord 中判断是否需要触发 DNS 记录更新。
@@ -118,6 +168,42 @@ func (d *Domain) AddrString() string {
 	return d.Addr.String()
 }
 
+// state 返回当前同步状态的快照，用于持久化（线程安全）。
+func (d *Domain) state() DomainState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st := DomainState{
+		RecordIDs:  append([]string(nil), d.recordIDs...),
+		UpdatedAt:  d.updatedAt,
+		VerifiedAt: d.verifiedAt,
+		Withdrawn:  d.withdrawn,
+	}
+	if d.Addr != nil {
+		st.Addr = d.Addr.String()
+	}
+	return st
+}
+
+// restore 用持久化状态预填缓存地址和同步元数据（线程安全）。
+//
+// trustUntil 之后 SyncRecord 会重新查询服务商核对记录，即使地址未变化。
+func (d *Domain) restore(addr net.IP, st DomainState, trustUntil time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	copyAddrToDomain(d, addr)
+	d.trustUntil = trustUntil
+	d.recordIDs = append([]string(nil), st.RecordIDs...)
+	d.updatedAt = st.UpdatedAt
+	d.verifiedAt = st.VerifiedAt
+}
+
+// markWithdrawn 恢复持久化的撤回标记（线程安全），缓存地址保持为空。
+func (d *Domain) markWithdrawn() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.withdrawn = true
+}
+
 // lock 内部加锁（非导出），供 SyncRecord 等包内函数在需要跨方法持锁时使用。
 func (d *Domain) lock() { d.mu.Lock() }
 
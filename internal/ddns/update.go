@@ -0,0 +1,135 @@
+package ddns
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// UpdateStatus 单次同步中一个域名的结果类型。
+type UpdateStatus string
+
+// 单次同步的结果类型。
+const (
+	UpdateUnchanged UpdateStatus = "unchanged" // 记录已是目标地址，未写入服务商
+	UpdateModified  UpdateStatus = "modified"  // 修改了已有记录（或重新启用被暂停的记录）
+	UpdateAdded     UpdateStatus = "added"     // 新增了缺失的记录
+	UpdateFailed    UpdateStatus = "failed"    // 同步失败，或未获取到可发布的地址
+)
+
+// UpdateResult 单次同步（UpdateOnce）中一个域名的结果。
+type UpdateResult struct {
+	Profile string
+	Domain  string // 完整域名（如 www.example.com）
+	Type    string // A 或 AAAA
+	Addr    net.IP // 目标地址，未获取到可发布的地址时为 nil
+	Status  UpdateStatus
+	Err     error // Status 为 UpdateFailed 时的原因
+}
+
+// Changed 返回结果是否修改或新增了服务商记录。
+func (r UpdateResult) Changed() bool {
+	return r.Status == UpdateModified || r.Status == UpdateAdded
+}
+
+// UpdateOnce 执行一次地址获取和所有 Profile 的同步后返回，适用于 cron 或 systemd timer。
+//
+// 与 RunProfiles 共用同步流程，支持其中的状态持久化、获取器、共识模式、钩子和通知选项；
+// 触发源、重试队列、状态端点、热重载和传播验证等运行期选项被忽略。
+// 结果按 Profile 和域名的配置顺序排列，每个域名一条。单个域名同步失败不中断其余域名，
+// 失败记录在对应结果中；返回的 error 仅表示无法开始同步（如 profiles 为空）。
+func UpdateOnce(ctx context.Context, profiles []Profile, fetchers []ipaddr.IPv6Fetcher, opts ...Option) ([]UpdateResult, error) {
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no profiles to update")
+	}
+	o, err := onceOptions(fetchers, opts)
+	if err != nil {
+		return nil, err
+	}
+	attachHooks(profiles, o.hooks)
+	domains := allDomains(profiles)
+	needV4, needV6 := requiredFamilies(domains)
+	if o.state != nil {
+		restoreState(profiles, o.state, o.reconcileWindow)
+	}
+
+	slog.Info("performing one-shot update", "module", "ddns",
+		"profile_count", len(profiles), "domain_count", len(domains), "ipv4", needV4, "ipv6", needV6)
+
+	addrs, fetchErr := fetchAddrs(ctx, needV4, needV6, policyEnabled(domains), o.ipv4Fetchers, fetchers, o.fetchPolicy)
+	if fetchErr != nil {
+		slog.Error("address fetch failed", "module", "ddns", "err", fetchErr)
+	}
+
+	outcomes := make(map[*Domain]syncOutcome, len(domains))
+	if addrs.ipv4 != nil || addrs.ipv6 != nil {
+		results, _ := applyProfiles(ctx, profiles, addrs, syncRecord, false)
+		events := newEventEmitter(o.notifier, domains)
+		for _, r := range results {
+			outcomes[r.domain] = r
+			events.outcome(r)
+		}
+		if o.state != nil {
+			saveState(profiles, o.state)
+		}
+	}
+
+	var results []UpdateResult
+	for _, pr := range profiles {
+		for _, d := range pr.Domains {
+			res := UpdateResult{Profile: pr.Name, Domain: d.FullDomain(), Type: d.Type, Status: UpdateUnchanged}
+			r, ok := outcomes[d]
+			switch {
+			case !ok:
+				res.Status, res.Err = UpdateFailed, noAddrError(d, addrs, fetchErr)
+			case r.err != nil:
+				res.Addr, res.Status, res.Err = r.addr, UpdateFailed, r.err
+			default:
+				res.Addr = r.addr
+				for _, c := range r.changes {
+					if c.action == changeAdded {
+						res.Status = UpdateAdded
+						break
+					}
+					res.Status = UpdateModified
+				}
+			}
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
+// onceOptions 返回单次执行（UpdateOnce、Plan）使用的选项，并检查共识模式所需的获取器数量。
+func onceOptions(fetchers []ipaddr.IPv6Fetcher, opts []Option) (serviceOptions, error) {
+	o := serviceOptions{ipv4Fetchers: DefaultIPv4Fetchers}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if q := o.fetchPolicy.Quorum; q.Enabled() && len(fetchers) < q.N {
+		return o, fmt.Errorf("IPv6 quorum of %d requires at least %d fetchers, got %d", q.N, q.N, len(fetchers))
+	}
+	return o, nil
+}
+
+// noAddrError 返回域名因没有可发布的地址而未同步的原因。
+func noAddrError(d *Domain, addrs addrSet, fetchErr error) error {
+	if addrs.forType(d.Type) == nil {
+		if fetchErr != nil {
+			return fmt.Errorf("no %s address: %w", familyOf(d.Type), fetchErr)
+		}
+		return fmt.Errorf("no %s address", familyOf(d.Type))
+	}
+	return errNoCandidate
+}
+
+// familyOf 返回记录类型对应的地址族名称。
+func familyOf(recordType string) string {
+	if recordType == RecordTypeA {
+		return "IPv4"
+	}
+	return "IPv6"
+}
@@ -0,0 +1,56 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// ============================================================
+// UpdateOnce 单次同步测试
+// ============================================================
+
+func TestUpdateOnce_Summary(t *testing.T) {
+	addr := "2001:db8::2"
+	profiles := []Profile{
+		{Name: "same", Provider: &mockProvider{records: []RecordInfo{
+			{ID: "1", Name: "www.example.com", Type: "AAAA", Value: addr, TTL: 600},
+		}}, Domains: []*Domain{{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600}}},
+		{Name: "old", Provider: &mockProvider{records: []RecordInfo{
+			{ID: "2", Name: "api.example.com", Type: "AAAA", Value: "2001:db8::1", TTL: 600},
+		}}, Domains: []*Domain{{Domain: "example.com", SubDomain: "api", Type: RecordTypeAAAA, TTL: 600}}},
+		{Name: "new", Provider: &mockProvider{}, Domains: []*Domain{
+			{Domain: "example.org", SubDomain: "nas", Type: RecordTypeAAAA, TTL: 600},
+			{Domain: "example.org", SubDomain: "nas", Type: RecordTypeA, TTL: 600},
+		}},
+	}
+	fetchers := []ipaddr.IPv6Fetcher{&mockFetcher{ip: net.ParseIP(addr)}}
+	v4 := WithIPv4Fetchers(&mockFetcher{err: errors.New("no route")})
+
+	results, err := UpdateOnce(context.Background(), profiles, fetchers, v4)
+	if err != nil {
+		t.Fatalf("UpdateOnce 不应返回错误: %v", err)
+	}
+	want := []UpdateStatus{UpdateUnchanged, UpdateModified, UpdateAdded, UpdateFailed}
+	if len(results) != len(want) {
+		t.Fatalf("每个域名应有一条结果, 得到 %+v", results)
+	}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("%s %s: 期望 %s, 得到 %s (err=%v)", r.Domain, r.Type, want[i], r.Status, r.Err)
+		}
+	}
+	if r := results[3]; r.Err == nil || r.Addr != nil {
+		t.Errorf("未获取到 IPv4 地址的 A 记录应带失败原因: %+v", r)
+	}
+	if !results[1].Changed() || results[0].Changed() {
+		t.Error("Changed 应只对修改或新增的结果返回 true")
+	}
+
+	if _, err := UpdateOnce(context.Background(), nil, fetchers); err == nil {
+		t.Error("profiles 为空时应返回错误")
+	}
+}
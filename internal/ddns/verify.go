@@ -0,0 +1,329 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+	"github.com/notes-bin/ddns6/pkg/metrics"
+)
+
+// 传播验证的默认参数。
+const (
+	DefaultVerifyTimeout  = 5 * time.Minute  // 等待所有权威服务器返回新值的默认截止时间
+	DefaultVerifyInterval = 10 * time.Second // 默认查询间隔
+)
+
+// verifyQueryTimeout 单次向权威服务器查询的超时时间。
+const verifyQueryTimeout = 5 * time.Second
+
+// 传播验证指标。
+var (
+	propagationDuration = metrics.NewHistogramVec("ddns6_record_propagation_seconds",
+		"Time from a record update until all authoritative nameservers return the new value.",
+		[]float64{1, 5, 10, 30, 60, 120, 300, 600}, "provider")
+	propagationTimeouts = metrics.NewCounterVec("ddns6_record_propagation_timeouts_total",
+		"Number of record updates not propagated to all authoritative nameservers before the deadline.", "provider")
+)
+
+// VerifyConfig 记录更新后的传播验证配置，见 WithVerify。
+type VerifyConfig struct {
+	Timeout  time.Duration // 等待所有权威服务器返回新值的截止时间（<= 0 时使用 DefaultVerifyTimeout）
+	Interval time.Duration // 查询间隔（<= 0 时使用 DefaultVerifyInterval）
+}
+
+// normalize 返回补全默认值后的配置。
+func (c VerifyConfig) normalize() VerifyConfig {
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultVerifyTimeout
+	}
+	if c.Interval <= 0 {
+		c.Interval = DefaultVerifyInterval
+	}
+	return c
+}
+
+// WithVerify 启用记录更新后的传播验证（默认不验证，服务商 API 返回成功即视为完成）。
+//
+// 每次修改或新增记录后，在后台查询记录所在区域的权威服务器（NS 记录），直接向每台服务器的
+// 每个地址查询记录，直到全部返回新值，记录所用时间；超过 cfg.Timeout 仍未全部生效时记录警告、
+// 发出 propagation_timeout 通知，并通过重试队列重新写入记录。
+func WithVerify(cfg VerifyConfig) Option {
+	return func(o *serviceOptions) {
+		cfg = cfg.normalize()
+		o.verify = &cfg
+	}
+}
+
+// lookupNS 返回 zone 的权威服务器主机名（可在测试中替换）。
+var lookupNS = func(ctx context.Context, zone string) ([]string, error) {
+	records, err := net.DefaultResolver.LookupNS(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, 0, len(records))
+	for _, ns := range records {
+		hosts = append(hosts, strings.TrimSuffix(ns.Host, "."))
+	}
+	return hosts, nil
+}
+
+// lookupNSAddrs 返回权威服务器 host 的全部 IPv4 和 IPv6 地址（可在测试中替换）。
+var lookupNSAddrs = func(ctx context.Context, host string) ([]net.IP, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, a := range addrs {
+		ips = append(ips, a.IP)
+	}
+	return ips, nil
+}
+
+// queryNameserver 直接向权威服务器地址 server 查询 fqdn 的 recordType 记录（可在测试中替换）。
+// 应答被截断时返回 ipaddr.ErrDnsTruncated。
+var queryNameserver = func(ctx context.Context, server, fqdn, recordType string) ([]net.IP, error) {
+	qtype := ipaddr.DnsTypeAAAA
+	if recordType == RecordTypeA {
+		qtype = ipaddr.DnsTypeA
+	}
+	return ipaddr.QueryDNS(ctx, server, fqdn, qtype)
+}
+
+// propagationError 截止时间内记录未传播到全部权威服务器。
+type propagationError struct {
+	timeout time.Duration
+	pending []string // 仍未返回新值的权威服务器地址
+}
+
+func (e *propagationError) Error() string {
+	return fmt.Sprintf("record not propagated within %s, stale nameservers: %s", e.timeout, strings.Join(e.pending, ", "))
+}
+
+// findZone 从 fqdn 开始逐级向上查找 NS 记录，返回 fqdn 所在区域（区域切割点）及其权威服务器。
+//
+// 子域名被委派到其他服务器时（如 home.example.com 由另一服务商托管），区域是子域名而不是根域名。
+// 某一级没有 NS 记录（NXDOMAIN 或 NODATA）时继续查上一级，其他错误直接返回。
+func findZone(ctx context.Context, fqdn string) (string, []string, error) {
+	for zone := fqdn; strings.Contains(zone, "."); zone = zone[strings.Index(zone, ".")+1:] {
+		hosts, err := lookupNS(ctx, zone)
+		var dnsErr *net.DNSError
+		if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+			return "", nil, err
+		}
+		if len(hosts) > 0 {
+			return zone, hosts, nil
+		}
+	}
+	return "", nil, fmt.Errorf("no NS records for %s or its parent domains", fqdn)
+}
+
+// nameserverAddrs 解析每台权威服务器的全部地址，返回待查询的服务器地址（IP）到其显示名称的映射，
+// 显示名称形如 ns1.example.net (2001:db8::53)。无法解析的服务器记录警告后忽略。
+func nameserverAddrs(ctx context.Context, nameservers []string) map[string]string {
+	servers := make(map[string]string)
+	for _, ns := range nameservers {
+		ips, err := lookupNSAddrs(ctx, ns)
+		if err != nil || len(ips) == 0 {
+			if ctx.Err() == nil {
+				slog.Warn("cannot resolve authoritative nameserver, skipping it", "module", "ddns",
+					"nameserver", ns, "err", err)
+			}
+			continue
+		}
+		for _, ip := range ips {
+			servers[ip.String()] = fmt.Sprintf("%s (%s)", ns, ip)
+		}
+	}
+	return servers
+}
+
+// verifyPropagation 轮询 d 所在区域每台权威服务器的每个地址，直到全部只返回 addr，返回所用时间。
+//
+// 应答被截断或查询失败的服务器视为尚未生效，下一轮重新查询。
+// 截止时间内未全部生效时返回 *propagationError；查询不到权威服务器时记录警告并返回 nil
+// （无法验证不视为传播失败）。ctx 取消时返回 ctx.Err()。
+func verifyPropagation(ctx context.Context, d *Domain, addr net.IP, cfg VerifyConfig) (time.Duration, error) {
+	fqdn := d.FullDomain()
+	start := time.Now()
+
+	zone, nameservers, err := findZone(ctx, fqdn)
+	var pending map[string]string
+	if err == nil {
+		if pending = nameserverAddrs(ctx, nameservers); len(pending) == 0 {
+			err = fmt.Errorf("no address for nameservers %s", strings.Join(nameservers, ", "))
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		slog.Warn("cannot look up authoritative nameservers, skipping propagation check", "module", "ddns",
+			"domain", d.Domain, "subdomain", d.SubDomain, "err", err)
+		return 0, nil
+	}
+	slog.Debug("verifying record propagation", "module", "ddns",
+		"fqdn", fqdn, "type", d.Type, "addr", addr.String(), "zone", zone, "nameservers", nameservers,
+		"server_count", len(pending))
+
+	deadline := time.NewTimer(cfg.Timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		for server, ns := range pending {
+			qctx, cancel := context.WithTimeout(ctx, verifyQueryTimeout)
+			ips, err := queryNameserver(qctx, server, fqdn, d.Type)
+			cancel()
+			if err != nil {
+				slog.Debug("nameserver query failed", "module", "ddns",
+					"fqdn", fqdn, "nameserver", ns, "err", err)
+				continue
+			}
+			if !onlyAddr(ips, addr) {
+				continue
+			}
+			delete(pending, server)
+			slog.Debug("nameserver returns new value", "module", "ddns",
+				"fqdn", fqdn, "nameserver", ns, "elapsed", time.Since(start).Round(time.Millisecond))
+		}
+		if len(pending) == 0 {
+			return time.Since(start), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-deadline.C:
+			stale := make([]string, 0, len(pending))
+			for _, ns := range pending {
+				stale = append(stale, ns)
+			}
+			sort.Strings(stale)
+			return 0, &propagationError{timeout: cfg.Timeout, pending: stale}
+		case <-ticker.C:
+		}
+	}
+}
+
+// onlyAddr 返回 ips 是否非空且全部等于 addr（多条记录时旧值仍在即未生效）。
+func onlyAddr(ips []net.IP, addr net.IP) bool {
+	if len(ips) == 0 {
+		return false
+	}
+	for _, ip := range ips {
+		if !ip.Equal(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// published 返回 changes 中是否有写入新值的修改（修改、新增或重新启用）。
+func published(changes []recordChange) bool {
+	for _, c := range changes {
+		switch c.action {
+		case changeModified, changeAdded, changeEnabled:
+			return true
+		}
+	}
+	return false
+}
+
+// markRewrite 传播超时后标记域名需重写记录：缓存地址仍为 addr 时清空缓存并设置 rewritePending，
+// 返回是否标记（地址已被新的同步取代时不标记）。
+func (d *Domain) markRewrite(addr net.IP) bool {
+	d.lock()
+	defer d.unlock()
+	if !d.Addr.Equal(addr) {
+		return false
+	}
+	d.Addr = nil
+	d.rewritePending = true
+	return true
+}
+
+// verifyRun 一个域名进行中的传播验证。
+type verifyRun struct {
+	cancel context.CancelFunc
+}
+
+// propagationVerifier 在后台对写入服务商的同步结果做传播验证。
+//
+// 同一域名同时只有一个验证：新的同步结果取消进行中的验证。
+type propagationVerifier struct {
+	cfg       VerifyConfig
+	onTimeout func(o syncOutcome, err error) // 传播超时时调用
+	mu        sync.Mutex
+	running   map[*Domain]*verifyRun
+}
+
+// newPropagationVerifier 创建传播验证器，cfg 为 nil（未启用）时返回 nil，其方法均为空操作。
+func newPropagationVerifier(cfg *VerifyConfig, onTimeout func(o syncOutcome, err error)) *propagationVerifier {
+	if cfg == nil {
+		return nil
+	}
+	return &propagationVerifier{cfg: cfg.normalize(), onTimeout: onTimeout, running: make(map[*Domain]*verifyRun)}
+}
+
+// start 对写入了新值的成功同步结果启动后台验证，其余结果忽略。
+func (v *propagationVerifier) start(ctx context.Context, o syncOutcome) {
+	if v == nil || o.err != nil || o.addr == nil || !published(o.changes) {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	run := &verifyRun{cancel: cancel}
+	v.mu.Lock()
+	if prev := v.running[o.domain]; prev != nil {
+		prev.cancel()
+	}
+	v.running[o.domain] = run
+	v.mu.Unlock()
+
+	go func() {
+		defer func() {
+			cancel()
+			v.mu.Lock()
+			if v.running[o.domain] == run {
+				delete(v.running, o.domain)
+			}
+			v.mu.Unlock()
+		}()
+		v.verify(ctx, o)
+	}()
+}
+
+// verify 执行一次传播验证并记录结果。
+func (v *propagationVerifier) verify(ctx context.Context, o syncOutcome) {
+	d := o.domain
+	elapsed, err := verifyPropagation(ctx, d, o.addr, v.cfg)
+	if ctx.Err() != nil {
+		return
+	}
+	provider := providerName(o.provider)
+	if err == nil {
+		if elapsed > 0 {
+			propagationDuration.WithLabelValues(provider).Observe(elapsed.Seconds())
+			slog.Info("record propagated to all authoritative nameservers", "module", "ddns",
+				"profile", o.profile, "domain", d.Domain, "subdomain", d.SubDomain, "type", d.Type,
+				"addr", o.addr.String(), "elapsed", elapsed.Round(time.Millisecond))
+		}
+		return
+	}
+	propagationTimeouts.WithLabelValues(provider).Inc()
+	slog.Warn("record propagation timed out, rewriting record", "module", "ddns",
+		"profile", o.profile, "domain", d.Domain, "subdomain", d.SubDomain, "type", d.Type,
+		"addr", o.addr.String(), "err", err)
+	if v.onTimeout != nil {
+		v.onTimeout(o, err)
+	}
+}
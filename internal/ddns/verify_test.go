@@ -0,0 +1,202 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// ============================================================
+// 传播验证测试
+// ============================================================
+
+// stubNameservers 替换 lookupNS、lookupNSAddrs 和 queryNameserver：第 i 台权威服务器的地址为 192.0.2.i，
+// answer 返回每台权威服务器当前的应答。
+func stubNameservers(t *testing.T, nameservers []string, answer func(ns string) []net.IP) {
+	t.Helper()
+	hosts := make(map[string]string, len(nameservers))
+	for i, ns := range nameservers {
+		hosts[fmt.Sprintf("192.0.2.%d", i+1)] = ns
+	}
+	origLookup, origAddrs, origQuery := lookupNS, lookupNSAddrs, queryNameserver
+	lookupNS = func(context.Context, string) ([]string, error) { return nameservers, nil }
+	lookupNSAddrs = func(_ context.Context, host string) ([]net.IP, error) {
+		for ip, ns := range hosts {
+			if ns == host {
+				return []net.IP{net.ParseIP(ip)}, nil
+			}
+		}
+		return nil, errors.New("no such host")
+	}
+	queryNameserver = func(_ context.Context, server, _, _ string) ([]net.IP, error) {
+		return answer(hosts[server]), nil
+	}
+	t.Cleanup(func() { lookupNS, lookupNSAddrs, queryNameserver = origLookup, origAddrs, origQuery })
+}
+
+func TestVerifyPropagation_AllNameserversUpdated(t *testing.T) {
+	addr := net.ParseIP("2001:db8::2")
+	var mu sync.Mutex
+	rounds := 0
+	stubNameservers(t, []string{"ns1.example.net", "ns2.example.net"}, func(ns string) []net.IP {
+		mu.Lock()
+		defer mu.Unlock()
+		if ns == "ns2.example.net" {
+			rounds++
+			if rounds < 3 {
+				return []net.IP{net.ParseIP("2001:db8::1"), addr} // 旧值仍在
+			}
+		}
+		return []net.IP{addr}
+	})
+
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	elapsed, err := verifyPropagation(context.Background(), d, addr, VerifyConfig{Timeout: time.Second, Interval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("所有权威服务器返回新值后不应返回错误: %v", err)
+	}
+	if elapsed <= 0 || rounds != 3 {
+		t.Errorf("应轮询到 ns2 返回新值: elapsed=%v rounds=%d", elapsed, rounds)
+	}
+}
+
+func TestVerifyPropagation_Timeout(t *testing.T) {
+	addr := net.ParseIP("2001:db8::2")
+	stubNameservers(t, []string{"ns1.example.net", "ns2.example.net"}, func(ns string) []net.IP {
+		if ns == "ns1.example.net" {
+			return []net.IP{addr}
+		}
+		return []net.IP{net.ParseIP("2001:db8::1")}
+	})
+
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	_, err := verifyPropagation(context.Background(), d, addr, VerifyConfig{Timeout: 50 * time.Millisecond, Interval: 10 * time.Millisecond})
+	var perr *propagationError
+	if !errors.As(err, &perr) {
+		t.Fatalf("截止时间内未生效应返回 propagationError, 得到 %v", err)
+	}
+	if len(perr.pending) != 1 || perr.pending[0] != "ns2.example.net (192.0.2.2)" {
+		t.Errorf("应只列出未生效的 ns2, 得到 %v", perr.pending)
+	}
+
+	// 查询不到权威服务器时跳过验证
+	lookupNS = func(context.Context, string) ([]string, error) { return nil, errors.New("SERVFAIL") }
+	if _, err := verifyPropagation(context.Background(), d, addr, VerifyConfig{Timeout: time.Second, Interval: time.Second}); err != nil {
+		t.Errorf("无法查询权威服务器时不应视为传播失败: %v", err)
+	}
+}
+
+func TestVerifyPropagation_QueriesEveryAddress(t *testing.T) {
+	addr := net.ParseIP("2001:db8::2")
+	stubNameservers(t, []string{"ns1.example.net"}, nil)
+	lookupNSAddrs = func(context.Context, string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::53"), net.ParseIP("2001:db8::54")}, nil
+	}
+	queryNameserver = func(_ context.Context, server, _, _ string) ([]net.IP, error) {
+		switch server {
+		case "2001:db8::53":
+			return []net.IP{net.ParseIP("2001:db8::1")}, nil // 任播节点之一仍返回旧值
+		case "2001:db8::54":
+			return nil, ipaddr.ErrDnsTruncated
+		}
+		return []net.IP{addr}, nil
+	}
+
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	_, err := verifyPropagation(context.Background(), d, addr, VerifyConfig{Timeout: 50 * time.Millisecond, Interval: 10 * time.Millisecond})
+	var perr *propagationError
+	if !errors.As(err, &perr) {
+		t.Fatalf("同一权威服务器的某个地址未生效时应返回 propagationError, 得到 %v", err)
+	}
+	want := []string{"ns1.example.net (2001:db8::53)", "ns1.example.net (2001:db8::54)"}
+	if len(perr.pending) != 2 || perr.pending[0] != want[0] || perr.pending[1] != want[1] {
+		t.Errorf("应列出返回旧值和应答被截断的地址 %v, 得到 %v", want, perr.pending)
+	}
+}
+
+func TestFindZone(t *testing.T) {
+	orig := lookupNS
+	t.Cleanup(func() { lookupNS = orig })
+	var queried []string
+	lookupNS = func(_ context.Context, zone string) ([]string, error) {
+		queried = append(queried, zone)
+		switch zone {
+		case "home.example.com":
+			return []string{"ns1.dynv6.net"}, nil
+		case "example.com":
+			return []string{"ns1.example.net"}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: zone, IsNotFound: true}
+	}
+
+	// 子域名被委派时区域为子域名，而不是根域名
+	zone, ns, err := findZone(context.Background(), "www.home.example.com")
+	if err != nil {
+		t.Fatalf("findZone 不应返回错误: %v", err)
+	}
+	if zone != "home.example.com" || len(ns) != 1 || ns[0] != "ns1.dynv6.net" {
+		t.Errorf("应找到委派的区域 home.example.com, 得到 %s %v", zone, ns)
+	}
+	if len(queried) != 2 {
+		t.Errorf("找到区域后不应继续查询上一级, 查询了 %v", queried)
+	}
+
+	// 查询失败（非 NXDOMAIN/NODATA）时返回错误，不回退到上一级
+	lookupNS = func(_ context.Context, zone string) ([]string, error) {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: zone, IsTemporary: true}
+	}
+	if _, _, err := findZone(context.Background(), "www.example.com"); err == nil {
+		t.Error("NS 查询失败时应返回错误")
+	}
+}
+
+func TestPropagationVerifier_TimeoutRewritesRecord(t *testing.T) {
+	addr := net.ParseIP("2001:db8::2")
+	stubNameservers(t, []string{"ns1.example.net"}, func(string) []net.IP {
+		return []net.IP{net.ParseIP("2001:db8::1")}
+	})
+
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600, Addr: addr}
+	timedOut := make(chan syncOutcome, 1)
+	v := newPropagationVerifier(&VerifyConfig{Timeout: 30 * time.Millisecond, Interval: 10 * time.Millisecond},
+		func(o syncOutcome, err error) {
+			if o.domain.markRewrite(o.addr) {
+				timedOut <- o
+			}
+		})
+
+	// 未写入新值的结果不验证
+	v.start(context.Background(), syncOutcome{domain: d, addr: addr})
+	v.start(context.Background(), syncOutcome{domain: d, addr: addr, changes: []recordChange{{action: changeModified}}})
+	select {
+	case <-timedOut:
+	case <-time.After(2 * time.Second):
+		t.Fatal("传播超时后应调用 onTimeout")
+	}
+	if d.Addr != nil || !d.rewritePending {
+		t.Fatalf("超时后应清空缓存地址并标记重写: addr=%v pending=%v", d.Addr, d.rewritePending)
+	}
+
+	// 服务商记录已是新值，仍应重写一次
+	m := &mockProvider{records: []RecordInfo{{ID: "1", Name: "www.example.com", Type: "AAAA", Value: addr.String(), TTL: 600}}}
+	if err := syncDNSRecord(context.Background(), d, m, addr); err != nil {
+		t.Fatalf("重写不应返回错误: %v", err)
+	}
+	if m.modCalls != 1 || d.rewritePending {
+		t.Errorf("应重写记录一次并清除标记: modCalls=%d pending=%v", m.modCalls, d.rewritePending)
+	}
+
+	// 地址已被新的同步取代时不标记
+	if d.markRewrite(net.ParseIP("2001:db8::3")) {
+		t.Error("缓存地址已变化时不应标记重写")
+	}
+	if newPropagationVerifier(nil, nil) != nil {
+		t.Error("未启用时应返回 nil")
+	}
+}
@@ -0,0 +1,220 @@
+package ddns
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// WithdrawPolicy 本机全局 IPv6 地址被撤回（地址删除、前缀弃用或生命周期到期）后对 AAAA 记录的处理策略。
+//
+// 获取不到 IPv6 且本机接口上已没有全局 IPv6 地址时按策略处理记录，地址恢复后的同步再恢复记录。
+type WithdrawPolicy string
+
+// 地址撤回策略。
+const (
+	WithdrawKeep     WithdrawPolicy = "keep"     // 保留记录不变（默认）
+	WithdrawDelete   WithdrawPolicy = "delete"   // 删除记录
+	WithdrawFallback WithdrawPolicy = "fallback" // 将记录改为 Domain.Fallback
+	WithdrawDisable  WithdrawPolicy = "disable"  // 暂停记录，需服务商实现 RecordDisabler
+)
+
+// WithdrawPolicies 所有地址撤回策略。
+var WithdrawPolicies = []WithdrawPolicy{WithdrawKeep, WithdrawDelete, WithdrawFallback, WithdrawDisable}
+
+// ParseWithdrawPolicy 解析地址撤回策略，空字符串表示 WithdrawKeep。
+func ParseWithdrawPolicy(s string) (WithdrawPolicy, error) {
+	if s == "" {
+		return WithdrawKeep, nil
+	}
+	for _, p := range WithdrawPolicies {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("invalid withdraw policy %q (supported: %v)", s, WithdrawPolicies)
+}
+
+// RecordDisabler 支持暂停（停用）记录的 DNSProvider 可选实现的接口，WithdrawDisable 策略依赖它。
+type RecordDisabler interface {
+	// SetRecordEnabled 启用或暂停一条 DNS 记录（按 record.ID 定位）
+	SetRecordEnabled(ctx context.Context, record RecordInfo, enabled bool) error
+}
+
+// SupportsDisable 返回 p 是否支持暂停记录（实现了 RecordDisabler）。
+func SupportsDisable(p DNSProvider) bool {
+	if ip, ok := p.(*instrumentedProvider); ok {
+		p = ip.next
+	}
+	_, ok := p.(RecordDisabler)
+	return ok
+}
+
+// localIPv6Gone 返回本机（iface 为空时为所有接口）是否已没有可用的全局 IPv6 地址。
+// 检查本身失败（如非 Linux 平台）时返回 false，不撤回任何记录。
+var localIPv6Gone = func(iface string) bool {
+	ok, err := ipaddr.HasGlobalIPv6(iface)
+	if err != nil {
+		slog.Debug("cannot check local IPv6 addresses", "module", "ddns", "interface", iface, "err", err)
+		return false
+	}
+	return !ok
+}
+
+// withdrawPending 返回 domains 中是否有配置了撤回策略、且尚未撤回的 AAAA 记录。
+func withdrawPending(domains []*Domain) bool {
+	for _, d := range domains {
+		if d.withdrawPending() {
+			return true
+		}
+	}
+	return false
+}
+
+// withdrawable 返回域名是否需要在本机地址撤回时处理记录（调用方必须持有 d 的锁）。
+func (d *Domain) withdrawable() bool {
+	return d.Type != RecordTypeA && d.OnWithdraw != "" && d.OnWithdraw != WithdrawKeep
+}
+
+// withdrawPending 返回域名是否配置了撤回策略、且记录尚未撤回（线程安全）。
+func (d *Domain) withdrawPending() bool {
+	d.lock()
+	defer d.unlock()
+	return d.withdrawable() && !d.withdrawn
+}
+
+// withdrawProfiles 对所有 Profile 中配置了撤回策略、尚未撤回的 AAAA 记录执行 withdrawRecord，返回各域名的结果。
+func withdrawProfiles(ctx context.Context, profiles []Profile) []syncOutcome {
+	var outcomes []syncOutcome
+	for _, pr := range profiles {
+		for _, d := range pr.Domains {
+			if !d.withdrawPending() {
+				continue
+			}
+			changes, fallback, err := withdrawRecord(ctx, d, pr.Provider)
+			if err != nil {
+				slog.Error("failed to withdraw record", "module", "ddns",
+					"profile", pr.Name, "domain", d.Domain, "subdomain", d.SubDomain,
+					"err", err)
+			}
+			outcomes = append(outcomes, syncOutcome{profile: pr.Name, domain: d, provider: pr.Provider,
+				addr: fallback, changes: changes, err: err})
+		}
+	}
+	return outcomes
+}
+
+// withdrawRecord 本机全局 IPv6 地址被撤回后，按 d.OnWithdraw 处理服务商记录，返回做出的修改。
+//
+// 成功后清空缓存地址并标记为已撤回：地址恢复后的下一次同步会重新写入记录，
+// WithdrawDisable 时还会重新启用被暂停的记录。已撤回的域名不重复处理。
+// 配置了钩子时与普通更新一样执行 pre/post 钩子，新地址为备用地址（删除和暂停时为空）。
+// 同时返回记录现在发布的地址（WithdrawFallback 时为备用地址，否则为 nil）。
+func withdrawRecord(ctx context.Context, d *Domain, p DNSProvider) (changes []recordChange, published net.IP, err error) {
+	d.lock()
+	defer d.unlock()
+	if d.withdrawn || !d.withdrawable() {
+		return nil, nil, nil
+	}
+
+	fqdn := d.FullDomain()
+	records, err := p.GetRecords(ctx, fqdn, d.Type)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query records: %w", err)
+	}
+
+	value := ""
+	if d.OnWithdraw == WithdrawFallback {
+		published = d.Fallback
+		value = published.String()
+	}
+	hooked, oldValue := false, ""
+	defer func() {
+		if hooked {
+			d.hooks.post(ctx, d, oldValue, value, err)
+		}
+	}()
+
+	var ids []string
+	for _, r := range records {
+		if !RecordNameMatches(r.Name, fqdn, d.SubDomain) || r.Type != d.Type {
+			continue
+		}
+		if d.OnWithdraw == WithdrawFallback && ipv6Equal(d.Fallback, r.Value) {
+			ids = append(ids, r.ID)
+			continue
+		}
+		if !hooked {
+			if err = d.hooks.pre(ctx, d, r.Value, value); err != nil {
+				return changes, nil, err
+			}
+			hooked, oldValue = true, r.Value
+		}
+
+		record := RecordInfo{ID: r.ID, Name: fqdn, Zone: d.Domain, Type: d.Type, Value: r.Value, TTL: r.TTL}
+		change := recordChange{recordID: r.ID, oldValue: r.Value}
+		switch d.OnWithdraw {
+		case WithdrawDelete:
+			err = p.DeleteRecord(ctx, record)
+			change.action = changeDeleted
+		case WithdrawFallback:
+			record.Value, record.TTL = value, d.TTL
+			err = p.ModifyRecord(ctx, record)
+			change.action = changeModified
+			ids = append(ids, r.ID)
+		case WithdrawDisable:
+			dis, ok := p.(RecordDisabler)
+			if !ok {
+				return changes, nil, fmt.Errorf("provider %s does not support disabling records", providerName(p))
+			}
+			err = dis.SetRecordEnabled(ctx, record, false)
+			change.action = changeDisabled
+			ids = append(ids, r.ID)
+		}
+		if err != nil {
+			return changes, nil, fmt.Errorf("failed to withdraw record %s: %w", r.ID, err)
+		}
+		changes = append(changes, change)
+	}
+
+	d.Addr = nil
+	d.withdrawn = true
+	d.recordIDs = ids
+	d.verifiedAt = time.Now()
+	d.trustUntil = time.Time{}
+	if len(changes) > 0 {
+		d.updatedAt = d.verifiedAt
+	}
+	slog.Warn("local IPv6 address withdrawn, record withdrawn", "module", "ddns",
+		"domain", d.Domain, "subdomain", d.SubDomain, "type", d.Type,
+		"policy", d.OnWithdraw, "fallback", value, "changed", len(changes))
+	return changes, published, nil
+}
+
+// restoreWithdrawn 地址恢复后重新启用被 WithdrawDisable 暂停的记录，返回做出的修改。
+// 调用方必须持有 d 的锁，且 ids 为本次同步已写入当前地址的记录。
+func restoreWithdrawn(ctx context.Context, d *Domain, p DNSProvider, ids []string) ([]recordChange, error) {
+	var changes []recordChange
+	if d.OnWithdraw == WithdrawDisable {
+		dis, ok := p.(RecordDisabler)
+		if !ok {
+			return nil, fmt.Errorf("provider %s does not support disabling records", providerName(p))
+		}
+		fqdn := d.FullDomain()
+		for _, id := range ids {
+			err := dis.SetRecordEnabled(ctx, RecordInfo{ID: id, Name: fqdn, Zone: d.Domain, Type: d.Type}, true)
+			if err != nil {
+				return changes, fmt.Errorf("failed to enable record %s: %w", id, err)
+			}
+			changes = append(changes, recordChange{action: changeEnabled, recordID: id})
+		}
+	}
+	d.withdrawn = false
+	slog.Info("local IPv6 address restored, record restored", "module", "ddns",
+		"domain", d.Domain, "subdomain", d.SubDomain, "type", d.Type, "policy", d.OnWithdraw)
+	return changes, nil
+}
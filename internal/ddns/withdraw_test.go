@@ -0,0 +1,190 @@
+package ddns
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+// ============================================================
+// 地址撤回测试
+// ============================================================
+
+// withdrawProvider 记录删除、修改和启停调用的 mockProvider。
+type withdrawProvider struct {
+	mockProvider
+	deleted  []string        // 被删除的记录 ID
+	modified []RecordInfo    // ModifyRecord 收到的记录
+	enabled  map[string]bool // 各记录最近一次 SetRecordEnabled 的参数
+}
+
+func (m *withdrawProvider) DeleteRecord(_ context.Context, r RecordInfo) error {
+	m.deleted = append(m.deleted, r.ID)
+	return nil
+}
+
+func (m *withdrawProvider) ModifyRecord(ctx context.Context, r RecordInfo) error {
+	m.modified = append(m.modified, r)
+	return m.mockProvider.ModifyRecord(ctx, r)
+}
+
+func (m *withdrawProvider) SetRecordEnabled(_ context.Context, r RecordInfo, enabled bool) error {
+	if m.enabled == nil {
+		m.enabled = make(map[string]bool)
+	}
+	m.enabled[r.ID] = enabled
+	return nil
+}
+
+func withdrawRecords() []RecordInfo {
+	return []RecordInfo{
+		{ID: "1", Name: "www.example.com", Type: RecordTypeAAAA, Value: "2001:db8::1", TTL: 600},
+		{ID: "2", Name: "api.example.com", Type: RecordTypeAAAA, Value: "2001:db8::1", TTL: 600},
+	}
+}
+
+func TestParseWithdrawPolicy(t *testing.T) {
+	if p, err := ParseWithdrawPolicy(""); err != nil || p != WithdrawKeep {
+		t.Errorf("空字符串应解析为 keep, 得到 %q, %v", p, err)
+	}
+	if p, err := ParseWithdrawPolicy("disable"); err != nil || p != WithdrawDisable {
+		t.Errorf("disable 解析结果不符: %q, %v", p, err)
+	}
+	if _, err := ParseWithdrawPolicy("remove"); err == nil {
+		t.Error("未知策略应返回错误")
+	}
+}
+
+func TestWithdrawRecord_Delete(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600,
+		Addr: net.ParseIP("2001:db8::1"), OnWithdraw: WithdrawDelete}
+	m := &withdrawProvider{mockProvider: mockProvider{records: withdrawRecords()}}
+
+	changes, published, err := withdrawRecord(context.Background(), d, m)
+	if err != nil {
+		t.Fatalf("withdrawRecord 不应返回错误: %v", err)
+	}
+	if len(m.deleted) != 1 || m.deleted[0] != "1" {
+		t.Errorf("应只删除 www 的记录, 得到 %v", m.deleted)
+	}
+	if len(changes) != 1 || changes[0].action != changeDeleted || published != nil {
+		t.Errorf("修改记录不符: %+v, published=%v", changes, published)
+	}
+	if d.Addr != nil || !d.withdrawn {
+		t.Errorf("撤回后应清空缓存地址并标记已撤回: addr=%v withdrawn=%v", d.Addr, d.withdrawn)
+	}
+
+	// 已撤回的记录不重复处理
+	if _, _, err := withdrawRecord(context.Background(), d, m); err != nil || m.getCalls != 1 {
+		t.Errorf("已撤回的记录不应再次查询服务商: getCalls=%d err=%v", m.getCalls, err)
+	}
+
+	// 地址恢复后重新新增记录
+	m.records = nil
+	changes, err = syncRecord(context.Background(), d, net.ParseIP("2001:db8::2"), m)
+	if err != nil || m.addCalls != 1 || d.withdrawn {
+		t.Errorf("地址恢复后应新增记录并清除撤回标记: addCalls=%d withdrawn=%v err=%v", m.addCalls, d.withdrawn, err)
+	}
+	if len(changes) != 1 || changes[0].action != changeAdded {
+		t.Errorf("恢复时的修改记录不符: %+v", changes)
+	}
+}
+
+func TestWithdrawRecord_Fallback(t *testing.T) {
+	fallback := net.ParseIP("2001:db8:ffff::1")
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 300,
+		OnWithdraw: WithdrawFallback, Fallback: fallback}
+	m := &withdrawProvider{mockProvider: mockProvider{records: withdrawRecords()}}
+
+	_, published, err := withdrawRecord(context.Background(), d, m)
+	if err != nil {
+		t.Fatalf("withdrawRecord 不应返回错误: %v", err)
+	}
+	if len(m.modified) != 1 || m.modified[0].Value != fallback.String() || m.modified[0].TTL != 300 {
+		t.Errorf("应将记录改为备用地址: %+v", m.modified)
+	}
+	if !published.Equal(fallback) {
+		t.Errorf("发布的地址应为备用地址, 得到 %v", published)
+	}
+}
+
+func TestWithdrawRecord_DisableAndRestore(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, TTL: 600,
+		OnWithdraw: WithdrawDisable}
+	m := &withdrawProvider{mockProvider: mockProvider{records: withdrawRecords()}}
+	p := instrumentProvider(m)
+
+	if _, _, err := withdrawRecord(context.Background(), d, p); err != nil {
+		t.Fatalf("withdrawRecord 不应返回错误: %v", err)
+	}
+	if enabled, ok := m.enabled["1"]; !ok || enabled {
+		t.Fatalf("应暂停 www 的记录: %v", m.enabled)
+	}
+
+	// 地址恢复且与原记录一致：不修改记录，只重新启用
+	changes, err := syncRecord(context.Background(), d, net.ParseIP("2001:db8::1"), p)
+	if err != nil {
+		t.Fatalf("syncRecord 不应返回错误: %v", err)
+	}
+	if !m.enabled["1"] || m.modCalls != 0 || d.withdrawn {
+		t.Errorf("地址恢复后应重新启用记录: enabled=%v modCalls=%d withdrawn=%v", m.enabled, m.modCalls, d.withdrawn)
+	}
+	if len(changes) != 1 || changes[0].action != changeEnabled || changes[0].recordID != "1" {
+		t.Errorf("恢复时的修改记录不符: %+v", changes)
+	}
+}
+
+func TestWithdrawRecord_DisableUnsupported(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, OnWithdraw: WithdrawDisable}
+	m := &mockProvider{records: withdrawRecords()}
+	if SupportsDisable(instrumentProvider(m)) {
+		t.Error("未实现 RecordDisabler 的服务商不应支持暂停记录")
+	}
+	if _, _, err := withdrawRecord(context.Background(), d, instrumentProvider(m)); err == nil {
+		t.Error("服务商不支持暂停记录时应返回错误")
+	}
+	if d.withdrawn {
+		t.Error("撤回失败时不应标记已撤回")
+	}
+}
+
+func TestWithdrawProfiles_SkipsKeepAndA(t *testing.T) {
+	keep := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA}
+	v4 := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeA, OnWithdraw: WithdrawDelete}
+	del := &Domain{Domain: "example.com", SubDomain: "api", Type: RecordTypeAAAA, OnWithdraw: WithdrawDelete}
+	m := &withdrawProvider{mockProvider: mockProvider{records: withdrawRecords()}}
+	profiles := []Profile{{Name: "p", Provider: m, Domains: []*Domain{keep, v4, del}}}
+
+	if !withdrawPending(profiles[0].Domains) {
+		t.Fatal("存在未撤回的 delete 策略域名时应返回 true")
+	}
+	outcomes := withdrawProfiles(context.Background(), profiles)
+	if len(outcomes) != 1 || outcomes[0].domain != del || outcomes[0].err != nil {
+		t.Fatalf("只应撤回配置了策略的 AAAA 记录: %+v", outcomes)
+	}
+	if withdrawPending(profiles[0].Domains) {
+		t.Error("全部撤回后应返回 false")
+	}
+}
+
+func TestState_WithdrawnPersisted(t *testing.T) {
+	d := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, OnWithdraw: WithdrawDelete}
+	m := &withdrawProvider{mockProvider: mockProvider{records: withdrawRecords()}}
+	if _, _, err := withdrawRecord(context.Background(), d, m); err != nil {
+		t.Fatalf("withdrawRecord 不应返回错误: %v", err)
+	}
+
+	store := NewStateStore(filepath.Join(t.TempDir(), "state.json"))
+	saveState(stateProfiles(d), store)
+	if st, ok := store.Get("default", d); !ok || !st.Withdrawn {
+		t.Fatalf("已撤回的域名应写入状态: %+v", st)
+	}
+
+	// 重启后即使禁用对账窗口也恢复撤回标记，缓存地址保持为空
+	restarted := &Domain{Domain: "example.com", SubDomain: "www", Type: RecordTypeAAAA, OnWithdraw: WithdrawDelete}
+	restoreState(stateProfiles(restarted), store, 0)
+	if !restarted.withdrawn || restarted.Addr != nil {
+		t.Errorf("应恢复撤回标记且不预填地址: withdrawn=%v addr=%v", restarted.withdrawn, restarted.Addr)
+	}
+}
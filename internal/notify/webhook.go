@@ -0,0 +1,375 @@
+// Package notify 将 DDNS 服务的通知事件（ddns.Event）发送到 webhook。
+//
+// 每个 webhook 的 URL 和请求体都是 Go text/template 模板，数据为 ddns.Event，
+// 因此同一套机制可以对接钉钉、企业微信、飞书、Slack、Bark、ntfy 等任意 HTTP 接口：
+//
+//	# 钉钉机器人
+//	url: https://oapi.dingtalk.com/robot/send?access_token=xxx
+//	body: '{"msgtype":"text","text":{"content":{{json .Message}}}}'
+//
+//	# Bark（GET 请求，消息放在 URL 中）
+//	method: GET
+//	url: https://api.day.app/your-key/{{urlquery .Message}}
+//
+// 事件异步发送：Notify 只入队，不阻塞同步流程。每个 webhook 独立限流（令牌桶，
+// 超出的事件丢弃并记录日志），发送失败时按 retry.Policy 重试网络错误、429 和 5xx。
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
+)
+
+// DefaultRateLimit 每个 webhook 默认每分钟最多发送的通知数。
+const DefaultRateLimit = 10
+
+// queueSize 每个 webhook 的待发送队列长度，队列满时丢弃新事件。
+const queueSize = 64
+
+// Webhook 单个 webhook 的配置。
+type Webhook struct {
+	Name    string            // 名称，用于日志（默认为 URL 的主机名）
+	URL     string            // 请求 URL（text/template 模板）
+	Method  string            // HTTP 方法（默认 POST）
+	Headers map[string]string // 附加请求头
+	Body    string            // 请求体（text/template 模板）；为空时 POST 发送事件的 JSON，GET 不带请求体
+	Events  []ddns.EventType  // 订阅的事件类型（为空表示全部）
+}
+
+// Validate 校验 webhook 配置，包括模板语法和事件类型。
+func (w Webhook) Validate() error {
+	_, err := newHook(w)
+	return err
+}
+
+// templateFuncs 模板中可用的附加函数。
+//
+//	json: 将值编码为 JSON（字符串带引号并转义），用于拼接 JSON 请求体
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Option Dispatcher 的可选配置项。
+type Option func(*Dispatcher)
+
+// WithRateLimit 设置每个 webhook 每分钟最多发送的通知数（默认 DefaultRateLimit，<= 0 表示不限）。
+func WithRateLimit(perMinute int) Option {
+	return func(d *Dispatcher) {
+		d.rateLimit = perMinute
+	}
+}
+
+// WithRetryPolicy 设置发送失败时的重试策略（默认 retry.DefaultPolicy）。
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(d *Dispatcher) {
+		d.policy = p
+	}
+}
+
+// WithHTTPClient 设置发送请求使用的 HTTP 客户端（默认超时 10 秒）。
+func WithHTTPClient(c *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = c
+	}
+}
+
+// Dispatcher 将通知事件分发到多个 webhook，实现 ddns.Notifier。
+type Dispatcher struct {
+	rateLimit int
+	policy    retry.Policy
+	client    *http.Client
+
+	hooks  []*hook
+	mu     sync.RWMutex // 保护 closed，防止向已关闭的队列发送
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// New 校验 webhook 配置并启动发送协程。使用完毕后应调用 Close。
+func New(webhooks []Webhook, opts ...Option) (*Dispatcher, error) {
+	d := &Dispatcher{
+		rateLimit: DefaultRateLimit,
+		policy:    retry.DefaultPolicy,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	for i, w := range webhooks {
+		h, err := newHook(w)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %d (%s): %w", i, h.displayName(w), err)
+		}
+		h.limiter = newLimiter(d.rateLimit, time.Minute)
+		d.hooks = append(d.hooks, h)
+	}
+	for _, h := range d.hooks {
+		d.wg.Add(1)
+		go d.worker(h)
+	}
+	return d, nil
+}
+
+// Notify 将事件放入订阅了该事件类型的 webhook 队列。
+//
+// 超出限流或队列已满时丢弃事件并记录警告；Close 之后调用时忽略。
+func (d *Dispatcher) Notify(e ddns.Event) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+	for _, h := range d.hooks {
+		if !h.subscribed(e.Type) {
+			continue
+		}
+		if !h.limiter.allow() {
+			slog.Warn("notification dropped by rate limit", "module", "notify",
+				"webhook", h.name, "event", e.Type, "domain", e.Domain)
+			continue
+		}
+		select {
+		case h.queue <- e:
+		default:
+			slog.Warn("notification queue full, dropping event", "module", "notify",
+				"webhook", h.name, "event", e.Type, "domain", e.Domain)
+		}
+	}
+}
+
+// Close 停止接收新事件，等待已入队的通知发送完成，直到 ctx 取消。
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, h := range d.hooks {
+			close(h.queue)
+		}
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("pending notifications not sent: %w", ctx.Err())
+	}
+}
+
+// worker 逐个发送 h 队列中的事件，直到队列关闭。
+func (d *Dispatcher) worker(h *hook) {
+	defer d.wg.Done()
+	for e := range h.queue {
+		if err := d.send(context.Background(), h, e); err != nil {
+			slog.Error("failed to send notification", "module", "notify",
+				"webhook", h.name, "event", e.Type, "domain", e.Domain, "err", err)
+			continue
+		}
+		slog.Debug("notification sent", "module", "notify",
+			"webhook", h.name, "event", e.Type, "domain", e.Domain)
+	}
+}
+
+// send 渲染模板并发送一次通知，临时错误按 d.policy 重试，最终非 2xx 响应视为失败。
+func (d *Dispatcher) send(ctx context.Context, h *hook, e ddns.Event) error {
+	url, body, err := h.render(e)
+	if err != nil {
+		return err
+	}
+	resp, err := d.policy.DoHTTP(ctx, d.client, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, h.method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > 0 {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for k, v := range h.headers {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// hook 解析后的 webhook。
+type hook struct {
+	name    string
+	method  string
+	url     *template.Template
+	body    *template.Template // nil 表示使用默认请求体
+	headers map[string]string
+	events  map[ddns.EventType]bool // nil 表示订阅全部
+	limiter *limiter
+	queue   chan ddns.Event
+}
+
+// newHook 解析 webhook 配置。返回错误时 hook 仍非 nil，仅用于 displayName。
+func newHook(w Webhook) (*hook, error) {
+	h := &hook{
+		name:    w.Name,
+		method:  strings.ToUpper(w.Method),
+		headers: w.Headers,
+		queue:   make(chan ddns.Event, queueSize),
+	}
+	if h.method == "" {
+		h.method = http.MethodPost
+	}
+	if w.URL == "" {
+		return h, fmt.Errorf("url is required")
+	}
+	var err error
+	if h.url, err = template.New("url").Funcs(templateFuncs).Parse(w.URL); err != nil {
+		return h, fmt.Errorf("invalid url template: %w", err)
+	}
+	if w.Body != "" {
+		if h.body, err = template.New("body").Funcs(templateFuncs).Parse(w.Body); err != nil {
+			return h, fmt.Errorf("invalid body template: %w", err)
+		}
+	}
+	if len(w.Events) > 0 {
+		h.events = make(map[ddns.EventType]bool)
+		for _, t := range w.Events {
+			if !validEventType(t) {
+				return h, fmt.Errorf("unknown event %q (supported: %s)", t, eventTypeList())
+			}
+			h.events[t] = true
+		}
+	}
+	if h.name == "" {
+		h.name = hostOf(w.URL)
+	}
+	return h, nil
+}
+
+// displayName 返回用于错误信息的 webhook 名称。
+func (h *hook) displayName(w Webhook) string {
+	if h != nil && h.name != "" {
+		return h.name
+	}
+	return hostOf(w.URL)
+}
+
+// subscribed 判断 webhook 是否订阅了事件类型 t。
+func (h *hook) subscribed(t ddns.EventType) bool {
+	return h.events == nil || h.events[t]
+}
+
+// render 渲染请求 URL 和请求体。
+func (h *hook) render(e ddns.Event) (string, []byte, error) {
+	var url bytes.Buffer
+	if err := h.url.Execute(&url, e); err != nil {
+		return "", nil, fmt.Errorf("cannot render url template: %w", err)
+	}
+	if h.body == nil {
+		if h.method == http.MethodGet || h.method == http.MethodHead {
+			return url.String(), nil, nil
+		}
+		body, err := json.Marshal(e)
+		return url.String(), body, err
+	}
+	var body bytes.Buffer
+	if err := h.body.Execute(&body, e); err != nil {
+		return "", nil, fmt.Errorf("cannot render body template: %w", err)
+	}
+	return url.String(), body.Bytes(), nil
+}
+
+// validEventType 判断 t 是否为已知的事件类型。
+func validEventType(t ddns.EventType) bool {
+	for _, known := range ddns.EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// eventTypeList 返回逗号分隔的事件类型列表，用于错误信息。
+func eventTypeList() string {
+	names := make([]string, len(ddns.EventTypes))
+	for i, t := range ddns.EventTypes {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
+// hostOf 返回 URL 中的主机名，URL 中含模板或无法识别时返回原串。
+// 不返回路径和查询字符串，其中可能含有 token。
+func hostOf(rawURL string) string {
+	s := rawURL
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	if i := strings.IndexAny(s, "/?#"); i >= 0 {
+		s = s[:i]
+	}
+	if s == "" {
+		return rawURL
+	}
+	return s
+}
+
+// limiter 令牌桶限流器：容量为 n，每 per 时间补满 n 个令牌。n <= 0 表示不限流。
+type limiter struct {
+	mu     sync.Mutex
+	n      float64
+	rate   float64 // 每秒补充的令牌数
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// newLimiter 创建每 per 时间最多放行 n 次的限流器。
+func newLimiter(n int, per time.Duration) *limiter {
+	l := &limiter{n: float64(n), tokens: float64(n), now: time.Now}
+	if n > 0 {
+		l.rate = float64(n) / per.Seconds()
+	}
+	l.last = l.now()
+	return l
+}
+
+// allow 消耗一个令牌，没有可用令牌时返回 false。
+func (l *limiter) allow() bool {
+	if l.n <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now := l.now(); now.After(l.last) {
+		l.tokens = min(l.n, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+	}
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
+)
+
+// ============================================================
+// webhook 发送测试
+// ============================================================
+
+// request 测试服务器收到的请求。
+type request struct {
+	method string
+	path   string
+	header http.Header
+	body   string
+}
+
+// newTestServer 返回记录请求的测试服务器；status 依次作为响应状态码，用完后返回 200。
+func newTestServer(t *testing.T, status ...int) (*httptest.Server, func() []request) {
+	t.Helper()
+	var mu sync.Mutex
+	var reqs []request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		reqs = append(reqs, request{r.Method, r.URL.RequestURI(), r.Header, string(body)})
+		code := http.StatusOK
+		if len(status) > 0 {
+			code, status = status[0], status[1:]
+		}
+		mu.Unlock()
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]request(nil), reqs...)
+	}
+}
+
+// closeDispatcher 等待所有通知发送完成。
+func closeDispatcher(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Close(ctx); err != nil {
+		t.Fatalf("Close 不应返回错误: %v", err)
+	}
+}
+
+var testEvent = ddns.Event{
+	Type: ddns.EventRecordUpdated, Domain: "www.example.com", RecordType: "AAAA",
+	OldAddr: "2001:db8::1", NewAddr: "2001:db8::2",
+}
+
+func TestDispatcher_BodyTemplate(t *testing.T) {
+	ts, reqs := newTestServer(t)
+	d, err := New([]Webhook{{
+		URL:     ts.URL + "/robot/send?token=abc",
+		Headers: map[string]string{"X-Token": "secret"},
+		Body:    `{"text":{{json .Message}},"new":"{{.NewAddr}}"}`,
+	}})
+	if err != nil {
+		t.Fatalf("New 不应返回错误: %v", err)
+	}
+	d.Notify(testEvent)
+	closeDispatcher(t, d)
+
+	got := reqs()
+	if len(got) != 1 {
+		t.Fatalf("应发送 1 个请求, 得到 %d", len(got))
+	}
+	r := got[0]
+	if r.method != http.MethodPost || r.path != "/robot/send?token=abc" || r.header.Get("X-Token") != "secret" {
+		t.Errorf("请求方法、路径或请求头不符: %+v", r)
+	}
+	var body struct{ Text, New string }
+	if err := json.Unmarshal([]byte(r.body), &body); err != nil {
+		t.Fatalf("请求体应为合法 JSON: %v (%s)", err, r.body)
+	}
+	if body.New != "2001:db8::2" || body.Text != testEvent.Message() {
+		t.Errorf("请求体内容不符: %+v", body)
+	}
+}
+
+func TestDispatcher_DefaultBodyAndGetURLTemplate(t *testing.T) {
+	ts, reqs := newTestServer(t)
+	d, err := New([]Webhook{
+		{URL: ts.URL + "/json"},
+		{Method: "get", URL: ts.URL + "/bark/{{urlquery .NewAddr}}"},
+	})
+	if err != nil {
+		t.Fatalf("New 不应返回错误: %v", err)
+	}
+	d.Notify(testEvent)
+	closeDispatcher(t, d)
+
+	for _, r := range reqs() {
+		switch r.method {
+		case http.MethodPost:
+			var ev ddns.Event
+			if err := json.Unmarshal([]byte(r.body), &ev); err != nil || ev.OldAddr != "2001:db8::1" {
+				t.Errorf("默认请求体应为事件 JSON: %s", r.body)
+			}
+		case http.MethodGet:
+			if r.path != "/bark/2001%3Adb8%3A%3A2" || r.body != "" {
+				t.Errorf("GET 请求应渲染 URL 模板且不带请求体: %+v", r)
+			}
+		}
+	}
+	if n := len(reqs()); n != 2 {
+		t.Errorf("两个 webhook 各应收到 1 个请求, 得到 %d", n)
+	}
+}
+
+func TestDispatcher_EventFilter(t *testing.T) {
+	ts, reqs := newTestServer(t)
+	d, err := New([]Webhook{{URL: ts.URL, Events: []ddns.EventType{ddns.EventSyncFailed}}})
+	if err != nil {
+		t.Fatalf("New 不应返回错误: %v", err)
+	}
+	d.Notify(testEvent)
+	d.Notify(ddns.Event{Type: ddns.EventSyncFailed, Domain: "www.example.com"})
+	closeDispatcher(t, d)
+
+	if got := reqs(); len(got) != 1 {
+		t.Errorf("只应发送订阅的事件, 得到 %d 个请求", len(got))
+	}
+}
+
+func TestDispatcher_RateLimit(t *testing.T) {
+	ts, reqs := newTestServer(t)
+	d, err := New([]Webhook{{URL: ts.URL}}, WithRateLimit(2))
+	if err != nil {
+		t.Fatalf("New 不应返回错误: %v", err)
+	}
+	for range 5 {
+		d.Notify(testEvent)
+	}
+	closeDispatcher(t, d)
+
+	if got := reqs(); len(got) != 2 {
+		t.Errorf("超出限流的事件应丢弃, 期望 2 个请求, 得到 %d", len(got))
+	}
+}
+
+func TestDispatcher_RetriesServerError(t *testing.T) {
+	ts, reqs := newTestServer(t, http.StatusBadGateway)
+	d, err := New([]Webhook{{URL: ts.URL}},
+		WithRetryPolicy(retry.Policy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("New 不应返回错误: %v", err)
+	}
+	d.Notify(testEvent)
+	closeDispatcher(t, d)
+
+	if got := reqs(); len(got) != 2 {
+		t.Errorf("5xx 响应应重试, 期望 2 个请求, 得到 %d", len(got))
+	}
+}
+
+func TestWebhook_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		w    Webhook
+	}{
+		{"缺少 URL", Webhook{}},
+		{"URL 模板错误", Webhook{URL: "https://example.com/{{.Message"}},
+		{"请求体模板错误", Webhook{URL: "https://example.com", Body: "{{if}}"}},
+		{"未知事件", Webhook{URL: "https://example.com", Events: []ddns.EventType{"updated"}}},
+	}
+	for _, tt := range tests {
+		if err := tt.w.Validate(); err == nil {
+			t.Errorf("%s: Validate 应返回错误", tt.name)
+		}
+	}
+	if err := (Webhook{URL: "https://example.com", Events: ddns.EventTypes}).Validate(); err != nil {
+		t.Errorf("合法配置不应返回错误: %v", err)
+	}
+}
+
+func TestLimiter_Refill(t *testing.T) {
+	now := time.Now()
+	l := newLimiter(2, time.Minute)
+	l.now = func() time.Time { return now }
+	l.last = now
+	if !l.allow() || !l.allow() || l.allow() {
+		t.Fatal("容量为 2 的限流器应放行 2 次后拒绝")
+	}
+	now = now.Add(30 * time.Second)
+	if !l.allow() || l.allow() {
+		t.Error("30 秒后应补充 1 个令牌")
+	}
+}
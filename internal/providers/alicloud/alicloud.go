@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 // AliDNSClient 阿里云 DNS API 客户端
@@ -34,6 +35,7 @@ type AliDNSClient struct {
 	BaseURL         string
 	HTTPClient      *http.Client
 	SignVersion     string // 签名版本："v1"（默认，HMAC-SHA1）或 "v3"（ACS3-HMAC-SHA256）
+	retry           retry.Policy
 }
 
 type Option func(*AliDNSClient)
@@ -46,6 +48,7 @@ func NewClient(accessKeyId, accessKeySecret string, options ...Option) *AliDNSCl
 		BaseURL:         "https://alidns.aliyuncs.com/",
 		HTTPClient:      &http.Client{Timeout: 30 * time.Second},
 		SignVersion:     "v1",
+		retry:           retry.DefaultPolicy,
 	}
 
 	for _, option := range options {
@@ -76,6 +79,13 @@ func WithSignVersion(version string) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *AliDNSClient) {
+		c.retry = p
+	}
+}
+
 // DNSRecord  an Alibaba Cloud DNS record
 type DNSRecord struct {
 	RecordId string `json:"RecordId"`
@@ -138,6 +148,22 @@ func (c *AliDNSClient) DeleteRecord(ctx context.Context, record ddns.RecordInfo)
 	return err
 }
 
+// SetRecordEnabled 启用或暂停域名解析记录，实现 ddns.RecordDisabler。
+func (c *AliDNSClient) SetRecordEnabled(ctx context.Context, record ddns.RecordInfo, enabled bool) error {
+	status := "Disable"
+	if enabled {
+		status = "Enable"
+	}
+	params := map[string]string{
+		"Action":   "SetDomainRecordStatus",
+		"RecordId": record.ID,
+		"Status":   status,
+	}
+
+	_, err := c.makeRequest(ctx, params)
+	return err
+}
+
 // GetRecords 查询域名的解析记录，返回通用 RecordInfo 列表
 func (c *AliDNSClient) GetRecords(ctx context.Context, fulldomain, recordType string) ([]ddns.RecordInfo, error) {
 	domain, subDomain, err := c.getRootDomain(ctx, fulldomain)
@@ -258,14 +284,72 @@ func (c *AliDNSClient) makeRequest(ctx context.Context, params map[string]string
 	return c.makeV1Request(ctx, params)
 }
 
-// makeV1Request 使用 V1 签名（HMAC-SHA1）发起认证请求。
+// markReplayable 按 action 声明请求是否可安全重放。
 //
-// 签名方式：所有参数放入查询字符串，对整个查询字符串进行 HMAC-SHA1 签名，
-// 签名结果附加在 URL 末尾。
+// 阿里云 API 的所有操作都以 GET 发送，不能按 HTTP 方法判断：Add* 操作（如 AddDomainRecord）
+// 重放会创建重复记录，请求发出后遇到网络错误时不重试；查询、修改、删除可安全重放。
+func markReplayable(req *http.Request, action string) {
+	if strings.HasPrefix(action, "Add") {
+		retry.MarkNotReplayable(req)
+	}
+}
+
+// makeV1Request 使用 V1 签名（HMAC-SHA1）发起认证请求，签名方式见 signV1URL。
 func (c *AliDNSClient) makeV1Request(ctx context.Context, params map[string]string) ([]byte, error) {
 	action := params["Action"]
 	slog.Debug("Alibaba Cloud API request", "module", "alicloud", "action", action)
 
+	// 每次尝试使用新的时间戳和 SignatureNonce 重新签名（阿里云拒绝重复的 nonce）
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", c.signV1URL(params), nil)
+		if err != nil {
+			return nil, err
+		}
+		markReplayable(req, action)
+		return req, nil
+	}
+
+	// 发起请求（网络错误、5xx 和限流错误码自动重试，新增记录的请求发出后不重试网络错误）
+	resp, err := c.retry.DoHTTP(ctx, c.HTTPClient, newReq, throttled)
+	if err != nil {
+		slog.Error("Alibaba Cloud API request failed", "module", "alicloud", "action", action, "err", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	slog.Debug("Alibaba Cloud API response", "module", "alicloud", "action", action, "status", resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		slog.Debug("Alibaba Cloud API returned non-200 status",
+			"module", "alicloud",
+			"action", action, "status", resp.StatusCode)
+		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for API errors
+	var apiError struct {
+		Message string `json:"Message"`
+	}
+	if err := json.Unmarshal(body, &apiError); err == nil && apiError.Message != "" {
+		slog.Error("Alibaba Cloud API business error",
+			"module", "alicloud",
+			"action", action, "message", apiError.Message)
+		return nil, fmt.Errorf("API error: %s", apiError.Message)
+	}
+
+	return body, nil
+}
+
+// signV1URL 为 params 计算 V1 签名，返回完整的请求 URL。
+//
+// 签名方式：所有参数放入查询字符串，对整个查询字符串进行 HMAC-SHA1 签名，
+// 签名结果附加在 URL 末尾。每次调用都会生成新的 Timestamp 和 SignatureNonce。
+func (c *AliDNSClient) signV1URL(params map[string]string) string {
 	// 复制参数避免污染调用方 map
 	reqParams := make(map[string]string, len(params)+8)
 	maps.Copy(reqParams, params)
@@ -299,46 +383,22 @@ func (c *AliDNSClient) makeV1Request(ctx context.Context, params map[string]stri
 	signature = url.QueryEscape(signature)
 
 	// Build final URL (注意：不记录完整 URL 以免泄露签名和 AccessKeyId)
-	fullURL := fmt.Sprintf("%s?%s&Signature=%s", c.BaseURL, queryString, signature)
-
-	// 发起请求
-	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		slog.Error("Alibaba Cloud API request failed", "module", "alicloud", "action", action, "err", err)
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	slog.Debug("Alibaba Cloud API response", "module", "alicloud", "action", action, "status", resp.StatusCode)
-
-	if resp.StatusCode != http.StatusOK {
-		slog.Debug("Alibaba Cloud API returned non-200 status",
-			"module", "alicloud",
-			"action", action, "status", resp.StatusCode)
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+	return fmt.Sprintf("%s?%s&Signature=%s", c.BaseURL, queryString, signature)
+}
 
-	// Check for API errors
+// throttled 判断响应是否为可重试的限流错误。
+//
+// 阿里云限流以 HTTP 400 返回，错误码为 Throttling 或 Throttling.User、Throttling.Api 等子码；
+// 服务端临时不可用时返回 ServiceUnavailable。
+func throttled(_ *http.Response, body []byte) bool {
 	var apiError struct {
-		Message string `json:"Message"`
+		Code string `json:"Code"`
 	}
-	if err := json.Unmarshal(body, &apiError); err == nil && apiError.Message != "" {
-		slog.Error("Alibaba Cloud API business error",
-			"module", "alicloud",
-			"action", action, "message", apiError.Message)
-		return nil, fmt.Errorf("API error: %s", apiError.Message)
+	if err := json.Unmarshal(body, &apiError); err != nil {
+		return false
 	}
-
-	return body, nil
+	return apiError.Code == "Throttling" || strings.HasPrefix(apiError.Code, "Throttling.") ||
+		apiError.Code == "ServiceUnavailable"
 }
 
 // makeV3Request 使用 V3 签名（ACS3-HMAC-SHA256）发起认证请求。
@@ -389,13 +449,18 @@ func (c *AliDNSClient) makeV3Request(ctx context.Context, params map[string]stri
 		Headers:         headers,
 	}
 
-	httpReq, err := SignV3(ctx, v3Req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to sign request: %w", err)
+	// 每次尝试重新签名（x-acs-signature-nonce 不能复用）
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		httpReq, err := SignV3(ctx, v3Req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+		markReplayable(httpReq, action)
+		return httpReq, nil
 	}
 
-	// 发起请求
-	resp, err := c.HTTPClient.Do(httpReq)
+	// 发起请求（网络错误、5xx 和限流错误码自动重试，新增记录的请求发出后不重试网络错误）
+	resp, err := c.retry.DoHTTP(ctx, c.HTTPClient, newReq, throttled)
 	if err != nil {
 		slog.Error("Alibaba Cloud API V3 request failed", "module", "alicloud", "action", action, "err", err)
 		return nil, err
@@ -4,10 +4,14 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 var ctx = context.Background()
@@ -57,6 +61,26 @@ func TestDeleteRecord(t *testing.T) {
 	}
 }
 
+func TestSetRecordEnabled(t *testing.T) {
+	var query url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"RequestId": "test-request-id", "RecordId": "123456", "Status": "Enable"}`))
+	}))
+	defer ts.Close()
+
+	client := NewClient("test-key", "test-secret", WithBaseURL(ts.URL))
+
+	err := client.SetRecordEnabled(ctx, ddns.RecordInfo{Name: "test.example.com", ID: "123456"}, true)
+	if err != nil {
+		t.Fatalf("SetRecordEnabled failed: %v", err)
+	}
+	if query.Get("Action") != "SetDomainRecordStatus" || query.Get("Status") != "Enable" || query.Get("RecordId") != "123456" {
+		t.Errorf("Unexpected request query: %v", query)
+	}
+}
+
 func TestGetRecords(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -167,3 +191,57 @@ func TestMakeV3Request(t *testing.T) {
 		t.Errorf("makeV3Request failed: %v", err)
 	}
 }
+
+func TestAddRecord_ResponseDroppedNotRetried(t *testing.T) {
+	for _, version := range []string{"v1", "v3"} {
+		t.Run(version, func(t *testing.T) {
+			var adds, modifies atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				action := r.URL.Query().Get("Action")
+				if version == "v3" {
+					action = r.Header.Get("x-acs-action")
+				}
+				switch action {
+				case "AddDomainRecord", "UpdateDomainRecord":
+					if action == "AddDomainRecord" {
+						adds.Add(1)
+						if r.ContentLength != 0 || len(r.TransferEncoding) > 0 {
+							t.Errorf("AddDomainRecord 不应带请求体, ContentLength=%d TransferEncoding=%v", r.ContentLength, r.TransferEncoding)
+						}
+					} else {
+						modifies.Add(1)
+					}
+					// 服务端已收到请求，但响应丢失
+					conn, _, err := w.(http.Hijacker).Hijack()
+					if err != nil {
+						t.Errorf("hijack: %v", err)
+						return
+					}
+					conn.Close()
+				default:
+					w.Write([]byte(`{"RequestId": "test-request-id", "TotalCount": 1}`))
+				}
+			}))
+			defer ts.Close()
+
+			client := NewClient("test-key", "test-secret", WithBaseURL(ts.URL), WithSignVersion(version),
+				WithRetryPolicy(retry.Policy{Attempts: 3, BaseDelay: time.Millisecond}))
+			record := ddns.RecordInfo{ID: "123456", Name: "test.example.com", Type: "AAAA", Value: "2001:db8::1", TTL: 600}
+
+			if err := client.AddRecord(ctx, record); err == nil {
+				t.Fatal("响应丢失时 AddRecord 应返回错误")
+			}
+			if n := adds.Load(); n != 1 {
+				t.Errorf("AddDomainRecord 发出后不应重放（可能创建重复记录）, 实际发送 %d 次", n)
+			}
+
+			// 修改记录可安全重放
+			if err := client.ModifyRecord(ctx, record); err == nil {
+				t.Fatal("响应丢失时 ModifyRecord 应返回错误")
+			}
+			if n := modifies.Load(); n < 3 {
+				t.Errorf("UpdateDomainRecord 应按重试策略重放, 实际发送 %d 次", n)
+			}
+		})
+	}
+}
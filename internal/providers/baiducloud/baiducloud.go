@@ -16,6 +16,7 @@ import (
 	"github.com/notes-bin/ddns6/internal/crypto"
 	"github.com/notes-bin/ddns6/internal/ddns"
 	"github.com/notes-bin/ddns6/pkg/domainutil"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 const (
@@ -27,6 +28,7 @@ type Client struct {
 	accessKey string
 	secretKey string
 	baseURL   string
+	retry     retry.Policy
 	*http.Client
 }
 
@@ -39,6 +41,7 @@ func NewClient(accessKey, secretKey string, options ...Option) *Client {
 		accessKey: accessKey,
 		secretKey: secretKey,
 		baseURL:   defaultBaseURL,
+		retry:     retry.DefaultPolicy,
 		Client:    &http.Client{Timeout: 10 * time.Second},
 	}
 	for _, opt := range options {
@@ -61,6 +64,13 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
 // DNSRecord 百度云 DNS 记录
 type DNSRecord struct {
 	RecordID string `json:"recordId,omitempty"`
@@ -229,7 +239,7 @@ func (c *Client) GetRecords(ctx context.Context, fulldomain, recordType string)
 	return result, nil
 }
 
-// request 执行签名 POST 请求
+// request 执行签名 POST 请求（网络错误、429 和 5xx 自动重试；新增记录的请求发出后不重试网络错误）
 func (c *Client) request(ctx context.Context, method, url string, payload any) ([]byte, error) {
 	var bodyBytes []byte
 	var err error
@@ -240,18 +250,26 @@ func (c *Client) request(ctx context.Context, method, url string, payload any) (
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(bodyBytes))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	// 每次尝试使用新的 x-bce-date 重新签名
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(bodyBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
 
-	// 设置 Content-Type 必须在 signRequest 之前，因为签名需要包含此 header
-	req.Header.Set("Content-Type", "application/json")
+		// 设置 Content-Type 必须在 signRequest 之前，因为签名需要包含此 header
+		req.Header.Set("Content-Type", "application/json")
 
-	// 生成 BCE 签名
-	c.signRequest(req, bodyBytes)
+		// 生成 BCE 签名
+		c.signRequest(req, bodyBytes)
+		// 新增记录不可安全重放，其余接口请求发出后的网络错误也重试（在签名之后标记，不参与签名）
+		if !strings.HasSuffix(url, "/resolve/add") {
+			retry.MarkIdempotent(req)
+		}
+		return req, nil
+	}
 
-	resp, err := c.Do(req)
+	resp, err := c.retry.DoHTTP(ctx, c.Client, newReq, nil)
 	if err != nil {
 		return nil, fmt.Errorf("BaiduCloud API request failed: %w", err)
 	}
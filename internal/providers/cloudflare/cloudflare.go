@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 // CloudflareClient Cloudflare DNS API 客户端
@@ -31,6 +32,7 @@ type CloudflareClient struct {
 	ZoneID     string
 	BaseURL    string
 	HTTPClient *http.Client
+	retry      retry.Policy
 }
 
 type Option func(*CloudflareClient)
@@ -40,6 +42,7 @@ func NewClient(options ...Option) *CloudflareClient {
 	client := &CloudflareClient{
 		BaseURL:    "https://api.cloudflare.com/client/v4",
 		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		retry:      retry.DefaultPolicy,
 	}
 
 	for _, option := range options {
@@ -92,6 +95,13 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *CloudflareClient) {
+		c.retry = p
+	}
+}
+
 // DNSRecord  a Cloudflare DNS record
 type DNSRecord struct {
 	ID      string `json:"id,omitempty"`
@@ -255,20 +265,7 @@ func (c *CloudflareClient) getRecords(ctx context.Context, zoneID, name, rtype,
 
 // listRequest performs a GET request and returns records with pagination info
 func (c *CloudflareClient) listRequest(ctx context.Context, reqURL string) ([]DNSRecord, *resultInfo, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if c.APIToken != "" {
-		req.Header.Set("Authorization", "Bearer "+c.APIToken)
-	} else {
-		req.Header.Set("X-Auth-Email", c.Email)
-		req.Header.Set("X-Auth-Key", c.APIKey)
-	}
-
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -328,7 +325,7 @@ func (c *CloudflareClient) updateDNSRecord(ctx context.Context, zoneID, recordID
 	}
 
 	var result DNSRecord
-	err = c.makeRequest(ctx, "PUT", url, bytes.NewBuffer(body), &result)
+	err = c.makeRequest(ctx, "PUT", url, body, &result)
 	if err != nil {
 		slog.Error("failed to update Cloudflare DNS record",
 			"module", "cloudflare",
@@ -424,7 +421,7 @@ func (c *CloudflareClient) createDNSRecord(ctx context.Context, zoneID string, r
 	}
 
 	var result DNSRecord
-	err = c.makeRequest(ctx, "POST", url, bytes.NewBuffer(body), &result)
+	err = c.makeRequest(ctx, "POST", url, body, &result)
 	if err != nil {
 		slog.Error("failed to create Cloudflare DNS record",
 			"module", "cloudflare",
@@ -445,24 +442,34 @@ func (c *CloudflareClient) deleteDNSRecord(ctx context.Context, zoneID, recordID
 	return err
 }
 
-// makeRequest performs an HTTP request to the Cloudflare API
-func (c *CloudflareClient) makeRequest(ctx context.Context, method, url string, body io.Reader, result interface{}) error {
-	slog.Debug("Cloudflare API request", "module", "cloudflare", "method", method, "url", url)
+// do 发送带认证头的请求，网络错误、429 和 5xx 按重试策略自动重试（遵循 Retry-After）
+func (c *CloudflareClient) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	return c.retry.DoHTTP(ctx, c.HTTPClient, func(ctx context.Context) (*http.Request, error) {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, r)
+		if err != nil {
+			return nil, err
+		}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, body)
-	if err != nil {
-		return err
-	}
+		req.Header.Set("Content-Type", "application/json")
+		if c.APIToken != "" {
+			req.Header.Set("Authorization", "Bearer "+c.APIToken)
+		} else {
+			req.Header.Set("X-Auth-Email", c.Email)
+			req.Header.Set("X-Auth-Key", c.APIKey)
+		}
+		return req, nil
+	}, nil)
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	if c.APIToken != "" {
-		req.Header.Set("Authorization", "Bearer "+c.APIToken)
-	} else {
-		req.Header.Set("X-Auth-Email", c.Email)
-		req.Header.Set("X-Auth-Key", c.APIKey)
-	}
+// makeRequest performs an HTTP request to the Cloudflare API
+func (c *CloudflareClient) makeRequest(ctx context.Context, method, url string, body []byte, result interface{}) error {
+	slog.Debug("Cloudflare API request", "module", "cloudflare", "method", method, "url", url)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(ctx, method, url, body)
 	if err != nil {
 		slog.Error("Cloudflare API request failed", "module", "cloudflare", "method", method, "url", url, "err", err)
 		return err
@@ -19,6 +19,7 @@ import (
 
 	"github.com/notes-bin/ddns6/internal/ddns"
 	"github.com/notes-bin/ddns6/pkg/domainutil"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 const (
@@ -29,6 +30,7 @@ const (
 type Client struct {
 	token   string
 	baseURL string
+	retry   retry.Policy
 	*http.Client
 }
 
@@ -40,6 +42,7 @@ func NewClient(token string, options ...Option) *Client {
 	c := &Client{
 		token:   token,
 		baseURL: defaultBaseURL,
+		retry:   retry.DefaultPolicy,
 		Client:  &http.Client{Timeout: 10 * time.Second},
 	}
 	for _, opt := range options {
@@ -62,6 +65,13 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
 // DomainRecord DigitalOcean DNS 记录
 type DomainRecord struct {
 	ID       int    `json:"id"`
@@ -193,25 +203,28 @@ func (c *Client) setAuth(req *http.Request) {
 	req.Header.Set("Authorization", "Bearer "+c.token)
 }
 
-// doRequest 执行 HTTP 请求，检查 2xx 状态码并返回响应体
+// doRequest 执行 HTTP 请求，检查 2xx 状态码并返回响应体（网络错误、429 和 5xx 自动重试）
 func (c *Client) doRequest(ctx context.Context, method, url string, body []byte) ([]byte, error) {
-	var req *http.Request
-	var err error
-
-	if body != nil {
-		req, err = http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
-	} else {
-		req, err = http.NewRequestWithContext(ctx, method, url, nil)
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	c.setAuth(req)
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		var req *http.Request
+		var err error
+
+		if body != nil {
+			req, err = http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
+		} else {
+			req, err = http.NewRequestWithContext(ctx, method, url, nil)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		c.setAuth(req)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req, nil
 	}
 
-	resp, err := c.Do(req)
+	resp, err := c.retry.DoHTTP(ctx, c.Client, newReq, nil)
 	if err != nil {
 		return nil, fmt.Errorf("DigitalOcean API request failed: %w", err)
 	}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/notes-bin/ddns6/internal/ddns"
 	"github.com/notes-bin/ddns6/pkg/domainutil"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 const (
@@ -27,6 +28,7 @@ type Client struct {
 	loginToken string
 	baseURL    string
 	httpClient *http.Client // 命名字段，避免暴露 http.Client 的公开方法
+	retry      retry.Policy
 }
 
 // Option 客户端配置选项函数
@@ -39,6 +41,7 @@ func NewClient(loginToken string, options ...Option) *Client {
 		loginToken: loginToken,
 		baseURL:    defaultBaseURL,
 		httpClient: &http.Client{Timeout: 10 * time.Second},
+		retry:      retry.DefaultPolicy,
 	}
 	for _, opt := range options {
 		opt(c)
@@ -60,6 +63,13 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
 // dnspodStatus DNSPod API 响应状态
 type dnspodStatus struct {
 	Code    string `json:"code"`
@@ -221,16 +231,24 @@ func (c *Client) GetRecords(ctx context.Context, fulldomain, recordType string)
 	return result, nil
 }
 
-// post 执行 POST form-data 请求并解码 JSON 响应
+// post 执行 POST form-data 请求并解码 JSON 响应（网络错误、429 和 5xx 自动重试）。
+// Record.Create 不可安全重放，请求发出后的网络错误不重试，避免重复创建记录。
 func (c *Client) post(ctx context.Context, reqURL string, params url.Values, result any) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, strings.NewReader(params.Encode()))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	form := params.Encode()
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, strings.NewReader(form))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("User-Agent", "ddns6/1.0")
+		if !strings.HasSuffix(reqURL, "/Record.Create") {
+			retry.MarkIdempotent(req)
+		}
+		return req, nil
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "ddns6/1.0")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.retry.DoHTTP(ctx, c.httpClient, newReq, nil)
 	if err != nil {
 		return fmt.Errorf("DNSPod API request failed: %w", err)
 	}
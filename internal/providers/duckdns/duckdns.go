@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 const (
@@ -24,6 +25,7 @@ const (
 type Client struct {
 	token   string
 	baseURL string
+	retry   retry.Policy
 	*http.Client
 }
 
@@ -35,6 +37,7 @@ func NewClient(token string, options ...Option) *Client {
 	c := &Client{
 		token:   token,
 		baseURL: defaultBaseURL,
+		retry:   retry.DefaultPolicy,
 		Client:  &http.Client{Timeout: 10 * time.Second},
 	}
 	for _, opt := range options {
@@ -57,22 +60,29 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
 // AddRecord 添加或更新域名解析记录
 // DuckDNS 无独立添加接口，调用 update 覆盖设置
 func (c *Client) AddRecord(ctx context.Context, record ddns.RecordInfo) error {
-	return c.update(ctx, record.Name, record.Value)
+	return c.update(ctx, record.Name, record.Type, record.Value)
 }
 
 // ModifyRecord 修改域名解析记录
 // DuckDNS 无独立修改接口，调用 update 覆盖设置
 func (c *Client) ModifyRecord(ctx context.Context, record ddns.RecordInfo) error {
-	return c.update(ctx, record.Name, record.Value)
+	return c.update(ctx, record.Name, record.Type, record.Value)
 }
 
 // DeleteRecord 删除域名解析记录
 // DuckDNS 不支持删除记录，更新为空 IP 以清除
 func (c *Client) DeleteRecord(ctx context.Context, record ddns.RecordInfo) error {
-	return c.update(ctx, record.Name, "")
+	return c.update(ctx, record.Name, record.Type, "")
 }
 
 // GetRecords 查询域名解析记录
@@ -85,7 +95,9 @@ func (c *Client) GetRecords(ctx context.Context, fulldomain, recordType string)
 }
 
 // update 执行 DuckDNS 的 API 更新请求
-func (c *Client) update(ctx context.Context, domain, ip string) error {
+//
+// recordType 为 "A" 时通过 ip 参数更新 IPv4，其余情况通过 ipv6 参数更新 IPv6。
+func (c *Client) update(ctx context.Context, domain, recordType, ip string) error {
 	// 验证域名格式：必须为 *.duckdns.org
 	if !strings.HasSuffix(domain, ".duckdns.org") {
 		return fmt.Errorf("duckdns domain must end with .duckdns.org, got: %s", domain)
@@ -103,20 +115,24 @@ func (c *Client) update(ctx context.Context, domain, ip string) error {
 	query.Set("domains", domainName)
 	query.Set("token", c.token)
 	if ip != "" {
-		query.Set("ipv6", ip)
+		if recordType == ddns.RecordTypeA {
+			query.Set("ip", ip)
+		} else {
+			query.Set("ipv6", ip)
+		}
 	}
 	// 设置 verbose 以获取明确的成功/失败响应
 	query.Set("verbose", "true")
 
 	reqURL := c.baseURL + updatePath + "?" + query.Encode()
-	slog.Debug("updating DuckDNS record", "module", "duckdns", "domain", domain, "ipv6", ip)
+	slog.Debug("updating DuckDNS record", "module", "duckdns", "domain", domain, "type", recordType, "addr", ip)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	}
 
-	resp, err := c.Do(req)
+	// 网络错误、429 和 5xx 自动重试
+	resp, err := c.retry.DoHTTP(ctx, c.Client, newReq, nil)
 	if err != nil {
 		slog.Error("DuckDNS API request failed", "module", "duckdns", "domain", domain, "err", err)
 		return fmt.Errorf("DuckDNS request failed: %w", err)
@@ -130,7 +146,7 @@ func (c *Client) update(ctx context.Context, domain, ip string) error {
 
 	response := strings.TrimSpace(string(body))
 	if response == "OK" {
-		slog.Info("DuckDNS record updated successfully", "module", "duckdns", "domain", domain, "ipv6", ip)
+		slog.Info("DuckDNS record updated successfully", "module", "duckdns", "domain", domain, "type", recordType, "addr", ip)
 		return nil
 	}
 
@@ -47,6 +47,25 @@ func TestClient_ModifyRecord(t *testing.T) {
 	}
 }
 
+func TestClient_ModifyRecord_IPv4(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ip") != "192.0.2.1" {
+			t.Errorf("expected ip 192.0.2.1, got %s", r.URL.Query().Get("ip"))
+		}
+		if r.URL.Query().Has("ipv6") {
+			t.Errorf("A record update should not send ipv6, got %s", r.URL.Query().Get("ipv6"))
+		}
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", WithBaseURL(server.URL))
+	err := client.ModifyRecord(context.Background(), ddns.RecordInfo{Name: "myhost.duckdns.org", Type: "A", Value: "192.0.2.1", TTL: 600})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestClient_DeleteRecord(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Query().Get("ipv6") != "" {
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 const (
@@ -24,6 +25,7 @@ const (
 type Client struct {
 	token   string
 	baseURL string
+	retry   retry.Policy
 	*http.Client
 }
 
@@ -35,6 +37,7 @@ func NewClient(token string, options ...Option) *Client {
 	c := &Client{
 		token:   token,
 		baseURL: defaultBaseURL,
+		retry:   retry.DefaultPolicy,
 		Client:  &http.Client{Timeout: 10 * time.Second},
 	}
 	for _, opt := range options {
@@ -57,6 +60,13 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
 // Zone Dynv6 区域信息
 type Zone struct {
 	ID   string `json:"id"`
@@ -81,9 +91,9 @@ func (c *Client) AddRecord(ctx context.Context, record ddns.RecordInfo) error {
 		return fmt.Errorf("failed to resolve zone: %w", err)
 	}
 
-	// 如果是主域名（无子域名），直接更新 zone 的 IPv6 地址
+	// 如果是主域名（无子域名），直接更新 zone 的地址
 	if subDomain == "" || subDomain == "@" {
-		return c.updateZoneIP(ctx, zoneID, record.Value)
+		return c.updateZoneIP(ctx, zoneID, record.Type, record.Value)
 	}
 
 	dnsRec := Record{
@@ -99,14 +109,7 @@ func (c *Client) AddRecord(ctx context.Context, record ddns.RecordInfo) error {
 	url := fmt.Sprintf("%s/api/v2/zones/%s/records", c.baseURL, zoneID)
 	slog.Debug("adding Dynv6 record", "module", "dynv6", "zone_id", zoneID, "name", subDomain, "type", record.Type)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-	c.setAuth(req)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.Do(req)
+	resp, err := c.do(ctx, http.MethodPost, url, body)
 	if err != nil {
 		return fmt.Errorf("Dynv6 API request failed: %w", err)
 	}
@@ -117,7 +120,7 @@ func (c *Client) AddRecord(ctx context.Context, record ddns.RecordInfo) error {
 		return fmt.Errorf("Dynv6 API error: status %d, body: %s", resp.StatusCode, string(bodyBytes))
 	}
 
-	slog.Info("Dynv6 record added successfully", "module", "dynv6", "zone_id", zoneID, "name", subDomain, "ipv6", record.Value)
+	slog.Info("Dynv6 record added successfully", "module", "dynv6", "zone_id", zoneID, "name", subDomain, "type", record.Type, "addr", record.Value)
 	return nil
 }
 
@@ -142,14 +145,7 @@ func (c *Client) ModifyRecord(ctx context.Context, record ddns.RecordInfo) error
 	url := fmt.Sprintf("%s/api/v2/zones/%s/records/%s", c.baseURL, zoneID, record.ID)
 	slog.Debug("modifying Dynv6 record", "module", "dynv6", "zone_id", zoneID, "record_id", record.ID)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-	c.setAuth(req)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.Do(req)
+	resp, err := c.do(ctx, http.MethodPatch, url, body)
 	if err != nil {
 		return fmt.Errorf("Dynv6 API request failed: %w", err)
 	}
@@ -160,7 +156,7 @@ func (c *Client) ModifyRecord(ctx context.Context, record ddns.RecordInfo) error
 		return fmt.Errorf("Dynv6 API error: status %d, body: %s", resp.StatusCode, string(bodyBytes))
 	}
 
-	slog.Info("Dynv6 record modified successfully", "module", "dynv6", "zone_id", zoneID, "record_id", record.ID, "ipv6", record.Value)
+	slog.Info("Dynv6 record modified successfully", "module", "dynv6", "zone_id", zoneID, "record_id", record.ID, "addr", record.Value)
 	return nil
 }
 
@@ -174,13 +170,7 @@ func (c *Client) DeleteRecord(ctx context.Context, record ddns.RecordInfo) error
 	url := fmt.Sprintf("%s/api/v2/zones/%s/records/%s", c.baseURL, zoneID, record.ID)
 	slog.Debug("deleting Dynv6 record", "module", "dynv6", "zone_id", zoneID, "record_id", record.ID)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-	c.setAuth(req)
-
-	resp, err := c.Do(req)
+	resp, err := c.do(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return fmt.Errorf("Dynv6 API request failed: %w", err)
 	}
@@ -202,29 +192,25 @@ func (c *Client) GetRecords(ctx context.Context, fulldomain, recordType string)
 		return nil, fmt.Errorf("failed to resolve zone: %w", err)
 	}
 
-	// 主域名：检查 zone 的 IPv6 地址
+	// 主域名：检查 zone 的 IPv4 / IPv6 地址
 	if subDomain == "" || subDomain == "@" {
 		zone, err := c.getZone(ctx, zoneID)
 		if err != nil {
 			return nil, err
 		}
-		if zone.IPv6 != "" {
-			return []ddns.RecordInfo{
-				{ID: zoneID, Name: zone.Name, Type: "AAAA", Value: zone.IPv6},
-			}, nil
+		result := []ddns.RecordInfo{}
+		if zone.IPv6 != "" && (recordType == "" || recordType == ddns.RecordTypeAAAA) {
+			result = append(result, ddns.RecordInfo{ID: zoneID, Name: zone.Name, Type: ddns.RecordTypeAAAA, Value: zone.IPv6})
+		}
+		if zone.IPv4 != "" && (recordType == "" || recordType == ddns.RecordTypeA) {
+			result = append(result, ddns.RecordInfo{ID: zoneID, Name: zone.Name, Type: ddns.RecordTypeA, Value: zone.IPv4})
 		}
-		return []ddns.RecordInfo{}, nil
+		return result, nil
 	}
 
 	// 子域名：查询 records
 	url := fmt.Sprintf("%s/api/v2/zones/%s/records", c.baseURL, zoneID)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	c.setAuth(req)
-
-	resp, err := c.Do(req)
+	resp, err := c.do(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("Dynv6 API request failed: %w", err)
 	}
@@ -260,13 +246,7 @@ func (c *Client) GetRecords(ctx context.Context, fulldomain, recordType string)
 func (c *Client) resolveZone(ctx context.Context, domain string) (string, string, error) {
 	// 获取所有 zones
 	url := c.baseURL + "/api/v2/zones"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return "", "", err
-	}
-	c.setAuth(req)
-
-	resp, err := c.Do(req)
+	resp, err := c.do(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to list zones: %w", err)
 	}
@@ -303,13 +283,7 @@ func (c *Client) resolveZone(ctx context.Context, domain string) (string, string
 // getZone 获取单个 zone 详情
 func (c *Client) getZone(ctx context.Context, zoneID string) (*Zone, error) {
 	url := fmt.Sprintf("%s/api/v2/zones/%s", c.baseURL, zoneID)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create zone request: %w", err)
-	}
-	c.setAuth(req)
-
-	resp, err := c.Do(req)
+	resp, err := c.do(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get zone: %w", err)
 	}
@@ -326,23 +300,20 @@ func (c *Client) getZone(ctx context.Context, zoneID string) (*Zone, error) {
 	return &zone, nil
 }
 
-// updateZoneIP 更新 zone 的 IPv6 地址（用于主域名）
-func (c *Client) updateZoneIP(ctx context.Context, zoneID, ipv6 string) error {
-	payload := map[string]string{"ipv6address": ipv6}
+// updateZoneIP 更新 zone 的地址（用于主域名），A 记录写入 ipv4address，其余写入 ipv6address
+func (c *Client) updateZoneIP(ctx context.Context, zoneID, recordType, addr string) error {
+	field := "ipv6address"
+	if recordType == ddns.RecordTypeA {
+		field = "ipv4address"
+	}
+	payload := map[string]string{field: addr}
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
 	url := fmt.Sprintf("%s/api/v2/zones/%s", c.baseURL, zoneID)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewBuffer(body))
-	if err != nil {
-		return err
-	}
-	c.setAuth(req)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.Do(req)
+	resp, err := c.do(ctx, http.MethodPatch, url, body)
 	if err != nil {
 		return err
 	}
@@ -353,10 +324,33 @@ func (c *Client) updateZoneIP(ctx context.Context, zoneID, ipv6 string) error {
 		return fmt.Errorf("Dynv6 API error: status %d, body: %s", resp.StatusCode, string(bodyBytes))
 	}
 
-	slog.Info("Dynv6 zone IPv6 updated", "module", "dynv6", "zone_id", zoneID, "ipv6", ipv6)
+	slog.Info("Dynv6 zone address updated", "module", "dynv6", "zone_id", zoneID, "type", recordType, "addr", addr)
 	return nil
 }
 
+// do 发送带认证头的请求（body 非 nil 时作为 JSON 请求体），网络错误、429 和 5xx 自动重试
+func (c *Client) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	return c.retry.DoHTTP(ctx, c.Client, func(ctx context.Context) (*http.Request, error) {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		c.setAuth(req)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		// PATCH 只把记录改为固定值，可安全重放；POST 新增记录不标记，请求发出后的网络错误不重试
+		if method == http.MethodPatch {
+			retry.MarkIdempotent(req)
+		}
+		return req, nil
+	}, nil)
+}
+
 // setAuth 设置认证头
 func (c *Client) setAuth(req *http.Request) {
 	req.Header.Set("Authorization", "Bearer "+c.token)
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 // GoDaddyClient GoDaddy DNS API 客户端
@@ -27,6 +28,7 @@ type GoDaddyClient struct {
 	APISecret  string
 	BaseURL    string
 	HTTPClient *http.Client
+	retry      retry.Policy
 	mu         sync.Mutex // 保护 deleteRecordsByValue 的并发安全
 }
 
@@ -39,6 +41,7 @@ func NewClient(apiKey, apiSecret string, options ...Option) *GoDaddyClient {
 		APISecret:  apiSecret,
 		BaseURL:    "https://api.godaddy.com/v1",
 		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		retry:      retry.DefaultPolicy,
 	}
 
 	for _, option := range options {
@@ -62,6 +65,13 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *GoDaddyClient) {
+		c.retry = p
+	}
+}
+
 // DNSRecord  a GoDaddy DNS record
 type DNSRecord struct {
 	Data string `json:"data"`
@@ -136,8 +146,12 @@ func (c *GoDaddyClient) DeleteRecord(ctx context.Context, record ddns.RecordInfo
 		return fmt.Errorf("failed to get root domain: %w", err)
 	}
 
-	// 按值匹配删除 AAAA 记录
-	return c.deleteRecordsByValue(ctx, domain, subDomain, "AAAA", record.ID)
+	// 按值匹配删除记录，未指定类型时默认 AAAA
+	rtype := record.Type
+	if rtype == "" {
+		rtype = ddns.RecordTypeAAAA
+	}
+	return c.deleteRecordsByValue(ctx, domain, subDomain, rtype, record.ID)
 }
 
 // deleteRecordsByValue 根据值删除特定类型的记录
@@ -220,7 +234,7 @@ func (c *GoDaddyClient) updateRecords(ctx context.Context, domain, subDomain, rt
 	if err != nil {
 		return err
 	}
-	return c.makeRequest(ctx, "PUT", url, bytes.NewBuffer(body), nil)
+	return c.makeRequest(ctx, "PUT", url, body, nil)
 }
 
 // deleteRecords 删除所有记录
@@ -262,18 +276,27 @@ func (c *GoDaddyClient) getDomain(ctx context.Context, domain string) (map[strin
 }
 
 // makeRequest performs an HTTP request to the GoDaddy API
-func (c *GoDaddyClient) makeRequest(ctx context.Context, method, url string, body io.Reader, result interface{}) error {
+//
+// 网络错误、429（TOO_MANY_REQUESTS）和 5xx 按重试策略自动重试。
+func (c *GoDaddyClient) makeRequest(ctx context.Context, method, url string, body []byte, result interface{}) error {
 	slog.Debug("GoDaddy API request", "module", "godaddy", "method", method, "url", url)
 
-	req, err := http.NewRequestWithContext(ctx, method, url, body)
-	if err != nil {
-		return err
-	}
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, r)
+		if err != nil {
+			return nil, err
+		}
 
-	req.Header.Set("Authorization", fmt.Sprintf("sso-key %s:%s", c.APIKey, c.APISecret))
-	req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("sso-key %s:%s", c.APIKey, c.APISecret))
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.retry.DoHTTP(ctx, c.HTTPClient, newReq, nil)
 	if err != nil {
 		slog.Error("GoDaddy API request failed", "module", "godaddy", "method", method, "url", url, "err", err)
 		return err
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 const (
@@ -23,6 +24,7 @@ const (
 type Client struct {
 	password string
 	baseURL  string
+	retry    retry.Policy
 	*http.Client
 }
 
@@ -35,6 +37,7 @@ func NewClient(password string, options ...Option) *Client {
 	c := &Client{
 		password: password,
 		baseURL:  defaultBaseURL,
+		retry:    retry.DefaultPolicy,
 		Client:   &http.Client{Timeout: 10 * time.Second},
 	}
 	for _, opt := range options {
@@ -57,6 +60,13 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
 // AddRecord 添加或更新域名解析记录
 // HE DDNS API 使用单次更新请求覆盖记录
 func (c *Client) AddRecord(ctx context.Context, record ddns.RecordInfo) error {
@@ -95,15 +105,19 @@ func (c *Client) update(ctx context.Context, hostname, ip string) error {
 
 	slog.Debug("updating HE DNS record", "module", "he", "hostname", hostname, "ipv6", ip)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
 
-	// HE 使用固定用户名 "hosted_dns_editapi" 和 DDNS 密钥认证
-	req.SetBasicAuth("hosted_dns_editapi", c.password)
+		// HE 使用固定用户名 "hosted_dns_editapi" 和 DDNS 密钥认证
+		req.SetBasicAuth("hosted_dns_editapi", c.password)
+		return req, nil
+	}
 
-	resp, err := c.Do(req)
+	// 网络错误、429 和 5xx 自动重试；abuse（"!"）等协议级错误不重试
+	resp, err := c.retry.DoHTTP(ctx, c.Client, newReq, nil)
 	if err != nil {
 		slog.Error("HE DNS API request failed", "module", "he", "hostname", hostname, "err", err)
 		return fmt.Errorf("HE DNS request failed: %w", err)
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 const (
@@ -31,6 +32,7 @@ type Client struct {
 	accessKey string
 	secretKey string
 	baseURL   string
+	retry     retry.Policy
 	*http.Client
 }
 
@@ -43,6 +45,7 @@ func NewClient(accessKey, secretKey string, options ...Option) *Client {
 		accessKey: accessKey,
 		secretKey: secretKey,
 		baseURL:   defaultBaseURL,
+		retry:     retry.DefaultPolicy,
 		Client:    &http.Client{Timeout: 30 * time.Second},
 	}
 	for _, opt := range options {
@@ -65,6 +68,13 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
 // DNSRecord 华为云 DNS 记录集
 type DNSRecord struct {
 	ID      string   `json:"id,omitempty"`
@@ -274,18 +284,11 @@ func (c *Client) getZoneID(ctx context.Context, domain string) (string, error) {
 	return "", fmt.Errorf("zone not found for domain %s", domain)
 }
 
-// request 执行签名 HTTP 请求，自动解码响应
-func (c *Client) request(ctx context.Context, method, url string, payload any) ([]byte, error) {
-	var bodyBytes []byte
-	var err error
-	if payload != nil {
-		bodyBytes, err = json.Marshal(payload)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal payload: %w", err)
-		}
-	}
-
-	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(bodyBytes))
+// newSignedRequest 创建带 SDK-HMAC-SHA256 签名的请求。
+//
+// 签名包含 X-Sdk-Date 时间戳，重试时需重新调用以生成新的签名。
+func (c *Client) newSignedRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -297,8 +300,24 @@ func (c *Client) request(ctx context.Context, method, url string, payload any) (
 	if err := signer.Sign(req); err != nil {
 		return nil, fmt.Errorf("failed to sign request: %w", err)
 	}
+	return req, nil
+}
+
+// request 执行签名 HTTP 请求，自动解码响应（网络错误、429 和 5xx 自动重试）
+func (c *Client) request(ctx context.Context, method, url string, payload any) ([]byte, error) {
+	var bodyBytes []byte
+	var err error
+	if payload != nil {
+		bodyBytes, err = json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+	}
 
-	resp, err := c.Do(req)
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		return c.newSignedRequest(ctx, method, url, bytes.NewBuffer(bodyBytes))
+	}
+	resp, err := c.retry.DoHTTP(ctx, c.Client, newReq, nil)
 	if err != nil {
 		return nil, fmt.Errorf("HuaweiCloud API request failed: %w", err)
 	}
@@ -316,22 +335,12 @@ func (c *Client) request(ctx context.Context, method, url string, payload any) (
 	return respBody, nil
 }
 
-// requestRaw 执行签名 HTTP 请求并解码到目标结构体（用于 GET 请求）
+// requestRaw 执行签名 HTTP 请求并解码到目标结构体（用于 GET 请求，重试规则同 request）
 func (c *Client) requestRaw(ctx context.Context, method, url string, result any) error {
-	req, err := http.NewRequestWithContext(ctx, method, url, http.NoBody)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		return c.newSignedRequest(ctx, method, url, http.NoBody)
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	// SDK-HMAC-SHA256 签名
-	signer := &Signer{Key: c.accessKey, Secret: c.secretKey}
-	if err := signer.Sign(req); err != nil {
-		return fmt.Errorf("failed to sign request: %w", err)
-	}
-
-	resp, err := c.Do(req)
+	resp, err := c.retry.DoHTTP(ctx, c.Client, newReq, nil)
 	if err != nil {
 		return fmt.Errorf("HuaweiCloud API request failed: %w", err)
 	}
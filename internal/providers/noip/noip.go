@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 const (
@@ -24,6 +25,7 @@ type Client struct {
 	username string
 	password string
 	baseURL  string
+	retry    retry.Policy
 	*http.Client
 }
 
@@ -36,6 +38,7 @@ func NewClient(username, password string, options ...Option) *Client {
 		username: username,
 		password: password,
 		baseURL:  defaultBaseURL,
+		retry:    retry.DefaultPolicy,
 		Client:   &http.Client{Timeout: 10 * time.Second},
 	}
 	for _, opt := range options {
@@ -58,6 +61,13 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
 // AddRecord 添加或更新域名解析记录
 // No-IP 无独立添加接口，调用 update 覆盖设置
 func (c *Client) AddRecord(ctx context.Context, record ddns.RecordInfo) error {
@@ -98,14 +108,19 @@ func (c *Client) update(ctx context.Context, hostname, ip string) error {
 
 	slog.Debug("updating No-IP record", "module", "noip", "hostname", hostname, "ipv6", ip)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.SetBasicAuth(c.username, c.password)
+		req.Header.Set("User-Agent", "ddns6/1.0 contact@notes-bin")
+		return req, nil
 	}
-	req.SetBasicAuth(c.username, c.password)
-	req.Header.Set("User-Agent", "ddns6/1.0 contact@notes-bin")
 
-	resp, err := c.Do(req)
+	// 网络错误、429 和 5xx 自动重试。No-IP 的 911（服务端故障）要求至少 30 分钟后再试，
+	// 交给上层重试队列处理，这里不做短时重试
+	resp, err := c.retry.DoHTTP(ctx, c.Client, newReq, nil)
 	if err != nil {
 		slog.Error("No-IP API request failed", "module", "noip", "hostname", hostname, "err", err)
 		return fmt.Errorf("No-IP request failed: %w", err)
@@ -17,6 +17,7 @@ import (
 
 	"github.com/notes-bin/ddns6/internal/ddns"
 	"github.com/notes-bin/ddns6/pkg/domainutil"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 const (
@@ -28,6 +29,7 @@ type Client struct {
 	apiKey       string
 	secretAPIKey string
 	baseURL      string
+	retry        retry.Policy
 	*http.Client
 }
 
@@ -40,6 +42,7 @@ func NewClient(apiKey, secretAPIKey string, options ...Option) *Client {
 		apiKey:       apiKey,
 		secretAPIKey: secretAPIKey,
 		baseURL:      defaultBaseURL,
+		retry:        retry.DefaultPolicy,
 		Client:       &http.Client{Timeout: 10 * time.Second},
 	}
 	for _, opt := range options {
@@ -62,6 +65,13 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
 // DNSRecord Porkbun DNS 记录
 // 注意：Porkbun API 的 TTL 字段为字符串格式，如 "600"
 type DNSRecord struct {
@@ -135,7 +145,12 @@ func (c *Client) ModifyRecord(ctx context.Context, record ddns.RecordInfo) error
 func (c *Client) DeleteRecord(ctx context.Context, record ddns.RecordInfo) error {
 	domain, subDomain := splitDomain(record.Name, record.Zone)
 
-	url := fmt.Sprintf("%s/deleteByNameType/%s/%s/%s", c.baseURL, urlpkg.PathEscape(domain), "AAAA", urlpkg.PathEscape(subDomain))
+	// 未指定类型时默认 AAAA
+	rtype := record.Type
+	if rtype == "" {
+		rtype = ddns.RecordTypeAAAA
+	}
+	url := fmt.Sprintf("%s/deleteByNameType/%s/%s/%s", c.baseURL, urlpkg.PathEscape(domain), rtype, urlpkg.PathEscape(subDomain))
 	slog.Debug("deleting Porkbun DNS record", "module", "porkbun", "domain", domain, "name", subDomain)
 
 	var resp apiResponse
@@ -191,7 +206,7 @@ type apiRequest struct {
 	*DNSRecord
 }
 
-// post 执行 POST JSON 请求，自动注入认证信息
+// post 执行 POST JSON 请求，自动注入认证信息（网络错误、429 和 5xx 自动重试）
 func (c *Client) post(ctx context.Context, url string, record *DNSRecord, result any) error {
 	apiReq := apiRequest{
 		APIKey:       c.apiKey,
@@ -204,13 +219,20 @@ func (c *Client) post(ctx context.Context, url string, record *DNSRecord, result
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		// 新增记录不可安全重放，其余接口请求发出后的网络错误也重试
+		if !strings.Contains(url, "/create/") {
+			retry.MarkIdempotent(req)
+		}
+		return req, nil
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.Do(req)
+	resp, err := c.retry.DoHTTP(ctx, c.Client, newReq, nil)
 	if err != nil {
 		return fmt.Errorf("Porkbun API request failed: %w", err)
 	}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/notes-bin/ddns6/internal/crypto"
 	"github.com/notes-bin/ddns6/internal/ddns"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 const (
@@ -103,6 +104,7 @@ type DNSPod struct {
 	secretId  string
 	secretKey string
 	apiURL    string
+	retry     retry.Policy
 	*http.Client
 }
 
@@ -115,6 +117,7 @@ func NewDNSPod(secretId, secretKey string, options ...Option) *DNSPod {
 		secretId:  secretId,
 		secretKey: secretKey,
 		apiURL:    "https://dnspod.tencentcloudapi.com",
+		retry:     retry.DefaultPolicy,
 		Client: &http.Client{
 			Timeout: 30 * time.Second,
 			Transport: &http.Transport{
@@ -144,6 +147,13 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRetryPolicy 设置 API 调用的重试策略（默认 retry.DefaultPolicy）。
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(ds *DNSPod) {
+		ds.retry = p
+	}
+}
+
 // AddRecord 添加域名解析记录。
 //
 // 当 Tencent API 返回「记录已存在」错误时，会查询现有记录并执行修改（值不同时）
@@ -298,6 +308,37 @@ func (ds *DNSPod) DeleteRecord(ctx context.Context, record ddns.RecordInfo) erro
 	return err
 }
 
+// SetRecordEnabled 启用或暂停域名解析记录，实现 ddns.RecordDisabler。
+func (ds *DNSPod) SetRecordEnabled(ctx context.Context, record ddns.RecordInfo, enabled bool) error {
+	status := "DISABLE"
+	if enabled {
+		status = "ENABLE"
+	}
+	slog.Info("setting Tencent DNS record status",
+		"module", "tencent",
+		"domain", record.Name, "record_id", record.ID, "status", status)
+
+	domain, _, err := ds.getRootDomain(ctx, record.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get root domain: %w", err)
+	}
+
+	recordId, err := strconv.Atoi(record.ID)
+	if err != nil {
+		return fmt.Errorf("invalid record ID %q: %v", record.ID, err)
+	}
+
+	payload := map[string]any{"Domain": domain, "RecordId": recordId, "Status": status}
+	response := new(Response)
+	err = ds.makeRequest(ctx, "ModifyRecordStatus", payload, response)
+	if err != nil {
+		slog.Error("failed to set Tencent DNS record status",
+			"module", "tencent",
+			"domain", record.Name, "record_id", record.ID, "err", err)
+	}
+	return err
+}
+
 // GetRecords 查询域名的解析记录，返回通用 RecordInfo 列表
 func (ds *DNSPod) GetRecords(ctx context.Context, fulldomain, recordType string) ([]ddns.RecordInfo, error) {
 	domain, subDomain, err := ds.getRootDomain(ctx, fulldomain)
@@ -469,37 +510,65 @@ func (ds *DNSPod) getDomainList(ctx context.Context) ([]domainListItem, error) {
 	return resp.DomainList, nil
 }
 
+// throttled 判断响应是否为可重试的限流或临时错误。
+//
+// Tencent Cloud API v3 的错误以 HTTP 200 返回，错误码位于 Response.Error.Code，
+// 如 RequestLimitExceeded（含 RequestLimitExceeded.UinLimitExceeded 等子码）和 InternalError。
+func throttled(_ *http.Response, body []byte) bool {
+	var errResp struct {
+		Response struct {
+			Error struct {
+				Code string `json:"Code"`
+			} `json:"Error"`
+		} `json:"Response"`
+	}
+	if err := json.Unmarshal(body, &errResp); err != nil {
+		return false
+	}
+	code := errResp.Response.Error.Code
+	return code == "RequestLimitExceeded" || strings.HasPrefix(code, "RequestLimitExceeded.") ||
+		code == "InternalError"
+}
+
 // makeRequest 执行Authenticated请求到Tencent Cloud API
 func (ds *DNSPod) makeRequest(ctx context.Context, action string, payload any, result any) error {
 	slog.Debug("Tencent API request", "module", "tencent", "action", action)
 
-	timestamp := time.Now().Unix()
-
 	// 序列化请求体
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	// 生成签名
-	signature := ds.generateSignatureV3(service, action, string(payloadBytes), timestamp)
+	// 每次尝试使用新的时间戳重新签名
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		timestamp := time.Now().Unix()
 
-	// 创建请求
-	req, err := http.NewRequestWithContext(ctx, "POST", ds.apiURL, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
+		// 生成签名
+		signature := ds.generateSignatureV3(service, action, string(payloadBytes), timestamp)
 
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-	req.Host = req.URL.Host
-	req.Header.Set("Authorization", signature)
-	req.Header.Set("X-TC-Version", version)
-	req.Header.Set("X-TC-Timestamp", strconv.FormatInt(timestamp, 10))
-	req.Header.Set("X-TC-Action", action)
+		// 创建请求
+		req, err := http.NewRequestWithContext(ctx, "POST", ds.apiURL, bytes.NewBuffer(payloadBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		// 设置请求头
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req.Host = req.URL.Host
+		req.Header.Set("Authorization", signature)
+		req.Header.Set("X-TC-Version", version)
+		req.Header.Set("X-TC-Timestamp", strconv.FormatInt(timestamp, 10))
+		req.Header.Set("X-TC-Action", action)
+		// 除新增记录外的操作可安全重放，请求已发出后的网络错误也重试
+		if action != "CreateRecord" {
+			retry.MarkIdempotent(req)
+		}
+		return req, nil
+	}
 
-	// 发送请求
-	resp, err := ds.Do(req)
+	// 发送请求（网络错误、5xx 和限流错误码自动重试；CreateRecord 请求发出后的网络错误不重试）
+	resp, err := ds.retry.DoHTTP(ctx, ds.Client, newReq, throttled)
 	if err != nil {
 		slog.Error("Tencent API request failed", "module", "tencent", "action", action, "err", err)
 		return fmt.Errorf("API request failed: %w", err)
@@ -2,13 +2,17 @@ package tencent_test
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/notes-bin/ddns6/internal/ddns"
 
 	"github.com/notes-bin/ddns6/internal/providers/tencent"
+	"github.com/notes-bin/ddns6/pkg/retry"
 )
 
 var ctx = context.Background()
@@ -76,6 +80,31 @@ func TestDeleteRecord(t *testing.T) {
 	}
 }
 
+func TestSetRecordEnabled(t *testing.T) {
+	var action, body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-TC-Action") == "DescribeDomainList" {
+			w.Write([]byte(domainListResponse))
+			return
+		}
+		action = r.Header.Get("X-TC-Action")
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"Response": {"RequestId": "req-123"}}`))
+	}))
+	defer ts.Close()
+
+	client := tencent.NewDNSPod("testId", "testKey", tencent.WithBaseURL(ts.URL))
+
+	err := client.SetRecordEnabled(ctx, ddns.RecordInfo{Name: "test.example.com", ID: "123456"}, false)
+	if err != nil {
+		t.Fatalf("SetRecordEnabled failed: %v", err)
+	}
+	if action != "ModifyRecordStatus" || !strings.Contains(body, `"Status":"DISABLE"`) || !strings.Contains(body, `"RecordId":123456`) {
+		t.Errorf("Unexpected request: action=%s body=%s", action, body)
+	}
+}
+
 func TestGetRecords(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("X-TC-Action") == "DescribeDomainList" {
@@ -121,3 +150,31 @@ func TestGetDomainRecord(t *testing.T) {
 		t.Errorf("Expected record ID 123456, got %d", record.RecordId)
 	}
 }
+
+func TestModifyRecord_RetriesRequestLimitExceeded(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-TC-Action") == "DescribeDomainList" {
+			w.Write([]byte(domainListResponse))
+			return
+		}
+		calls++
+		if calls == 1 {
+			w.Write([]byte(`{"Response": {"Error": {"Code": "RequestLimitExceeded", "Message": "too many requests"}, "RequestId": "req-1"}}`))
+			return
+		}
+		w.Write([]byte(`{"Response": {"RequestId": "req-2"}}`))
+	}))
+	defer ts.Close()
+
+	client := tencent.NewDNSPod("testId", "testKey", tencent.WithBaseURL(ts.URL),
+		tencent.WithRetryPolicy(retry.Policy{Attempts: 3, BaseDelay: time.Millisecond}))
+
+	err := client.ModifyRecord(ctx, ddns.RecordInfo{Name: "test.example.com", ID: "123456", Type: "A", Value: "192.168.1.2", TTL: 600})
+	if err != nil {
+		t.Fatalf("ModifyRecord should succeed after retry: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 ModifyRecord calls, got %d", calls)
+	}
+}
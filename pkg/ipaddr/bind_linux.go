@@ -0,0 +1,27 @@
+//go:build linux
+
+package ipaddr
+
+import (
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// bindToDevice 返回通过 SO_BINDTODEVICE 将套接字绑定到网络接口 name 的 net.Dialer.Control 函数。
+func bindToDevice(name string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		err := c.Control(func(fd uintptr) {
+			sockErr = unix.SetsockoptString(int(fd), unix.SOL_SOCKET, unix.SO_BINDTODEVICE, name)
+		})
+		if err != nil {
+			return err
+		}
+		if sockErr != nil {
+			return fmt.Errorf("failed to bind to interface %s: %w", name, sockErr)
+		}
+		return nil
+	}
+}
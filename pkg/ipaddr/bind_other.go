@@ -0,0 +1,15 @@
+//go:build !linux
+
+package ipaddr
+
+import (
+	"errors"
+	"syscall"
+)
+
+// bindToDevice 非 Linux 平台不支持绑定网络接口，连接时始终返回错误。
+func bindToDevice(name string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		return errors.New("binding to an interface is only supported on Linux")
+	}
+}
@@ -0,0 +1,108 @@
+package ipaddr
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// CandidateFetcher 可以返回多个候选地址的获取器，如读取接口上所有可用地址的 InterfaceFetcher。
+//
+// CollectIPv6 对实现了该接口的获取器使用 FetchCandidates 的全部结果，
+// 其余场景（竞速、共识）仍只使用 Fetch 选出的一个地址。
+type CandidateFetcher interface {
+	// FetchCandidates 返回按优先级排列的全部候选地址，首个即 Fetch 的结果
+	FetchCandidates(ctx context.Context) ([]net.IP, error)
+}
+
+// runFetcher 执行 fetcher，candidates=true 且 fetcher 实现了 CandidateFetcher 时返回其全部候选。
+func runFetcher[F addrFetcher](ctx context.Context, fetcher F, candidates bool) ([]net.IP, error) {
+	if cf, ok := any(fetcher).(CandidateFetcher); ok && candidates {
+		return cf.FetchCandidates(ctx)
+	}
+	ip, err := fetcher.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []net.IP{ip}, nil
+}
+
+// CollectIPv6 按 p 获取本机 IPv6 地址的全部候选，供调用方按自己的规则（如地址策略）从中选择。
+//
+// 与 FetchIPv6 不同，第一个结果到达后不取消其余 fetcher，而是等待全部结束或总超时，
+// 返回去重后的地址，按到达顺序排列（最快者在前）；实现了 CandidateFetcher 的 fetcher
+// 贡献其全部候选地址。所有 fetcher 都失败时返回错误。
+// 启用共识模式（p.Quorum）时只返回达成共识的地址。
+func CollectIPv6(ctx context.Context, p FetchPolicy, fetchers ...IPv6Fetcher) ([]net.IP, error) {
+	if p.Quorum.Enabled() {
+		ip, err := quorumFetchers(ctx, 6, p.timeout(), p.Quorum, fetchers)
+		if err != nil {
+			return nil, err
+		}
+		return []net.IP{ip}, nil
+	}
+	return collectFetchers(ctx, 6, p.timeout(), fetchers)
+}
+
+// collectFetchers 并发执行 fetchers，返回总超时内得到的所有不同地址（按到达顺序）。
+func collectFetchers[F addrFetcher](ctx context.Context, family int, timeout time.Duration, fetchers []F) ([]net.IP, error) {
+	if len(fetchers) == 0 {
+		return nil, fmt.Errorf("no fetcher provided")
+	}
+
+	slog.Debug("collecting addresses from all fetchers", "module", "ipaddr",
+		"family", familyName(family), "fetcher_count", len(fetchers))
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	results := launchFetchers(ctx, fetchers, true)
+
+	var addrs []net.IP
+	seen := make(map[string]bool)
+	var errs fetchErrors
+	for range fetchers {
+		r := <-results
+		if r.err != nil {
+			errs.add(r.err)
+			continue
+		}
+		for _, ip := range r.ips {
+			if key := ip.String(); !seen[key] {
+				seen[key] = true
+				addrs = append(addrs, ip)
+			}
+		}
+	}
+
+	if len(addrs) == 0 {
+		slog.Error("all address fetchers failed",
+			"module", "ipaddr",
+			"family", familyName(family),
+			"total", len(fetchers),
+			"canceled", errs.canceled, "timed_out", errs.timedOut, "failed", errs.failed,
+			"last_err", errs.last)
+		return nil, fmt.Errorf("all %d %s fetchers failed: %w", len(fetchers), familyName(family), errs.last)
+	}
+	slog.Info("address candidates collected",
+		"module", "ipaddr",
+		"family", familyName(family),
+		"addrs", addrs,
+		"canceled", errs.canceled, "timed_out", errs.timedOut, "failed", errs.failed)
+	return addrs, nil
+}
+
+// IsLocalAddr 返回 ip 是否配置在本机的某个网络接口上。
+func IsLocalAddr(ip net.IP) (bool, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false, fmt.Errorf("failed to list interface addresses: %w", err)
+	}
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok && n.IP.Equal(ip) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,62 @@
+package ipaddr_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// ============================================================
+// 候选地址收集测试
+// ============================================================
+
+// candidateFetcher 返回多个候选地址的 fetcher，模拟接口上有多个可用地址的 InterfaceFetcher。
+type candidateFetcher struct {
+	ips []net.IP
+}
+
+func (c *candidateFetcher) Fetch(ctx context.Context) (net.IP, error) {
+	return c.ips[0], nil
+}
+
+func (c *candidateFetcher) FetchCandidates(ctx context.Context) ([]net.IP, error) {
+	return c.ips, nil
+}
+
+// TestCollectIPv6_CandidateFetcherContributesAll 测试实现了 CandidateFetcher 的获取器贡献全部候选地址
+func TestCollectIPv6_CandidateFetcherContributesAll(t *testing.T) {
+	fetchers := []ipaddr.IPv6Fetcher{
+		&candidateFetcher{ips: []net.IP{net.ParseIP("2001:db8:1::1"), net.ParseIP("2001:db8:2::1")}},
+		&slowFetcher{ip: net.ParseIP("2001:db8:1::1"), delay: 10 * time.Millisecond},
+		&slowFetcher{ip: net.ParseIP("2001:db8:3::1"), delay: 20 * time.Millisecond},
+	}
+	got, err := ipaddr.CollectIPv6(context.Background(), ipaddr.FetchPolicy{}, fetchers...)
+	if err != nil {
+		t.Fatalf("CollectIPv6 不应返回错误: %v", err)
+	}
+
+	want := map[string]bool{"2001:db8:1::1": true, "2001:db8:2::1": true, "2001:db8:3::1": true}
+	if len(got) != len(want) {
+		t.Fatalf("应返回去重后的 3 个候选地址, 得到 %v", got)
+	}
+	for _, ip := range got {
+		if !want[ip.String()] {
+			t.Errorf("意外的候选地址 %s", ip)
+		}
+	}
+}
+
+// TestGetIPv6Addr_CandidateFetcherUsesFetch 测试竞速时 CandidateFetcher 只贡献 Fetch 选出的地址
+func TestGetIPv6Addr_CandidateFetcherUsesFetch(t *testing.T) {
+	f := &candidateFetcher{ips: []net.IP{net.ParseIP("2001:db8:1::1"), net.ParseIP("2001:db8:2::1")}}
+	ip, err := ipaddr.GetIPv6Addr(context.Background(), f)
+	if err != nil {
+		t.Fatalf("GetIPv6Addr 不应返回错误: %v", err)
+	}
+	if !ip.Equal(net.ParseIP("2001:db8:1::1")) {
+		t.Errorf("应返回首选地址 2001:db8:1::1, 得到 %s", ip)
+	}
+}
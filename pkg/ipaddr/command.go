@@ -0,0 +1,75 @@
+package ipaddr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// commandFetcher 外部命令获取器的公共实现，按地址族从命令输出中提取地址。
+type commandFetcher struct {
+	command string
+	family  int // 4 或 6
+}
+
+// CommandIPv6Fetcher 执行外部命令（通过系统 shell），从标准输出中取第一个 IPv6 地址
+type CommandIPv6Fetcher struct {
+	commandFetcher
+}
+
+// CommandIPv4Fetcher 执行外部命令（通过系统 shell），从标准输出中取第一个 IPv4 地址
+type CommandIPv4Fetcher struct {
+	commandFetcher
+}
+
+// NewCommandIPv6Fetcher 创建新的 CommandIPv6Fetcher
+func NewCommandIPv6Fetcher(command string) *CommandIPv6Fetcher {
+	return &CommandIPv6Fetcher{commandFetcher{command: command, family: 6}}
+}
+
+// NewCommandIPv4Fetcher 创建新的 CommandIPv4Fetcher
+func NewCommandIPv4Fetcher(command string) *CommandIPv4Fetcher {
+	return &CommandIPv4Fetcher{commandFetcher{command: command, family: 4}}
+}
+
+// String 返回命令获取器的字符串表示
+func (c *commandFetcher) String() string {
+	return "command:" + c.command
+}
+
+// Fetch 实现 Fetcher 接口。命令非零退出时返回错误；
+// 输出按空白分隔，每个字段去掉前缀长度（如 /64）后尝试解析，第一个匹配地址族的地址即为结果。
+func (c *commandFetcher) Fetch(ctx context.Context) (net.IP, error) {
+	slog.Debug("fetching address via command", "module", "ipaddr", "command", c.command, "family", familyName(c.family))
+
+	var stdout, stderr bytes.Buffer
+	cmd := shellCommand(ctx, c.command)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("command %q failed: %w: %s", c.command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	for _, field := range strings.Fields(stdout.String()) {
+		field, _, _ = strings.Cut(field, "/")
+		if ip := net.ParseIP(field); matchFamily(ip, c.family) {
+			return ip, nil
+		}
+	}
+	return nil, fmt.Errorf("no valid %s address in output of command %q", familyName(c.family), c.command)
+}
+
+// shellCommand 通过系统 shell 执行命令。
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "/bin/sh", "-c", command)
+}
@@ -0,0 +1,65 @@
+package ipaddr_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// ============================================================
+// 外部命令获取器与单个获取器超时测试
+// ============================================================
+
+func TestCommandIPv6Fetcher(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 /bin/sh")
+	}
+	f := ipaddr.NewCommandIPv6Fetcher("echo 'inet6 fe80::1/64'; echo 'inet 192.0.2.1/24 inet6 2001:db8::5/64'")
+	ip, err := f.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch 不应返回错误: %v", err)
+	}
+	// 第一个 IPv6 字段为 fe80::1：命令获取器不做地址类型筛选，由用户命令决定输出
+	if !ip.Equal(net.ParseIP("fe80::1")) {
+		t.Errorf("应返回输出中第一个 IPv6 地址, 得到 %s", ip)
+	}
+
+	v4, err := ipaddr.NewCommandIPv4Fetcher("echo 2001:db8::5 192.0.2.1").Fetch(context.Background())
+	if err != nil || !v4.Equal(net.ParseIP("192.0.2.1")) {
+		t.Errorf("IPv4 命令获取器应跳过 IPv6 地址: %v, %v", v4, err)
+	}
+
+	if _, err := ipaddr.NewCommandIPv6Fetcher("echo no address").Fetch(context.Background()); err == nil {
+		t.Error("输出中没有地址时应返回错误")
+	}
+	if _, err := ipaddr.NewCommandIPv6Fetcher("echo 2001:db8::1; exit 3").Fetch(context.Background()); err == nil {
+		t.Error("命令非零退出时应返回错误")
+	}
+}
+
+func TestTimeoutFetcher(t *testing.T) {
+	slow := &slowFetcher{ip: net.ParseIP("2001:db8::1"), delay: time.Second}
+	f := ipaddr.NewTimeoutFetcher(slow, 20*time.Millisecond)
+	if _, err := f.Fetch(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("超过单个获取器超时应返回 DeadlineExceeded, 得到 %v", err)
+	}
+	if ipaddr.FetcherName(f) != ipaddr.FetcherName(slow) {
+		t.Errorf("TimeoutFetcher 的名称应为被包装获取器的名称, 得到 %s", ipaddr.FetcherName(f))
+	}
+}
+
+func TestFetchIPv6_PolicyTimeout(t *testing.T) {
+	slow := &slowFetcher{ip: net.ParseIP("2001:db8::1"), delay: time.Second}
+	start := time.Now()
+	if _, err := ipaddr.FetchIPv6(context.Background(), ipaddr.FetchPolicy{Timeout: 50 * time.Millisecond}, slow); err == nil {
+		t.Fatal("超过总超时应返回错误")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("应在总超时后返回, 耗时 %v", elapsed)
+	}
+}
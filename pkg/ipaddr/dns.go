@@ -22,10 +22,35 @@ func (d *DnsFetcher) String() string {
 
 // Fetch 实现 Fetcher 接口
 func (d *DnsFetcher) Fetch(ctx context.Context) (net.IP, error) {
-	slog.Debug("fetching IPv6 via DNS", "module", "ipaddr", "dns_server", d.String())
+	return dialLocalAddr(ctx, "udp6", net.JoinHostPort(d.String(), "53"), 6)
+}
+
+// DnsIPv4Fetcher 从 DNS 服务器获取 IPv4 地址
+type DnsIPv4Fetcher string
+
+// NewDnsIPv4Fetcher 创建新的 DnsIPv4Fetcher
+func NewDnsIPv4Fetcher(server string) *DnsIPv4Fetcher {
+	return (*DnsIPv4Fetcher)(&server)
+}
+
+// String 返回 DnsIPv4Fetcher 的字符串表示
+func (d *DnsIPv4Fetcher) String() string {
+	return string(*d)
+}
+
+// Fetch 实现 Fetcher 接口
+func (d *DnsIPv4Fetcher) Fetch(ctx context.Context) (net.IP, error) {
+	return dialLocalAddr(ctx, "udp4", net.JoinHostPort(d.String(), "53"), 4)
+}
+
+// dialLocalAddr 向 DNS 服务器发起 UDP 连接，返回本机用于该连接的源地址。
+//
+// UDP 连接不会真正发送数据包，仅由内核完成路由选择，因此返回的是出口接口地址。
+func dialLocalAddr(ctx context.Context, network, server string, family int) (net.IP, error) {
+	slog.Debug("fetching address via DNS", "module", "ipaddr", "dns_server", server, "family", familyName(family))
 
 	var dialer net.Dialer
-	conn, err := dialer.DialContext(ctx, "udp6", fmt.Sprintf("[%s]:53", *d))
+	conn, err := dialer.DialContext(ctx, network, server)
 	if err != nil {
 		return nil, fmt.Errorf("dial DNS server failed: %w", err)
 	}
@@ -36,18 +61,20 @@ func (d *DnsFetcher) Fetch(ctx context.Context) (net.IP, error) {
 	if !ok {
 		return nil, fmt.Errorf("unexpected local address type from dial")
 	}
-	if localAddr.IP.To16() != nil && localAddr.IP.To4() == nil {
-		slog.Info("got local IPv6 address via DNS",
+	if matchFamily(localAddr.IP, family) {
+		slog.Info("got local address via DNS",
 			"module", "ipaddr",
-			"dns_server", d.String(),
+			"dns_server", server,
+			"family", familyName(family),
 			"local_addr", localAddr.IP.String())
 		return localAddr.IP, nil
 	}
 
-	slog.Warn("DNS dial did not return a valid IPv6 address",
+	slog.Warn("DNS dial did not return a valid address",
 		"module", "ipaddr",
-		"dns_server", d.String(),
+		"dns_server", server,
+		"family", familyName(family),
 		"local_addr", localAddr.IP.String())
 
-	return nil, fmt.Errorf("no valid IPv6 address obtained from dial")
+	return nil, fmt.Errorf("no valid %s address obtained from dial", familyName(family))
 }
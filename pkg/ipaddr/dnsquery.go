@@ -0,0 +1,359 @@
+package ipaddr
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+// DnsQueryType DNS 查询的记录类型。
+type DnsQueryType uint16
+
+// DnsQueryFetcher 支持的查询类型。
+const (
+	DnsTypeA    DnsQueryType = 1
+	DnsTypeTXT  DnsQueryType = 16
+	DnsTypeAAAA DnsQueryType = 28
+)
+
+// String 返回记录类型名称
+func (t DnsQueryType) String() string {
+	switch t {
+	case DnsTypeA:
+		return "A"
+	case DnsTypeTXT:
+		return "TXT"
+	case DnsTypeAAAA:
+		return "AAAA"
+	}
+	return fmt.Sprintf("TYPE%d", uint16(t))
+}
+
+// ParseDnsQueryType 解析记录类型名称（A、AAAA、TXT，不区分大小写）。
+func ParseDnsQueryType(s string) (DnsQueryType, error) {
+	for _, t := range []DnsQueryType{DnsTypeA, DnsTypeAAAA, DnsTypeTXT} {
+		if strings.EqualFold(s, t.String()) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported DNS query type %q (supported: A, AAAA, TXT)", s)
+}
+
+// dnsQueryTimeout 父 context 未设置截止时间时单次 DNS 查询的超时时间。
+const dnsQueryTimeout = 5 * time.Second
+
+// dnsQueryFetcher DNS 查询获取器的公共实现。
+type dnsQueryFetcher struct {
+	server string // 服务器地址（主机名或 IP，可带端口，默认 53）
+	name   string // 查询的域名
+	qtype  DnsQueryType
+	family int // 4 或 6，决定查询走 udp4 还是 udp6，以及应答中接受的地址族
+}
+
+// DnsQueryFetcher 向 DNS 服务器发送真实的查询（如 myip.opendns.com AAAA），从应答中读取
+// 服务器看到的来源地址，获取 IPv6 地址。
+//
+// 与 DnsFetcher 只读取本机出口地址不同，返回的是服务器看到的公网地址，
+// 因此在 NAT66 / NPTv6 之后也能得到正确结果。查询通过 IPv6 发送。
+type DnsQueryFetcher struct {
+	dnsQueryFetcher
+}
+
+// DnsQueryIPv4Fetcher 与 DnsQueryFetcher 相同，查询通过 IPv4 发送，获取 IPv4 地址
+type DnsQueryIPv4Fetcher struct {
+	dnsQueryFetcher
+}
+
+// NewDnsQueryFetcher 创建向 server 查询 name 的 qtype 记录的 DnsQueryFetcher（qtype 通常为 AAAA 或 TXT）
+func NewDnsQueryFetcher(server, name string, qtype DnsQueryType) *DnsQueryFetcher {
+	return &DnsQueryFetcher{dnsQueryFetcher{server: server, name: name, qtype: qtype, family: 6}}
+}
+
+// NewDnsQueryIPv4Fetcher 创建向 server 查询 name 的 qtype 记录的 DnsQueryIPv4Fetcher（qtype 通常为 A 或 TXT）
+func NewDnsQueryIPv4Fetcher(server, name string, qtype DnsQueryType) *DnsQueryIPv4Fetcher {
+	return &DnsQueryIPv4Fetcher{dnsQueryFetcher{server: server, name: name, qtype: qtype, family: 4}}
+}
+
+// 常见的"我的 IP"查询服务，可用于 NewDnsQueryFetcher 和 NewDnsQueryIPv4Fetcher。
+//
+//   - OpenDNS：解析服务器对 myip.opendns.com 返回查询来源地址（AAAA 或 A）
+//   - Google：权威服务器对 o-o.myaddr.l.google.com 的 TXT 记录返回查询来源地址
+//   - Akamai：权威服务器对 whoami.akamai.net 返回查询来源地址（AAAA 或 A）
+var (
+	DnsQueryOpenDNS = DnsQueryService{Server: "resolver1.opendns.com", Name: "myip.opendns.com", IPv6Type: DnsTypeAAAA, IPv4Type: DnsTypeA}
+	DnsQueryGoogle  = DnsQueryService{Server: "ns1.google.com", Name: "o-o.myaddr.l.google.com", IPv6Type: DnsTypeTXT, IPv4Type: DnsTypeTXT}
+	DnsQueryAkamai  = DnsQueryService{Server: "ns1-1.akamaitech.net", Name: "whoami.akamai.net", IPv6Type: DnsTypeAAAA, IPv4Type: DnsTypeA}
+)
+
+// DnsQueryServices 按名称索引的常见查询服务。
+var DnsQueryServices = map[string]DnsQueryService{
+	"opendns": DnsQueryOpenDNS,
+	"google":  DnsQueryGoogle,
+	"akamai":  DnsQueryAkamai,
+}
+
+// DnsQueryService 一个"我的 IP"DNS 查询服务：服务器、查询的域名和各地址族使用的记录类型。
+type DnsQueryService struct {
+	Server   string
+	Name     string
+	IPv6Type DnsQueryType
+	IPv4Type DnsQueryType
+}
+
+// ParseDnsQueryService 解析查询服务描述：DnsQueryServices 中的名称（opendns、google、akamai），
+// 或 NAME/TYPE@SERVER（如 myip.opendns.com/AAAA@resolver1.opendns.com，两个地址族使用同一记录类型）。
+func ParseDnsQueryService(spec string) (DnsQueryService, error) {
+	if s, ok := DnsQueryServices[strings.ToLower(spec)]; ok {
+		return s, nil
+	}
+	query, server, ok := strings.Cut(spec, "@")
+	name, typ, ok2 := strings.Cut(query, "/")
+	if !ok || !ok2 || name == "" || server == "" {
+		return DnsQueryService{}, fmt.Errorf("invalid DNS query %q: expected opendns, google, akamai or NAME/TYPE@SERVER", spec)
+	}
+	qtype, err := ParseDnsQueryType(typ)
+	if err != nil {
+		return DnsQueryService{}, err
+	}
+	return DnsQueryService{Server: server, Name: name, IPv6Type: qtype, IPv4Type: qtype}, nil
+}
+
+// IPv6Fetcher 创建查询该服务的 DnsQueryFetcher
+func (s DnsQueryService) IPv6Fetcher() *DnsQueryFetcher {
+	return NewDnsQueryFetcher(s.Server, s.Name, s.IPv6Type)
+}
+
+// IPv4Fetcher 创建查询该服务的 DnsQueryIPv4Fetcher
+func (s DnsQueryService) IPv4Fetcher() *DnsQueryIPv4Fetcher {
+	return NewDnsQueryIPv4Fetcher(s.Server, s.Name, s.IPv4Type)
+}
+
+// String 返回 DNS 查询获取器的字符串表示，如 dnsquery:myip.opendns.com/AAAA@resolver1.opendns.com
+func (d *dnsQueryFetcher) String() string {
+	return fmt.Sprintf("dnsquery:%s/%s@%s", d.name, d.qtype, d.server)
+}
+
+// Fetch 实现 Fetcher 接口
+func (d *dnsQueryFetcher) Fetch(ctx context.Context) (net.IP, error) {
+	network := "udp6"
+	if d.family == 4 {
+		network = "udp4"
+	}
+	server := dnsServerAddr(d.server)
+
+	slog.Debug("fetching address via DNS query", "module", "ipaddr",
+		"dns_server", server, "name", d.name, "type", d.qtype, "family", familyName(d.family))
+
+	ips, err := exchangeDns(ctx, network, server, d.name, d.qtype)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if matchFamily(ip, d.family) {
+			slog.Info("got public address via DNS query", "module", "ipaddr",
+				"dns_server", server, "name", d.name, "addr", ip.String())
+			return ip, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s address in DNS %s answer for %s from %s", familyName(d.family), d.qtype, d.name, server)
+}
+
+// QueryDNS 直接向 server（主机名或 IP，可带端口，默认 53）查询 name 的 qtype 记录，
+// 返回回答段中的全部地址，回答段为空时返回空切片。
+//
+// 不经过本机解析器及其缓存，可用于向权威服务器确认记录是否已生效。
+// 应答被截断（TC 位）时返回 ErrDnsTruncated，回答段可能不完整。
+func QueryDNS(ctx context.Context, server, name string, qtype DnsQueryType) ([]net.IP, error) {
+	return exchangeDns(ctx, "udp", dnsServerAddr(server), name, qtype)
+}
+
+// dnsServerAddr 为未指定端口的服务器地址补上默认端口 53。
+func dnsServerAddr(server string) string {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	return server
+}
+
+// exchangeDns 通过 network 向 server 发送一次查询并解析应答。
+func exchangeDns(ctx context.Context, network, server, name string, qtype DnsQueryType) ([]net.IP, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial DNS server %s: %w", server, err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(dnsQueryTimeout)
+	}
+	conn.SetDeadline(deadline)
+	// context 被取消时（如竞速已有结果）立即中断读取
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	id := uint16(rand.Intn(1 << 16))
+	query, err := buildDnsQuery(id, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, fmt.Errorf("failed to send DNS query to %s: %w", server, err)
+	}
+
+	buf := make([]byte, 1232)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("failed to read DNS response from %s: %w", server, err)
+		}
+		ips, err := parseDnsResponse(buf[:n], id, qtype)
+		if errors.Is(err, errDnsIDMismatch) {
+			continue // 非本次查询的应答，继续等待
+		}
+		if errors.Is(err, ErrDnsTruncated) {
+			return nil, fmt.Errorf("DNS response from %s: %w", server, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid DNS response from %s: %w", server, err)
+		}
+		return ips, nil
+	}
+}
+
+// ErrDnsTruncated 应答设置了 TC 位（超出 UDP 报文长度被截断），回答段可能不完整。
+var ErrDnsTruncated = errors.New("DNS response truncated")
+
+// errDnsIDMismatch 应答的 ID 与查询不一致。
+var errDnsIDMismatch = errors.New("DNS response ID mismatch")
+
+// buildDnsQuery 构造一个 DNS 查询报文（RFC 1035 4.1，设置 RD 位，class IN）。
+func buildDnsQuery(id uint16, name string, qtype DnsQueryType) ([]byte, error) {
+	msg := make([]byte, 12, 12+len(name)+6)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], 0x0100) // RD
+	binary.BigEndian.PutUint16(msg[4:], 1)      // QDCOUNT
+
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" || len(label) > 63 {
+			return nil, fmt.Errorf("invalid DNS name %q", name)
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(qtype))
+	msg = binary.BigEndian.AppendUint16(msg, 1) // IN
+	return msg, nil
+}
+
+// parseDnsResponse 解析应答报文，返回回答段中 qtype 记录包含的地址：
+// AAAA / A 记录直接取 RDATA，TXT 记录取每个能解析为 IP 的字符串（或以空白分隔的字段）。
+func parseDnsResponse(msg []byte, id uint16, qtype DnsQueryType) ([]net.IP, error) {
+	if len(msg) < 12 {
+		return nil, fmt.Errorf("message too short")
+	}
+	if binary.BigEndian.Uint16(msg[0:]) != id {
+		return nil, errDnsIDMismatch
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&0x8000 == 0 {
+		return nil, fmt.Errorf("not a response")
+	}
+	if flags&0x0200 != 0 {
+		return nil, ErrDnsTruncated
+	}
+	if rcode := flags & 0x000f; rcode != 0 {
+		return nil, fmt.Errorf("server returned rcode %d", rcode)
+	}
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+
+	off := 12
+	var err error
+	for range qdcount {
+		if off, err = skipDnsName(msg, off); err != nil {
+			return nil, err
+		}
+		off += 4 // QTYPE + QCLASS
+	}
+
+	var ips []net.IP
+	for range ancount {
+		if off, err = skipDnsName(msg, off); err != nil {
+			return nil, err
+		}
+		if off+10 > len(msg) {
+			return nil, fmt.Errorf("truncated resource record")
+		}
+		rtype := DnsQueryType(binary.BigEndian.Uint16(msg[off:]))
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+rdlen > len(msg) {
+			return nil, fmt.Errorf("truncated resource record data")
+		}
+		rdata := msg[off : off+rdlen]
+		off += rdlen
+		if rtype != qtype {
+			continue // 如 CNAME
+		}
+		switch rtype {
+		case DnsTypeA, DnsTypeAAAA:
+			if len(rdata) == net.IPv4len || len(rdata) == net.IPv6len {
+				ips = append(ips, net.IP(append([]byte(nil), rdata...)))
+			}
+		case DnsTypeTXT:
+			ips = append(ips, txtAddrs(rdata)...)
+		}
+	}
+	return ips, nil
+}
+
+// txtAddrs 返回 TXT 记录 RDATA 中能解析为 IP 的字符串或字段。
+func txtAddrs(rdata []byte) []net.IP {
+	var ips []net.IP
+	for len(rdata) > 0 {
+		n := int(rdata[0])
+		if 1+n > len(rdata) {
+			break
+		}
+		for _, field := range strings.Fields(string(rdata[1 : 1+n])) {
+			if ip := net.ParseIP(field); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+		rdata = rdata[1+n:]
+	}
+	return ips
+}
+
+// skipDnsName 跳过 off 处的域名（支持压缩指针），返回其后的偏移。
+func skipDnsName(msg []byte, off int) (int, error) {
+	for {
+		if off >= len(msg) {
+			return 0, fmt.Errorf("truncated name")
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			return off + 1, nil
+		case n&0xc0 == 0xc0:
+			if off+2 > len(msg) {
+				return 0, fmt.Errorf("truncated name pointer")
+			}
+			return off + 2, nil
+		default:
+			off += 1 + n
+		}
+	}
+}
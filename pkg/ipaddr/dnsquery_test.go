@@ -0,0 +1,218 @@
+package ipaddr
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// ============================================================
+// DNS 查询获取器测试（本地 DNS 桩服务器）
+// ============================================================
+
+// stubRecord 桩服务器应答中的一条记录。
+type stubRecord struct {
+	rtype DnsQueryType
+	rdata []byte
+}
+
+// stubDnsServer 在 network（udp6 或 udp4）的回环地址上启动 DNS 桩服务器，
+// 对每个查询按 reply 的返回值应答：rcode 和回答段记录；wrongID 为 true 时先发送一个 ID 不符的应答。
+func stubDnsServer(t *testing.T, network string, reply func(name string, qtype DnsQueryType) (rcode int, records []stubRecord), wrongID bool) string {
+	t.Helper()
+	addr := "[::1]:0"
+	if network == "udp4" {
+		addr = "127.0.0.1:0"
+	}
+	conn, err := net.ListenPacket(network, addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s: %v", addr, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, peer, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query := buf[:n]
+			name, qtype, qend := parseStubQuestion(query)
+			rcode, records := reply(name, qtype)
+
+			resp := append([]byte(nil), query[:qend]...)
+			binary.BigEndian.PutUint16(resp[2:], 0x8180|uint16(rcode))
+			binary.BigEndian.PutUint16(resp[6:], uint16(len(records)))
+			for _, r := range records {
+				resp = append(resp, 0xc0, 0x0c) // 指向问题段中的域名
+				resp = binary.BigEndian.AppendUint16(resp, uint16(r.rtype))
+				resp = binary.BigEndian.AppendUint16(resp, 1)
+				resp = binary.BigEndian.AppendUint32(resp, 0)
+				resp = binary.BigEndian.AppendUint16(resp, uint16(len(r.rdata)))
+				resp = append(resp, r.rdata...)
+			}
+			if wrongID {
+				bad := append([]byte(nil), resp...)
+				bad[0] ^= 0xff
+				conn.WriteTo(bad, peer)
+			}
+			conn.WriteTo(resp, peer)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// parseStubQuestion 解析查询的问题段，返回域名、类型和问题段结束偏移。
+func parseStubQuestion(msg []byte) (string, DnsQueryType, int) {
+	off := 12
+	var name string
+	for msg[off] != 0 {
+		n := int(msg[off])
+		if name != "" {
+			name += "."
+		}
+		name += string(msg[off+1 : off+1+n])
+		off += 1 + n
+	}
+	off++
+	qtype := DnsQueryType(binary.BigEndian.Uint16(msg[off:]))
+	return name, qtype, off + 4
+}
+
+// txtRdata 构造 TXT 记录的 RDATA。
+func txtRdata(strs ...string) []byte {
+	var b []byte
+	for _, s := range strs {
+		b = append(b, byte(len(s)))
+		b = append(b, s...)
+	}
+	return b
+}
+
+func TestDnsQueryFetcher_AAAA(t *testing.T) {
+	public := net.ParseIP("2001:db8:1::42")
+	server := stubDnsServer(t, "udp6", func(name string, qtype DnsQueryType) (int, []stubRecord) {
+		if name != "myip.opendns.com" || qtype != DnsTypeAAAA {
+			return 3, nil // NXDOMAIN
+		}
+		return 0, []stubRecord{
+			{rtype: 5, rdata: []byte{0}}, // CNAME 记录应被跳过
+			{rtype: DnsTypeAAAA, rdata: public},
+		}
+	}, true)
+
+	f := NewDnsQueryFetcher(server, "myip.opendns.com", DnsTypeAAAA)
+	ip, err := f.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch 不应返回错误: %v", err)
+	}
+	if !ip.Equal(public) {
+		t.Errorf("应返回应答中的公网地址 %s, 得到 %s", public, ip)
+	}
+	if want := "dnsquery:myip.opendns.com/AAAA@" + server; f.String() != want {
+		t.Errorf("String() = %s, 期望 %s", f.String(), want)
+	}
+
+	if _, err := NewDnsQueryFetcher(server, "other.example", DnsTypeAAAA).Fetch(context.Background()); err == nil {
+		t.Error("NXDOMAIN 应返回错误")
+	}
+}
+
+func TestDnsQueryFetcher_TXT(t *testing.T) {
+	server := stubDnsServer(t, "udp6", func(name string, qtype DnsQueryType) (int, []stubRecord) {
+		return 0, []stubRecord{
+			{rtype: DnsTypeTXT, rdata: txtRdata("edns0-client-subnet 192.0.2.0/24")},
+			{rtype: DnsTypeTXT, rdata: txtRdata("2001:db8:2::7")},
+		}
+	}, false)
+
+	ip, err := DnsQueryService{Server: server, Name: "o-o.myaddr.l.google.com", IPv6Type: DnsTypeTXT}.IPv6Fetcher().Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch 不应返回错误: %v", err)
+	}
+	if !ip.Equal(net.ParseIP("2001:db8:2::7")) {
+		t.Errorf("应返回 TXT 记录中的 IPv6 地址, 得到 %s", ip)
+	}
+}
+
+func TestDnsQueryIPv4Fetcher(t *testing.T) {
+	server := stubDnsServer(t, "udp4", func(name string, qtype DnsQueryType) (int, []stubRecord) {
+		return 0, []stubRecord{{rtype: DnsTypeA, rdata: []byte{198, 51, 100, 9}}}
+	}, false)
+
+	ip, err := NewDnsQueryIPv4Fetcher(server, "whoami.akamai.net", DnsTypeA).Fetch(context.Background())
+	if err != nil || !ip.Equal(net.ParseIP("198.51.100.9")) {
+		t.Errorf("应返回 A 记录中的地址: %v, %v", ip, err)
+	}
+}
+
+func TestDnsQueryFetcher_NoAnswerAndCancel(t *testing.T) {
+	server := stubDnsServer(t, "udp6", func(string, DnsQueryType) (int, []stubRecord) {
+		return 0, nil
+	}, false)
+	if _, err := NewDnsQueryFetcher(server, "myip.opendns.com", DnsTypeAAAA).Fetch(context.Background()); err == nil {
+		t.Error("应答中没有地址时应返回错误")
+	}
+
+	// 不应答的服务器：context 取消后立即返回
+	silent, err := net.ListenPacket("udp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("cannot listen on [::1]: %v", err)
+	}
+	defer silent.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := NewDnsQueryFetcher(silent.LocalAddr().String(), "myip.opendns.com", DnsTypeAAAA).Fetch(ctx); err == nil {
+		t.Error("服务器无应答时应返回错误")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("应在 context 截止时返回, 耗时 %v", elapsed)
+	}
+}
+
+func TestQueryDNS(t *testing.T) {
+	server := stubDnsServer(t, "udp4", func(name string, qtype DnsQueryType) (int, []stubRecord) {
+		if name != "www.example.com" {
+			return 0, nil
+		}
+		return 0, []stubRecord{
+			{rtype: DnsTypeAAAA, rdata: net.ParseIP("2001:db8::1")},
+			{rtype: DnsTypeAAAA, rdata: net.ParseIP("2001:db8::2")},
+		}
+	}, false)
+
+	ips, err := QueryDNS(context.Background(), server, "www.example.com", DnsTypeAAAA)
+	if err != nil {
+		t.Fatalf("QueryDNS 不应返回错误: %v", err)
+	}
+	if len(ips) != 2 || !ips[1].Equal(net.ParseIP("2001:db8::2")) {
+		t.Errorf("应返回回答段中的全部地址, 得到 %v", ips)
+	}
+	if ips, err := QueryDNS(context.Background(), server, "none.example.com", DnsTypeAAAA); err != nil || len(ips) != 0 {
+		t.Errorf("回答段为空时应返回空结果而不是错误, 得到 %v, %v", ips, err)
+	}
+}
+
+func TestQueryDNS_Truncated(t *testing.T) {
+	server := stubDnsServer(t, "udp4", func(string, DnsQueryType) (int, []stubRecord) {
+		return 0x0200, []stubRecord{{rtype: DnsTypeAAAA, rdata: net.ParseIP("2001:db8::1")}} // TC 位
+	}, false)
+
+	if _, err := QueryDNS(context.Background(), server, "www.example.com", DnsTypeAAAA); !errors.Is(err, ErrDnsTruncated) {
+		t.Errorf("应答被截断时应返回 ErrDnsTruncated, 得到 %v", err)
+	}
+}
+
+func TestParseDnsQueryType(t *testing.T) {
+	if q, err := ParseDnsQueryType("aaaa"); err != nil || q != DnsTypeAAAA {
+		t.Errorf("aaaa 应解析为 AAAA: %v, %v", q, err)
+	}
+	if _, err := ParseDnsQueryType("MX"); err == nil {
+		t.Error("不支持的记录类型应返回错误")
+	}
+}
@@ -0,0 +1,181 @@
+package ipaddr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Extractor 从 HTTP 响应体中提取候选地址字符串，HTTP 获取器取第一个匹配地址族的候选。
+type Extractor interface {
+	Extract(body []byte) ([]string, error)
+	String() string
+}
+
+// jsonPathExtractor 按路径从 JSON 响应中取值。
+type jsonPathExtractor struct {
+	path  string
+	steps []any // string 为对象键，int 为数组下标
+}
+
+// NewJSONPathExtractor 创建按路径从 JSON 响应中取值的 Extractor。
+//
+// 路径由点分隔的对象键和 [N] 数组下标组成，可选 $ 前缀，如 ip、data.ipv6、$.addrs[0].ip。
+// 取到的值为字符串数组时，每个元素都是候选地址。
+func NewJSONPathExtractor(path string) (Extractor, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonPathExtractor{path: path, steps: steps}, nil
+}
+
+// parseJSONPath 将路径拆分为对象键和数组下标。
+func parseJSONPath(path string) ([]any, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if p == "" {
+		return nil, fmt.Errorf("invalid JSON path %q: empty", path)
+	}
+	var steps []any
+	for _, part := range strings.Split(p, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key != "" {
+			steps = append(steps, key)
+		} else if rest == "" {
+			return nil, fmt.Errorf("invalid JSON path %q: empty key", path)
+		}
+		for rest != "" {
+			idx, after, ok := strings.Cut(rest, "]")
+			n, err := strconv.Atoi(idx)
+			if !ok || err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: bad index [%s", path, rest)
+			}
+			steps = append(steps, n)
+			if after == "" {
+				break
+			}
+			if !strings.HasPrefix(after, "[") {
+				return nil, fmt.Errorf("invalid JSON path %q: unexpected %q", path, after)
+			}
+			rest = after[1:]
+		}
+	}
+	return steps, nil
+}
+
+// Extract 实现 Extractor 接口
+func (e *jsonPathExtractor) Extract(body []byte) ([]string, error) {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	for _, step := range e.steps {
+		switch s := step.(type) {
+		case string:
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("JSON path %s: %q is not an object key", e.path, s)
+			}
+			if v, ok = obj[s]; !ok {
+				return nil, fmt.Errorf("JSON path %s: key %q not found", e.path, s)
+			}
+		case int:
+			arr, ok := v.([]any)
+			if !ok || s >= len(arr) {
+				return nil, fmt.Errorf("JSON path %s: index %d out of range", e.path, s)
+			}
+			v = arr[s]
+		}
+	}
+	switch val := v.(type) {
+	case string:
+		return []string{val}, nil
+	case []any:
+		var out []string
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("JSON path %s: value is not a string", e.path)
+}
+
+// String 返回 Extractor 的字符串表示
+func (e *jsonPathExtractor) String() string {
+	return "json:" + e.path
+}
+
+// regexExtractor 用正则表达式从响应中匹配地址。
+type regexExtractor struct {
+	re *regexp.Regexp
+}
+
+// NewRegexExtractor 创建按正则表达式匹配的 Extractor。
+//
+// 表达式含捕获组时取第一个捕获组，否则取整个匹配；响应中的每处匹配都是候选地址，
+// 适用于路由器状态页等 HTML 响应。
+func NewRegexExtractor(expr string) (Extractor, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %w", expr, err)
+	}
+	return &regexExtractor{re: re}, nil
+}
+
+// Extract 实现 Extractor 接口
+func (e *regexExtractor) Extract(body []byte) ([]string, error) {
+	var out []string
+	for _, m := range e.re.FindAllSubmatch(body, -1) {
+		if len(m) > 1 {
+			out = append(out, string(m[1]))
+		} else {
+			out = append(out, string(m[0]))
+		}
+	}
+	return out, nil
+}
+
+// String 返回 Extractor 的字符串表示
+func (e *regexExtractor) String() string {
+	return "regex:" + e.re.String()
+}
+
+// keyValueExtractor 从每行一个 key=value 的文本中取指定字段。
+type keyValueExtractor struct {
+	key string
+}
+
+// NewKeyValueExtractor 创建从 key=value 文本（如 Cloudflare 的 /cdn-cgi/trace）中取 key 字段的 Extractor。
+func NewKeyValueExtractor(key string) (Extractor, error) {
+	if key == "" {
+		return nil, fmt.Errorf("key=value field name must not be empty")
+	}
+	return &keyValueExtractor{key: key}, nil
+}
+
+// Extract 实现 Extractor 接口
+func (e *keyValueExtractor) Extract(body []byte) ([]string, error) {
+	var out []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), "=")
+		if ok && strings.TrimSpace(k) == e.key {
+			out = append(out, strings.TrimSpace(v))
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("field %q not found", e.key)
+	}
+	return out, nil
+}
+
+// String 返回 Extractor 的字符串表示
+func (e *keyValueExtractor) String() string {
+	return "field:" + e.key
+}
@@ -0,0 +1,122 @@
+package ipaddr_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// ==================== Extractor ====================
+
+func TestExtractors(t *testing.T) {
+	mustExtractor := func(e ipaddr.Extractor, err error) ipaddr.Extractor {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("创建 Extractor 失败: %v", err)
+		}
+		return e
+	}
+
+	tests := []struct {
+		name string
+		ext  ipaddr.Extractor
+		body string
+		want []string
+	}{
+		{"JSON 顶层键", mustExtractor(ipaddr.NewJSONPathExtractor("ip")), `{"ip": "2001:db8::1"}`, []string{"2001:db8::1"}},
+		{"JSON 嵌套路径", mustExtractor(ipaddr.NewJSONPathExtractor("$.data.addrs[1].ip")),
+			`{"data": {"addrs": [{"ip": "192.0.2.1"}, {"ip": "2001:db8::2"}]}}`, []string{"2001:db8::2"}},
+		{"JSON 字符串数组", mustExtractor(ipaddr.NewJSONPathExtractor("ips")), `{"ips": ["192.0.2.1", "2001:db8::3"]}`,
+			[]string{"192.0.2.1", "2001:db8::3"}},
+		{"正则捕获组", mustExtractor(ipaddr.NewRegexExtractor(`WAN IPv6: <b>([0-9a-f:]+)</b>`)),
+			`<td>WAN IPv6: <b>2001:db8::4</b></td>`, []string{"2001:db8::4"}},
+		{"key=value", mustExtractor(ipaddr.NewKeyValueExtractor("ip")), "fl=1\nh=example.com\nip=2001:db8::5\nts=1\n",
+			[]string{"2001:db8::5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.ext.Extract([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("Extract 不应返回错误: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("期望 %v, 得到 %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("期望 %v, 得到 %v", tt.want, got)
+				}
+			}
+		})
+	}
+
+	for _, path := range []string{"", "$", "a[x]", "a[0", "a[0]b", "a..b"} {
+		if _, err := ipaddr.NewJSONPathExtractor(path); err == nil {
+			t.Errorf("无效的 JSON 路径 %q 应返回错误", path)
+		}
+	}
+	if _, err := ipaddr.NewRegexExtractor("("); err == nil {
+		t.Error("无效的正则表达式应返回错误")
+	}
+	e := mustExtractor(ipaddr.NewJSONPathExtractor("data.ip"))
+	if _, err := e.Extract([]byte(`{"data": "x"}`)); err == nil {
+		t.Error("路径不存在时应返回错误")
+	}
+}
+
+// ==================== HTTP 获取器 ====================
+
+func TestHttpIPv6Fetcher_ExtractorHeadersAuth(t *testing.T) {
+	server := newIPv6TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Requested-With") != "ddns6" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`<tr><td>IPv4</td><td>192.0.2.1</td></tr><tr><td>IPv6</td><td>2001:db8::6/64</td></tr>`))
+	}))
+
+	re, err := ipaddr.NewRegexExtractor(`<td>IPv6</td><td>([^<]+)</td>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fetcher := ipaddr.NewHttpIPv6Fetcher(server.URL, ipaddr.WithExtractor(re),
+		ipaddr.WithHttpHeader("X-Requested-With", "ddns6"), ipaddr.WithBasicAuth("admin", "secret"))
+	ip, err := fetcher.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("Fetch 不应返回错误: %v", err)
+	}
+	if ip.String() != "2001:db8::6" {
+		t.Errorf("期望去掉前缀长度后得到 2001:db8::6, 得到 %s", ip)
+	}
+
+	unauth := ipaddr.NewHttpIPv6Fetcher(server.URL, ipaddr.WithExtractor(re))
+	if _, err := unauth.Fetch(ctx); err == nil {
+		t.Error("未认证（401）时应返回错误")
+	}
+
+	kv, _ := ipaddr.NewKeyValueExtractor("ip")
+	v4only := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ip=192.0.2.1\n"))
+	})
+	v6Server := newIPv6TestServer(t, v4only)
+	if _, err := ipaddr.NewHttpIPv6Fetcher(v6Server.URL, ipaddr.WithExtractor(kv)).Fetch(ctx); err == nil {
+		t.Error("提取结果不含 IPv6 地址时应返回错误")
+	}
+	v4Server := httptest.NewServer(v4only)
+	defer v4Server.Close()
+	if ip, err := ipaddr.NewHttpIPv4Fetcher(v4Server.URL, ipaddr.WithExtractor(kv)).Fetch(ctx); err != nil || ip.String() != "192.0.2.1" {
+		t.Errorf("IPv4 获取器应得到 192.0.2.1, 得到 %v, %v", ip, err)
+	}
+}
@@ -0,0 +1,49 @@
+package ipaddr
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DefaultPrefixLength 运营商下发前缀（局域网网段）的默认长度。
+const DefaultPrefixLength = 64
+
+// ParseInterfaceID 解析局域网主机的接口标识（地址后缀）。
+//
+// 支持两种写法：
+//   - IPv6 地址形式的后缀，如 ::1234、::211:22ff:fe33:4455（EUI-64）
+//   - MAC 地址，如 00:11:22:33:44:55，按 EUI-64 规则（RFC 4291 附录 A）转换为 ::211:22ff:fe33:4455
+func ParseInterfaceID(s string) (net.IP, error) {
+	s = strings.TrimSpace(s)
+	if mac, err := net.ParseMAC(s); err == nil && len(mac) == 6 {
+		iid := make(net.IP, net.IPv6len)
+		copy(iid[8:11], mac[0:3])
+		iid[11], iid[12] = 0xff, 0xfe
+		copy(iid[13:], mac[3:6])
+		iid[8] ^= 0x02 // 翻转 universal/local 位
+		return iid, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil || ip.To4() != nil {
+		return nil, fmt.Errorf("invalid interface ID %q: must be an IPv6 suffix (e.g. ::1234) or a MAC address", s)
+	}
+	return ip, nil
+}
+
+// HostAddr 将 prefix 的前 prefixLen 位与接口标识 iid 的其余位组合为局域网主机地址。
+//
+// prefix 通常为本机检测到的公网 IPv6 地址（运营商下发前缀 + 本机接口标识）。
+// prefix 不是 IPv6 地址、prefixLen 不在 [0, 128] 范围内时返回 nil。
+func HostAddr(prefix net.IP, prefixLen int, iid net.IP) net.IP {
+	p, id := prefix.To16(), iid.To16()
+	if p == nil || id == nil || prefix.To4() != nil || prefixLen < 0 || prefixLen > 128 {
+		return nil
+	}
+	addr := make(net.IP, net.IPv6len)
+	mask := net.CIDRMask(prefixLen, 128)
+	for i := range addr {
+		addr[i] = p[i]&mask[i] | id[i]&^mask[i]
+	}
+	return addr
+}
@@ -0,0 +1,63 @@
+package ipaddr_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+func TestParseInterfaceID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"::1234", "::1234"},
+		{"::211:22ff:fe33:4455", "::211:22ff:fe33:4455"},
+		{"00:11:22:33:44:55", "::211:22ff:fe33:4455"},
+		{"02-11-22-33-44-55", "::11:22ff:fe33:4455"},
+	}
+	for _, tt := range tests {
+		got, err := ipaddr.ParseInterfaceID(tt.in)
+		if err != nil {
+			t.Errorf("ParseInterfaceID(%q) 不应返回错误: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(net.ParseIP(tt.want)) {
+			t.Errorf("ParseInterfaceID(%q) = %s, 期望 %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "nas", "192.168.1.10"} {
+		if _, err := ipaddr.ParseInterfaceID(in); err == nil {
+			t.Errorf("ParseInterfaceID(%q) 应返回错误", in)
+		}
+	}
+}
+
+func TestHostAddr(t *testing.T) {
+	prefix := net.ParseIP("2001:db8:1:2:aaaa:bbbb:cccc:dddd")
+	tests := []struct {
+		prefixLen int
+		iid       string
+		want      string
+	}{
+		{64, "::1234", "2001:db8:1:2::1234"},
+		{64, "::211:22ff:fe33:4455", "2001:db8:1:2:211:22ff:fe33:4455"},
+		{56, "::3:0:0:0:10", "2001:db8:1:3::10"},
+		{128, "::1", "2001:db8:1:2:aaaa:bbbb:cccc:dddd"},
+	}
+	for _, tt := range tests {
+		got := ipaddr.HostAddr(prefix, tt.prefixLen, net.ParseIP(tt.iid))
+		if !got.Equal(net.ParseIP(tt.want)) {
+			t.Errorf("HostAddr(/%d, %s) = %s, 期望 %s", tt.prefixLen, tt.iid, got, tt.want)
+		}
+	}
+
+	if got := ipaddr.HostAddr(net.ParseIP("192.0.2.1"), 64, net.ParseIP("::1")); got != nil {
+		t.Errorf("IPv4 前缀应返回 nil, 得到 %s", got)
+	}
+	if got := ipaddr.HostAddr(prefix, 129, net.ParseIP("::1")); got != nil {
+		t.Errorf("无效的前缀长度应返回 nil, 得到 %s", got)
+	}
+}
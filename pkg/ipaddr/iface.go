@@ -0,0 +1,106 @@
+package ipaddr
+
+import (
+	"fmt"
+	"net"
+	"sort"
+)
+
+// InterfaceFetcher 直接读取本机网络接口上的公网 IPv6 地址，不依赖外部回显服务。
+//
+// 仅 Linux 支持（通过 Netlink 读取地址及其标志和生命周期），其他平台 Fetch 返回错误。
+// 排除临时（隐私扩展）地址、已弃用地址和 DAD 未完成（tentative）的地址，
+// 剩余地址中优先选择稳定地址（EUI-64 或 stable-privacy），其次选择首选生命周期最长的地址。
+// 作为 CandidateFetcher 时按同样的优先级返回全部剩余地址，供地址策略选择。
+type InterfaceFetcher struct {
+	iface string // 网络接口名，空表示所有接口
+}
+
+// NewInterfaceFetcher 创建读取接口 iface（如 ppp0、eth0，空表示所有接口）地址的 InterfaceFetcher。
+func NewInterfaceFetcher(iface string) *InterfaceFetcher {
+	return &InterfaceFetcher{iface: iface}
+}
+
+// String 返回 InterfaceFetcher 的字符串表示
+func (f *InterfaceFetcher) String() string {
+	if f.iface == "" {
+		return "interface:*"
+	}
+	return "interface:" + f.iface
+}
+
+// ifaceAddr 接口上的一个地址及其状态。
+type ifaceAddr struct {
+	ip            net.IP
+	temporary     bool   // 隐私扩展临时地址（IFA_F_TEMPORARY）
+	deprecated    bool   // 已弃用（IFA_F_DEPRECATED 或首选生命周期为 0）
+	tentative     bool   // 重复地址检测未完成或失败（IFA_F_TENTATIVE、IFA_F_DADFAILED）
+	stablePrivacy bool   // RFC 7217 稳定隐私地址（IFA_F_STABLE_PRIVACY）
+	preferredLft  uint32 // 首选生命周期（秒），0xffffffff 表示永久
+}
+
+// usable 返回地址是否为可用的全局 IPv6 地址：全局单播、非 ULA，且未弃用、DAD 已完成。
+func (a ifaceAddr) usable() bool {
+	if a.ip.To4() != nil || !a.ip.IsGlobalUnicast() || a.ip.IsPrivate() {
+		return false
+	}
+	return !a.deprecated && !a.tentative
+}
+
+// stable 返回地址是否为稳定地址：RFC 7217 稳定隐私地址，或由 MAC 地址生成的 EUI-64 地址。
+func (a ifaceAddr) stable() bool {
+	return a.stablePrivacy || isEUI64(a.ip)
+}
+
+// isEUI64 判断 IPv6 地址的接口标识是否为 EUI-64 格式（中间为 ff:fe）。
+func isEUI64(ip net.IP) bool {
+	ip = ip.To16()
+	return ip != nil && ip[11] == 0xff && ip[12] == 0xfe
+}
+
+// selectInterfaceAddr 从接口地址中选择要发布的公网 IPv6 地址，即 interfaceCandidates 的首个地址。
+func selectInterfaceAddr(addrs []ifaceAddr) (net.IP, error) {
+	candidates, err := interfaceCandidates(addrs)
+	if err != nil {
+		return nil, err
+	}
+	return candidates[0], nil
+}
+
+// interfaceCandidates 返回接口地址中可发布的公网 IPv6 地址，按优先级排列。
+//
+// 只考虑全局单播、非 ULA 的 IPv6 地址，排除临时、已弃用和 tentative 的地址；
+// 剩余地址中稳定地址优先，其次首选生命周期最长者，再次保持原有顺序。
+func interfaceCandidates(addrs []ifaceAddr) ([]net.IP, error) {
+	var candidates []ifaceAddr
+	for _, a := range addrs {
+		if a.usable() && !a.temporary {
+			candidates = append(candidates, a)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no usable global IPv6 address (%d addresses checked)", len(addrs))
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.stable() != b.stable() {
+			return a.stable()
+		}
+		return a.preferredLft > b.preferredLft
+	})
+	ips := make([]net.IP, len(candidates))
+	for i, a := range candidates {
+		ips[i] = a.ip
+	}
+	return ips, nil
+}
+
+// hasGlobalAddr 返回 addrs 中是否存在可用的全局 IPv6 地址，临时地址也计入。
+func hasGlobalAddr(addrs []ifaceAddr) bool {
+	for _, a := range addrs {
+		if a.usable() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+//go:build linux
+
+package ipaddr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// Fetch 实现 Fetcher 接口，通过 Netlink 读取接口地址并选择要发布的地址。
+func (f *InterfaceFetcher) Fetch(ctx context.Context) (net.IP, error) {
+	ips, err := f.FetchCandidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ips[0], nil
+}
+
+// FetchCandidates 实现 CandidateFetcher 接口，按优先级返回接口上所有可发布的地址。
+func (f *InterfaceFetcher) FetchCandidates(ctx context.Context) ([]net.IP, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	addrs, err := interfaceAddrs(f.iface)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := interfaceCandidates(addrs)
+	if err != nil && f.iface != "" {
+		return nil, fmt.Errorf("interface %s: %w", f.iface, err)
+	}
+	return ips, err
+}
+
+// HasGlobalIPv6 返回接口 iface（空表示所有接口）上是否仍有可用的全局 IPv6 地址。
+//
+// 与 InterfaceFetcher 不同，临时（隐私扩展）地址也计入；只排除已弃用和 tentative 的地址。
+// 接口不存在（如 PPPoE 断线后 ppp0 被移除）视为没有地址。
+func HasGlobalIPv6(iface string) (bool, error) {
+	addrs, err := interfaceAddrs(iface)
+	if errors.As(err, &netlink.LinkNotFoundError{}) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return hasGlobalAddr(addrs), nil
+}
+
+// interfaceAddrs 通过 Netlink 读取接口 iface（空表示所有接口）的 IPv6 地址及其标志。
+func interfaceAddrs(iface string) ([]ifaceAddr, error) {
+	var link netlink.Link
+	if iface != "" {
+		l, err := netlink.LinkByName(iface)
+		if err != nil {
+			return nil, fmt.Errorf("interface %s: %w", iface, err)
+		}
+		link = l
+	}
+	list, err := netlink.AddrList(link, netlink.FAMILY_V6)
+	if err != nil {
+		return nil, fmt.Errorf("list addresses: %w", err)
+	}
+
+	addrs := make([]ifaceAddr, 0, len(list))
+	for _, a := range list {
+		if a.IPNet == nil {
+			continue
+		}
+		addrs = append(addrs, ifaceAddr{
+			ip:            a.IP,
+			temporary:     a.Flags&unix.IFA_F_TEMPORARY != 0,
+			deprecated:    a.Flags&unix.IFA_F_DEPRECATED != 0 || a.PreferedLft == 0,
+			tentative:     a.Flags&(unix.IFA_F_TENTATIVE|unix.IFA_F_DADFAILED) != 0,
+			stablePrivacy: a.Flags&unix.IFA_F_STABLE_PRIVACY != 0,
+			preferredLft:  uint32(a.PreferedLft),
+		})
+	}
+	return addrs, nil
+}
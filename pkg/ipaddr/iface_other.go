@@ -0,0 +1,24 @@
+//go:build !linux
+
+package ipaddr
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// Fetch 实现 Fetcher 接口。非 Linux 平台不支持读取接口地址的标志和生命周期。
+func (f *InterfaceFetcher) Fetch(ctx context.Context) (net.IP, error) {
+	return nil, errors.New("interface address fetcher is only supported on Linux")
+}
+
+// FetchCandidates 实现 CandidateFetcher 接口。非 Linux 平台不支持，始终返回错误。
+func (f *InterfaceFetcher) FetchCandidates(ctx context.Context) ([]net.IP, error) {
+	return nil, errors.New("interface address fetcher is only supported on Linux")
+}
+
+// HasGlobalIPv6 非 Linux 平台不支持，始终返回错误。
+func HasGlobalIPv6(iface string) (bool, error) {
+	return false, errors.New("interface address check is only supported on Linux")
+}
@@ -0,0 +1,124 @@
+package ipaddr
+
+import (
+	"net"
+	"testing"
+)
+
+// TestSelectInterfaceAddr 测试接口地址的过滤和优先级
+func TestSelectInterfaceAddr(t *testing.T) {
+	const forever = 0xffffffff
+	tests := []struct {
+		name  string
+		addrs []ifaceAddr
+		want  string
+	}{
+		{
+			name: "排除临时、已弃用和 tentative 地址",
+			addrs: []ifaceAddr{
+				{ip: net.ParseIP("2001:db8::aaaa"), temporary: true, preferredLft: 3600},
+				{ip: net.ParseIP("2001:db8::bbbb"), deprecated: true},
+				{ip: net.ParseIP("2001:db8::cccc"), tentative: true, preferredLft: forever},
+				{ip: net.ParseIP("2001:db8::dddd"), preferredLft: 600},
+			},
+			want: "2001:db8::dddd",
+		},
+		{
+			name: "排除链路本地、ULA 和 IPv4 地址",
+			addrs: []ifaceAddr{
+				{ip: net.ParseIP("fe80::1"), preferredLft: forever},
+				{ip: net.ParseIP("fd00::1"), preferredLft: forever},
+				{ip: net.ParseIP("192.0.2.1"), preferredLft: forever},
+				{ip: net.ParseIP("2001:db8::1"), preferredLft: 600},
+			},
+			want: "2001:db8::1",
+		},
+		{
+			name: "EUI-64 地址优先于生命周期更长的地址",
+			addrs: []ifaceAddr{
+				{ip: net.ParseIP("2001:db8::1"), preferredLft: forever},
+				{ip: net.ParseIP("2001:db8::211:22ff:fe33:4455"), preferredLft: 600},
+			},
+			want: "2001:db8::211:22ff:fe33:4455",
+		},
+		{
+			name: "稳定隐私地址优先",
+			addrs: []ifaceAddr{
+				{ip: net.ParseIP("2001:db8::1"), preferredLft: 7200},
+				{ip: net.ParseIP("2001:db8::abcd:1234"), stablePrivacy: true, preferredLft: 600},
+			},
+			want: "2001:db8::abcd:1234",
+		},
+		{
+			name: "其次选择首选生命周期最长的地址",
+			addrs: []ifaceAddr{
+				{ip: net.ParseIP("2001:db8::1"), preferredLft: 600},
+				{ip: net.ParseIP("2001:db8::2"), preferredLft: 7200},
+			},
+			want: "2001:db8::2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectInterfaceAddr(tt.addrs)
+			if err != nil {
+				t.Fatalf("selectInterfaceAddr 不应返回错误: %v", err)
+			}
+			if !got.Equal(net.ParseIP(tt.want)) {
+				t.Errorf("selectInterfaceAddr = %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSelectInterfaceAddr_NoCandidate 测试没有可用地址时返回错误
+func TestSelectInterfaceAddr_NoCandidate(t *testing.T) {
+	_, err := selectInterfaceAddr([]ifaceAddr{
+		{ip: net.ParseIP("fe80::1")},
+		{ip: net.ParseIP("2001:db8::1"), temporary: true},
+	})
+	if err == nil {
+		t.Fatal("没有可用地址时应返回错误")
+	}
+}
+
+// TestHasGlobalAddr 测试撤回检测：临时地址计入，已弃用、tentative 和非全局地址不计入
+func TestHasGlobalAddr(t *testing.T) {
+	if !hasGlobalAddr([]ifaceAddr{
+		{ip: net.ParseIP("fe80::1")},
+		{ip: net.ParseIP("2001:db8::aaaa"), temporary: true, preferredLft: 3600},
+	}) {
+		t.Error("仅有临时地址时应视为仍有全局地址")
+	}
+	if hasGlobalAddr([]ifaceAddr{
+		{ip: net.ParseIP("fe80::1"), preferredLft: 0xffffffff},
+		{ip: net.ParseIP("fd00::1"), preferredLft: 0xffffffff},
+		{ip: net.ParseIP("2001:db8::1"), deprecated: true},
+		{ip: net.ParseIP("2001:db8::2"), tentative: true},
+	}) {
+		t.Error("只剩已弃用、tentative 和非全局地址时应视为没有全局地址")
+	}
+}
+
+// TestInterfaceCandidates 测试候选地址按优先级返回全部可用地址
+func TestInterfaceCandidates(t *testing.T) {
+	got, err := interfaceCandidates([]ifaceAddr{
+		{ip: net.ParseIP("2001:db8:1::1"), preferredLft: 600},
+		{ip: net.ParseIP("2001:db8:2::aaaa"), temporary: true, preferredLft: 3600},
+		{ip: net.ParseIP("2001:db8:3::1"), preferredLft: 7200},
+		{ip: net.ParseIP("fd00::1"), preferredLft: 0xffffffff},
+		{ip: net.ParseIP("2001:db8:4::211:22ff:fe33:4455"), preferredLft: 300},
+	})
+	if err != nil {
+		t.Fatalf("interfaceCandidates 不应返回错误: %v", err)
+	}
+	want := []string{"2001:db8:4::211:22ff:fe33:4455", "2001:db8:3::1", "2001:db8:1::1"}
+	if len(got) != len(want) {
+		t.Fatalf("interfaceCandidates = %v, 期望 %v", got, want)
+	}
+	for i, ip := range got {
+		if !ip.Equal(net.ParseIP(want[i])) {
+			t.Errorf("第 %d 个候选 = %s, 期望 %s", i, ip, want[i])
+		}
+	}
+}
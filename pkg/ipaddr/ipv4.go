@@ -0,0 +1,26 @@
+package ipaddr
+
+import (
+	"context"
+	"net"
+)
+
+// IPv4Fetcher 定义了获取 IPv4 地址的接口
+//
+// 方法集与 IPv6Fetcher 相同，单独定义以在类型层面区分地址族，
+// 避免将 IPv6 获取器误用于 A 记录。
+type IPv4Fetcher interface {
+	Fetch(ctx context.Context) (net.IP, error)
+}
+
+// GetIPv4Addr 获取本机公网 IPv4 地址。
+//
+// 策略与 GetIPv6Addr 相同：随机打乱后并发竞速，第一个成功的结果即返回。
+func GetIPv4Addr(ctx context.Context, fetchers ...IPv4Fetcher) (net.IP, error) {
+	return FetchIPv4(ctx, FetchPolicy{}, fetchers...)
+}
+
+// FetchIPv4 同 GetIPv4Addr，总超时为 p.Timeout（p.Quorum 不用于 IPv4）。
+func FetchIPv4(ctx context.Context, p FetchPolicy, fetchers ...IPv4Fetcher) (net.IP, error) {
+	return raceFetchers(ctx, 4, p.timeout(), fetchers)
+}
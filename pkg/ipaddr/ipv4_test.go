@@ -0,0 +1,73 @@
+package ipaddr_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// TestHttpIPv4Fetcher 测试 HttpIPv4Fetcher 只接受 IPv4 响应
+func TestHttpIPv4Fetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("203.0.113.7\n"))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ip, err := ipaddr.NewHttpIPv4Fetcher(server.URL).Fetch(ctx)
+	if err != nil {
+		t.Fatalf("Expected HttpIPv4Fetcher to succeed, got error: %v", err)
+	}
+	if ip.String() != "203.0.113.7" {
+		t.Errorf("Expected 203.0.113.7, got %s", ip)
+	}
+
+	// IPv6 响应应被拒绝
+	v6Server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("2001:db8::1"))
+	}))
+	defer v6Server.Close()
+
+	if _, err := ipaddr.NewHttpIPv4Fetcher(v6Server.URL).Fetch(ctx); err == nil {
+		t.Error("Expected HttpIPv4Fetcher to reject IPv6 response")
+	}
+}
+
+// TestHttpIPv6Fetcher_RejectsIPv4 测试 HttpIPv6Fetcher 拒绝 IPv4 响应
+func TestHttpIPv6Fetcher_RejectsIPv4(t *testing.T) {
+	server := newIPv6TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("203.0.113.7"))
+	}))
+
+	if _, err := ipaddr.NewHttpIPv6Fetcher(server.URL).Fetch(context.Background()); err == nil {
+		t.Error("Expected HttpIPv6Fetcher to reject IPv4 response")
+	}
+}
+
+// TestGetIPv4Addr_SingleFetcher 测试单个 IPv4 fetcher 成功场景。
+func TestGetIPv4Addr_SingleFetcher(t *testing.T) {
+	testIP := net.ParseIP("203.0.113.7")
+	fetcher := &slowFetcher{ip: testIP, delay: 0}
+
+	ip, err := ipaddr.GetIPv4Addr(context.Background(), fetcher)
+	if err != nil {
+		t.Fatalf("单个 fetcher 成功时不应返回错误: %v", err)
+	}
+	if !ip.Equal(testIP) {
+		t.Errorf("应返回 %s, 得到 %s", testIP, ip)
+	}
+}
+
+// TestGetIPv4Addr_NoFetchers 测试不提供 fetcher 时返回错误。
+func TestGetIPv4Addr_NoFetchers(t *testing.T) {
+	if _, err := ipaddr.GetIPv4Addr(context.Background()); err == nil {
+		t.Fatal("不提供 fetcher 时应返回错误")
+	}
+}
@@ -1,4 +1,4 @@
-// Package ipaddr 提供本机 IPv6 地址获取功能。
+// Package ipaddr 提供本机 IPv6（及 IPv4）地址获取功能。
 //
 // 使用示例：
 //
@@ -14,6 +14,13 @@
 //  2. 所有 fetcher 并发执行
 //  3. 第一个成功返回的地址即为结果
 //  4. 全部失败则返回错误
+//
+// 不希望单个回显服务决定结果时，GetIPv6AddrQuorum 等待至少 N 个获取器的结果一致
+// （完整地址或同一 /64 前缀），结果分歧时记录列出各来源的警告日志。
+//
+// IPv4 获取器（IPv4Fetcher、GetIPv4Addr）采用完全相同的策略，用于双栈场景下的 A 记录。
+//
+// Linux 上 InterfaceFetcher 通过 Netlink 直接读取接口地址，不依赖外部服务。
 package ipaddr
 
 import (
@@ -31,8 +38,22 @@ type IPv6Fetcher interface {
 	Fetch(ctx context.Context) (net.IP, error)
 }
 
-// fetchTimeout IPv6 地址获取总超时时间。
-const fetchTimeout = 5 * time.Second
+// DefaultFetchTimeout 一次地址获取的默认总超时时间。
+const DefaultFetchTimeout = 5 * time.Second
+
+// FetchPolicy 一次地址获取的策略，零值为默认策略（最快者胜出，总超时 DefaultFetchTimeout）。
+type FetchPolicy struct {
+	Timeout time.Duration // 总超时（<= 0 时使用 DefaultFetchTimeout），同时受父 context 控制
+	Quorum  Quorum        // 共识模式（仅 FetchIPv6 使用，未启用时最快者胜出）
+}
+
+// timeout 返回生效的总超时时间。
+func (p FetchPolicy) timeout() time.Duration {
+	if p.Timeout <= 0 {
+		return DefaultFetchTimeout
+	}
+	return p.Timeout
+}
 
 // GetIPv6Addr 获取本机 IPv6 地址。
 //
@@ -40,78 +61,158 @@ const fetchTimeout = 5 * time.Second
 // 第一个成功返回的地址即作为结果。全部失败则返回错误。
 // 总超时时间为 5 秒，受父 context 控制。
 func GetIPv6Addr(ctx context.Context, fetchers ...IPv6Fetcher) (net.IP, error) {
+	return FetchIPv6(ctx, FetchPolicy{}, fetchers...)
+}
+
+// FetchIPv6 按策略 p 获取本机 IPv6 地址：启用共识模式时见 GetIPv6AddrQuorum，否则同 GetIPv6Addr，
+// 总超时为 p.Timeout。
+func FetchIPv6(ctx context.Context, p FetchPolicy, fetchers ...IPv6Fetcher) (net.IP, error) {
+	if p.Quorum.Enabled() {
+		return quorumFetchers(ctx, 6, p.timeout(), p.Quorum, fetchers)
+	}
+	return raceFetchers(ctx, 6, p.timeout(), fetchers)
+}
+
+// addrFetcher 是 IPv6Fetcher 与 IPv4Fetcher 共同的方法集，供 raceFetchers 泛型复用。
+type addrFetcher interface {
+	Fetch(ctx context.Context) (net.IP, error)
+}
+
+// raceFetchers 随机打乱 fetchers 后并发竞速，返回第一个成功的地址。
+//
+// family 仅用于日志和错误信息（4 或 6），地址族校验由各 fetcher 自行完成。
+func raceFetchers[F addrFetcher](ctx context.Context, family int, timeout time.Duration, fetchers []F) (net.IP, error) {
 	if len(fetchers) == 0 {
 		return nil, fmt.Errorf("no fetcher provided")
 	}
 
+	slog.Debug("attempting to fetch address", "module", "ipaddr",
+		"family", familyName(family), "fetcher_count", len(fetchers))
+
+	// 总超时（默认 5 秒），继承父 context 以支持优雅关闭
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// 并发竞速：所有 fetcher 同时启动，第一个成功返回的即为结果
+	results := launchFetchers(ctx, fetchers, false)
+
+	// 等待第一个成功结果或所有失败，同时统计各类错误数量
+	var errs fetchErrors
+	for range fetchers {
+		r := <-results
+		if r.err != nil {
+			errs.add(r.err)
+			continue
+		}
+		slog.Info("address obtained successfully",
+			"module", "ipaddr",
+			"family", familyName(family),
+			"addr", r.ip.String(),
+			"canceled", errs.canceled, "timed_out", errs.timedOut, "failed", errs.failed)
+		return r.ip, nil
+	}
+
+	slog.Error("all address fetchers failed",
+		"module", "ipaddr",
+		"family", familyName(family),
+		"total", len(fetchers),
+		"canceled", errs.canceled, "timed_out", errs.timedOut, "failed", errs.failed,
+		"last_err", errs.last)
+	return nil, fmt.Errorf("all %d %s fetchers failed: %w", len(fetchers), familyName(family), errs.last)
+}
+
+// fetchResult 单个 fetcher 的结果。
+type fetchResult struct {
+	name string
+	ip   net.IP
+	ips  []net.IP // 全部候选地址（ip 在首位），仅 candidates=true 时设置
+	err  error
+}
+
+// launchFetchers 随机打乱 fetchers 顺序后并发执行，每个 fetcher 的结果写入返回的 channel
+// （容量为 len(fetchers)，调用方无需读完）。
+//
+// candidates=true 时实现了 CandidateFetcher 的 fetcher 返回其全部候选地址。
+func launchFetchers[F addrFetcher](ctx context.Context, fetchers []F, candidates bool) <-chan fetchResult {
 	// 随机打乱 fetchers 顺序，避免对某个源产生固定依赖
-	shuffled := make([]IPv6Fetcher, len(fetchers))
+	shuffled := make([]F, len(fetchers))
 	copy(shuffled, fetchers)
 	rand.Shuffle(len(shuffled), func(i, j int) {
 		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
 	})
 
-	slog.Debug("attempting to fetch IPv6 address", "module", "ipaddr", "fetcher_count", len(fetchers))
-
-	// 总超时 5 秒，继承父 context 以支持优雅关闭
-	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
-	defer cancel()
-
-	// 并发竞速：所有 fetcher 同时启动，第一个成功返回的即为结果
-	resultCh := make(chan net.IP, len(shuffled))
-	errCh := make(chan error, len(shuffled))
-
+	results := make(chan fetchResult, len(shuffled))
 	for _, fn := range shuffled {
-		go func(fetcher IPv6Fetcher) {
-			slog.Debug("starting fetcher", "module", "ipaddr", "fetcher", fmt.Sprintf("%T", fetcher))
-			ip, err := fetcher.Fetch(ctx)
+		go func(fetcher F) {
+			name := FetcherName(fetcher)
+			slog.Debug("starting fetcher", "module", "ipaddr", "fetcher", name)
+			ips, err := runFetcher(ctx, fetcher, candidates)
 			if err != nil {
 				// 区分错误类型：取消=竞速正常副作用，超时=可能网络问题，其他=真正故障
 				switch {
 				case errors.Is(err, context.Canceled):
-					slog.Debug("fetcher canceled", "module", "ipaddr", "fetcher", fmt.Sprintf("%T", fetcher))
+					slog.Debug("fetcher canceled", "module", "ipaddr", "fetcher", name)
 				case errors.Is(err, context.DeadlineExceeded):
-					slog.Info("fetcher timed out", "module", "ipaddr", "fetcher", fmt.Sprintf("%T", fetcher))
+					slog.Info("fetcher timed out", "module", "ipaddr", "fetcher", name)
 				default:
-					slog.Warn("fetcher failed", "module", "ipaddr", "fetcher", fmt.Sprintf("%T", fetcher), "err", err)
+					slog.Warn("fetcher failed", "module", "ipaddr", "fetcher", name, "err", err)
 				}
-				errCh <- err
-				return
 			}
-			resultCh <- ip
+			r := fetchResult{name: name, ips: ips, err: err}
+			if len(ips) > 0 {
+				r.ip = ips[0]
+			}
+			results <- r
 		}(fn)
 	}
+	return results
+}
 
-	// 等待第一个成功结果或所有失败，同时统计各类错误数量
-	var lastErr error
-	var canceledCount, timeoutCount, failedCount int
-	remaining := len(shuffled)
-	for remaining > 0 {
-		select {
-		case ip := <-resultCh:
-			slog.Info("IPv6 address obtained successfully",
-				"module", "ipaddr",
-				"ipv6", ip.String(),
-				"canceled", canceledCount, "timed_out", timeoutCount, "failed", failedCount)
-			return ip, nil
-		case err := <-errCh:
-			lastErr = err
-			remaining--
-			switch {
-			case errors.Is(err, context.Canceled):
-				canceledCount++
-			case errors.Is(err, context.DeadlineExceeded):
-				timeoutCount++
-			default:
-				failedCount++
-			}
-		}
+// fetchErrors 按类型统计 fetcher 的错误数量。
+type fetchErrors struct {
+	canceled, timedOut, failed int
+	last                       error
+}
+
+// add 记录一个 fetcher 错误。
+func (e *fetchErrors) add(err error) {
+	e.last = err
+	switch {
+	case errors.Is(err, context.Canceled):
+		e.canceled++
+	case errors.Is(err, context.DeadlineExceeded):
+		e.timedOut++
+	default:
+		e.failed++
 	}
+}
 
-	slog.Error("all IPv6 fetchers failed",
-		"module", "ipaddr",
-		"total", len(fetchers),
-		"canceled", canceledCount, "timed_out", timeoutCount, "failed", failedCount,
-		"last_err", lastErr)
-	return nil, fmt.Errorf("all %d fetchers failed: %w", len(fetchers), lastErr)
+// matchFamily 判断 ip 是否属于指定地址族（4 或 6）。
+func matchFamily(ip net.IP, family int) bool {
+	if ip == nil || ip.To16() == nil {
+		return false
+	}
+	if family == 4 {
+		return ip.To4() != nil
+	}
+	return ip.To4() == nil
+}
+
+// FetcherName 返回 fetcher 的可读名称，用于日志和状态展示。
+//
+// 实现了 fmt.Stringer 的 fetcher（如 HttpIPv6Fetcher 返回 URL，DnsFetcher 返回服务器地址）
+// 使用其 String()，否则使用类型名。
+func FetcherName(f any) string {
+	if s, ok := f.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", f)
+}
+
+// familyName 返回地址族的可读名称。
+func familyName(family int) string {
+	if family == 4 {
+		return "IPv4"
+	}
+	return "IPv6"
 }
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"net"
 	"net/http"
-	"net/http/httptest"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -44,11 +43,10 @@ func TestDnsFetcher(t *testing.T) {
 func TestHttpIPv6Fetcher(t *testing.T) {
 	// 创建测试 HTTP 服务器
 	mockIPv6 := "2001:db8::1"
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	server := newIPv6TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(mockIPv6))
 	}))
-	defer server.Close()
 
 	// 创建 HttpIPv6Fetcher
 	fetcher := ipaddr.NewHttpIPv6Fetcher(server.URL)
@@ -71,11 +69,10 @@ func TestHttpIPv6Fetcher(t *testing.T) {
 	}
 
 	// 测试错误情况 - 无效 IP 地址
-	errorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	errorServer := newIPv6TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("invalid-ip"))
 	}))
-	defer errorServer.Close()
 
 	errorFetcher := ipaddr.NewHttpIPv6Fetcher(errorServer.URL)
 	_, err = errorFetcher.Fetch(ctx)
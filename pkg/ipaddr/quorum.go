@@ -0,0 +1,145 @@
+package ipaddr
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"time"
+)
+
+// QuorumMatch 共识模式下判定两个获取器结果一致的方式。
+type QuorumMatch string
+
+// 共识判定方式。
+const (
+	MatchAddress QuorumMatch = "address" // 完整地址相同
+	MatchPrefix  QuorumMatch = "prefix"  // 同一 /64 前缀（接口标识可不同，如各服务看到不同的临时地址）
+)
+
+// ParseQuorumMatch 解析共识判定方式，空字符串表示 MatchAddress。
+func ParseQuorumMatch(s string) (QuorumMatch, error) {
+	switch QuorumMatch(s) {
+	case "", MatchAddress:
+		return MatchAddress, nil
+	case MatchPrefix:
+		return MatchPrefix, nil
+	}
+	return "", fmt.Errorf("invalid quorum match %q (supported: %s, %s)", s, MatchAddress, MatchPrefix)
+}
+
+// Quorum IPv6 地址获取的共识配置，零值表示最快者胜出（GetIPv6Addr 的默认策略）。
+type Quorum struct {
+	N     int         // 至少需要多少个获取器的结果一致（<= 1 表示最快者胜出）
+	Match QuorumMatch // 一致的判定方式（默认 MatchAddress）
+}
+
+// Enabled 返回是否启用共识模式。
+func (q Quorum) Enabled() bool {
+	return q.N > 1
+}
+
+// GetIPv6AddrQuorum 按共识模式获取本机 IPv6 地址。
+//
+// 所有 fetcher 并发执行，在总超时内等待至少 q.N 个 fetcher 的结果一致（按 q.Match 判定），
+// 返回该组中最先到达的地址。一个异常或被劫持的回显服务因此无法单独决定发布的地址。
+// 结果出现分歧时记录一条列出各组地址及其来源的警告日志；超时前无法达成共识时返回错误。
+//
+// q 未启用（q.N <= 1）时等价于 GetIPv6Addr。
+func GetIPv6AddrQuorum(ctx context.Context, q Quorum, fetchers ...IPv6Fetcher) (net.IP, error) {
+	return FetchIPv6(ctx, FetchPolicy{Quorum: q}, fetchers...)
+}
+
+// quorumGroup 结果一致的一组 fetcher。
+type quorumGroup struct {
+	ip      net.IP   // 组内最先到达的地址
+	sources []string // 组内 fetcher 名称，按到达顺序
+}
+
+// quorumFetchers 并发执行 fetchers，返回第一个达到 q.N 个一致结果的地址。
+func quorumFetchers[F addrFetcher](ctx context.Context, family int, timeout time.Duration, q Quorum, fetchers []F) (net.IP, error) {
+	if len(fetchers) < q.N {
+		return nil, fmt.Errorf("quorum of %d requires at least %d fetchers, got %d", q.N, q.N, len(fetchers))
+	}
+
+	slog.Debug("attempting to fetch address with quorum", "module", "ipaddr",
+		"family", familyName(family), "fetcher_count", len(fetchers), "quorum", q.N, "match", q.Match)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	results := launchFetchers(ctx, fetchers, false)
+
+	groups := make(map[string]*quorumGroup)
+	var order []string // 各组的出现顺序
+	var errs fetchErrors
+	for range fetchers {
+		r := <-results
+		if r.err != nil {
+			errs.add(r.err)
+			continue
+		}
+		key := quorumKey(r.ip, q.Match)
+		g, ok := groups[key]
+		if !ok {
+			g = &quorumGroup{ip: r.ip}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.sources = append(g.sources, r.name)
+		if len(g.sources) < q.N {
+			continue
+		}
+
+		if len(groups) > 1 {
+			logDisagreement(family, q, groups, order, "quorum reached despite disagreement")
+		}
+		slog.Info("address obtained by quorum",
+			"module", "ipaddr",
+			"family", familyName(family),
+			"addr", g.ip.String(),
+			"quorum", q.N, "match", q.Match,
+			"sources", g.sources)
+		return g.ip, nil
+	}
+
+	if len(groups) > 1 {
+		logDisagreement(family, q, groups, order, "address fetchers disagree, no quorum")
+	}
+	slog.Error("address quorum not reached",
+		"module", "ipaddr",
+		"family", familyName(family),
+		"total", len(fetchers),
+		"quorum", q.N, "match", q.Match,
+		"distinct", len(groups),
+		"canceled", errs.canceled, "timed_out", errs.timedOut, "failed", errs.failed,
+		"last_err", errs.last)
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("all %d %s fetchers failed: %w", len(fetchers), familyName(family), errs.last)
+	}
+	return nil, fmt.Errorf("%s quorum of %d not reached: %d distinct results from %d fetchers",
+		familyName(family), q.N, len(groups), len(fetchers)-errs.canceled-errs.timedOut-errs.failed)
+}
+
+// quorumKey 返回 ip 在 match 判定方式下的分组键。
+func quorumKey(ip net.IP, match QuorumMatch) string {
+	if match == MatchPrefix && ip.To4() == nil {
+		return ip.Mask(net.CIDRMask(64, 128)).String() + "/64"
+	}
+	return ip.String()
+}
+
+// logDisagreement 记录获取器结果分歧：每组的地址（或 /64 前缀）及其来源。
+func logDisagreement(family int, q Quorum, groups map[string]*quorumGroup, order []string, msg string) {
+	results := make(map[string][]string, len(groups))
+	for _, key := range order {
+		sources := append([]string(nil), groups[key].sources...)
+		sort.Strings(sources)
+		results[key] = sources
+	}
+	slog.Warn(msg,
+		"module", "ipaddr",
+		"family", familyName(family),
+		"quorum", q.N, "match", q.Match,
+		"results", results)
+}
@@ -0,0 +1,78 @@
+package ipaddr_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// ============================================================
+// 共识模式测试
+// ============================================================
+
+// namedFetcher 带名称的 slowFetcher，用于分歧日志。
+type namedFetcher struct {
+	slowFetcher
+	name string
+}
+
+func (n *namedFetcher) String() string { return n.name }
+
+func quorumFetcher(name, addr string, delay time.Duration) ipaddr.IPv6Fetcher {
+	return &namedFetcher{slowFetcher: slowFetcher{ip: net.ParseIP(addr), delay: delay}, name: name}
+}
+
+func TestGetIPv6AddrQuorum_OutvotesHijackedSource(t *testing.T) {
+	fetchers := []ipaddr.IPv6Fetcher{
+		quorumFetcher("hijacked", "2001:db8:bad::1", time.Millisecond),
+		quorumFetcher("a", "2001:db8::1", 20*time.Millisecond),
+		quorumFetcher("b", "2001:db8::1", 30*time.Millisecond),
+	}
+	ip, err := ipaddr.GetIPv6AddrQuorum(context.Background(), ipaddr.Quorum{N: 2}, fetchers...)
+	if err != nil {
+		t.Fatalf("两个来源一致时不应返回错误: %v", err)
+	}
+	if !ip.Equal(net.ParseIP("2001:db8::1")) {
+		t.Errorf("应返回多数来源的地址, 得到 %s", ip)
+	}
+}
+
+func TestGetIPv6AddrQuorum_PrefixMatch(t *testing.T) {
+	fetchers := []ipaddr.IPv6Fetcher{
+		quorumFetcher("a", "2001:db8:1:2::aaaa", time.Millisecond),
+		quorumFetcher("b", "2001:db8:1:2::bbbb", 10*time.Millisecond),
+	}
+	if _, err := ipaddr.GetIPv6AddrQuorum(context.Background(), ipaddr.Quorum{N: 2}, fetchers...); err == nil {
+		t.Error("按完整地址判定时接口标识不同应无法达成共识")
+	}
+	ip, err := ipaddr.GetIPv6AddrQuorum(context.Background(), ipaddr.Quorum{N: 2, Match: ipaddr.MatchPrefix}, fetchers...)
+	if err != nil {
+		t.Fatalf("同一 /64 前缀应达成共识: %v", err)
+	}
+	if !ip.Equal(net.ParseIP("2001:db8:1:2::aaaa")) {
+		t.Errorf("应返回组内最先到达的地址, 得到 %s", ip)
+	}
+}
+
+func TestGetIPv6AddrQuorum_NotEnoughFetchers(t *testing.T) {
+	fetchers := []ipaddr.IPv6Fetcher{quorumFetcher("a", "2001:db8::1", 0)}
+	if _, err := ipaddr.GetIPv6AddrQuorum(context.Background(), ipaddr.Quorum{N: 2}, fetchers...); err == nil {
+		t.Error("获取器数量少于共识数量时应返回错误")
+	}
+	// N <= 1 时为最快者胜出
+	if _, err := ipaddr.GetIPv6AddrQuorum(context.Background(), ipaddr.Quorum{N: 1}, fetchers...); err != nil {
+		t.Errorf("未启用共识模式时应等价于 GetIPv6Addr: %v", err)
+	}
+}
+
+func TestParseQuorumMatch(t *testing.T) {
+	if m, err := ipaddr.ParseQuorumMatch(""); err != nil || m != ipaddr.MatchAddress {
+		t.Errorf("空字符串应解析为 address, 得到 %q, %v", m, err)
+	}
+	if _, err := ipaddr.ParseQuorumMatch("subnet"); err == nil {
+		t.Error("未知判定方式应返回错误")
+	}
+}
@@ -8,37 +8,192 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
+// httpFetcher HTTP 获取器的公共实现，按地址族校验响应内容。
+type httpFetcher struct {
+	url     string
+	family  int // 4 或 6
+	client  *http.Client
+	extract Extractor   // 为 nil 时整个响应体即为地址
+	header  http.Header // 附加的请求头
+	auth    *basicAuth  // HTTP Basic 认证（可选）
+	source  net.IP      // 连接使用的本机源地址（可选）
+	device  string      // 连接绑定的网络接口（可选，仅 Linux）
+	network string      // 建立连接使用的网络（可选，见 WithNetwork）
+}
+
+// basicAuth HTTP Basic 认证凭据。
+type basicAuth struct {
+	username, password string
+}
+
 // HttpIPv6Fetcher 从 HTTP 端点获取 IPv6 地址
 type HttpIPv6Fetcher struct {
-	url    string
-	client *http.Client
+	httpFetcher
+}
+
+// HttpIPv4Fetcher 从 HTTP 端点获取 IPv4 地址
+type HttpIPv4Fetcher struct {
+	httpFetcher
+}
+
+// HttpOption HTTP 获取器的可选配置项。
+type HttpOption func(*httpFetcher)
+
+// WithHttpTimeout 设置单次 HTTP 请求的超时时间（默认 5 秒）。
+func WithHttpTimeout(d time.Duration) HttpOption {
+	return func(h *httpFetcher) {
+		h.client.Timeout = d
+	}
+}
+
+// WithExtractor 设置从响应体中提取地址的方式（JSON 路径、正则表达式或 key=value 字段），
+// 默认整个响应体即为地址。
+func WithExtractor(e Extractor) HttpOption {
+	return func(h *httpFetcher) {
+		h.extract = e
+	}
+}
+
+// WithHttpHeader 为请求附加一个请求头，可多次使用。
+func WithHttpHeader(key, value string) HttpOption {
+	return func(h *httpFetcher) {
+		if h.header == nil {
+			h.header = make(http.Header)
+		}
+		h.header.Add(key, value)
+	}
+}
+
+// WithBasicAuth 使用 HTTP Basic 认证，适用于需要登录的路由器状态页。
+func WithBasicAuth(username, password string) HttpOption {
+	return func(h *httpFetcher) {
+		h.auth = &basicAuth{username: username, password: password}
+	}
+}
+
+// WithSourceAddr 使用指定的本机源地址建立连接，地址族须与获取器一致。
+func WithSourceAddr(ip net.IP) HttpOption {
+	return func(h *httpFetcher) {
+		h.source = ip
+	}
+}
+
+// WithBindInterface 将连接绑定到指定网络接口（Linux 上通过 SO_BINDTODEVICE，需要 CAP_NET_RAW 或 root），
+// 多出口时可获取指定出口（如 ppp1）的地址。其他平台上请求返回错误。
+func WithBindInterface(name string) HttpOption {
+	return func(h *httpFetcher) {
+		h.device = name
+	}
+}
+
+// WithNetwork 设置建立连接使用的网络："tcp4"、"tcp6"，或 "tcp"（不限地址族，使用 HTTP_PROXY 等代理设置）。
+//
+// 默认按 URL 的主机选择：IP 字面量（如路由器状态页 http://192.168.1.1/status）使用该地址的地址族，
+// 主机名使用获取器的地址族。主机名只解析到另一地址族的数据源（如只有 A 记录的 router.lan）需设置为 "tcp" 或对应的地址族。
+func WithNetwork(network string) HttpOption {
+	return func(h *httpFetcher) {
+		h.network = network
+	}
 }
 
 // NewHttpIPv6Fetcher 创建新的 HttpIPv6Fetcher
-func NewHttpIPv6Fetcher(url string) *HttpIPv6Fetcher {
-	return &HttpIPv6Fetcher{
+func NewHttpIPv6Fetcher(url string, opts ...HttpOption) *HttpIPv6Fetcher {
+	return &HttpIPv6Fetcher{httpFetcher: newHttpFetcher(url, 6, opts)}
+}
+
+// NewHttpIPv4Fetcher 创建新的 HttpIPv4Fetcher
+func NewHttpIPv4Fetcher(url string, opts ...HttpOption) *HttpIPv4Fetcher {
+	return &HttpIPv4Fetcher{httpFetcher: newHttpFetcher(url, 4, opts)}
+}
+
+// newHttpFetcher 创建指定地址族的 httpFetcher。
+func newHttpFetcher(url string, family int, opts []HttpOption) httpFetcher {
+	h := httpFetcher{
 		url:    url,
+		family: family,
 		client: &http.Client{Timeout: 5 * time.Second},
 	}
+	for _, opt := range opts {
+		opt(&h)
+	}
+	h.client.Transport = h.transport()
+	return h
+}
+
+// transport 创建按 dialNetwork 建立连接的 Transport。
+//
+// 双栈主机上默认 Transport 可能走另一个地址族，回显服务随之返回错误地址族的地址而被丢弃。
+// 限定地址族（tcp4 或 tcp6）时不使用代理：经过代理时回显服务看到的是代理的地址。
+func (h *httpFetcher) transport() *http.Transport {
+	network := h.dialNetwork()
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if h.source != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: h.source}
+	}
+	if h.device != "" {
+		dialer.Control = bindToDevice(h.device)
+	}
+
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if network != "tcp" {
+		tr.Proxy = nil
+	}
+	tr.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return tr
+}
+
+// dialNetwork 返回建立连接使用的网络：WithNetwork 的设置，或按 URL 主机选择（见 WithNetwork）。
+func (h *httpFetcher) dialNetwork() string {
+	if h.network != "" {
+		return h.network
+	}
+	if u, err := url.Parse(h.url); err == nil {
+		if ip := net.ParseIP(u.Hostname()); ip != nil {
+			if ip.To4() != nil {
+				return "tcp4"
+			}
+			return "tcp6"
+		}
+	}
+	if h.family == 4 {
+		return "tcp4"
+	}
+	return "tcp6"
 }
 
-// String 返回 HttpIPv6Fetcher 的字符串表示
-func (h *HttpIPv6Fetcher) String() string {
+// String 返回 HTTP 获取器的字符串表示，设置了 Extractor 时附带其描述，如 https://example.com/ip (json:ip)
+func (h *httpFetcher) String() string {
+	if h.extract != nil {
+		return h.url + " (" + h.extract.String() + ")"
+	}
 	return h.url
 }
 
 // Fetch 实现 Fetcher 接口
-func (h *HttpIPv6Fetcher) Fetch(ctx context.Context) (net.IP, error) {
+func (h *httpFetcher) Fetch(ctx context.Context) (net.IP, error) {
 	// 创建 HTTP 请求
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request for %s: %w", h.url, err)
 	}
+	for key, values := range h.header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	if h.auth != nil {
+		req.SetBasicAuth(h.auth.username, h.auth.password)
+	}
 
-	slog.Debug("fetching IPv6 via HTTP", "module", "ipaddr", "url", h.url)
+	slog.Debug("fetching address via HTTP", "module", "ipaddr", "url", h.url, "family", familyName(h.family),
+		"network", h.dialNetwork(), "source", h.source, "interface", h.device)
 
 	client := h.client
 
@@ -53,15 +208,23 @@ func (h *HttpIPv6Fetcher) Fetch(ctx context.Context) (net.IP, error) {
 		return nil, fmt.Errorf("failed to read response body from %s: %w", h.url, err)
 	}
 
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %s from %s", resp.Status, h.url)
+	}
+
+	if h.extract != nil {
+		return h.extractAddr(body)
+	}
+
 	// 清理响应内容
 	body = bytes.TrimSpace(body)
 	if bytes.Contains(body, []byte("%")) {
 		body = bytes.Trim(body, "%")
 	}
 
-	// 解析 IPv6 地址
+	// 解析并校验地址族
 	ip := net.ParseIP(string(body))
-	if ip != nil && ip.To16() != nil && ip.To4() == nil {
+	if matchFamily(ip, h.family) {
 		return ip, nil
 	}
 
@@ -70,11 +233,36 @@ func (h *HttpIPv6Fetcher) Fetch(ctx context.Context) (net.IP, error) {
 	if len(respStr) > 100 {
 		respStr = respStr[:100] + "..."
 	}
-	slog.Warn("HTTP response is not a valid IPv6 address",
+	slog.Warn("HTTP response is not a valid address",
 		"module", "ipaddr",
 		"url", h.String(),
+		"family", familyName(h.family),
 		"response", respStr,
 	)
 
-	return nil, fmt.Errorf("no valid IPv6 address found from %s", h.url)
+	return nil, fmt.Errorf("no valid %s address found from %s", familyName(h.family), h.url)
+}
+
+// extractAddr 用 Extractor 从响应体中提取候选地址，返回第一个匹配地址族的地址。
+// 候选去掉首尾空白、% 和前缀长度（如 /64）后解析。
+func (h *httpFetcher) extractAddr(body []byte) (net.IP, error) {
+	candidates, err := h.extract.Extract(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract address from %s with %s: %w", h.url, h.extract, err)
+	}
+	for _, c := range candidates {
+		c = strings.Trim(strings.TrimSpace(c), "%")
+		c, _, _ = strings.Cut(c, "/")
+		if ip := net.ParseIP(c); matchFamily(ip, h.family) {
+			return ip, nil
+		}
+	}
+	slog.Warn("HTTP response has no address matching the extractor",
+		"module", "ipaddr",
+		"url", h.url,
+		"extractor", h.extract.String(),
+		"family", familyName(h.family),
+		"candidates", candidates,
+	)
+	return nil, fmt.Errorf("no valid %s address found from %s with %s", familyName(h.family), h.url, h.extract)
 }
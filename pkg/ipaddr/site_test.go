@@ -0,0 +1,134 @@
+package ipaddr_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/notes-bin/ddns6/pkg/ipaddr"
+)
+
+// newIPv6TestServer 在 [::1] 上启动测试 HTTP 服务器（HttpIPv6Fetcher 只通过 tcp6 连接），
+// 测试结束时自动关闭。环境不支持 IPv6 回环时跳过测试。
+func newIPv6TestServer(t *testing.T, h http.Handler) *httptest.Server {
+	t.Helper()
+	ln, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %v", err)
+	}
+	server := httptest.NewUnstartedServer(h)
+	server.Listener.Close()
+	server.Listener = ln
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// localhostURL 返回以 localhost 为主机名访问 server 的 URL。
+func localhostURL(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Host = net.JoinHostPort("localhost", u.Port())
+	return u.String()
+}
+
+// ==================== 传输地址族 ====================
+
+func TestHttpFetcher_ForcesAddressFamily(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("2001:db8::1"))
+	})
+	v6Server := newIPv6TestServer(t, handler)
+	v4Server := httptest.NewServer(handler)
+	defer v4Server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// localhost 解析到 127.0.0.1 和 ::1，服务器只监听其中一个地址族，获取器不应回落到另一个地址族
+	if _, err := ipaddr.NewHttpIPv6Fetcher(localhostURL(t, v4Server)).Fetch(ctx); err == nil {
+		t.Error("IPv6 获取器不应通过 IPv4 连接")
+	}
+	if _, err := ipaddr.NewHttpIPv4Fetcher(localhostURL(t, v6Server)).Fetch(ctx); err == nil {
+		t.Error("IPv4 获取器不应通过 IPv6 连接")
+	}
+}
+
+func TestHttpFetcher_ExtractorOverIPv4(t *testing.T) {
+	// 只监听 IPv4 的路由器状态页，页面中列出 WAN 口的 IPv6 地址
+	router := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<td>WAN IPv6: 2001:db8::7</td>"))
+	}))
+	defer router.Close()
+	re, err := ipaddr.NewRegexExtractor(`WAN IPv6: ([0-9a-f:]+)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// URL 为 IPv4 字面量时按该地址族连接
+	ip, err := ipaddr.NewHttpIPv6Fetcher(router.URL, ipaddr.WithExtractor(re)).Fetch(ctx)
+	if err != nil || ip.String() != "2001:db8::7" {
+		t.Errorf("IPv6 获取器应通过 IPv4 读取状态页得到 2001:db8::7, 得到 %v, %v", ip, err)
+	}
+
+	// 主机名按 network 设置连接
+	ip, err = ipaddr.NewHttpIPv6Fetcher(localhostURL(t, router), ipaddr.WithExtractor(re), ipaddr.WithNetwork("tcp4")).Fetch(ctx)
+	if err != nil || ip.String() != "2001:db8::7" {
+		t.Errorf("network 为 tcp4 时应得到 2001:db8::7, 得到 %v, %v", ip, err)
+	}
+	if _, err := ipaddr.NewHttpIPv6Fetcher(router.URL, ipaddr.WithExtractor(re), ipaddr.WithNetwork("tcp6")).Fetch(ctx); err == nil {
+		t.Error("network 为 tcp6 时不应通过 IPv4 连接")
+	}
+}
+
+func TestHttpFetcher_SourceBinding(t *testing.T) {
+	var remote string
+	server := newIPv6TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remote = r.RemoteAddr
+		w.Write([]byte("2001:db8::1"))
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := ipaddr.NewHttpIPv6Fetcher(server.URL, ipaddr.WithSourceAddr(net.IPv6loopback)).Fetch(ctx); err != nil {
+		t.Fatalf("使用 ::1 作为源地址不应失败: %v", err)
+	}
+	if host, _, _ := net.SplitHostPort(remote); host != "::1" {
+		t.Errorf("服务器看到的来源应为 ::1, 得到 %s", remote)
+	}
+	// 2001:db8::1 不是本机地址，无法作为源地址
+	if _, err := ipaddr.NewHttpIPv6Fetcher(server.URL, ipaddr.WithSourceAddr(net.ParseIP("2001:db8::1"))).Fetch(ctx); err == nil {
+		t.Error("非本机源地址应连接失败")
+	}
+
+	_, err := ipaddr.NewHttpIPv6Fetcher(server.URL, ipaddr.WithBindInterface("lo")).Fetch(ctx)
+	switch {
+	case runtime.GOOS != "linux":
+		if err == nil {
+			t.Error("非 Linux 平台绑定接口应返回错误")
+		}
+	case errors.Is(err, syscall.EPERM):
+		t.Skip("SO_BINDTODEVICE requires CAP_NET_RAW")
+	case err != nil:
+		t.Errorf("绑定到 lo 不应失败: %v", err)
+	}
+	if runtime.GOOS == "linux" {
+		if _, err := ipaddr.NewHttpIPv6Fetcher(server.URL, ipaddr.WithBindInterface("ddns6-nonexistent")).Fetch(ctx); err == nil {
+			t.Error("绑定到不存在的接口应返回错误")
+		}
+	}
+}
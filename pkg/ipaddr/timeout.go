@@ -0,0 +1,32 @@
+package ipaddr
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// TimeoutFetcher 为单个获取器设置独立的超时时间，可包装 IPv6Fetcher 或 IPv4Fetcher。
+//
+// 超时只限制该获取器本身；GetIPv6Addr 等函数的总超时仍然生效，取两者中较早的一个。
+type TimeoutFetcher struct {
+	next    addrFetcher
+	timeout time.Duration
+}
+
+// NewTimeoutFetcher 创建新的 TimeoutFetcher（IPv4Fetcher 的方法集与 IPv6Fetcher 相同，同样可以传入）
+func NewTimeoutFetcher(f IPv6Fetcher, timeout time.Duration) *TimeoutFetcher {
+	return &TimeoutFetcher{next: f, timeout: timeout}
+}
+
+// String 返回被包装获取器的名称
+func (t *TimeoutFetcher) String() string {
+	return FetcherName(t.next)
+}
+
+// Fetch 实现 Fetcher 接口
+func (t *TimeoutFetcher) Fetch(ctx context.Context) (net.IP, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.next.Fetch(ctx)
+}
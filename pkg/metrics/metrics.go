@@ -0,0 +1,403 @@
+// Package metrics 提供轻量的 Prometheus 指标（计数器、仪表盘、直方图），
+// 以 Prometheus 文本格式（text/plain; version=0.0.4）导出，不依赖第三方库。
+//
+// 使用示例：
+//
+//	var syncTotal = metrics.NewCounterVec("ddns6_sync_attempts_total", "同步次数", "domain")
+//
+//	syncTotal.WithLabelValues("www.example.com").Inc()
+//	http.Handle("/metrics", metrics.Default.Handler())
+//
+// 包级构造函数（NewCounterVec 等）注册到 Default，各指标按注册顺序输出，
+// 同一指标的序列按标签值排序。所有类型并发安全。
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets 默认直方图分桶（秒），覆盖 5ms 到 10s 的 API 调用延迟。
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default 默认注册表，包级构造函数创建的指标注册于此。
+var Default = NewRegistry()
+
+// collector 可导出的一组指标。
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry 指标注册表。
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+	names      map[string]bool
+}
+
+// NewRegistry 创建空的注册表。
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register 注册指标，名称重复时 panic（属于编程错误）。
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", name))
+	}
+	r.names[name] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo 以 Prometheus 文本格式写出所有指标。
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler 返回导出指标的 HTTP 处理器。
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+// countingWriter 统计写入字节数。
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// desc 指标的名称、说明和标签名。
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+// key 将标签值编码为序列的映射键。
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// writeHeader 写出 HELP 和 TYPE 行。
+func (d *desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.typ)
+}
+
+// writeSample 写出一条样本。extra 为附加的标签（如直方图的 le），成对出现。
+func (d *desc) writeSample(w *bufio.Writer, suffix string, values []string, v float64, extra ...string) {
+	w.WriteString(d.name + suffix)
+	if len(values)+len(extra) > 0 {
+		w.WriteByte('{')
+		sep := ""
+		for i, l := range d.labels {
+			fmt.Fprintf(w, `%s%s="%s"`, sep, l, escapeLabel(values[i]))
+			sep = ","
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			fmt.Fprintf(w, `%s%s="%s"`, sep, extra[i], escapeLabel(extra[i+1]))
+			sep = ","
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+// series 一条时间序列的标签值和数据。
+type series[T any] struct {
+	values []string
+	data   T
+}
+
+// vec 按标签值索引的序列集合。
+type vec[T any] struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series[T]
+	init   func() T
+}
+
+// get 返回标签值对应的序列，不存在时创建。
+func (v *vec[T]) get(values []string) T {
+	k := v.key(values)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[k]
+	if !ok {
+		s = &series[T]{values: slices.Clone(values), data: v.init()}
+		v.series[k] = s
+	}
+	return s.data
+}
+
+// sorted 返回按标签值排序的所有序列。
+func (v *vec[T]) sorted() []*series[T] {
+	v.mu.Lock()
+	out := make([]*series[T], 0, len(v.series))
+	for _, s := range v.series {
+		out = append(out, s)
+	}
+	v.mu.Unlock()
+	slices.SortFunc(out, func(a, b *series[T]) int {
+		return slices.Compare(a.values, b.values)
+	})
+	return out
+}
+
+// value 并发安全的 float64。
+type value struct {
+	mu sync.Mutex
+	v  float64
+}
+
+func (x *value) add(d float64) {
+	x.mu.Lock()
+	x.v += d
+	x.mu.Unlock()
+}
+
+func (x *value) set(v float64) {
+	x.mu.Lock()
+	x.v = v
+	x.mu.Unlock()
+}
+
+func (x *value) get() float64 {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.v
+}
+
+// ============================================================
+// Counter
+// ============================================================
+
+// Counter 单调递增的计数器。
+type Counter struct{ v value }
+
+// Inc 加 1。
+func (c *Counter) Inc() { c.v.add(1) }
+
+// Add 增加 d，d 为负数时 panic。
+func (c *Counter) Add(d float64) {
+	if d < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.v.add(d)
+}
+
+// Value 返回当前值。
+func (c *Counter) Value() float64 { return c.v.get() }
+
+// CounterVec 带标签的计数器。
+type CounterVec struct{ *vec[*Counter] }
+
+// NewCounterVec 在 Default 中注册带标签的计数器。
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// NewCounterVec 在 r 中注册带标签的计数器。
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newVec(name, help, "counter", labels, func() *Counter { return new(Counter) })}
+	r.register(name, c)
+	return c
+}
+
+// WithLabelValues 返回标签值（按注册顺序）对应的计数器。
+func (c *CounterVec) WithLabelValues(values ...string) *Counter { return c.get(values) }
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	for _, s := range c.sorted() {
+		c.writeSample(w, "", s.values, s.data.Value())
+	}
+}
+
+// ============================================================
+// Gauge
+// ============================================================
+
+// Gauge 可增可减的仪表盘。
+type Gauge struct{ v value }
+
+// Set 设置为 v。
+func (g *Gauge) Set(v float64) { g.v.set(v) }
+
+// SetToCurrentTime 设置为当前 Unix 时间戳（秒）。
+func (g *Gauge) SetToCurrentTime() { g.Set(float64(time.Now().UnixNano()) / 1e9) }
+
+// Add 增加 d（可为负数）。
+func (g *Gauge) Add(d float64) { g.v.add(d) }
+
+// Value 返回当前值。
+func (g *Gauge) Value() float64 { return g.v.get() }
+
+// GaugeVec 带标签的仪表盘。
+type GaugeVec struct{ *vec[*Gauge] }
+
+// NewGaugeVec 在 Default 中注册带标签的仪表盘。
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labels...)
+}
+
+// NewGaugeVec 在 r 中注册带标签的仪表盘。
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{newVec(name, help, "gauge", labels, func() *Gauge { return new(Gauge) })}
+	r.register(name, g)
+	return g
+}
+
+// WithLabelValues 返回标签值（按注册顺序）对应的仪表盘。
+func (g *GaugeVec) WithLabelValues(values ...string) *Gauge { return g.get(values) }
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	for _, s := range g.sorted() {
+		g.writeSample(w, "", s.values, s.data.Value())
+	}
+}
+
+// ============================================================
+// Histogram
+// ============================================================
+
+// Histogram 分桶统计观测值的直方图。
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64 // 升序的上界，不含 +Inf
+	counts  []uint64  // 各桶的非累积计数，最后一个为 +Inf 桶
+	sum     float64
+	count   uint64
+}
+
+// Observe 记录一个观测值。
+func (h *Histogram) Observe(v float64) {
+	i, _ := slices.BinarySearch(h.buckets, v)
+	h.mu.Lock()
+	h.counts[i]++
+	h.sum += v
+	h.count++
+	h.mu.Unlock()
+}
+
+// ObserveDuration 记录自 start 以来经过的秒数。
+func (h *Histogram) ObserveDuration(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// Count 返回观测次数。
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// HistogramVec 带标签的直方图。
+type HistogramVec struct {
+	*vec[*Histogram]
+}
+
+// NewHistogramVec 在 Default 中注册带标签的直方图，buckets 为 nil 时使用 DefBuckets。
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// NewHistogramVec 在 r 中注册带标签的直方图，buckets 为 nil 时使用 DefBuckets。
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	h := &HistogramVec{newVec(name, help, "histogram", labels, func() *Histogram {
+		return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets)+1)}
+	})}
+	r.register(name, h)
+	return h
+}
+
+// WithLabelValues 返回标签值（按注册顺序）对应的直方图。
+func (h *HistogramVec) WithLabelValues(values ...string) *Histogram { return h.get(values) }
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w)
+	for _, s := range h.sorted() {
+		hist := s.data
+		hist.mu.Lock()
+		var cum uint64
+		for i, le := range hist.buckets {
+			cum += hist.counts[i]
+			h.writeSample(w, "_bucket", s.values, float64(cum), "le", formatFloat(le))
+		}
+		h.writeSample(w, "_bucket", s.values, float64(hist.count), "le", "+Inf")
+		h.writeSample(w, "_sum", s.values, hist.sum)
+		h.writeSample(w, "_count", s.values, float64(hist.count))
+		hist.mu.Unlock()
+	}
+}
+
+// newVec 创建指定类型的序列集合。
+func newVec[T any](name, help, typ string, labels []string, init func() T) *vec[T] {
+	return &vec[T]{
+		desc:   desc{name: name, help: help, typ: typ, labels: labels},
+		series: make(map[string]*series[T]),
+		init:   init,
+	}
+}
+
+// formatFloat 按 Prometheus 文本格式输出浮点数。
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escapeHelp 转义 HELP 文本中的反斜杠和换行。
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// escapeLabel 转义标签值中的反斜杠、双引号和换行。
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ============================================================
+// 文本格式输出测试
+// ============================================================
+
+func export(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestCounterVec_Export(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", "测试计数", "domain", "result")
+	c.WithLabelValues("b.example.com", "ok").Inc()
+	c.WithLabelValues("a.example.com", "ok").Add(2)
+	c.WithLabelValues("a.example.com", "ok").Inc()
+
+	want := `# HELP test_total 测试计数
+# TYPE test_total counter
+test_total{domain="a.example.com",result="ok"} 3
+test_total{domain="b.example.com",result="ok"} 1
+`
+	if got := export(t, r); got != want {
+		t.Errorf("输出不符:\n%s\n期望:\n%s", got, want)
+	}
+}
+
+func TestGaugeVec_NoLabels(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGaugeVec("test_gauge", "测试仪表盘")
+	g.WithLabelValues().Set(1.5)
+
+	if got := export(t, r); !strings.Contains(got, "test_gauge 1.5\n") {
+		t.Errorf("无标签仪表盘输出不符:\n%s", got)
+	}
+}
+
+func TestHistogramVec_Export(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("test_seconds", "测试延迟", []float64{0.1, 1}, "method")
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		h.WithLabelValues("GetRecords").Observe(v)
+	}
+
+	want := `# HELP test_seconds 测试延迟
+# TYPE test_seconds histogram
+test_seconds_bucket{method="GetRecords",le="0.1"} 2
+test_seconds_bucket{method="GetRecords",le="1"} 3
+test_seconds_bucket{method="GetRecords",le="+Inf"} 4
+test_seconds_sum{method="GetRecords"} 3.65
+test_seconds_count{method="GetRecords"} 4
+`
+	if got := export(t, r); got != want {
+		t.Errorf("输出不符:\n%s\n期望:\n%s", got, want)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "a\nb", "v").WithLabelValues(`x"y\z`).Inc()
+	got := export(t, r)
+	if !strings.Contains(got, `# HELP test_total a\nb`) || !strings.Contains(got, `test_total{v="x\"y\\z"} 1`) {
+		t.Errorf("转义不符:\n%s", got)
+	}
+}
+
+// ============================================================
+// 注册与 Handler 测试
+// ============================================================
+
+func TestRegister_DuplicatePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("dup_total", "")
+	defer func() {
+		if recover() == nil {
+			t.Error("重复注册同名指标应 panic")
+		}
+	}()
+	r.NewGaugeVec("dup_total", "")
+}
+
+func TestWithLabelValues_WrongCountPanics(t *testing.T) {
+	c := NewRegistry().NewCounterVec("test_total", "", "a", "b")
+	defer func() {
+		if recover() == nil {
+			t.Error("标签值数量不符应 panic")
+		}
+	}()
+	c.WithLabelValues("only-one")
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "").WithLabelValues().Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "test_total 1\n") {
+		t.Errorf("响应内容不符:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,179 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ThrottledFunc 判断一个 HTTP 响应是否为服务商的限流或临时错误。
+//
+// 用于识别不通过 HTTP 状态码表达的临时错误，如腾讯云在 200 响应体中返回的
+// RequestLimitExceeded、阿里云 400 响应中的 Throttling。body 为完整响应体。
+type ThrottledFunc func(resp *http.Response, body []byte) bool
+
+// DoHTTP 发送 HTTP 请求，遇到临时错误时按策略重试。
+//
+// newReq 在每次尝试前调用，返回新构造的请求。需要签名的 API 应在 newReq 中
+// 重新签名（时间戳、nonce 等不能复用）。
+//
+// 以下情况视为可重试：
+//   - 请求未发出的网络错误（如连接失败、DNS 解析失败）
+//   - 幂等请求（见 Replayable）的其他网络错误，ctx 取消除外
+//   - 429 和 5xx 响应
+//   - throttled 非 nil 且返回 true
+//
+// 非幂等请求（如 POST 新增记录）在请求发出后遇到网络错误时不重试：服务端可能已经处理了请求，
+// 重放会创建重复记录。查询、修改等可安全重放的 POST 请求由调用方通过 MarkIdempotent 声明；
+// 以 GET 实现的新增接口（如阿里云的 AddDomainRecord）由调用方通过 MarkNotReplayable 声明。
+//
+// 响应中的 Retry-After 头（秒数或 HTTP 日期）优先于退避时间，超过 MaxDelay 时不再重试。
+//
+// 最后一次尝试得到响应时（无论状态码），返回该响应且 err 为 nil，由调用方按自己的
+// 规则解析错误；响应体已完整读入内存，resp.Body 可直接读取，无需关心原始连接。
+// 最后一次尝试为网络错误、newReq 失败或 ctx 取消时返回错误。
+func (p Policy) DoHTTP(ctx context.Context, client *http.Client, newReq func(ctx context.Context) (*http.Request, error), throttled ThrottledFunc) (*http.Response, error) {
+	var last *http.Response
+	attempt := 0
+	err := p.Do(ctx, func(ctx context.Context) error {
+		attempt++
+		last = nil
+
+		req, err := newReq(ctx)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			if !Replayable(req) && !notSent(err) {
+				logNotReplayed(req, err)
+				return err
+			}
+			logRetryable(req, attempt, p.Attempts, "err", err)
+			return Retryable(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			err = fmt.Errorf("failed to read response body: %w", err)
+			if !Replayable(req) {
+				logNotReplayed(req, err)
+				return err
+			}
+			logRetryable(req, attempt, p.Attempts, "err", err)
+			return Retryable(err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		last = resp
+
+		if !RetryableStatus(resp.StatusCode) && (throttled == nil || !throttled(resp, body)) {
+			return nil
+		}
+		after, _ := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		logRetryable(req, attempt, p.Attempts, "status", resp.StatusCode, "retry_after", after)
+		return RetryAfter(fmt.Errorf("HTTP %d from %s", resp.StatusCode, req.URL.Host), after)
+	})
+	if err != nil && (last == nil || ctx.Err() != nil) {
+		return nil, err
+	}
+	return last, nil
+}
+
+// MarkIdempotent 声明 req 可安全重放，请求发出后遇到网络错误时也重试，用于以 POST 实现的
+// 查询、修改等接口（如腾讯云、Porkbun 的 API）。
+//
+// 与 net/http 的约定相同：设置值为 nil 的 Idempotency-Key 头，该头不会被发送。
+// 需要签名请求头的 API 应在签名后调用。
+func MarkIdempotent(req *http.Request) {
+	req.Header["Idempotency-Key"] = nil
+}
+
+// notReplayableHeader MarkNotReplayable 设置的标记头，值为 nil，不会被发送。
+const notReplayableHeader = "X-Ddns6-Not-Replayable"
+
+// MarkNotReplayable 声明 req 不可安全重放，即使方法为 GET 等幂等方法，请求发出后遇到
+// 网络错误时也不重试，用于以 GET 实现的新增接口（如阿里云 RPC 风格的 AddDomainRecord）。
+//
+// 标记头的值为 nil，不会被发送；需要签名请求头的 API 应在签名后调用。net/http 的 Transport
+// 在复用的连接被服务端断开时会自动重发 GET 等无请求体的请求，因此同时把请求体替换为
+// 不可重建（GetBody 为 nil）的空请求体，使 Transport 也不重发；空请求体不会被发送。
+func MarkNotReplayable(req *http.Request) {
+	req.Header[notReplayableHeader] = nil
+	if req.Body == nil || req.Body == http.NoBody {
+		req.Body = io.NopCloser(strings.NewReader(""))
+	}
+	req.GetBody = nil
+}
+
+// Replayable 判断 req 是否可安全重放：未被 MarkNotReplayable 标记，且方法为 GET、HEAD、
+// OPTIONS、TRACE、PUT、DELETE，或带有 Idempotency-Key / X-Idempotency-Key 头（见 MarkIdempotent）。
+func Replayable(req *http.Request) bool {
+	if _, ok := req.Header[notReplayableHeader]; ok {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	_, ok := req.Header["X-Idempotency-Key"]
+	return ok
+}
+
+// notSent 判断网络错误是否发生在请求发出之前（建立连接失败），此时重试任何请求都是安全的。
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// logNotReplayed 记录一次因请求不可安全重放而未重试的网络错误。
+func logNotReplayed(req *http.Request, err error) {
+	slog.Warn("network error after non-idempotent request was sent, not retrying", "module", "retry",
+		"method", req.Method, "host", req.URL.Host, "path", req.URL.Path, "err", err)
+}
+
+// logRetryable 记录一次可重试的失败。只记录主机和路径，查询字符串中可能含有签名或密钥。
+func logRetryable(req *http.Request, attempt, attempts int, args ...any) {
+	args = append([]any{"module", "retry",
+		"method", req.Method, "host", req.URL.Host, "path", req.URL.Path,
+		"attempt", attempt, "max_attempts", max(attempts, 1)}, args...)
+	slog.Warn("transient HTTP failure", args...)
+}
+
+// RetryableStatus 判断 HTTP 状态码是否为临时错误（429 或 5xx）。
+func RetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// ParseRetryAfter 解析 Retry-After 头，支持秒数和 HTTP 日期两种格式。
+//
+// 返回相对 now 的等待时间；头为空、格式无效时 ok 为 false，日期已过去时返回 0。
+func ParseRetryAfter(value string, now time.Time) (d time.Duration, ok bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(t.Sub(now), 0), true
+}
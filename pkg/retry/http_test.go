@@ -0,0 +1,289 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ============================================================
+// DoHTTP 测试
+// ============================================================
+
+// testPolicy 测试用的快速重试策略
+var testPolicy = Policy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+func getRequest(url string) func(ctx context.Context) (*http.Request, error) {
+	return func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	}
+}
+
+func TestDoHTTP_RetriesServerError(t *testing.T) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	resp, err := testPolicy.DoHTTP(context.Background(), ts.Client(), getRequest(ts.URL), nil)
+	if err != nil {
+		t.Fatalf("重试成功后不应返回错误: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Errorf("应返回最后一次成功的响应, 得到 %d %q", resp.StatusCode, body)
+	}
+	if count != 3 {
+		t.Errorf("应请求 3 次, 实际 %d", count)
+	}
+}
+
+func TestDoHTTP_ClientErrorNotRetried(t *testing.T) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	resp, err := testPolicy.DoHTTP(context.Background(), ts.Client(), getRequest(ts.URL), nil)
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("4xx 应直接返回响应, 得到 resp=%v err=%v", resp, err)
+	}
+	if count != 1 {
+		t.Errorf("4xx 不应重试, 实际请求 %d 次", count)
+	}
+}
+
+func TestDoHTTP_ExhaustedReturnsLastResponse(t *testing.T) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":"rate limited"}`))
+	}))
+	defer ts.Close()
+
+	resp, err := testPolicy.DoHTTP(context.Background(), ts.Client(), getRequest(ts.URL), nil)
+	if err != nil {
+		t.Fatalf("重试耗尽时应返回最后一次响应而非错误: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusTooManyRequests || string(body) != `{"error":"rate limited"}` {
+		t.Errorf("响应内容不符: %d %q", resp.StatusCode, body)
+	}
+	if count != 3 {
+		t.Errorf("应请求 3 次, 实际 %d", count)
+	}
+}
+
+func TestDoHTTP_ThrottledBody(t *testing.T) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) == 1 {
+			w.Write([]byte(`{"Code":"Throttling"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	throttled := func(_ *http.Response, body []byte) bool { return string(body) == `{"Code":"Throttling"}` }
+	if _, err := testPolicy.DoHTTP(context.Background(), ts.Client(), getRequest(ts.URL), throttled); err != nil {
+		t.Fatalf("不应返回错误: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("响应体限流应重试一次, 实际请求 %d 次", count)
+	}
+}
+
+func TestDoHTTP_RetryAfterBeyondMaxDelay(t *testing.T) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	start := time.Now()
+	resp, err := testPolicy.DoHTTP(context.Background(), ts.Client(), getRequest(ts.URL), nil)
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("应返回 503 响应, 得到 resp=%v err=%v", resp, err)
+	}
+	if count != 1 || time.Since(start) > time.Second {
+		t.Errorf("Retry-After 超过 MaxDelay 时不应等待重试, 请求 %d 次, 耗时 %v", count, time.Since(start))
+	}
+}
+
+func TestDoHTTP_NewRequestPerAttempt(t *testing.T) {
+	var built int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		atomic.AddInt32(&built, 1)
+		return http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	}
+	testPolicy.DoHTTP(context.Background(), ts.Client(), newReq, nil)
+	if built != 3 {
+		t.Errorf("每次尝试都应重新构造请求, 实际构造 %d 次", built)
+	}
+}
+
+func TestDoHTTP_NetworkError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	resp, err := testPolicy.DoHTTP(context.Background(), http.DefaultClient, getRequest(url), nil)
+	if err == nil || resp != nil {
+		t.Errorf("网络错误重试耗尽后应返回错误, 得到 resp=%v err=%v", resp, err)
+	}
+}
+
+// droppingServer 读取请求后不返回响应直接断开连接，模拟服务端已处理但响应丢失。
+func droppingServer(t *testing.T, count *int32) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(count, 1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		conn.Close()
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestDoHTTP_PostResponseDroppedNotRetried(t *testing.T) {
+	var count int32
+	ts := droppingServer(t, &count)
+
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodPost, ts.URL, strings.NewReader(`{"type":"AAAA"}`))
+	}
+	resp, err := testPolicy.DoHTTP(context.Background(), ts.Client(), newReq, nil)
+	if err == nil || resp != nil {
+		t.Fatalf("响应丢失时应返回错误, 得到 resp=%v err=%v", resp, err)
+	}
+	if count != 1 {
+		t.Errorf("已发出的 POST 请求不应重放（可能创建重复记录）, 实际请求 %d 次", count)
+	}
+
+	// 调用方声明可安全重放时重试
+	count = 0
+	idempotent := func(ctx context.Context) (*http.Request, error) {
+		req, err := newReq(ctx)
+		if err == nil {
+			MarkIdempotent(req)
+		}
+		return req, err
+	}
+	testPolicy.DoHTTP(context.Background(), ts.Client(), idempotent, nil)
+	if count != 3 {
+		t.Errorf("MarkIdempotent 的请求应重试, 实际请求 %d 次", count)
+	}
+}
+
+func TestDoHTTP_GetMarkedNotReplayable(t *testing.T) {
+	var count int32
+	ts := droppingServer(t, &count)
+
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/?Action=AddDomainRecord", nil)
+		if err == nil {
+			MarkNotReplayable(req)
+		}
+		return req, err
+	}
+	if _, err := testPolicy.DoHTTP(context.Background(), ts.Client(), newReq, nil); err == nil {
+		t.Fatal("响应丢失时应返回错误")
+	}
+	if count != 1 {
+		t.Errorf("标记为不可重放的 GET 请求不应重放, 实际请求 %d 次", count)
+	}
+}
+
+func TestDoHTTP_PostDialErrorRetried(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	addr := ts.Listener.Addr().String()
+	ts.Close()
+
+	var dials int32
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodPost, "http://"+addr, strings.NewReader("{}"))
+	}
+	if _, err := testPolicy.DoHTTP(context.Background(), client, newReq, nil); err == nil {
+		t.Fatal("连接失败时应返回错误")
+	}
+	if dials != 3 {
+		t.Errorf("请求未发出的连接错误应重试, 实际连接 %d 次", dials)
+	}
+}
+
+// ============================================================
+// ParseRetryAfter 测试
+// ============================================================
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := ParseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseRetryAfter(%q) = %v, %v, 期望 %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPolicy_RetryAfterOverridesBackoff(t *testing.T) {
+	p := Policy{Attempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	var count int
+	start := time.Now()
+	err := p.Do(context.Background(), func(ctx context.Context) error {
+		count++
+		if count == 1 {
+			return RetryAfter(io.ErrUnexpectedEOF, time.Millisecond)
+		}
+		return nil
+	})
+	if err != nil || count != 2 {
+		t.Fatalf("应重试一次后成功, err=%v count=%d", err, count)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("应按 RetryAfter 等待而非退避时间, 耗时 %v", time.Since(start))
+	}
+}
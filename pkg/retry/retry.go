@@ -12,12 +12,22 @@
 //	    if resp.StatusCode >= 500 { return retry.Retryable(fmt.Errorf("server error: %d", resp.StatusCode)) }
 //	    return nil  // 成功，不重试
 //	})
+//
+// DNS 服务商客户端通过 Policy.DoHTTP 共用同一套 HTTP 重试逻辑：
+//
+//	resp, err := c.retry.DoHTTP(ctx, c.HTTPClient, newReq, throttled)
+//
+// 需要跨越较长时间、由调用方自行调度的重试（如后台重试队列）使用 Backoff 计算等待时间：
+//
+//	b := retry.Backoff{Base: 30 * time.Second, Max: 30 * time.Minute}
+//	wait := b.Delay(attempt) // attempt 从 0 开始
 package retry
 
 import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"time"
 )
@@ -25,7 +35,8 @@ import (
 // RetryableError 标记一个错误是可重试的临时错误。
 // Do 遇到 RetryableError 时按退避策略重试，遇到其他错误则立即返回。
 type RetryableError struct {
-	Err error
+	Err   error
+	After time.Duration // 服务端建议的等待时间（如 Retry-After），> 0 时代替退避时间
 }
 
 // Error 返回错误信息。
@@ -47,6 +58,15 @@ func Retryable(err error) error {
 	return &RetryableError{Err: err}
 }
 
+// RetryAfter 将 err 包装为可重试错误，并指定下次重试前的等待时间。
+// 如果 err 本身为 nil 则返回 nil。
+func RetryAfter(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryableError{Err: err, After: after}
+}
+
 // IsRetryable 判断错误是否为 RetryableError。
 func IsRetryable(err error) bool {
 	var re *RetryableError
@@ -62,8 +82,32 @@ func IsRetryable(err error) bool {
 //   - fn: 要执行的函数，返回 RetryableError 时重试，其他错误或 nil 时停止
 //
 // 返回 fn 的最后一次返回值（成功时为 nil，超过重试次数时返回最后一次错误）。
+// 等价于 Policy{Attempts: attempts, BaseDelay: baseDelay}.Do(ctx, fn)。
 func Do(ctx context.Context, attempts int, baseDelay time.Duration, fn func(context.Context) error) error {
+	return Policy{Attempts: attempts, BaseDelay: baseDelay}.Do(ctx, fn)
+}
+
+// Policy 短时重试策略，用于单次 API 调用内的快速重试。
+//
+// 第 n 次重试（n 从 0 开始）前等待 [0, BaseDelay * 2^n) 内的随机时间（全 jitter），
+// 不超过 MaxDelay。
+type Policy struct {
+	Attempts  int           // 最大尝试次数（包括首次调用），< 1 视为 1
+	BaseDelay time.Duration // 基础延迟
+	MaxDelay  time.Duration // 单次等待上限（含 Retry-After），<= 0 表示不设上限
+}
+
+// DefaultPolicy 服务商 API 调用的默认重试策略：最多 3 次，500ms 起，单次等待不超过 30s。
+// 视为只读：需要其他策略时通过各客户端的 WithRetryPolicy 选项传入，不要修改此变量。
+var DefaultPolicy = Policy{Attempts: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+// Do 执行 fn，遇到 RetryableError 时按策略重试，语义同包级函数 Do。
+//
+// RetryableError 带有 After 时按 After 等待；After 超过 MaxDelay 时不再重试，
+// 直接返回该错误（交由调用方在更长的时间尺度上重试）。
+func (p Policy) Do(ctx context.Context, fn func(context.Context) error) error {
 	var lastErr error
+	attempts := max(p.Attempts, 1)
 
 	for i := range attempts {
 		// 检查上下文是否已取消
@@ -89,12 +133,13 @@ func Do(ctx context.Context, attempts int, baseDelay time.Duration, fn func(cont
 			break
 		}
 
-		// 指数退避 + 全 jitter（范围 [0, delay)）
-		delay := baseDelay * (1 << i) // baseDelay * 2^i
-		if delay <= 0 {
-			delay = 1 // 确保 delay > 0，防止 rand.Int63n 恐慌
+		wait := p.delay(i)
+		if re.After > 0 {
+			if p.MaxDelay > 0 && re.After > p.MaxDelay {
+				break
+			}
+			wait = re.After
 		}
-		wait := time.Duration(rand.Int63n(int64(delay)))
 
 		select {
 		case <-ctx.Done():
@@ -105,3 +150,49 @@ func Do(ctx context.Context, attempts int, baseDelay time.Duration, fn func(cont
 
 	return lastErr
 }
+
+// delay 返回第 attempt 次重试前的退避时间：指数退避 + 全 jitter（范围 [0, delay)）。
+func (p Policy) delay(attempt int) time.Duration {
+	delay := exponential(p.BaseDelay, p.MaxDelay, attempt)
+	if delay <= 0 {
+		delay = 1 // 确保 delay > 0，防止 rand.Int63n 恐慌
+	}
+	return time.Duration(rand.Int63n(int64(delay)))
+}
+
+// exponential 返回 base * 2^attempt，不超过 limit（<= 0 表示不设上限），翻倍时防止溢出。
+func exponential(base, limit time.Duration, attempt int) time.Duration {
+	delay := base
+	for range attempt {
+		// 翻倍前检查上限和溢出
+		if (limit > 0 && delay >= limit) || delay > math.MaxInt64/2 {
+			break
+		}
+		delay *= 2
+	}
+	if limit > 0 && delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// Backoff 带上限的指数退避策略。
+//
+// 第 n 次重试（n 从 0 开始）的基准等待时间为 Base * 2^n，不超过 Max；
+// 实际等待时间在 [基准/2, 基准) 范围内随机，避免多个任务同时重试。
+type Backoff struct {
+	Base time.Duration // 首次重试的基准等待时间
+	Max  time.Duration // 等待时间上限，<= 0 表示不设上限
+}
+
+// Delay 返回第 attempt 次重试前的等待时间（attempt 从 0 开始）。
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := exponential(b.Base, b.Max, attempt)
+	if delay <= 1 {
+		return delay
+	}
+
+	// 等量 jitter：保留一半基准时间，另一半随机
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)))
+}
@@ -322,3 +322,47 @@ func TestDo_BackoffIncreasing(t *testing.T) {
 		t.Logf("退避时间应大致递增: diff1=%v, diff2=%v", diff1, diff2)
 	}
 }
+
+// ============================================================
+// Backoff 测试
+// ============================================================
+
+func TestBackoff_DelayRange(t *testing.T) {
+	b := Backoff{Base: 100 * time.Millisecond, Max: time.Second}
+	cases := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second}, // 达到上限
+		{50, time.Second},
+	}
+	for _, c := range cases {
+		for range 20 {
+			d := b.Delay(c.attempt)
+			if d < c.base/2 || d >= c.base {
+				t.Fatalf("Delay(%d) = %v, 期望在 [%v, %v) 范围内", c.attempt, d, c.base/2, c.base)
+			}
+		}
+	}
+}
+
+func TestBackoff_NoMax(t *testing.T) {
+	b := Backoff{Base: time.Second}
+	if d := b.Delay(10); d < 512*time.Second {
+		t.Errorf("未设上限时 Delay(10) 应 >= 512s, 得到 %v", d)
+	}
+	// 超大 attempt 不应溢出为负数
+	if d := b.Delay(1000); d <= 0 {
+		t.Errorf("Delay(1000) 不应溢出, 得到 %v", d)
+	}
+}
+
+func TestBackoff_NegativeAttempt(t *testing.T) {
+	b := Backoff{Base: 100 * time.Millisecond}
+	if d := b.Delay(-1); d < 50*time.Millisecond || d >= 100*time.Millisecond {
+		t.Errorf("负数 attempt 应按 0 处理, 得到 %v", d)
+	}
+}